@@ -0,0 +1,38 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package example
+
+import (
+	"github.com/patfair/raytracer/render"
+	"github.com/patfair/raytracer/scenefile"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAllElementsScene(t *testing.T) {
+	scene, err := AllElementsScene(0)
+	if assert.Nil(t, err) {
+		_, err = scene.Render(render.RenderDraftPass, 32, 18)
+		assert.Nil(t, err)
+	}
+}
+
+func TestAllElementsSceneFile(t *testing.T) {
+	// The scene file should describe a scene that renders just like the one built in code.
+	scene, err := scenefile.Load("all_elements_scene.json")
+	if !assert.Nil(t, err) {
+		return
+	}
+	actual, err := scene.Render(render.RenderDraftPass, 32, 18)
+	if !assert.Nil(t, err) {
+		return
+	}
+	scene, err = AllElementsScene(0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	expected, err := scene.Render(render.RenderDraftPass, 32, 18)
+	if assert.Nil(t, err) {
+		assertImageEqual(t, expected, actual, 2)
+	}
+}
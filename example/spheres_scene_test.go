@@ -0,0 +1,54 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package example
+
+import (
+	"github.com/patfair/raytracer/render"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestSpheresScene_Whitted(t *testing.T) {
+	// Compare a draft render against one made before shading was moved behind the material interface, to catch
+	// unintended changes to the Whitted integrator's output.
+	scene, err := SpheresScene(0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	scene.DitherVariation = 0
+	actual, err := scene.Render(render.RenderDraftPass, 160, 90)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	file, err := os.Open("testdata/spheres_scene_draft.png")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer file.Close()
+	expected, err := png.Decode(file)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, expected.Bounds(), actual.Bounds())
+	assertImageEqual(t, expected, actual, 2)
+}
+
+// Asserts that each channel of each pixel of the given images differs by no more than the given number of levels.
+func assertImageEqual(t *testing.T, expected, actual image.Image, tolerance int) {
+	bounds := expected.Bounds().Intersect(actual.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			expectedR, expectedG, expectedB, _ := expected.At(x, y).RGBA()
+			actualR, actualG, actualB, _ := actual.At(x, y).RGBA()
+			for _, channels := range [][2]uint32{{expectedR, actualR}, {expectedG, actualG}, {expectedB, actualB}} {
+				if !assert.InDelta(t, channels[0]>>8, channels[1]>>8, float64(tolerance), "pixel (%d, %d)", x, y) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Represents a box whose faces are aligned with the coordinate axes, used for quickly rejecting rays that cannot
+// intersect whatever lies inside it.
+type BoundingBox struct {
+	Min Point // Corner of the box having the smallest coordinates along every axis
+	Max Point // Corner of the box having the largest coordinates along every axis
+}
+
+// Returns a box that contains nothing, and which acts as the identity when taking the union with another box.
+func EmptyBoundingBox() BoundingBox {
+	return BoundingBox{
+		Min: Point{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: Point{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+}
+
+// Returns the smallest box that contains all of the given points.
+func NewBoundingBox(points ...Point) BoundingBox {
+	box := EmptyBoundingBox()
+	for _, point := range points {
+		box = box.AddPoint(point)
+	}
+	return box
+}
+
+// Returns the smallest box that contains both this box and the given point.
+func (box BoundingBox) AddPoint(point Point) BoundingBox {
+	return BoundingBox{
+		Min: Point{math.Min(box.Min.X, point.X), math.Min(box.Min.Y, point.Y), math.Min(box.Min.Z, point.Z)},
+		Max: Point{math.Max(box.Max.X, point.X), math.Max(box.Max.Y, point.Y), math.Max(box.Max.Z, point.Z)},
+	}
+}
+
+// Returns the smallest box that contains both this box and the given other one.
+func (box BoundingBox) Union(other BoundingBox) BoundingBox {
+	return box.AddPoint(other.Min).AddPoint(other.Max)
+}
+
+// Returns the point at the center of the box.
+func (box BoundingBox) Centroid() Point {
+	return Point{(box.Min.X + box.Max.X) / 2, (box.Min.Y + box.Max.Y) / 2, (box.Min.Z + box.Max.Z) / 2}
+}
+
+// Returns the total area of the six faces of the box, or zero if the box is empty.
+func (box BoundingBox) SurfaceArea() float64 {
+	extent := box.Min.VectorTo(box.Max)
+	if extent.X < 0 || extent.Y < 0 || extent.Z < 0 {
+		return 0
+	}
+	return 2 * (extent.X*extent.Y + extent.Y*extent.Z + extent.Z*extent.X)
+}
+
+// Returns the index of the axis (0 for X, 1 for Y and 2 for Z) along which the box is longest.
+func (box BoundingBox) LongestAxis() int {
+	extent := box.Min.VectorTo(box.Max)
+	if extent.X >= extent.Y && extent.X >= extent.Z {
+		return 0
+	} else if extent.Y >= extent.Z {
+		return 1
+	}
+	return 2
+}
+
+// Determines the distances along the given ray (measured in units of its normalized direction) at which it enters and
+// exits the box. The entry distance may be negative if the ray originates inside the box. The third return value is
+// false if the ray misses the box entirely.
+func (box BoundingBox) Intersection(ray Ray) (float64, float64, bool) {
+	direction := ray.Direction.ToUnit()
+	return box.IntersectionWithInverse(ray.Origin, Vector{1 / direction.X, 1 / direction.Y, 1 / direction.Z})
+}
+
+// Equivalent to Intersection, but takes the component-wise reciprocal of the ray's normalized direction so that it can
+// be precomputed once when testing the same ray against many boxes.
+func (box BoundingBox) IntersectionWithInverse(origin Point, inverseDirection Vector) (float64, float64, bool) {
+	near := math.Inf(-1)
+	far := math.Inf(1)
+	near, far = clipSlab(near, far, origin.X, inverseDirection.X, box.Min.X, box.Max.X)
+	near, far = clipSlab(near, far, origin.Y, inverseDirection.Y, box.Min.Y, box.Max.Y)
+	near, far = clipSlab(near, far, origin.Z, inverseDirection.Z, box.Min.Z, box.Max.Z)
+	return near, far, near <= far && far >= 0
+}
+
+func (box BoundingBox) String() string {
+	return fmt.Sprintf("[%v, %v]", box.Min, box.Max)
+}
+
+// Narrows the given entry and exit distances to the portion of the ray lying between the two planes of a single axis.
+func clipSlab(near, far, origin, inverseDirection, min, max float64) (float64, float64) {
+	t1 := (min - origin) * inverseDirection
+	t2 := (max - origin) * inverseDirection
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+
+	// Comparisons are written so that a NaN (from a ray lying exactly in one of the planes) leaves the limits unchanged.
+	if t1 > near {
+		near = t1
+	}
+	if t2 < far {
+		far = t2
+	}
+	return near, far
+}
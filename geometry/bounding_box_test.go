@@ -0,0 +1,89 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package geometry
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewBoundingBox(t *testing.T) {
+	box := NewBoundingBox(Point{1, -2, 3}, Point{-4, 5, 0}, Point{0, 0, 6})
+	assert.Equal(t, Point{-4, -2, 0}, box.Min)
+	assert.Equal(t, Point{1, 5, 6}, box.Max)
+	assert.Equal(t, Point{-1.5, 1.5, 3}, box.Centroid())
+	assert.Equal(t, 2*(5*7+7*6+6*5.0), box.SurfaceArea())
+
+	assert.Equal(t, 0.0, EmptyBoundingBox().SurfaceArea())
+	assert.Equal(t, box, EmptyBoundingBox().Union(box))
+}
+
+func TestBoundingBox_Union(t *testing.T) {
+	box1 := NewBoundingBox(Point{0, 0, 0}, Point{1, 1, 1})
+	box2 := NewBoundingBox(Point{2, -1, 0.5}, Point{3, 0, 0.5})
+
+	union := box1.Union(box2)
+	assert.Equal(t, Point{0, -1, 0}, union.Min)
+	assert.Equal(t, Point{3, 1, 1}, union.Max)
+	assert.Equal(t, union, box2.Union(box1))
+}
+
+func TestBoundingBox_LongestAxis(t *testing.T) {
+	assert.Equal(t, 0, NewBoundingBox(Point{-2, 0, 0}, Point{2, 1, 3}).LongestAxis())
+	assert.Equal(t, 1, NewBoundingBox(Point{0, -5, 0}, Point{1, 1, 3}).LongestAxis())
+	assert.Equal(t, 2, NewBoundingBox(Point{0, 0, -1}, Point{1, 1, 3}).LongestAxis())
+}
+
+func TestBoundingBox_Intersection(t *testing.T) {
+	box := NewBoundingBox(Point{-1, -1, -1}, Point{1, 1, 1})
+
+	near, far, ok := box.Intersection(Ray{Point{-5, 0, 0}, Vector{2, 0, 0}})
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, near)
+	assert.Equal(t, 6.0, far)
+
+	// Originating inside the box
+	near, far, ok = box.Intersection(Ray{Point{0, 0, 0}, Vector{0, 0, -1}})
+	assert.True(t, ok)
+	assert.Equal(t, -1.0, near)
+	assert.Equal(t, 1.0, far)
+
+	// Diagonal
+	near, far, ok = box.Intersection(Ray{Point{-2, -2, -2}, Vector{1, 1, 1}})
+	assert.True(t, ok)
+	assert.InDelta(t, math.Sqrt(3), near, 1e-9)
+	assert.InDelta(t, 3*math.Sqrt(3), far, 1e-9)
+
+	// Behind the ray
+	_, _, ok = box.Intersection(Ray{Point{5, 0, 0}, Vector{1, 0, 0}})
+	assert.False(t, ok)
+
+	// Passing beside the box
+	_, _, ok = box.Intersection(Ray{Point{-5, 1.1, 0}, Vector{1, 0, 0}})
+	assert.False(t, ok)
+}
+
+func TestBoundingBox_IntersectionFlat(t *testing.T) {
+	// A box with zero thickness, such as one bounding an axis-aligned plane.
+	box := NewBoundingBox(Point{-1, -1, 0}, Point{1, 1, 0})
+
+	near, far, ok := box.Intersection(Ray{Point{0.5, 0.5, 3}, Vector{0, 0, -1}})
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, near)
+	assert.Equal(t, 3.0, far)
+
+	// Ray lying within the plane of the box
+	_, _, ok = box.Intersection(Ray{Point{-3, 0, 0}, Vector{1, 0, 0}})
+	assert.True(t, ok)
+
+	// Ray parallel to but outside the plane of the box
+	_, _, ok = box.Intersection(Ray{Point{-3, 0, 0.1}, Vector{1, 0, 0}})
+	assert.False(t, ok)
+}
+
+func TestBoundingBox_String(t *testing.T) {
+	box := NewBoundingBox(Point{1.234, -14.567, 0.088888}, Point{2, 3, 4})
+	assert.Equal(t, "[(1.23, -14.57, 0.09), (2.00, 3.00, 4.00)]", fmt.Sprintf("%v", box))
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package geometry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Represents an affine transformation of 3D space as a 4x4 matrix in row-major order, which acts on points and vectors
+// expressed as column vectors in homogeneous coordinates.
+type Matrix [4][4]float64
+
+// Returns the matrix representing no transformation at all.
+func IdentityMatrix() Matrix {
+	return Matrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+// Returns the matrix representing a translation by the given vector.
+func NewTranslationMatrix(translation Vector) Matrix {
+	return Matrix{{1, 0, 0, translation.X}, {0, 1, 0, translation.Y}, {0, 0, 1, translation.Z}, {0, 0, 0, 1}}
+}
+
+// Returns the matrix representing scaling about the origin by the given factor along each axis.
+func NewScalingMatrix(x, y, z float64) Matrix {
+	return Matrix{{x, 0, 0, 0}, {0, y, 0, 0}, {0, 0, z, 0}, {0, 0, 0, 1}}
+}
+
+// Returns the matrix representing a counterclockwise rotation (when looking back along the axis towards the origin) by
+// the given angle about the given axis through the origin. The axis must be non-zero.
+func NewRotationMatrix(axis Vector, angleDeg float64) Matrix {
+	axis = axis.ToUnit()
+	angle := angleDeg * math.Pi / 180
+	cos := math.Cos(angle)
+	sin := math.Sin(angle)
+	t := 1 - cos
+	x, y, z := axis.X, axis.Y, axis.Z
+	return Matrix{
+		{t*x*x + cos, t*x*y - sin*z, t*x*z + sin*y, 0},
+		{t*x*y + sin*z, t*y*y + cos, t*y*z - sin*x, 0},
+		{t*x*z - sin*y, t*y*z + sin*x, t*z*z + cos, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Returns the product of this matrix and the given other one, which represents applying the other transformation
+// first and then this one.
+func (matrix Matrix) Multiply(other Matrix) Matrix {
+	var product Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				product[i][j] += matrix[i][k] * other[k][j]
+			}
+		}
+	}
+	return product
+}
+
+// Returns the matrix with its rows and columns swapped.
+func (matrix Matrix) Transpose() Matrix {
+	var transpose Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			transpose[i][j] = matrix[j][i]
+		}
+	}
+	return transpose
+}
+
+// Returns the matrix representing the opposite transformation to this one, or an error if there isn't one (such as for
+// a scaling by zero).
+func (matrix Matrix) Inverse() (Matrix, error) {
+	// Use Gauss-Jordan elimination with partial pivoting, applying the same row operations to the identity matrix.
+	inverse := IdentityMatrix()
+	for column := 0; column < 4; column++ {
+		pivot := column
+		for row := column + 1; row < 4; row++ {
+			if math.Abs(matrix[row][column]) > math.Abs(matrix[pivot][column]) {
+				pivot = row
+			}
+		}
+		if math.Abs(matrix[pivot][column]) < 1e-12 {
+			return Matrix{}, errors.New("matrix is not invertible")
+		}
+		matrix[column], matrix[pivot] = matrix[pivot], matrix[column]
+		inverse[column], inverse[pivot] = inverse[pivot], inverse[column]
+
+		scale := 1 / matrix[column][column]
+		for j := 0; j < 4; j++ {
+			matrix[column][j] *= scale
+			inverse[column][j] *= scale
+		}
+		for row := 0; row < 4; row++ {
+			if row == column {
+				continue
+			}
+			factor := matrix[row][column]
+			for j := 0; j < 4; j++ {
+				matrix[row][j] -= factor * matrix[column][j]
+				inverse[row][j] -= factor * inverse[column][j]
+			}
+		}
+	}
+	return inverse, nil
+}
+
+// Returns the result of applying the transformation to the given point.
+func (matrix Matrix) TransformPoint(point Point) Point {
+	return Point{
+		matrix[0][0]*point.X + matrix[0][1]*point.Y + matrix[0][2]*point.Z + matrix[0][3],
+		matrix[1][0]*point.X + matrix[1][1]*point.Y + matrix[1][2]*point.Z + matrix[1][3],
+		matrix[2][0]*point.X + matrix[2][1]*point.Y + matrix[2][2]*point.Z + matrix[2][3],
+	}
+}
+
+// Returns the result of applying the transformation to the given vector, which is unaffected by translation.
+func (matrix Matrix) TransformVector(vector Vector) Vector {
+	return Vector{
+		matrix[0][0]*vector.X + matrix[0][1]*vector.Y + matrix[0][2]*vector.Z,
+		matrix[1][0]*vector.X + matrix[1][1]*vector.Y + matrix[1][2]*vector.Z,
+		matrix[2][0]*vector.X + matrix[2][1]*vector.Y + matrix[2][2]*vector.Z,
+	}
+}
+
+// Returns the smallest axis-aligned box that contains the given box after applying the transformation to it.
+func (matrix Matrix) TransformBoundingBox(box BoundingBox) BoundingBox {
+	if box.Min.X > box.Max.X || box.Min.Y > box.Max.Y || box.Min.Z > box.Max.Z {
+		return EmptyBoundingBox()
+	}
+	transformedBox := EmptyBoundingBox()
+	for _, x := range []float64{box.Min.X, box.Max.X} {
+		for _, y := range []float64{box.Min.Y, box.Max.Y} {
+			for _, z := range []float64{box.Min.Z, box.Max.Z} {
+				transformedBox = transformedBox.AddPoint(matrix.TransformPoint(Point{x, y, z}))
+			}
+		}
+	}
+	return transformedBox
+}
+
+func (matrix Matrix) String() string {
+	return fmt.Sprintf("[%.2f %.2f %.2f %.2f; %.2f %.2f %.2f %.2f; %.2f %.2f %.2f %.2f; %.2f %.2f %.2f %.2f]",
+		matrix[0][0], matrix[0][1], matrix[0][2], matrix[0][3], matrix[1][0], matrix[1][1], matrix[1][2], matrix[1][3],
+		matrix[2][0], matrix[2][1], matrix[2][2], matrix[2][3], matrix[3][0], matrix[3][1], matrix[3][2], matrix[3][3])
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package geometry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestMatrix_Transform(t *testing.T) {
+	point := Point{1, 2, 3}
+	vector := Vector{1, 2, 3}
+
+	assert.Equal(t, point, IdentityMatrix().TransformPoint(point))
+	assert.Equal(t, vector, IdentityMatrix().TransformVector(vector))
+
+	translation := NewTranslationMatrix(Vector{-1, 0, 5})
+	assert.Equal(t, Point{0, 2, 8}, translation.TransformPoint(point))
+	assert.Equal(t, vector, translation.TransformVector(vector))
+
+	scaling := NewScalingMatrix(2, -1, 0.5)
+	assert.Equal(t, Point{2, -2, 1.5}, scaling.TransformPoint(point))
+	assert.Equal(t, Vector{2, -2, 1.5}, scaling.TransformVector(vector))
+
+	rotation := NewRotationMatrix(Vector{0, 0, 2}, 90)
+	AssertPointEqual(t, Point{-2, 1, 3}, rotation.TransformPoint(point))
+	AssertVectorEqual(t, Vector{-2, 1, 3}, rotation.TransformVector(vector))
+	rotation = NewRotationMatrix(Vector{1, 1, 1}, 120)
+	AssertVectorEqual(t, Vector{3, 1, 2}, rotation.TransformVector(vector))
+}
+
+func TestMatrix_Multiply(t *testing.T) {
+	// Scale, then rotate, then translate.
+	matrix := NewTranslationMatrix(Vector{0, 0, 1}).Multiply(NewRotationMatrix(Vector{0, 1, 0}, 90)).
+		Multiply(NewScalingMatrix(2, 2, 2))
+	AssertPointEqual(t, Point{0, 0, -1}, matrix.TransformPoint(Point{1, 0, 0}))
+	AssertPointEqual(t, Point{0, 4, 1}, matrix.TransformPoint(Point{0, 2, 0}))
+	assert.Equal(t, matrix, matrix.Multiply(IdentityMatrix()))
+	assert.Equal(t, matrix, IdentityMatrix().Multiply(matrix))
+}
+
+func TestMatrix_Transpose(t *testing.T) {
+	matrix := Matrix{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13, 14, 15, 16}}
+	assert.Equal(t, Matrix{{1, 5, 9, 13}, {2, 6, 10, 14}, {3, 7, 11, 15}, {4, 8, 12, 16}}, matrix.Transpose())
+}
+
+func TestMatrix_Inverse(t *testing.T) {
+	matrix := NewTranslationMatrix(Vector{3, -1, 2}).Multiply(NewRotationMatrix(Vector{1, 2, 3}, 40)).
+		Multiply(NewScalingMatrix(0.5, 4, 1))
+	inverse, err := matrix.Inverse()
+	assert.Nil(t, err)
+	product := matrix.Multiply(inverse)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			assert.InDelta(t, IdentityMatrix()[i][j], product[i][j], 1e-9)
+		}
+	}
+	AssertPointEqual(t, Point{1, 2, 3}, inverse.TransformPoint(matrix.TransformPoint(Point{1, 2, 3})))
+
+	// Swapping axes requires pivoting, since the first column has a zero where the pivot would otherwise be.
+	inverse, err = Matrix{{0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}.Inverse()
+	assert.Nil(t, err)
+	assert.Equal(t, Matrix{{0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}, inverse)
+
+	_, err = NewScalingMatrix(1, 0, 1).Inverse()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not invertible")
+	}
+}
+
+func TestMatrix_TransformBoundingBox(t *testing.T) {
+	box := NewBoundingBox(Point{0, 0, 0}, Point{1, 2, 3})
+	transformedBox := NewRotationMatrix(Vector{0, 0, 1}, 45).TransformBoundingBox(box)
+	AssertPointEqual(t, Point{-math.Sqrt2, 0, 0}, transformedBox.Min)
+	AssertPointEqual(t, Point{1 / math.Sqrt2, 3 / math.Sqrt2, 3}, transformedBox.Max)
+
+	assert.Equal(t, EmptyBoundingBox(), IdentityMatrix().TransformBoundingBox(EmptyBoundingBox()))
+}
+
+func TestMatrix_String(t *testing.T) {
+	assert.Equal(t, "[1.00 0.00 0.00 2.00; 0.00 1.00 0.00 3.00; 0.00 0.00 1.00 4.00; 0.00 0.00 0.00 1.00]",
+		NewTranslationMatrix(Vector{2, 3, 4}).String())
+}
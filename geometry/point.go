@@ -36,6 +36,18 @@ func (point Point) VectorTo(other Point) Vector {
 	}
 }
 
+// Returns the coordinate of the point along the axis having the given index (0 for X, 1 for Y and 2 for Z).
+func (point Point) Coordinate(axis int) float64 {
+	switch axis {
+	case 0:
+		return point.X
+	case 1:
+		return point.Y
+	default:
+		return point.Z
+	}
+}
+
 func (point Point) String() string {
 	return fmt.Sprintf("(%.2f, %.2f, %.2f)", point.X, point.Y, point.Z)
 }
@@ -39,6 +39,13 @@ func TestPoint_VectorTo(t *testing.T) {
 	assert.Equal(t, Vector{-6.5, 2, -4.23}, vector2)
 }
 
+func TestPoint_Coordinate(t *testing.T) {
+	point := Point{-1, 2, -3}
+	assert.Equal(t, -1.0, point.Coordinate(0))
+	assert.Equal(t, 2.0, point.Coordinate(1))
+	assert.Equal(t, -3.0, point.Coordinate(2))
+}
+
 func TestPoint_String(t *testing.T) {
 	point := Point{1.234, -14.567, 0.088888}
 
@@ -20,3 +20,8 @@ func AssertVectorEqual(t *testing.T, expected, actual Vector) {
 	assert.InDelta(t, expected.Y, actual.Y, epsilon, "Y expected: %v, actual: %v", expected.Y, actual.Y)
 	assert.InDelta(t, expected.Z, actual.Z, epsilon, "Z expected: %v, actual: %v", expected.Z, actual.Z)
 }
+
+// Asserts equality of the two given points, within a small allowable error.
+func AssertPointEqual(t *testing.T, expected, actual Point) {
+	AssertVectorEqual(t, Vector(expected), Vector(actual))
+}
@@ -60,6 +60,18 @@ func (vector Vector) Cross(other Vector) Vector {
 	}
 }
 
+// Returns two unit vectors that, together with this one (which must itself be a unit vector), form a right-handed
+// orthonormal basis. Useful for converting directions sampled relative to a surface normal into world coordinates.
+func (vector Vector) OrthonormalBasis() (Vector, Vector) {
+	// Uses the branchless method of Duff et al. (2017), which is robust for all input directions.
+	sign := math.Copysign(1, vector.Z)
+	a := -1 / (sign + vector.Z)
+	b := vector.X * vector.Y * a
+	u := Vector{1 + sign*vector.X*vector.X*a, sign * b, -sign * vector.X}
+	v := Vector{b, sign + vector.Y*vector.Y*a, -vector.Y}
+	return u, v
+}
+
 func (vector Vector) String() string {
 	return fmt.Sprintf("(%.2f, %.2f, %.2f)", vector.X, vector.Y, vector.Z)
 }
@@ -53,6 +53,19 @@ func TestVector_Cross(t *testing.T) {
 	AssertVectorEqual(t, Vector{-7, -14, -7}, Vector{-1, 2, -3}.Cross(Vector{6, -5, 4}))
 }
 
+func TestVector_OrthonormalBasis(t *testing.T) {
+	for _, vector := range []Vector{{0, 0, 1}, {0, 0, -1}, {1, 0, 0}, {0, -1, 0}, Vector{1, 2, -3}.ToUnit(),
+		Vector{-0.01, 0.02, -1}.ToUnit()} {
+		u, v := vector.OrthonormalBasis()
+		assert.InDelta(t, 1, u.Norm(), 1e-9)
+		assert.InDelta(t, 1, v.Norm(), 1e-9)
+		assert.InDelta(t, 0, u.Dot(v), 1e-9)
+		assert.InDelta(t, 0, u.Dot(vector), 1e-9)
+		assert.InDelta(t, 0, v.Dot(vector), 1e-9)
+		AssertVectorEqual(t, vector, u.Cross(v))
+	}
+}
+
 func TestVector_String(t *testing.T) {
 	vector := Vector{-1.2, 3, 4.56}
 	assert.Equal(t, "(-1.20, 3.00, 4.56)", fmt.Sprintf("%v", vector))
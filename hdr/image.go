@@ -0,0 +1,104 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+// Package hdr contains the high dynamic range framebuffer produced by rendering, along with writers for the Radiance
+// HDR and OpenEXR file formats that preserve its full range of values.
+package hdr
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Represents a rendered image whose pixels are unclamped floating-point colors, so that highlights brighter than white
+// are preserved.
+type Image struct {
+	Pixels [][]shading.Color // Pixel colors indexed by row and then column, with the first row at the top
+}
+
+// Returns a new black image of the given size.
+func NewImage(width, height int) *Image {
+	pixels := make([][]shading.Color, height)
+	for i := range pixels {
+		pixels[i] = make([]shading.Color, width)
+	}
+	return &Image{Pixels: pixels}
+}
+
+// Returns a new image containing the linear values of the given 8- or 16-bit image. If the image holds colors, its
+// components are assumed to be encoded with the sRGB transfer function as is usual for PNG and JPEG files; otherwise
+// (such as for a normal map) they are used as-is. Transparency is ignored.
+func FromImage(img image.Image, isColor bool) *Image {
+	bounds := img.Bounds()
+	result := NewImage(bounds.Dx(), bounds.Dy())
+	decode := func(value uint32) float64 {
+		if isColor {
+			return decodeSrgb(value)
+		}
+		return float64(value) / 0xffff
+	}
+	for y, row := range result.Pixels {
+		for x := range row {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			row[x] = shading.Color{decode(r), decode(g), decode(b)}
+		}
+	}
+	return result
+}
+
+// Loads the image at the given path, which may be a Radiance HDR (.hdr) file or any PNG or JPEG file. Whether the image
+// holds colors determines how PNG and JPEG values are decoded, as for FromImage.
+func LoadImage(path string, isColor bool) (*Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".hdr" {
+		return ReadRadianceHdr(file)
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return FromImage(img, isColor), nil
+}
+
+// Returns the width of the image in pixels.
+func (img *Image) Width() int {
+	if len(img.Pixels) == 0 {
+		return 0
+	}
+	return len(img.Pixels[0])
+}
+
+// Returns the height of the image in pixels.
+func (img *Image) Height() int {
+	return len(img.Pixels)
+}
+
+// Returns an 8-bit version of the image, with each color component clamped to [0, 1].
+func (img *Image) ToRgba() *image.RGBA {
+	rgba := image.NewRGBA(image.Rectangle{image.Point{0, 0}, image.Point{img.Width(), img.Height()}})
+	for y, row := range img.Pixels {
+		for x, pixel := range row {
+			rgba.SetRGBA(x, y, pixel.ToRgba())
+		}
+	}
+	return rgba
+}
+
+// Returns the linear value of the given 16-bit sRGB-encoded color component, as the inverse of encodeSrgb.
+func decodeSrgb(value uint32) float64 {
+	encoded := float64(value) / 0xffff
+	if encoded <= 0.04045 {
+		return encoded / 12.92
+	}
+	return math.Pow((encoded+0.055)/1.055, 2.4)
+}
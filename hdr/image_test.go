@@ -0,0 +1,101 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"bytes"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	img := NewImage(3, 2)
+	assert.Equal(t, 3, img.Width())
+	assert.Equal(t, 2, img.Height())
+	assert.Equal(t, shading.Color{}, img.Pixels[1][2])
+	assert.Equal(t, 0, (&Image{}).Width())
+
+	img.Pixels[0][1] = shading.Color{0.5, 2, -1}
+	img.Pixels[1][2] = shading.Color{1, 0.25, 0}
+	rgba := img.ToRgba()
+	assert.Equal(t, 3, rgba.Bounds().Dx())
+	assert.Equal(t, 2, rgba.Bounds().Dy())
+	assert.Equal(t, color.RGBA{127, 255, 0, 255}, rgba.RGBAAt(1, 0))
+	assert.Equal(t, color.RGBA{255, 63, 0, 255}, rgba.RGBAAt(2, 1))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, rgba.RGBAAt(0, 0))
+}
+
+func TestFromImage(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	rgba.SetRGBA(0, 0, color.RGBA{255, 188, 0, 255})
+	rgba.SetRGBA(1, 0, color.RGBA{10, 0, 0, 255})
+	img := FromImage(rgba, true)
+	assert.Equal(t, 2, img.Width())
+	assert.Equal(t, 1, img.Height())
+	assert.Equal(t, 1.0, img.Pixels[0][0].R)
+	assert.InDelta(t, 0.5, img.Pixels[0][0].G, 0.005)
+	assert.Equal(t, 0.0, img.Pixels[0][0].B)
+	assert.InDelta(t, 0.003, img.Pixels[0][1].R, 0.0001)
+
+	// Non-color data shouldn't be decoded.
+	img = FromImage(rgba, false)
+	assert.Equal(t, shading.Color{1, 188.0 / 255, 0}, img.Pixels[0][0])
+
+	// Decoding should undo the encoding used for tone mapping.
+	for _, value := range []float64{0, 0.002, 0.2, 0.7, 1} {
+		assert.InDelta(t, value, decodeSrgb(uint32(encodeSrgb(value))*0x101), 0.005)
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	directory, err := ioutil.TempDir("", "hdr")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.SetRGBA(0, 0, color.RGBA{255, 0, 255, 255})
+	var pngData bytes.Buffer
+	assert.Nil(t, png.Encode(&pngData, rgba))
+	pngPath := filepath.Join(directory, "test.png")
+	assert.Nil(t, ioutil.WriteFile(pngPath, pngData.Bytes(), 0644))
+	img, err := LoadImage(pngPath, true)
+	if assert.Nil(t, err) {
+		assert.Equal(t, [][]shading.Color{{{1, 0, 1}}}, img.Pixels)
+	}
+
+	var hdrData bytes.Buffer
+	assert.Nil(t, WriteRadianceHdr(&hdrData, &Image{Pixels: [][]shading.Color{{{4, 0, 2}}}}))
+	hdrPath := filepath.Join(directory, "test.HDR")
+	assert.Nil(t, ioutil.WriteFile(hdrPath, hdrData.Bytes(), 0644))
+	img, err = LoadImage(hdrPath, true)
+	if assert.Nil(t, err) {
+		assert.InDelta(t, 4, img.Pixels[0][0].R, 4.0/128)
+		assert.Equal(t, 0.0, img.Pixels[0][0].G)
+		assert.InDelta(t, 2, img.Pixels[0][0].B, 4.0/128)
+	}
+
+	_, err = LoadImage(filepath.Join(directory, "nonexistent.png"), true)
+	assert.NotNil(t, err)
+	textPath := filepath.Join(directory, "test.jpg")
+	assert.Nil(t, ioutil.WriteFile(textPath, []byte("not an image"), 0644))
+	_, err = LoadImage(textPath, true)
+	assert.NotNil(t, err)
+}
+
+// Returns an image of the given size filled with a gradient containing values much brighter than white.
+func newTestImage(width, height int) *Image {
+	img := NewImage(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Pixels[y][x] = shading.Color{float64(x) / 4, float64(y) * 8, 0.5}
+		}
+	}
+	return img
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"github.com/patfair/raytracer/shading"
+	"io"
+	"math"
+)
+
+const (
+	exrMagicNumber       = 20000630
+	exrVersion           = 2
+	exrZipScanlinesBlock = 16 // Number of scanlines compressed together in each block when using ZIP compression
+)
+
+// Represents the data type used to store each color component in an OpenEXR file.
+type ExrPixelType int
+
+const (
+	ExrHalf  ExrPixelType = 1 // 16-bit floating-point
+	ExrFloat ExrPixelType = 2 // 32-bit floating-point
+)
+
+// Represents the compression scheme used for the pixel data in an OpenEXR file.
+type ExrCompression int
+
+const (
+	ExrNoCompression  ExrCompression = 0
+	ExrZipCompression ExrCompression = 3 // Lossless zlib compression of blocks of 16 scanlines
+)
+
+// Writes the given image to the given writer as a scanline-based OpenEXR (.exr) file, storing the unclamped color
+// components using the given pixel type and compression.
+func WriteExr(writer io.Writer, img *Image, pixelType ExrPixelType, compression ExrCompression) error {
+	if pixelType != ExrHalf && pixelType != ExrFloat {
+		return errors.New("unsupported OpenEXR pixel type")
+	}
+	linesPerBlock := 1
+	switch compression {
+	case ExrNoCompression:
+	case ExrZipCompression:
+		linesPerBlock = exrZipScanlinesBlock
+	default:
+		return errors.New("unsupported OpenEXR compression")
+	}
+	width, height := img.Width(), img.Height()
+	if width == 0 || height == 0 {
+		return errors.New("image must not be empty")
+	}
+
+	// The pixel data is divided into blocks of scanlines, each of which is prefixed by its first scanline number and
+	// size and compressed independently.
+	var blocks [][]byte
+	for y := 0; y < height; y += linesPerBlock {
+		endY := int(math.Min(float64(y+linesPerBlock), float64(height)))
+		data := exrScanlineData(img, y, endY, pixelType)
+		if compression == ExrZipCompression {
+			// Leave the block uncompressed if compression doesn't make it smaller, as the format allows.
+			if compressedData, err := zipCompress(data); err != nil {
+				return err
+			} else if len(compressedData) < len(data) {
+				data = compressedData
+			}
+		}
+		block := make([]byte, 8, 8+len(data))
+		binary.LittleEndian.PutUint32(block[0:], uint32(y))
+		binary.LittleEndian.PutUint32(block[4:], uint32(len(data)))
+		blocks = append(blocks, append(block, data...))
+	}
+
+	header := exrHeader(width, height, pixelType, compression)
+
+	// The header is followed by a table of the offset of each block from the start of the file.
+	offsetTable := make([]byte, 8*len(blocks))
+	offset := len(header) + len(offsetTable)
+	for i, block := range blocks {
+		binary.LittleEndian.PutUint64(offsetTable[8*i:], uint64(offset))
+		offset += len(block)
+	}
+
+	bufferedWriter := bufio.NewWriter(writer)
+	if _, err := bufferedWriter.Write(header); err != nil {
+		return err
+	}
+	if _, err := bufferedWriter.Write(offsetTable); err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if _, err := bufferedWriter.Write(block); err != nil {
+			return err
+		}
+	}
+	return bufferedWriter.Flush()
+}
+
+// Returns the OpenEXR header describing an image with the given properties, including the attributes that the format
+// requires to be present.
+func exrHeader(width, height int, pixelType ExrPixelType, compression ExrCompression) []byte {
+	var header bytes.Buffer
+	writeInt32 := func(value int) {
+		_ = binary.Write(&header, binary.LittleEndian, int32(value))
+	}
+	writeFloat32 := func(value float32) {
+		_ = binary.Write(&header, binary.LittleEndian, value)
+	}
+	writeAttribute := func(name, attributeType string, size int) {
+		header.WriteString(name + "\x00" + attributeType + "\x00")
+		writeInt32(size)
+	}
+
+	writeInt32(exrMagicNumber)
+	writeInt32(exrVersion)
+
+	// The channels must be listed in alphabetical order, each followed by its pixel type, linearity flag, three
+	// reserved bytes and horizontal and vertical sampling rates.
+	channels := []string{"B", "G", "R"}
+	writeAttribute("channels", "chlist", len(channels)*18+1)
+	for _, channel := range channels {
+		header.WriteString(channel + "\x00")
+		writeInt32(int(pixelType))
+		header.Write([]byte{0, 0, 0, 0})
+		writeInt32(1)
+		writeInt32(1)
+	}
+	header.WriteByte(0)
+
+	writeAttribute("compression", "compression", 1)
+	header.WriteByte(byte(compression))
+	for _, window := range []string{"dataWindow", "displayWindow"} {
+		writeAttribute(window, "box2i", 16)
+		writeInt32(0)
+		writeInt32(0)
+		writeInt32(width - 1)
+		writeInt32(height - 1)
+	}
+	writeAttribute("lineOrder", "lineOrder", 1)
+	header.WriteByte(0) // Increasing Y
+	writeAttribute("pixelAspectRatio", "float", 4)
+	writeFloat32(1)
+	writeAttribute("screenWindowCenter", "v2f", 8)
+	writeFloat32(0)
+	writeFloat32(0)
+	writeAttribute("screenWindowWidth", "float", 4)
+	writeFloat32(1)
+
+	header.WriteByte(0)
+	return header.Bytes()
+}
+
+// Returns the uncompressed pixel data for the scanlines in [startY, endY), in which each scanline contains every
+// pixel's value for each channel in turn, in alphabetical order of channel name.
+func exrScanlineData(img *Image, startY, endY int, pixelType ExrPixelType) []byte {
+	var data bytes.Buffer
+	for y := startY; y < endY; y++ {
+		row := img.Pixels[y]
+		for _, channel := range []func(shading.Color) float64{
+			func(color shading.Color) float64 { return color.B },
+			func(color shading.Color) float64 { return color.G },
+			func(color shading.Color) float64 { return color.R },
+		} {
+			for _, pixel := range row {
+				value := channel(pixel)
+				if pixelType == ExrHalf {
+					_ = binary.Write(&data, binary.LittleEndian, floatToHalf(float32(value)))
+				} else {
+					_ = binary.Write(&data, binary.LittleEndian, float32(value))
+				}
+			}
+		}
+	}
+	return data.Bytes()
+}
+
+// Returns the given pixel data compressed using the OpenEXR ZIP scheme, in which the bytes are first reordered and
+// delta-encoded to make them more compressible and then compressed using zlib.
+func zipCompress(data []byte) ([]byte, error) {
+	// Split the bytes into those at even and odd positions, so that the similar high bytes of the values are together.
+	reordered := make([]byte, len(data))
+	halfLength := (len(data) + 1) / 2
+	for i, value := range data {
+		if i%2 == 0 {
+			reordered[i/2] = value
+		} else {
+			reordered[halfLength+i/2] = value
+		}
+	}
+
+	// Replace each byte with its difference from the previous one.
+	for i := len(reordered) - 1; i > 0; i-- {
+		reordered[i] = reordered[i] - reordered[i-1] + 128
+	}
+
+	var compressed bytes.Buffer
+	zlibWriter := zlib.NewWriter(&compressed)
+	if _, err := zlibWriter.Write(reordered); err != nil {
+		return nil, err
+	}
+	if err := zlibWriter.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// Returns the given value as an IEEE 754 half-precision floating-point number, rounded to the nearest representable
+// value. Values too large to represent become infinity.
+func floatToHalf(value float32) uint16 {
+	bits := math.Float32bits(value)
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int(bits>>23) & 0xff
+	mantissa := bits & 0x7fffff
+
+	if exponent == 0xff {
+		if mantissa != 0 {
+			return sign | 0x7e00 // NaN
+		}
+		return sign | 0x7c00 // Infinity
+	}
+
+	// Rebias the exponent for the smaller half-precision range.
+	exponent = exponent - 127 + 15
+	if exponent >= 0x1f {
+		return sign | 0x7c00
+	}
+	if exponent <= 0 {
+		// The value is too small for a normalized half, so make it subnormal by shifting in the implicit leading bit.
+		if exponent < -10 {
+			return sign
+		}
+		mantissa |= 0x800000
+		shift := uint(14 - exponent)
+		return sign | roundShiftedMantissa(mantissa, shift)
+	}
+
+	// Rounding may carry into the exponent, which correctly produces the next power of two (or infinity).
+	return sign | (uint16(exponent<<10) + roundShiftedMantissa(mantissa, 13))
+}
+
+// Returns the given mantissa shifted right by the given number of bits, rounding to the nearest value and to even in
+// the case of a tie.
+func roundShiftedMantissa(mantissa uint32, shift uint) uint16 {
+	result := mantissa >> shift
+	remainder := mantissa & (1<<shift - 1)
+	halfway := uint32(1) << (shift - 1)
+	if remainder > halfway || remainder == halfway && result&1 == 1 {
+		result++
+	}
+	return uint16(result)
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestWriteExr(t *testing.T) {
+	width, height := 7, 20
+	img := newTestImage(width, height)
+	for _, pixelType := range []ExrPixelType{ExrHalf, ExrFloat} {
+		for _, compression := range []ExrCompression{ExrNoCompression, ExrZipCompression} {
+			var buffer bytes.Buffer
+			assert.Nil(t, WriteExr(&buffer, img, pixelType, compression))
+			data := buffer.Bytes()
+
+			header := exrHeader(width, height, pixelType, compression)
+			assert.Equal(t, header, data[:len(header)])
+			assert.Equal(t, uint32(exrMagicNumber), binary.LittleEndian.Uint32(data))
+
+			// Read each block of scanlines via the offset table and check that the pixels survived intact.
+			linesPerBlock := 1
+			if compression == ExrZipCompression {
+				linesPerBlock = exrZipScanlinesBlock
+			}
+			numBlocks := (height + linesPerBlock - 1) / linesPerBlock
+			for i := 0; i < numBlocks; i++ {
+				offset := binary.LittleEndian.Uint64(data[len(header)+8*i:])
+				y := int(binary.LittleEndian.Uint32(data[offset:]))
+				size := binary.LittleEndian.Uint32(data[offset+4:])
+				assert.Equal(t, i*linesPerBlock, y)
+				blockData := data[offset+8 : offset+8+uint64(size)]
+				expectedData := exrScanlineData(img, y, int(math.Min(float64(y+linesPerBlock), float64(height))),
+					pixelType)
+				if compression == ExrZipCompression {
+					blockData = zipDecompress(t, blockData)
+				}
+				assert.Equal(t, expectedData, blockData)
+			}
+
+			// Check a single pixel's components, which are stored in the order B, G, R.
+			y, x := 3, 5
+			blockStart := binary.LittleEndian.Uint64(data[len(header)+8*(y/linesPerBlock):]) + 8
+			blockData := data[blockStart:]
+			if compression == ExrZipCompression {
+				blockData = zipDecompress(t, blockData[:binary.LittleEndian.Uint32(data[blockStart-4:])])
+			}
+			componentSize := 2
+			if pixelType == ExrFloat {
+				componentSize = 4
+			}
+			lineSize := 3 * width * componentSize
+			for channel, expected := range []float64{0.5, 24, 1.25} {
+				position := (y%linesPerBlock)*lineSize + channel*width*componentSize + x*componentSize
+				var actual float64
+				if pixelType == ExrHalf {
+					actual = halfToFloat(binary.LittleEndian.Uint16(blockData[position:]))
+				} else {
+					actual = float64(math.Float32frombits(binary.LittleEndian.Uint32(blockData[position:])))
+				}
+				assert.Equal(t, expected, actual)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	err := WriteExr(&buffer, img, 3, ExrNoCompression)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "pixel type")
+	}
+	err = WriteExr(&buffer, img, ExrHalf, 4)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "compression")
+	}
+	err = WriteExr(&buffer, NewImage(0, 0), ExrHalf, ExrNoCompression)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "empty")
+	}
+}
+
+func TestFloatToHalf(t *testing.T) {
+	assert.Equal(t, uint16(0x0000), floatToHalf(0))
+	assert.Equal(t, uint16(0x3c00), floatToHalf(1))
+	assert.Equal(t, uint16(0x3800), floatToHalf(0.5))
+	assert.Equal(t, uint16(0xc000), floatToHalf(-2))
+	assert.Equal(t, uint16(0x2e66), floatToHalf(0.1))
+	assert.Equal(t, uint16(0x7bff), floatToHalf(65504))
+	assert.Equal(t, uint16(0x7c00), floatToHalf(1e6))
+	assert.Equal(t, uint16(0x7c00), floatToHalf(float32(math.Inf(1))))
+	assert.Equal(t, uint16(0x7e00), floatToHalf(float32(math.NaN())))
+
+	// Subnormal values
+	assert.Equal(t, uint16(0x0001), floatToHalf(float32(math.Ldexp(1, -24))))
+	assert.Equal(t, uint16(0x0200), floatToHalf(float32(math.Ldexp(1, -15))))
+	assert.Equal(t, uint16(0x0000), floatToHalf(float32(math.Ldexp(1, -26))))
+
+	// Ties should round to even.
+	assert.Equal(t, uint16(0x3c00), floatToHalf(1+float32(math.Ldexp(1, -11))))
+	assert.Equal(t, uint16(0x3c02), floatToHalf(1+3*float32(math.Ldexp(1, -11))))
+}
+
+// Returns the given data decompressed using the OpenEXR ZIP scheme.
+func zipDecompress(t *testing.T, data []byte) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	reordered, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+
+	for i := 1; i < len(reordered); i++ {
+		reordered[i] = reordered[i-1] + reordered[i] - 128
+	}
+	decompressed := make([]byte, len(reordered))
+	halfLength := (len(reordered) + 1) / 2
+	for i := range decompressed {
+		if i%2 == 0 {
+			decompressed[i] = reordered[i/2]
+		} else {
+			decompressed[i] = reordered[halfLength+i/2]
+		}
+	}
+	return decompressed
+}
+
+// Returns the value of the given IEEE 754 half-precision floating-point number.
+func halfToFloat(half uint16) float64 {
+	sign := 1.0
+	if half&0x8000 != 0 {
+		sign = -1
+	}
+	exponent := int(half>>10) & 0x1f
+	mantissa := float64(half & 0x3ff)
+	if exponent == 0 {
+		return sign * math.Ldexp(mantissa, -24)
+	}
+	return sign * math.Ldexp(1+mantissa/1024, exponent-15)
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/shading"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	minRleScanlineWidth = 8      // Narrowest scanline that the run-length encoding can be used for
+	maxRleScanlineWidth = 0x7fff // Widest scanline that the run-length encoding can be used for
+	minRleRunLength     = 4      // Shortest run of identical bytes worth encoding as a run
+	maxRleRunLength     = 127    // Longest run of identical bytes that can be encoded at once
+	maxRleDumpLength    = 128    // Longest sequence of literal bytes that can be encoded at once
+)
+
+// Writes the given image to the given writer in the Radiance HDR (.hdr) format, which stores each pixel as an 8-bit
+// mantissa for each color component along with a shared 8-bit exponent (RGBE). Scanlines are run-length encoded where
+// the format allows it.
+func WriteRadianceHdr(writer io.Writer, img *Image) error {
+	bufferedWriter := bufio.NewWriter(writer)
+	width, height := img.Width(), img.Height()
+	if _, err := fmt.Fprintf(bufferedWriter, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", height,
+		width); err != nil {
+		return err
+	}
+
+	rgbe := make([]byte, 4*width)
+	component := make([]byte, width)
+	for _, row := range img.Pixels {
+		for x, pixel := range row {
+			copy(rgbe[4*x:], toRgbe(pixel))
+		}
+
+		if width < minRleScanlineWidth || width > maxRleScanlineWidth {
+			// The run-length encoding can't represent this width, so write the pixels as-is.
+			if _, err := bufferedWriter.Write(rgbe); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Each run-length encoded scanline starts with a marker that can't be mistaken for a pixel, followed by each of
+		// the four components for the whole scanline encoded separately.
+		if _, err := bufferedWriter.Write([]byte{2, 2, byte(width >> 8), byte(width & 0xff)}); err != nil {
+			return err
+		}
+		for i := 0; i < 4; i++ {
+			for x := range component {
+				component[x] = rgbe[4*x+i]
+			}
+			if _, err := bufferedWriter.Write(encodeRle(component)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Reads an image in the Radiance HDR (.hdr) format from the given reader, accepting both run-length encoded and flat
+// scanlines. Only the standard orientation, in which the first scanline is at the top, is supported.
+func ReadRadianceHdr(reader io.Reader) (*Image, error) {
+	bufferedReader := bufio.NewReader(reader)
+	readLine := func() (string, error) {
+		line, err := bufferedReader.ReadString('\n')
+		if err != nil {
+			return "", errors.New("unexpected end of Radiance HDR header")
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	// The header is a series of lines terminated by a blank one, followed by a line giving the resolution.
+	line, err := readLine()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return nil, errors.New("not a Radiance HDR file")
+	}
+	for line != "" {
+		if line, err = readLine(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported Radiance HDR format %q", strings.TrimPrefix(line, "FORMAT="))
+		}
+	}
+	if line, err = readLine(); err != nil {
+		return nil, err
+	}
+	var width, height int
+	if _, err = fmt.Sscanf(line, "-Y %d +X %d", &height, &width); err != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("unsupported Radiance HDR resolution %q", line)
+	}
+
+	img := NewImage(width, height)
+	rgbe := make([]byte, 4*width)
+	for _, row := range img.Pixels {
+		if _, err = io.ReadFull(bufferedReader, rgbe[:4]); err != nil {
+			return nil, errors.New("unexpected end of Radiance HDR pixel data")
+		}
+		if width >= minRleScanlineWidth && width <= maxRleScanlineWidth && rgbe[0] == 2 && rgbe[1] == 2 &&
+			rgbe[2] < 128 {
+			if int(rgbe[2])<<8|int(rgbe[3]) != width {
+				return nil, errors.New("Radiance HDR scanline width doesn't match the image")
+			}
+			if err = decodeRleScanline(bufferedReader, rgbe); err != nil {
+				return nil, err
+			}
+		} else if _, err = io.ReadFull(bufferedReader, rgbe[4:]); err != nil {
+			return nil, errors.New("unexpected end of Radiance HDR pixel data")
+		}
+
+		for x := range row {
+			row[x] = fromRgbe(rgbe[4*x : 4*x+4])
+		}
+	}
+	return img, nil
+}
+
+// Reads a run-length encoded scanline (following its marker) from the given reader into the given buffer of
+// interleaved RGBE values.
+func decodeRleScanline(reader *bufio.Reader, rgbe []byte) error {
+	width := len(rgbe) / 4
+	for i := 0; i < 4; i++ {
+		for x := 0; x < width; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return errors.New("unexpected end of Radiance HDR pixel data")
+			}
+			isRun := count > 128
+			if isRun {
+				count -= 128
+			}
+			if count == 0 || x+int(count) > width {
+				return errors.New("invalid Radiance HDR run-length encoding")
+			}
+			for j := 0; j < int(count); j++ {
+				if j == 0 || !isRun {
+					if rgbe[4*x+i], err = reader.ReadByte(); err != nil {
+						return errors.New("unexpected end of Radiance HDR pixel data")
+					}
+				} else {
+					rgbe[4*x+i] = rgbe[4*(x-1)+i]
+				}
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the color represented by the given RGBE value, taking the middle of the range that each non-zero mantissa
+// covers.
+func fromRgbe(rgbe []byte) shading.Color {
+	if rgbe[3] == 0 {
+		return shading.Color{}
+	}
+	scale := math.Ldexp(1, int(rgbe[3])-(128+8))
+	component := func(mantissa byte) float64 {
+		if mantissa == 0 {
+			return 0
+		}
+		return (float64(mantissa) + 0.5) * scale
+	}
+	return shading.Color{component(rgbe[0]), component(rgbe[1]), component(rgbe[2])}
+}
+
+// Returns the RGBE representation of the given color, in which the components share the exponent of the largest one.
+// Negative components are clamped to zero.
+func toRgbe(color shading.Color) []byte {
+	r, g, b := math.Max(color.R, 0), math.Max(color.G, 0), math.Max(color.B, 0)
+	maxComponent := math.Max(r, math.Max(g, b))
+	if maxComponent < 1e-32 {
+		return []byte{0, 0, 0, 0}
+	}
+	mantissa, exponent := math.Frexp(maxComponent)
+	if exponent > 127 {
+		// Too bright to represent; use the largest possible value instead.
+		return []byte{255, 255, 255, 255}
+	}
+	scale := mantissa * 256 / maxComponent
+	return []byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exponent + 128)}
+}
+
+// Returns the given bytes encoded using the Radiance scheme, in which a count byte above 128 indicates a run of the
+// following byte repeated (count - 128) times, and any other count byte indicates that many literal bytes following.
+func encodeRle(data []byte) []byte {
+	var encoded []byte
+	position := 0
+	for position < len(data) {
+		// Find the start of the next run that is long enough to be worth encoding as such.
+		runStart := position
+		runLength := 0
+		previousRunLength := 0
+		for runLength < minRleRunLength && runStart < len(data) {
+			runStart += runLength
+			previousRunLength = runLength
+			runLength = 1
+			for runStart+runLength < len(data) && runLength < maxRleRunLength &&
+				data[runStart] == data[runStart+runLength] {
+				runLength++
+			}
+		}
+
+		// If the bytes before the next run are a shorter run of their own, they can still be encoded as such.
+		if previousRunLength > 1 && previousRunLength == runStart-position {
+			encoded = append(encoded, byte(128+previousRunLength), data[position])
+			position = runStart
+		}
+
+		// Write out the literal bytes up to the start of the run.
+		for position < runStart {
+			dumpLength := runStart - position
+			if dumpLength > maxRleDumpLength {
+				dumpLength = maxRleDumpLength
+			}
+			encoded = append(encoded, byte(dumpLength))
+			encoded = append(encoded, data[position:position+dumpLength]...)
+			position += dumpLength
+		}
+
+		if runLength >= minRleRunLength {
+			encoded = append(encoded, byte(128+runLength), data[runStart])
+			position += runLength
+		}
+	}
+	return encoded
+}
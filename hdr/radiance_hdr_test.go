@@ -0,0 +1,180 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestWriteRadianceHdr(t *testing.T) {
+	// Use one width that can be run-length encoded and one that can't.
+	for _, width := range []int{20, 5} {
+		img := newTestImage(width, 3)
+		var buffer bytes.Buffer
+		assert.Nil(t, WriteRadianceHdr(&buffer, img))
+
+		header := fmt.Sprintf("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 3 +X %d\n", width)
+		if assert.True(t, bytes.HasPrefix(buffer.Bytes(), []byte(header))) {
+			pixels := decodeRadianceHdrPixels(t, buffer.Bytes()[len(header):], width, 3)
+			for y, row := range img.Pixels {
+				for x, expected := range row {
+					// RGBE has eight bits of precision relative to the largest component.
+					actual := pixels[y][x]
+					tolerance := expected.MaxComponent() / 128
+					assert.InDelta(t, expected.R, actual.R, tolerance)
+					assert.InDelta(t, expected.G, actual.G, tolerance)
+					assert.InDelta(t, expected.B, actual.B, tolerance)
+				}
+			}
+		}
+	}
+}
+
+func TestReadRadianceHdr(t *testing.T) {
+	// Use one width that can be run-length encoded and one that can't.
+	for _, width := range []int{20, 5} {
+		expected := newTestImage(width, 3)
+		var buffer bytes.Buffer
+		assert.Nil(t, WriteRadianceHdr(&buffer, expected))
+		img, err := ReadRadianceHdr(&buffer)
+		if assert.Nil(t, err) && assert.Equal(t, width, img.Width()) && assert.Equal(t, 3, img.Height()) {
+			for y, row := range expected.Pixels {
+				for x, expectedPixel := range row {
+					actual := img.Pixels[y][x]
+					tolerance := expectedPixel.MaxComponent() / 128
+					assert.InDelta(t, expectedPixel.R, actual.R, tolerance)
+					assert.InDelta(t, expectedPixel.G, actual.G, tolerance)
+					assert.InDelta(t, expectedPixel.B, actual.B, tolerance)
+				}
+			}
+		}
+	}
+
+	// Headers written by other tools may contain additional lines and CRLF line endings.
+	img, err := ReadRadianceHdr(bytes.NewReader([]byte("#?RGBE\r\n# Made elsewhere\r\nEXPOSURE=1\r\n\r\n" +
+		"-Y 1 +X 2\r\n\x80\x40\x00\x81\x00\x00\x00\x00")))
+	if assert.Nil(t, err) {
+		assert.Equal(t, [][]shading.Color{{{1.00390625, 0.50390625, 0}, {0, 0, 0}}}, img.Pixels)
+	}
+}
+
+func TestReadRadianceHdrInvalid(t *testing.T) {
+	rleHeader := "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x08"
+	testCases := []struct {
+		data          string
+		expectedError string
+	}{
+		{"P6\n", "not a Radiance HDR file"},
+		{"#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n", "unsupported Radiance HDR format \"32-bit_rle_xyze\""},
+		{"#?RADIANCE\n", "unexpected end of Radiance HDR header"},
+		{"#?RADIANCE\n\n+Y 1 +X 1\n", "unsupported Radiance HDR resolution \"+Y 1 +X 1\""},
+		{"#?RADIANCE\n\n-Y 1 +X 2\n\x80\x40\x00\x81", "unexpected end of Radiance HDR pixel data"},
+		{"#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x09", "scanline width doesn't match the image"},
+		{rleHeader + "\x89\x01", "invalid Radiance HDR run-length encoding"},
+		{rleHeader + "\x00", "invalid Radiance HDR run-length encoding"},
+		{rleHeader + "\x88\x01\x08\x01", "unexpected end of Radiance HDR pixel data"},
+	}
+	for _, testCase := range testCases {
+		_, err := ReadRadianceHdr(bytes.NewReader([]byte(testCase.data)))
+		if assert.NotNil(t, err, testCase.data) {
+			assert.Contains(t, err.Error(), testCase.expectedError)
+		}
+	}
+}
+
+func TestToRgbe(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0}, toRgbe(shading.Color{0, 0, 0}))
+	assert.Equal(t, []byte{128, 64, 0, 129}, toRgbe(shading.Color{1, 0.5, 0}))
+	assert.Equal(t, []byte{0, 128, 192, 130}, toRgbe(shading.Color{-1, 2, 3}))
+	assert.Equal(t, []byte{255, 255, 255, 255}, toRgbe(shading.Color{math.MaxFloat64, 0, 0}))
+}
+
+func TestEncodeRle(t *testing.T) {
+	assert.Equal(t, []byte{3, 1, 2, 3}, encodeRle([]byte{1, 2, 3}))
+	assert.Equal(t, []byte{133, 7}, encodeRle([]byte{7, 7, 7, 7, 7}))
+	assert.Equal(t, []byte{2, 1, 2, 132, 9, 130, 4}, encodeRle([]byte{1, 2, 9, 9, 9, 9, 4, 4}))
+	assert.Equal(t, []byte{130, 5, 132, 6}, encodeRle([]byte{5, 5, 6, 6, 6, 6}))
+
+	// Long sequences should be split up.
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(i % 2)
+	}
+	encoded := encodeRle(data)
+	assert.Equal(t, 303, len(encoded))
+	assert.Equal(t, byte(128), encoded[0])
+	assert.Equal(t, data, decodeRle(t, encoded, len(data)))
+	encoded = encodeRle(make([]byte, 300))
+	assert.Equal(t, []byte{255, 0, 255, 0, 174, 0}, encoded)
+}
+
+// Returns the pixels decoded from the given Radiance HDR pixel data.
+func decodeRadianceHdrPixels(t *testing.T, data []byte, width, height int) [][]shading.Color {
+	pixels := make([][]shading.Color, height)
+	rgbe := make([]byte, 4*width)
+	for y := range pixels {
+		if len(data) >= 4 && data[0] == 2 && data[1] == 2 {
+			assert.Equal(t, width, int(data[2])<<8|int(data[3]))
+			data = data[4:]
+			for i := 0; i < 4; i++ {
+				var component []byte
+				component, data = decodeRleWithRemainder(t, data, width)
+				for x := range component {
+					rgbe[4*x+i] = component[x]
+				}
+			}
+		} else {
+			copy(rgbe, data)
+			data = data[4*width:]
+		}
+
+		pixels[y] = make([]shading.Color, width)
+		for x := range pixels[y] {
+			if rgbe[4*x+3] != 0 {
+				scale := math.Ldexp(1, int(rgbe[4*x+3])-128-8)
+				pixels[y][x] = shading.Color{
+					(float64(rgbe[4*x]) + 0.5) * scale,
+					(float64(rgbe[4*x+1]) + 0.5) * scale,
+					(float64(rgbe[4*x+2]) + 0.5) * scale,
+				}
+			}
+		}
+	}
+	assert.Empty(t, data)
+	return pixels
+}
+
+// Returns the given number of bytes decoded from the given run-length encoded data.
+func decodeRle(t *testing.T, data []byte, length int) []byte {
+	decoded, remainder := decodeRleWithRemainder(t, data, length)
+	assert.Empty(t, remainder)
+	return decoded
+}
+
+// Returns the given number of bytes decoded from the start of the given run-length encoded data, along with the rest
+// of the data.
+func decodeRleWithRemainder(t *testing.T, data []byte, length int) ([]byte, []byte) {
+	var decoded []byte
+	for len(decoded) < length {
+		if !assert.NotEmpty(t, data) {
+			break
+		}
+		count := int(data[0])
+		if count > 128 {
+			for i := 0; i < count-128; i++ {
+				decoded = append(decoded, data[1])
+			}
+			data = data[2:]
+		} else {
+			decoded = append(decoded, data[1:1+count]...)
+			data = data[1+count:]
+		}
+	}
+	assert.Equal(t, length, len(decoded))
+	return decoded, data
+}
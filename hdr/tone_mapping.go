@@ -0,0 +1,93 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"image"
+	imagecolor "image/color"
+	"math"
+)
+
+// Represents an operator for compressing the unbounded range of a high dynamic range color into [0, 1] for display.
+type ToneMapOperator interface {
+	// Returns the given linear color mapped into [0, 1].
+	Map(color shading.Color) shading.Color
+}
+
+// Maps colors by simply clipping any components that are brighter than white.
+type ClampOperator struct{}
+
+func (operator ClampOperator) Map(color shading.Color) shading.Color {
+	return shading.Color{clamp(color.R), clamp(color.G), clamp(color.B)}
+}
+
+// Maps colors using Reinhard's global operator, which compresses highlights smoothly by scaling each color according to
+// its luminance so that its hue is preserved.
+type ReinhardOperator struct {
+	// Luminance that is mapped to pure white; if zero, luminance approaches white only asymptotically
+	WhitePoint float64
+}
+
+func (operator ReinhardOperator) Map(color shading.Color) shading.Color {
+	luminance := color.Luminance()
+	if luminance <= 0 {
+		return shading.Color{}
+	}
+	mappedLuminance := luminance / (1 + luminance)
+	if operator.WhitePoint > 0 {
+		mappedLuminance *= 1 + luminance/(operator.WhitePoint*operator.WhitePoint)
+	}
+	return ClampOperator{}.Map(color.Multiply(mappedLuminance / luminance))
+}
+
+// Maps colors using an approximation of the filmic curve from the Academy Color Encoding System (ACES) reference
+// rendering transform, which gives a pleasing contrast and roll-off of highlights.
+type AcesFilmicOperator struct{}
+
+func (operator AcesFilmicOperator) Map(color shading.Color) shading.Color {
+	// Fit by Krzysztof Narkowicz: https://knarkowicz.wordpress.com/2016/01/06/aces-filmic-tone-mapping-curve/
+	curve := func(x float64) float64 {
+		x = math.Max(x, 0)
+		return clamp(x * (2.51*x + 0.03) / (x*(2.43*x+0.59) + 0.14))
+	}
+	return shading.Color{curve(color.R), curve(color.G), curve(color.B)}
+}
+
+// Returns an 8-bit version of the image for display, produced by scaling its colors by the given exposure adjustment
+// (in stops, each of which doubles or halves the brightness), mapping them into [0, 1] using the given operator, and
+// encoding them with the sRGB transfer function. The image itself is left unchanged, so that it can be mapped again
+// with different settings.
+func (img *Image) ToneMap(operator ToneMapOperator, exposureStops float64) *image.RGBA {
+	exposure := math.Pow(2, exposureStops)
+	rgba := image.NewRGBA(image.Rectangle{image.Point{0, 0}, image.Point{img.Width(), img.Height()}})
+	for y, row := range img.Pixels {
+		for x, pixel := range row {
+			mapped := operator.Map(pixel.Multiply(exposure))
+			rgba.SetRGBA(x, y, imagecolor.RGBA{
+				R: encodeSrgb(mapped.R),
+				G: encodeSrgb(mapped.G),
+				B: encodeSrgb(mapped.B),
+				A: 255,
+			})
+		}
+	}
+	return rgba
+}
+
+// Returns the 8-bit sRGB encoding of the given linear color component in [0, 1], which allots more of the values to
+// darker shades in line with human perception.
+func encodeSrgb(value float64) uint8 {
+	value = clamp(value)
+	if value <= 0.0031308 {
+		value *= 12.92
+	} else {
+		value = 1.055*math.Pow(value, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(255 * value))
+}
+
+// Returns the given value limited to [0, 1].
+func clamp(value float64) float64 {
+	return math.Max(math.Min(value, 1), 0)
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package hdr
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+func TestClampOperator(t *testing.T) {
+	assert.Equal(t, shading.Color{1, 0.5, 0}, ClampOperator{}.Map(shading.Color{2, 0.5, -1}))
+}
+
+func TestReinhardOperator(t *testing.T) {
+	assert.Equal(t, shading.Color{}, ReinhardOperator{}.Map(shading.Color{}))
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.5, 0.5}, ReinhardOperator{}.Map(shading.Color{1, 1, 1}), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0.99, 0.99, 0.99}, ReinhardOperator{}.Map(shading.Color{99, 99, 99}),
+		1e-9)
+
+	// The hue should be preserved.
+	mapped := ReinhardOperator{}.Map(shading.Color{0.5, 0.25, 0})
+	assert.InDelta(t, 2, mapped.R/mapped.G, 1e-9)
+	assert.Equal(t, 0.0, mapped.B)
+
+	// Luminance at the white point should map to white.
+	operator := ReinhardOperator{WhitePoint: 4}
+	shading.AssertColorEqual(t, shading.Color{1, 1, 1}, operator.Map(shading.Color{4, 4, 4}), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0.53125, 0.53125, 0.53125}, operator.Map(shading.Color{1, 1, 1}), 1e-9)
+}
+
+func TestAcesFilmicOperator(t *testing.T) {
+	assert.Equal(t, shading.Color{}, AcesFilmicOperator{}.Map(shading.Color{0, -1, 0}))
+	mapped := AcesFilmicOperator{}.Map(shading.Color{0.18, 1, 100})
+	assert.InDelta(t, 0.2669, mapped.R, 1e-4)
+	assert.InDelta(t, 0.8038, mapped.G, 1e-4)
+	assert.Equal(t, 1.0, mapped.B)
+}
+
+func TestImage_ToneMap(t *testing.T) {
+	img := NewImage(2, 1)
+	img.Pixels[0][0] = shading.Color{0.5, 0.001, 0}
+	img.Pixels[0][1] = shading.Color{2, 0.25, 1}
+
+	rgba := img.ToneMap(ClampOperator{}, 0)
+	assert.Equal(t, color.RGBA{188, 3, 0, 255}, rgba.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 137, 255, 255}, rgba.RGBAAt(1, 0))
+
+	// Each stop of exposure should double the brightness.
+	rgba = img.ToneMap(ClampOperator{}, 1)
+	assert.Equal(t, color.RGBA{255, 7, 0, 255}, rgba.RGBAAt(0, 0))
+	rgba = img.ToneMap(ClampOperator{}, -1)
+	assert.Equal(t, color.RGBA{137, 2, 0, 255}, rgba.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 99, 188, 255}, rgba.RGBAAt(1, 0))
+
+	// The original values should be unaffected.
+	assert.Equal(t, shading.Color{2, 0.25, 1}, img.Pixels[0][1])
+}
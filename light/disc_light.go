@@ -0,0 +1,91 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a circular light source that emits light evenly from every point on its front side, which faces in the
+// direction of the cross product of its width and height.
+type DiscLight struct {
+	center    geometry.Point  // Point at the center of the disc
+	width     geometry.Vector // Direction and radius of the disc extending "right" from the center
+	height    geometry.Vector // Direction and radius of the disc extending "up" from the center
+	normal    geometry.Vector // Unit vector pointing in the direction that the light is emitted
+	color     shading.Color
+	intensity float64 // Total power of the light emitted by the source
+}
+
+// Returns a new circular light, or an error if the parameters are invalid. The intensity is the total power emitted, as
+// for point lights, so that the light's brightness doesn't depend on its size.
+func NewDiscLight(center geometry.Point, width, height geometry.Vector, color shading.Color,
+	intensity float64) (DiscLight, error) {
+	if intensity <= 0 {
+		return DiscLight{}, errors.New("intensity must be positive")
+	}
+	if width.Norm() == 0 {
+		return DiscLight{}, errors.New("light width and height must be non-zero")
+	}
+	if math.Abs(width.ToUnit().Dot(height.ToUnit())) > 1e-9 {
+		return DiscLight{}, errors.New("light width and height must be perpendicular")
+	}
+	if math.Abs(width.Norm()-height.Norm()) > 1e-9 {
+		return DiscLight{}, errors.New("light width and height must have the same magnitude")
+	}
+
+	return DiscLight{
+		center:    center,
+		width:     width,
+		height:    height,
+		normal:    width.Cross(height).ToUnit(),
+		color:     color,
+		intensity: intensity,
+	}, nil
+}
+
+func (light DiscLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	// Take the square root of the radial coordinate so that points are spread evenly over the disc's area.
+	u1, u2 := sampler.Get2D()
+	r := math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	lightPoint := light.center.Translate(light.width.Multiply(r * math.Cos(phi))).
+		Translate(light.height.Multiply(r * math.Sin(phi)))
+	return areaSample(point, lightPoint, light.normal, light.color, light.Radiance(), 1/light.area())
+}
+
+func (light DiscLight) Color() shading.Color {
+	return light.color
+}
+
+func (light DiscLight) Intersect(ray geometry.Ray) (float64, bool) {
+	distance, point, ok := intersectFront(ray, light.center, light.normal)
+	if !ok {
+		return 0, false
+	}
+	radius := light.width.Norm()
+	return distance, light.center.DistanceTo(point) <= radius
+}
+
+// Returns the radiance of a surface emitting the light's total power evenly in every direction from its front side.
+func (light DiscLight) Radiance() float64 {
+	return light.intensity / (math.Pi * light.area())
+}
+
+func (light DiscLight) Pdf(ray geometry.Ray) float64 {
+	distance, ok := light.Intersect(ray)
+	if !ok {
+		return 0
+	}
+	return solidAnglePdf(1/light.area(), distance, -light.normal.Dot(ray.Direction.ToUnit()))
+}
+
+// Returns the area of the disc.
+func (light DiscLight) area() float64 {
+	radius := light.width.Norm()
+	return math.Pi * radius * radius
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewDiscLight(t *testing.T) {
+	light, err := NewDiscLight(geometry.Point{0, 0, 5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.Color{0.1, 0.2, 0.3}, 254)
+	assert.Nil(t, err)
+
+	assert.Equal(t, shading.Color{0.1, 0.2, 0.3}, light.Color())
+	assert.InDelta(t, 254/(4*math.Pi*math.Pi), light.Radiance(), 1e-9)
+}
+
+func TestNewDiscLightInvalid(t *testing.T) {
+	_, err := NewDiscLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, shading.Color{}, -1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be positive")
+	}
+
+	_, err = NewDiscLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{1, 1, 0}, shading.Color{}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be perpendicular")
+	}
+
+	_, err = NewDiscLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 2, 0}, shading.Color{}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must have the same magnitude")
+	}
+}
+
+func TestDiscLight_Sample(t *testing.T) {
+	// The light faces downwards.
+	light, _ := NewDiscLight(geometry.Point{0, 0, 1}, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.Color{1, 1, 1}, 1)
+	point := geometry.Point{0, 0, 0}
+
+	// The irradiance directly below a disc of uniform radiance is known exactly.
+	const numSamples = 10000
+	irradiance := 0.0
+	for i := 0; i < numSamples; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, numSamples))
+		assert.LessOrEqual(t, geometry.Point{sample.Direction.X, sample.Direction.Y, 0}.DistanceTo(point),
+			math.Sqrt(0.5)+1e-9)
+		irradiance += sample.Intensity * -sample.Direction.Z / numSamples
+	}
+	assert.InEpsilon(t, light.Radiance()*math.Pi/2, irradiance, 0.01)
+
+	// No light should be emitted from the back of the light.
+	assert.Equal(t, 0.0, light.Sample(geometry.Point{0, 0, 2}, sampling.NewFixedSampler(0, 1)).Intensity)
+}
+
+func TestDiscLight_Intersect(t *testing.T) {
+	light, _ := NewDiscLight(geometry.Point{0, 0, 1}, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.Color{1, 1, 1}, 1)
+
+	distance, ok := light.Intersect(geometry.Ray{geometry.Point{0.5, 0.5, 0}, geometry.Vector{0, 0, 1}})
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, distance)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0.75, 0.75, 0}, geometry.Vector{0, 0, 1}})
+	assert.False(t, ok)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0, 0, 2}, geometry.Vector{0, 0, -1}})
+	assert.False(t, ok)
+
+	assertPdfMatchesSample(t, light, geometry.Point{1, 2, -3})
+}
@@ -5,8 +5,9 @@ package light
 import (
 	"errors"
 	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
-	"math/rand"
+	"math"
 )
 
 // Represents a light source for which the incident direction and intensity are the same regardless of the location of
@@ -15,7 +16,7 @@ type DistantLight struct {
 	direction          geometry.Vector // Fixed direction of the light rays leaving the light source
 	color              shading.Color
 	intensity          float64 // Fixed intensity of the light rays leaving the light source
-	directionVariation float64 // Value in [0, 1] by which each component of the direction can be randomly varied
+	directionVariation float64 // Value in [0, 1] by which the direction can be randomly tilted in each direction
 }
 
 func NewDistantLight(direction geometry.Vector, color shading.Color, intensity float64,
@@ -35,17 +36,27 @@ func NewDistantLight(direction geometry.Vector, color shading.Color, intensity f
 	}, nil
 }
 
-func (light DistantLight) Direction(point geometry.Point, sampleNumber, numSamples int) geometry.Vector {
+func (light DistantLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	return Sample{
+		Direction: light.Direction(point, sampler),
+		Distance:  math.Inf(1),
+		Color:     light.color,
+		Intensity: light.Intensity(point),
+	}
+}
+
+// Returns the direction of the light incident to the given point, which for soft shadows is varied at random.
+func (light DistantLight) Direction(point geometry.Point, sampler sampling.Sampler) geometry.Vector {
 	nominalDirection := light.direction.ToUnit()
-	if light.directionVariation == 0 || numSamples <= 1 {
+	if light.directionVariation == 0 || sampler.NumSamples() <= 1 {
 		return nominalDirection
 	}
 
-	// Adjust each component of the direction by a random factor.
-	direction := nominalDirection
-	direction.X += (2*rand.Float64() - 1) * light.directionVariation
-	direction.Y += (2*rand.Float64() - 1) * light.directionVariation
-	direction.Z += (2*rand.Float64() - 1) * light.directionVariation
+	// Offset the direction by a random amount along each of the axes perpendicular to it.
+	u, v := sampler.Get2D()
+	uDirection, vDirection := nominalDirection.OrthonormalBasis()
+	direction := nominalDirection.Add(uDirection.Multiply((2*u - 1) * light.directionVariation)).
+		Add(vDirection.Multiply((2*v - 1) * light.directionVariation))
 
 	return direction.ToUnit()
 }
@@ -54,11 +65,7 @@ func (light DistantLight) Color() shading.Color {
 	return light.color
 }
 
+// Returns the intensity of the light incident to the given point, which is the same everywhere.
 func (light DistantLight) Intensity(point geometry.Point) float64 {
 	return light.intensity
 }
-
-func (light DistantLight) IsBlockedByIntersection(point geometry.Point, intersection *geometry.Intersection) bool {
-	// Intersecting distances are always closer than a distant light, which is infinitely far away.
-	return true
-}
@@ -4,6 +4,7 @@ package light
 
 import (
 	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -41,15 +42,15 @@ func TestNewDistantLightInvalid(t *testing.T) {
 
 func TestDistantLight_Direction(t *testing.T) {
 	light, _ := NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0)
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 0, 1))
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 1, 1))
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 2, 1))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(0, 1)))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(1, 1)))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(2, 1)))
 
 	light, _ = NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0.1)
 	direction := geometry.Vector{0, 0, -1}
 
 	for i := 0; i < 10; i++ {
-		newDirection := light.Direction(geometry.Point{0, 0, 0}, i, 10)
+		newDirection := light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(i, 10))
 		assert.NotEqual(t, direction, newDirection)
 		assert.Greater(t, newDirection.X, -0.1)
 		assert.Less(t, newDirection.X, 0.1)
@@ -58,14 +59,3 @@ func TestDistantLight_Direction(t *testing.T) {
 		assert.InEpsilon(t, -1, newDirection.Z, 0.01)
 	}
 }
-
-func TestDistantLight_IsBlockedByIntersection(t *testing.T) {
-	light, _ := NewDistantLight(geometry.Vector{-1, 2, 3}, shading.Color{1, 1, 1}, 1, 0)
-	intersection := geometry.Intersection{
-		Point:    geometry.Point{1, 6, -13},
-		Distance: 3,
-		Normal:   geometry.Vector{},
-	}
-
-	assert.True(t, light.IsBlockedByIntersection(geometry.Point{1, 2, 3}, &intersection))
-}
@@ -0,0 +1,224 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+	"sort"
+)
+
+// Represents light arriving from every direction around the scene, taken from a high dynamic range image in
+// equirectangular projection (such as a photograph of a real environment). Directions are sampled in proportion to the
+// brightness of the image, so that small bright features such as the sun are found without much noise.
+type EnvironmentLight struct {
+	pixels     [][]shading.Color // Linear pixel colors indexed by row and then column, with the first row at the zenith
+	uDirection geometry.Vector   // Unit vector towards the center of the image
+	vDirection geometry.Vector   // Unit vector towards the point a quarter of the way from the image's right edge
+	wDirection geometry.Vector   // Unit vector towards the zenith
+	rotation   float64           // Angle in radians by which the image is rotated about the zenith
+	intensity  float64           // Factor by which the image's colors are multiplied
+
+	rowCdf      []float64   // Cumulative probability of sampling each row of the image
+	columnCdfs  [][]float64 // Cumulative probability of sampling each column of the image, given its row
+	totalWeight float64     // Sum of the sampling weights of all the image's pixels
+}
+
+// Returns a new environment light using the given linear pixel colors, indexed by row and then column with the first
+// row at the top, or an error if the parameters are invalid. The top of the image lies at the zenith, the center of the
+// image in the direction of the azimuth reference, and the image is then rotated about the zenith by the given angle in
+// degrees. The intensity is multiplied by each of the image's colors to give the light arriving from that direction.
+func NewEnvironmentLight(pixels [][]shading.Color, zenithReference, azimuthReference geometry.Vector, rotationDeg,
+	intensity float64) (EnvironmentLight, error) {
+	if len(pixels) == 0 || len(pixels[0]) == 0 {
+		return EnvironmentLight{}, errors.New("environment image must not be empty")
+	}
+	for _, row := range pixels {
+		if len(row) != len(pixels[0]) {
+			return EnvironmentLight{}, errors.New("environment image rows must all be the same width")
+		}
+	}
+	if intensity <= 0 {
+		return EnvironmentLight{}, errors.New("intensity must be positive")
+	}
+	if zenithReference.Norm() == 0 || azimuthReference.Norm() == 0 {
+		return EnvironmentLight{}, errors.New("zenith and azimuth references must be non-zero")
+	}
+	if math.Abs(zenithReference.ToUnit().Dot(azimuthReference.ToUnit())) > 1e-9 {
+		return EnvironmentLight{}, errors.New("zenith and azimuth references must be perpendicular")
+	}
+
+	uDirection := azimuthReference.ToUnit()
+	wDirection := zenithReference.ToUnit()
+	light := EnvironmentLight{
+		pixels:     pixels,
+		uDirection: uDirection,
+		vDirection: wDirection.Cross(uDirection),
+		wDirection: wDirection,
+		rotation:   rotationDeg * math.Pi / 180,
+		intensity:  intensity,
+	}
+	light.buildDistribution()
+	return light, nil
+}
+
+// Chooses a direction at random in proportion to the brightness of the image, with each pixel weighted by the solid
+// angle that it covers.
+func (light EnvironmentLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	if light.totalWeight == 0 {
+		// The image is completely black.
+		return Sample{}
+	}
+	u1, u2 := sampler.Get2D()
+	y, row := sampleCdf(light.rowCdf, u2)
+	x, _ := sampleCdf(light.columnCdfs[row], u1)
+	direction := light.toDirection(x/float64(light.width()), y/float64(light.height()))
+	pdf := light.Pdf(direction)
+	if pdf == 0 {
+		return Sample{}
+	}
+	column, row := light.toPixel(direction)
+	return Sample{
+		Direction: direction.Multiply(-1),
+		Distance:  math.Inf(1),
+		Color:     light.pixels[row][column],
+		Intensity: light.intensity / pdf,
+		Pdf:       pdf,
+	}
+}
+
+// Returns the average color of the image, weighted by the solid angle covered by each pixel.
+func (light EnvironmentLight) Color() shading.Color {
+	var color shading.Color
+	totalSolidAngle := 0.0
+	for y, row := range light.pixels {
+		solidAngle := light.rowSinTheta(y)
+		for _, pixel := range row {
+			color = color.Add(pixel.Multiply(solidAngle))
+			totalSolidAngle += solidAngle
+		}
+	}
+	return color.Multiply(1 / totalSolidAngle)
+}
+
+func (light EnvironmentLight) Radiance(direction geometry.Vector) shading.Color {
+	column, row := light.toPixel(direction)
+	return light.pixels[row][column].Multiply(light.intensity)
+}
+
+func (light EnvironmentLight) Pdf(direction geometry.Vector) float64 {
+	if light.totalWeight == 0 {
+		return 0
+	}
+	column, row := light.toPixel(direction)
+	cosTheta := direction.ToUnit().Dot(light.wDirection)
+	sinTheta := math.Sqrt(math.Max(1-cosTheta*cosTheta, 0))
+	if sinTheta == 0 {
+		return 0
+	}
+
+	// Convert the density over the area of the image into one over the sphere of directions, which the image
+	// stretches by 2π horizontally and π vertically, and by 1/sin(theta) towards the poles.
+	imagePdf := light.weight(column, row) / light.totalWeight * float64(light.width()*light.height())
+	return imagePdf / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+// Computes the cumulative distributions used to sample the image's pixels in proportion to their weights.
+func (light *EnvironmentLight) buildDistribution() {
+	light.rowCdf = make([]float64, light.height()+1)
+	light.columnCdfs = make([][]float64, light.height())
+	for y := range light.pixels {
+		columnCdf := make([]float64, light.width()+1)
+		for x := range light.pixels[y] {
+			columnCdf[x+1] = columnCdf[x] + light.weight(x, y)
+		}
+		rowWeight := columnCdf[light.width()]
+		normalizeCdf(columnCdf)
+		light.columnCdfs[y] = columnCdf
+		light.rowCdf[y+1] = light.rowCdf[y] + rowWeight
+	}
+	light.totalWeight = light.rowCdf[light.height()]
+	normalizeCdf(light.rowCdf)
+}
+
+// Returns the weight with which the given pixel is sampled, which is its brightness multiplied by the solid angle that
+// it covers.
+func (light EnvironmentLight) weight(x, y int) float64 {
+	return light.pixels[y][x].Luminance() * light.rowSinTheta(y)
+}
+
+// Returns the sine of the angle from the zenith of the center of the given row, which is proportional to the solid
+// angle covered by each pixel in it.
+func (light EnvironmentLight) rowSinTheta(y int) float64 {
+	return math.Sin((float64(y) + 0.5) / float64(light.height()) * math.Pi)
+}
+
+// Returns the unit direction corresponding to the given image coordinates, which run from 0 to 1 from left to right and
+// from top to bottom.
+func (light EnvironmentLight) toDirection(u, v float64) geometry.Vector {
+	// The image is viewed from inside the sphere, so the azimuth increases from right to left.
+	phi := (0.5-u)*2*math.Pi + light.rotation
+	theta := v * math.Pi
+	return light.uDirection.Multiply(math.Sin(theta) * math.Cos(phi)).
+		Add(light.vDirection.Multiply(math.Sin(theta) * math.Sin(phi))).
+		Add(light.wDirection.Multiply(math.Cos(theta)))
+}
+
+// Returns the column and row of the pixel seen in the given direction.
+func (light EnvironmentLight) toPixel(direction geometry.Vector) (int, int) {
+	direction = direction.ToUnit()
+	phi := math.Atan2(direction.Dot(light.vDirection), direction.Dot(light.uDirection)) - light.rotation
+	theta := math.Acos(math.Max(math.Min(direction.Dot(light.wDirection), 1), -1))
+	u := 0.5 - phi/(2*math.Pi)
+	u -= math.Floor(u)
+	column := int(u * float64(light.width()))
+	row := int(theta / math.Pi * float64(light.height()))
+	return clampIndex(column, light.width()), clampIndex(row, light.height())
+}
+
+// Returns the width of the image in pixels.
+func (light EnvironmentLight) width() int {
+	return len(light.pixels[0])
+}
+
+// Returns the height of the image in pixels.
+func (light EnvironmentLight) height() int {
+	return len(light.pixels)
+}
+
+// Scales the given cumulative distribution so that it ends at one, unless it is entirely zero.
+func normalizeCdf(cdf []float64) {
+	total := cdf[len(cdf)-1]
+	if total == 0 {
+		return
+	}
+	for i := range cdf {
+		cdf[i] /= total
+	}
+}
+
+// Returns the continuous position at which the given cumulative distribution reaches the given random number in
+// [0, 1), along with the index of the interval it lies within.
+func sampleCdf(cdf []float64, u float64) (float64, int) {
+	index := sort.Search(len(cdf)-1, func(i int) bool { return cdf[i+1] > u })
+	index = clampIndex(index, len(cdf)-1)
+	offset := 0.0
+	if width := cdf[index+1] - cdf[index]; width > 0 {
+		offset = (u - cdf[index]) / width
+	}
+	return float64(index) + offset, index
+}
+
+// Returns the given index brought within [0, size).
+func clampIndex(index, size int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= size {
+		return size - 1
+	}
+	return index
+}
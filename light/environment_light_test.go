@@ -0,0 +1,142 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewEnvironmentLightInvalid(t *testing.T) {
+	pixels := [][]shading.Color{{{1, 1, 1}}}
+	zenith := geometry.Vector{0, 0, 1}
+	azimuth := geometry.Vector{1, 0, 0}
+
+	_, err := NewEnvironmentLight([][]shading.Color{}, zenith, azimuth, 0, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must not be empty")
+	}
+
+	_, err = NewEnvironmentLight([][]shading.Color{{{}, {}}, {{}}}, zenith, azimuth, 0, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must all be the same width")
+	}
+
+	_, err = NewEnvironmentLight(pixels, zenith, azimuth, 0, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "intensity must be positive")
+	}
+
+	_, err = NewEnvironmentLight(pixels, zenith, geometry.Vector{}, 0, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be non-zero")
+	}
+
+	_, err = NewEnvironmentLight(pixels, zenith, geometry.Vector{1, 0, 1}, 0, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be perpendicular")
+	}
+}
+
+func TestEnvironmentLight_Radiance(t *testing.T) {
+	pixels := [][]shading.Color{
+		{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		{{1, 1, 0}, {0, 1, 1}, {1, 0, 1}},
+	}
+	light, err := NewEnvironmentLight(pixels, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0}, 0, 2)
+	assert.Nil(t, err)
+
+	// The center of the image should lie towards the azimuth reference, with the left of the image to its left as seen
+	// from inside the sphere.
+	assert.Equal(t, shading.Color{0, 2, 0}, light.Radiance(geometry.Vector{1, 0, 1}))
+	assert.Equal(t, shading.Color{2, 0, 0}, light.Radiance(geometry.Vector{0, 1, 1}))
+	assert.Equal(t, shading.Color{0, 0, 2}, light.Radiance(geometry.Vector{0, -1, 1}))
+	assert.Equal(t, shading.Color{0, 2, 2}, light.Radiance(geometry.Vector{1, 0, -1}))
+	assert.Equal(t, shading.Color{0, 2, 2}, light.Radiance(geometry.Vector{0, 0, -1}))
+
+	// Rotating the image should move it about the zenith.
+	light, _ = NewEnvironmentLight(pixels, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0}, 90, 2)
+	assert.Equal(t, shading.Color{0, 2, 0}, light.Radiance(geometry.Vector{0, 1, 1}))
+	assert.Equal(t, shading.Color{2, 0, 0}, light.Radiance(geometry.Vector{-1, 0, 1}))
+
+	assert.InDelta(t, 0.5, light.Color().R, 1e-9)
+	assert.InDelta(t, 0.5, light.Color().G, 1e-9)
+}
+
+func TestEnvironmentLight_Pdf(t *testing.T) {
+	pixels := make([][]shading.Color, 16)
+	for y := range pixels {
+		pixels[y] = make([]shading.Color, 32)
+		for x := range pixels[y] {
+			pixels[y][x] = shading.Color{float64(x), float64(y), 1}
+		}
+	}
+	light, _ := NewEnvironmentLight(pixels, geometry.Vector{0, 1, 0}, geometry.Vector{0, 0, 1}, 30, 1)
+
+	// The probability density should integrate to one over the sphere of directions.
+	const steps = 1000
+	total := 0.0
+	for i := 0; i < steps; i++ {
+		for j := 0; j < steps; j++ {
+			theta := (float64(i) + 0.5) / steps * math.Pi
+			phi := (float64(j) + 0.5) / steps * 2 * math.Pi
+			direction := geometry.Vector{math.Sin(theta) * math.Cos(phi), math.Cos(theta),
+				math.Sin(theta) * math.Sin(phi)}
+			total += light.Pdf(direction) * math.Sin(theta) * math.Pi / steps * 2 * math.Pi / steps
+		}
+	}
+	assert.InDelta(t, 1, total, 1e-2)
+
+	// Each sample should be consistent with the density of choosing it.
+	for i := 0; i < 100; i++ {
+		sample := light.Sample(geometry.Point{}, sampling.NewFixedSampler(i, 100))
+		direction := sample.Direction.Multiply(-1)
+		assert.InEpsilon(t, light.Pdf(direction), sample.Pdf, 1e-9)
+		assert.InEpsilon(t, 1/sample.Pdf, sample.Intensity, 1e-9)
+		assert.Equal(t, light.Radiance(direction), sample.Color)
+		assert.True(t, math.IsInf(sample.Distance, 1))
+	}
+}
+
+func TestEnvironmentLight_Sample(t *testing.T) {
+	// Nearly all samples should come from the one bright pixel.
+	pixels := make([][]shading.Color, 8)
+	for y := range pixels {
+		pixels[y] = make([]shading.Color, 16)
+		for x := range pixels[y] {
+			pixels[y][x] = shading.Color{0.01, 0.01, 0.01}
+		}
+	}
+	pixels[2][12] = shading.Color{1000, 1000, 1000}
+	light, _ := NewEnvironmentLight(pixels, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0}, 0, 1)
+	numBright := 0
+	for i := 0; i < 100; i++ {
+		if light.Sample(geometry.Point{}, sampling.NewFixedSampler(i, 100)).Color.R == 1000 {
+			numBright++
+		}
+	}
+	assert.Greater(t, numBright, 95)
+
+	// A uniform environment should give a surface facing any direction an irradiance of pi times its radiance.
+	light, _ = NewEnvironmentLight([][]shading.Color{{{1, 1, 1}}}, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		0, 2)
+	for _, normal := range []geometry.Vector{{0, 0, 1}, {1, 0, 0}, {0, -1, -1}} {
+		normal = normal.ToUnit()
+		const numSamples = 10000
+		irradiance := 0.0
+		for i := 0; i < numSamples; i++ {
+			sample := light.Sample(geometry.Point{}, sampling.NewFixedSampler(i, numSamples))
+			irradiance += sample.Intensity * math.Max(-sample.Direction.Dot(normal), 0) / numSamples
+		}
+		assert.InEpsilon(t, 2*math.Pi, irradiance, 0.02, "normal: %v", normal)
+	}
+
+	// A black environment should give no light.
+	light, _ = NewEnvironmentLight([][]shading.Color{{{}}}, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0}, 0, 1)
+	assert.Equal(t, 0.0, light.Sample(geometry.Point{}, sampling.NewFixedSampler(0, 1)).Intensity)
+	assert.Equal(t, 0.0, light.Pdf(geometry.Vector{1, 0, 0}))
+}
@@ -4,23 +4,105 @@ package light
 
 import (
 	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
 )
 
 // Represents a source of light within a set.
 type Light interface {
-	// Determines the direction of the light incident to the given point. Depending on the light source's properties and
-	// the value of the sampling arguments, the direction may include some random variation in order to produce soft
-	// shadows.
-	Direction(point geometry.Point, sampleNumber, numSamples int) geometry.Vector
+	// Determines the light arriving at the given point from the light source. Depending on the light source's
+	// properties and the number of samples being taken, the light may be taken to come from a randomly varied point on
+	// the source in order to produce soft shadows, chosen using the next pair of dimensions from the given sampler.
+	Sample(point geometry.Point, sampler sampling.Sampler) Sample
 
 	// Returns the color of the light produced by the light source.
 	Color() shading.Color
+}
+
+// Represents a light source with a physical shape, which is seen directly by rays that hit it (including those from
+// the camera and reflections) and whose points are sampled at random over its area.
+type AreaLight interface {
+	Light
+
+	// Returns the distance along the given ray at which it hits the emitting side of the light source, or false if it
+	// misses it.
+	Intersect(ray geometry.Ray) (float64, bool)
 
-	// Determines the intensity of the light incident to the given point.
-	Intensity(point geometry.Point) float64
+	// Returns the intensity of the light leaving each point on the emitting side of the light source in each direction
+	// (i.e. its radiance).
+	Radiance() float64
+
+	// Returns the probability density, with respect to solid angle, of Sample choosing the point at which the given
+	// ray hits the light source when illuminating the ray's origin, or zero if it misses.
+	Pdf(ray geometry.Ray) float64
+}
+
+// Represents a light source infinitely far away that surrounds the scene (such as the sky), which is seen by rays that
+// don't hit any surface and whose directions are sampled at random.
+type InfiniteLight interface {
+	Light
+
+	// Returns the light arriving from the given direction, which points away from the lit point.
+	Radiance(direction geometry.Vector) shading.Color
+
+	// Returns the probability density, with respect to solid angle, of Sample choosing the given direction.
+	Pdf(direction geometry.Vector) float64
+}
+
+// Holds the details of the light arriving at an illuminated point from a single point on a light source.
+type Sample struct {
+	Direction geometry.Vector // Unit direction in which the light travels from the light source to the lit point
+	Distance  float64         // Distance from the lit point to the light source, which may be infinite
+	Color     shading.Color   // Color of the light, which for most light sources is the same for every sample
+
+	// Intensity of the light arriving at the illuminated point, which in the case of an area light is divided by the
+	// probability density of choosing the point on it so that the average over many samples is correct
+	Intensity float64
+
+	// Probability density, with respect to solid angle, of choosing the point on the light source, or zero for light
+	// sources such as point lights that rays can't hit
+	Pdf float64
+}
+
+// Returns the sample for light arriving at the given point from the given point on an area light with the given
+// normal, color, radiance and probability density of choosing the point with respect to area.
+func areaSample(point, lightPoint geometry.Point, lightNormal geometry.Vector, color shading.Color, radiance,
+	areaPdf float64) Sample {
+	toPoint := lightPoint.VectorTo(point)
+	distance := toPoint.Norm()
+	if distance == 0 {
+		return Sample{}
+	}
+	sample := Sample{Direction: toPoint.Multiply(1 / distance), Distance: distance, Color: color}
+	cosLight := lightNormal.Dot(sample.Direction)
+	if cosLight <= 0 {
+		// The point is behind the light source, which only emits light from its front.
+		return sample
+	}
+	sample.Pdf = solidAnglePdf(areaPdf, distance, cosLight)
+	sample.Intensity = radiance / sample.Pdf
+	return sample
+}
+
+// Converts the given probability density of choosing a point with respect to area into one with respect to solid
+// angle as seen from the given distance, at which the point's surface is at the given angle to the line of sight.
+func solidAnglePdf(areaPdf, distance, cosLight float64) float64 {
+	return areaPdf * distance * distance / cosLight
+}
 
-	// Determines whether the given point is blocked from receiving light by the surface whose intersection with the
-	// light ray is given.
-	IsBlockedByIntersection(point geometry.Point, intersection *geometry.Intersection) bool
+// Returns the distance along the given ray at which it hits the front of the plane containing the given point with the
+// given unit normal, along with the point at which it does so, or false if it misses it.
+func intersectFront(ray geometry.Ray, planePoint geometry.Point, normal geometry.Vector) (float64, geometry.Point,
+	bool) {
+	direction := ray.Direction.ToUnit()
+	denominator := normal.Dot(direction)
+	if denominator >= 0 {
+		// The ray is parallel to the plane or approaches it from behind.
+		return 0, geometry.Point{}, false
+	}
+	distance := ray.Origin.VectorTo(planePoint).Dot(normal) / denominator
+	if distance <= 0 {
+		return 0, geometry.Point{}, false
+	}
+	return distance, ray.Origin.Translate(direction.Multiply(distance)), true
 }
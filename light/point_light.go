@@ -5,9 +5,9 @@ package light
 import (
 	"errors"
 	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
 	"math"
-	"math/rand"
 )
 
 // Represents a light source located at a specific point emitting light in all directions.
@@ -34,12 +34,22 @@ func NewPointLight(point geometry.Point, color shading.Color, intensity float64,
 	}, nil
 }
 
-func (light PointLight) Direction(point geometry.Point, sampleNumber, numSamples int) geometry.Vector {
+func (light PointLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	return Sample{
+		Direction: light.Direction(point, sampler),
+		Distance:  light.point.DistanceTo(point),
+		Color:     light.color,
+		Intensity: light.Intensity(point),
+	}
+}
+
+// Returns the direction of the light incident to the given point, which for soft shadows comes from a point chosen at
+// random within the light's radius.
+func (light PointLight) Direction(point geometry.Point, sampler sampling.Sampler) geometry.Vector {
 	nominalDirection := light.point.VectorTo(point).ToUnit()
-	if numSamples <= 1 {
+	if sampler.NumSamples() <= 1 {
 		return nominalDirection
 	}
-	sampleNumber %= numSamples
 
 	// Pick a arbitrary vectors normal to the nominal direction and to each other to represent the plane of the light.
 	var uDirection geometry.Vector
@@ -56,8 +66,9 @@ func (light PointLight) Direction(point geometry.Point, sampleNumber, numSamples
 	vDirection := nominalDirection.Cross(uDirection).ToUnit()
 
 	// Randomize the point within the light's radius.
-	r := light.radius * math.Sqrt(rand.Float64())
-	phi := (float64(sampleNumber) + rand.Float64()) * 2 * math.Pi / float64(numSamples)
+	u1, u2 := sampler.Get2D()
+	r := light.radius * math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
 	u := uDirection.Multiply(r * math.Cos(phi))
 	v := vDirection.Multiply(r * math.Sin(phi))
 	randomPoint := light.point.Translate(u).Translate(v)
@@ -69,14 +80,9 @@ func (light PointLight) Color() shading.Color {
 	return light.color
 }
 
+// Returns the intensity of the light incident to the given point, which falls off with the square of the distance.
 func (light PointLight) Intensity(point geometry.Point) float64 {
 	distance := light.point.DistanceTo(point)
 	sphereSurfaceArea := 4 * math.Pi * distance * distance
 	return light.intensity / sphereSurfaceArea
 }
-
-func (light PointLight) IsBlockedByIntersection(point geometry.Point, intersection *geometry.Intersection) bool {
-	// Intersecting distances don't block a point light if they are further away than the light from the ray origin.
-	distance := light.point.DistanceTo(point)
-	return distance > intersection.Distance
-}
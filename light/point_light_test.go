@@ -4,6 +4,7 @@ package light
 
 import (
 	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
 	"github.com/stretchr/testify/assert"
 	"math"
@@ -37,15 +38,15 @@ func TestNewPointLightInvalid(t *testing.T) {
 
 func TestPointLight_Direction(t *testing.T) {
 	light, _ := NewPointLight(geometry.Point{0, 0, 1}, shading.Color{1, 1, 1}, 1, 0)
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 0, 1))
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 1, 1))
-	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, 2, 1))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(0, 1)))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(1, 1)))
+	assert.Equal(t, geometry.Vector{0, 0, -1}, light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(2, 1)))
 
 	light, _ = NewPointLight(geometry.Point{0, 0, 1}, shading.Color{1, 1, 1}, 1, 0.1)
 	direction := geometry.Vector{0, 0, -1}
 
 	for i := 0; i < 10; i++ {
-		newDirection := light.Direction(geometry.Point{0, 0, 0}, i, 10)
+		newDirection := light.Direction(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(i, 10))
 		assert.NotEqual(t, direction, newDirection)
 		assert.Greater(t, newDirection.X, -0.1)
 		assert.Less(t, newDirection.X, 0.1)
@@ -56,25 +57,8 @@ func TestPointLight_Direction(t *testing.T) {
 
 	// Check calculation edge cases
 	light, _ = NewPointLight(geometry.Point{0, 0, 1}, shading.Color{1, 1, 1}, 1, 0.001)
-	geometry.AssertVectorEqual(t, geometry.Vector{1, 0, 0}, light.Direction(geometry.Point{1, 0, 1}, 1, 2))
-	geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 0}, light.Direction(geometry.Point{0, -1, 1}, 2, 2))
-	geometry.AssertVectorEqual(t, geometry.Vector{0, 0, 0}, light.Direction(geometry.Point{0, 0, 1}, 3, 2))
-}
-
-func TestPointLight_IsBlockedByIntersection(t *testing.T) {
-	point := geometry.Point{1, -1, 0}
-	light, _ := NewPointLight(geometry.Point{1, -1, 5}, shading.Color{1, 1, 1}, 1, 0)
-	intersection1 := geometry.Intersection{
-		Point:    geometry.Point{1, -1, 3},
-		Distance: 3,
-		Normal:   geometry.Vector{},
-	}
-	intersection2 := geometry.Intersection{
-		Point:    geometry.Point{1, -1, 6},
-		Distance: 6,
-		Normal:   geometry.Vector{},
-	}
-
-	assert.True(t, light.IsBlockedByIntersection(point, &intersection1))
-	assert.False(t, light.IsBlockedByIntersection(point, &intersection2))
+	sampler := sampling.NewFixedSampler(1, 2)
+	geometry.AssertVectorEqual(t, geometry.Vector{1, 0, 0}, light.Direction(geometry.Point{1, 0, 1}, sampler))
+	geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 0}, light.Direction(geometry.Point{0, -1, 1}, sampler))
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0, 0}, light.Direction(geometry.Point{0, 0, 1}, sampler))
 }
@@ -0,0 +1,85 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a rectangular light source that emits light evenly from every point on its front side, which faces in the
+// direction of the cross product of its width and height.
+type RectLight struct {
+	bottomLeftCorner geometry.Point  // Point representing the bottom left corner of the rectangle
+	width            geometry.Vector // Direction and size of the rectangle extending "right" from the corner
+	height           geometry.Vector // Direction and size of the rectangle extending "up" from the corner
+	normal           geometry.Vector // Unit vector pointing in the direction that the light is emitted
+	color            shading.Color
+	intensity        float64 // Total power of the light emitted by the source
+}
+
+// Returns a new rectangular light, or an error if the parameters are invalid. The intensity is the total power emitted,
+// as for point lights, so that the light's brightness doesn't depend on its size.
+func NewRectLight(bottomLeftCorner geometry.Point, width, height geometry.Vector, color shading.Color,
+	intensity float64) (RectLight, error) {
+	if intensity <= 0 {
+		return RectLight{}, errors.New("intensity must be positive")
+	}
+	if width.Norm() == 0 || height.Norm() == 0 {
+		return RectLight{}, errors.New("light width and height must be non-zero")
+	}
+	if math.Abs(width.ToUnit().Dot(height.ToUnit())) > 1e-9 {
+		return RectLight{}, errors.New("light width and height must be perpendicular")
+	}
+
+	return RectLight{
+		bottomLeftCorner: bottomLeftCorner,
+		width:            width,
+		height:           height,
+		normal:           width.Cross(height).ToUnit(),
+		color:            color,
+		intensity:        intensity,
+	}, nil
+}
+
+func (light RectLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	u, v := sampler.Get2D()
+	lightPoint := light.bottomLeftCorner.Translate(light.width.Multiply(u)).Translate(light.height.Multiply(v))
+	return areaSample(point, lightPoint, light.normal, light.color, light.Radiance(), 1/light.area())
+}
+
+func (light RectLight) Color() shading.Color {
+	return light.color
+}
+
+func (light RectLight) Intersect(ray geometry.Ray) (float64, bool) {
+	distance, point, ok := intersectFront(ray, light.bottomLeftCorner, light.normal)
+	if !ok {
+		return 0, false
+	}
+	offset := light.bottomLeftCorner.VectorTo(point)
+	u := offset.Dot(light.width) / light.width.Dot(light.width)
+	v := offset.Dot(light.height) / light.height.Dot(light.height)
+	return distance, u >= 0 && u <= 1 && v >= 0 && v <= 1
+}
+
+// Returns the radiance of a surface emitting the light's total power evenly in every direction from its front side.
+func (light RectLight) Radiance() float64 {
+	return light.intensity / (math.Pi * light.area())
+}
+
+func (light RectLight) Pdf(ray geometry.Ray) float64 {
+	distance, ok := light.Intersect(ray)
+	if !ok {
+		return 0
+	}
+	return solidAnglePdf(1/light.area(), distance, -light.normal.Dot(ray.Direction.ToUnit()))
+}
+
+// Returns the area of the rectangle.
+func (light RectLight) area() float64 {
+	return light.width.Norm() * light.height.Norm()
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewRectLight(t *testing.T) {
+	light, err := NewRectLight(geometry.Point{-1, -2, 5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 4, 0},
+		shading.Color{0.1, 0.2, 0.3}, 254)
+	assert.Nil(t, err)
+
+	assert.Equal(t, shading.Color{0.1, 0.2, 0.3}, light.Color())
+	assert.InDelta(t, 254/(8*math.Pi), light.Radiance(), 1e-9)
+}
+
+func TestNewRectLightInvalid(t *testing.T) {
+	_, err := NewRectLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, shading.Color{}, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be positive")
+	}
+
+	_, err = NewRectLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{}, shading.Color{}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be non-zero")
+	}
+
+	_, err = NewRectLight(geometry.Point{}, geometry.Vector{1, 0, 0}, geometry.Vector{1, 1, 0}, shading.Color{}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be perpendicular")
+	}
+}
+
+func TestRectLight_Sample(t *testing.T) {
+	// The light faces downwards.
+	light, _ := NewRectLight(geometry.Point{-0.5, -0.5, 10}, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.Color{1, 1, 1}, 1)
+	point := geometry.Point{0, 0, 0}
+
+	// Far from the light, the average irradiance should approach that from a point source emitting the same power
+	// into a hemisphere with a cosine falloff.
+	const numSamples = 10000
+	irradiance := 0.0
+	for i := 0; i < numSamples; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, numSamples))
+		assert.Greater(t, sample.Pdf, 0.0)
+		assert.InDelta(t, 10, sample.Distance, 0.1)
+		assert.Less(t, math.Abs(sample.Direction.X), 0.05)
+		assert.Less(t, math.Abs(sample.Direction.Y), 0.05)
+		irradiance += sample.Intensity * -sample.Direction.Z / numSamples
+	}
+	assert.InEpsilon(t, 1/(math.Pi*100), irradiance, 0.01)
+
+	// No light should be emitted from the back of the light.
+	assert.Equal(t, 0.0, light.Sample(geometry.Point{0, 0, 20}, sampling.NewFixedSampler(0, 1)).Intensity)
+}
+
+func TestRectLight_Intersect(t *testing.T) {
+	light, _ := NewRectLight(geometry.Point{-0.5, -0.5, 10}, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.Color{1, 1, 1}, 1)
+
+	distance, ok := light.Intersect(geometry.Ray{geometry.Point{0.25, 0.25, 0}, geometry.Vector{0, 0, 2}})
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, distance)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0.75, 0.25, 0}, geometry.Vector{0, 0, 1}})
+	assert.False(t, ok)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0.25, 0.25, 20}, geometry.Vector{0, 0, -1}})
+	assert.False(t, ok)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0.25, 0.25, 0}, geometry.Vector{0, 0, -1}})
+	assert.False(t, ok)
+
+	assertPdfMatchesSample(t, light, geometry.Point{1, 2, 3})
+}
+
+// Asserts that the given light's probability density for rays towards the points it samples matches that of the
+// samples themselves.
+func assertPdfMatchesSample(t *testing.T, light AreaLight, point geometry.Point) {
+	for i := 0; i < 10; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, 10))
+		ray := geometry.Ray{point, sample.Direction.Multiply(-1)}
+		distance, ok := light.Intersect(ray)
+		if assert.True(t, ok) {
+			assert.InDelta(t, sample.Distance, distance, 1e-9)
+			assert.InEpsilon(t, sample.Pdf, light.Pdf(ray), 1e-6)
+		}
+	}
+}
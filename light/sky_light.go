@@ -0,0 +1,223 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+const (
+	skyTableWidth         = 256   // Number of columns in the tabulated copy of the sky used for sampling
+	skyTableHeight        = 128   // Number of rows in the tabulated copy of the sky used for sampling
+	solarIlluminance      = 128   // Illuminance in kilolux of the sun's light before it enters the atmosphere
+	sunDirectionVariation = 0.005 // Variation in the sun's direction that gives roughly its apparent size
+)
+
+// Represents the light of a clear daytime sky, following the analytic model of Preetham, Shirley and Smits ("A
+// Practical Analytic Model for Daylight"), in which the brightness and color of the sky depend on the position of the
+// sun and the turbidity (haziness) of the atmosphere. Directions below the horizon see the ground, which reflects the
+// light of the sky and sun diffusely. The sun itself is a separate distant light, given by Sun.
+type SkyLight struct {
+	zenith       geometry.Vector // Unit vector towards the zenith
+	sunDirection geometry.Vector // Unit vector towards the sun
+	intensity    float64         // Factor by which the physical radiance of the sky in kcd/m^2 is multiplied
+
+	luminanceCoefficients perezCoefficients // Distribution of the sky's luminance
+	xCoefficients         perezCoefficients // Distribution of the x component of the sky's chromaticity
+	yCoefficients         perezCoefficients // Distribution of the y component of the sky's chromaticity
+	zenithLuminance       float64           // Luminance of the sky at the zenith, in kcd/m^2
+	zenithX               float64           // x component of the chromaticity of the sky at the zenith
+	zenithY               float64           // y component of the chromaticity of the sky at the zenith
+	groundRadiance        shading.Color     // Light reflected by the ground, before being multiplied by the intensity
+
+	sun          DistantLight     // Light arriving directly from the sun
+	distribution EnvironmentLight // Tabulated copy of the sky used to choose directions in proportion to its brightness
+}
+
+// Holds the five coefficients of the Perez formula for the distribution of a quantity over the sky.
+type perezCoefficients [5]float64
+
+// Returns a new sky light, or an error if the parameters are invalid. The sun's elevation is its angle in degrees above
+// the horizon and its azimuth is its angle in degrees clockwise (when viewed from above) from the azimuth reference.
+// The turbidity is in [2, 10], from a very clear sky to a hazy one, and the ground albedo is the fraction of light of
+// each color reflected by the ground. The intensity is multiplied by the physical radiance of the sky, in kilocandelas
+// per square meter, to give the light arriving from each direction.
+func NewSkyLight(zenithReference, azimuthReference geometry.Vector, sunElevationDeg, sunAzimuthDeg, turbidity float64,
+	groundAlbedo shading.Color, intensity float64) (SkyLight, error) {
+	if sunElevationDeg < 0 || sunElevationDeg > 90 {
+		return SkyLight{}, errors.New("sun elevation must be in [0, 90]")
+	}
+	if turbidity < 2 || turbidity > 10 {
+		return SkyLight{}, errors.New("turbidity must be in [2, 10]")
+	}
+	if groundAlbedo.R < 0 || groundAlbedo.R > 1 || groundAlbedo.G < 0 || groundAlbedo.G > 1 || groundAlbedo.B < 0 ||
+		groundAlbedo.B > 1 {
+		return SkyLight{}, errors.New("ground albedo must be in [0, 1]")
+	}
+
+	// Create the environment used for sampling first, since it validates the remaining parameters.
+	pixels := make([][]shading.Color, skyTableHeight)
+	for y := range pixels {
+		pixels[y] = make([]shading.Color, skyTableWidth)
+	}
+	distribution, err := NewEnvironmentLight(pixels, zenithReference, azimuthReference, 0, intensity)
+	if err != nil {
+		return SkyLight{}, err
+	}
+
+	zenith := zenithReference.ToUnit()
+	north := azimuthReference.ToUnit()
+	east := north.Cross(zenith)
+	elevation := sunElevationDeg * math.Pi / 180
+	azimuth := sunAzimuthDeg * math.Pi / 180
+	sunDirection := north.Multiply(math.Cos(elevation) * math.Cos(azimuth)).
+		Add(east.Multiply(math.Cos(elevation) * math.Sin(azimuth))).Add(zenith.Multiply(math.Sin(elevation)))
+	light := SkyLight{zenith: zenith, sunDirection: sunDirection.ToUnit(), intensity: intensity}
+	light.setCoefficients(turbidity, math.Pi/2-elevation)
+
+	sunIrradiance := sunTransmittance(turbidity, math.Pi/2-elevation).Multiply(solarIlluminance)
+	light.sun, err = NewDistantLight(light.sunDirection.Multiply(-1),
+		sunIrradiance.Multiply(1/sunIrradiance.MaxComponent()), sunIrradiance.MaxComponent()*intensity,
+		sunDirectionVariation)
+	if err != nil {
+		return SkyLight{}, err
+	}
+
+	// Tabulate the sky, adding up the light it casts onto the ground, and then fill in the ground.
+	skyIrradiance := shading.Color{}
+	pixelSolidAngle := 2 * math.Pi * math.Pi / (skyTableWidth * skyTableHeight)
+	for y := range pixels {
+		for x := range pixels[y] {
+			direction := distribution.toDirection((float64(x)+0.5)/skyTableWidth, (float64(y)+0.5)/skyTableHeight)
+			if cosTheta := direction.Dot(zenith); cosTheta > 0 {
+				pixels[y][x] = light.skyRadiance(direction)
+				solidAngle := pixelSolidAngle * distribution.rowSinTheta(y)
+				skyIrradiance = skyIrradiance.Add(pixels[y][x].Multiply(cosTheta * solidAngle))
+			}
+		}
+	}
+	groundIrradiance := skyIrradiance.Add(sunIrradiance.Multiply(math.Sin(elevation)))
+	light.groundRadiance = groundAlbedo.Filter(groundIrradiance).Multiply(1 / math.Pi)
+	for y := range pixels {
+		for x := range pixels[y] {
+			direction := distribution.toDirection((float64(x)+0.5)/skyTableWidth, (float64(y)+0.5)/skyTableHeight)
+			if direction.Dot(zenith) <= 0 {
+				pixels[y][x] = light.groundRadiance
+			}
+		}
+	}
+	distribution.buildDistribution()
+	light.distribution = distribution
+
+	return light, nil
+}
+
+func (light SkyLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	sample := light.distribution.Sample(point, sampler)
+	if sample.Pdf > 0 {
+		// Use the exact color of the sky rather than that of the tabulated copy.
+		sample.Color = light.radiance(sample.Direction.Multiply(-1))
+	}
+	return sample
+}
+
+// Returns the average color of the sky and ground, weighted by the solid angle covered by each direction.
+func (light SkyLight) Color() shading.Color {
+	return light.distribution.Color()
+}
+
+func (light SkyLight) Radiance(direction geometry.Vector) shading.Color {
+	return light.radiance(direction).Multiply(light.intensity)
+}
+
+func (light SkyLight) Pdf(direction geometry.Vector) float64 {
+	return light.distribution.Pdf(direction)
+}
+
+// Returns the distant light representing the sun, whose color and intensity are those of sunlight after it has passed
+// through the atmosphere.
+func (light SkyLight) Sun() DistantLight {
+	return light.sun
+}
+
+// Returns the light arriving from the given direction, before it is multiplied by the intensity.
+func (light SkyLight) radiance(direction geometry.Vector) shading.Color {
+	direction = direction.ToUnit()
+	if direction.Dot(light.zenith) <= 0 {
+		return light.groundRadiance
+	}
+	return light.skyRadiance(direction)
+}
+
+// Returns the light arriving from the given unit direction above the horizon according to the sky model, before it is
+// multiplied by the intensity.
+func (light SkyLight) skyRadiance(direction geometry.Vector) shading.Color {
+	cosTheta := math.Max(direction.Dot(light.zenith), 1e-6)
+	gamma := math.Acos(math.Max(math.Min(direction.Dot(light.sunDirection), 1), -1))
+	sunTheta := math.Acos(math.Max(math.Min(light.sunDirection.Dot(light.zenith), 1), -1))
+
+	// Each quantity is given relative to its value at the zenith.
+	relative := func(coefficients perezCoefficients) float64 {
+		return coefficients.evaluate(cosTheta, gamma) / coefficients.evaluate(1, sunTheta)
+	}
+	color := shading.ColorFromChromaticity(light.zenithX*relative(light.xCoefficients),
+		light.zenithY*relative(light.yCoefficients), light.zenithLuminance*relative(light.luminanceCoefficients))
+
+	// Chromaticities outside the range of RGB colors give slightly negative components.
+	return shading.Color{math.Max(color.R, 0), math.Max(color.G, 0), math.Max(color.B, 0)}
+}
+
+// Calculates the coefficients of the sky model for the given turbidity and angle in radians of the sun from the
+// zenith.
+func (light *SkyLight) setCoefficients(turbidity, sunTheta float64) {
+	t := turbidity
+	light.luminanceCoefficients = perezCoefficients{
+		0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703,
+	}
+	light.xCoefficients = perezCoefficients{
+		-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452,
+	}
+	light.yCoefficients = perezCoefficients{
+		-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529,
+	}
+
+	chi := (4.0/9 - t/120) * (math.Pi - 2*sunTheta)
+	light.zenithLuminance = (4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192
+	theta2 := sunTheta * sunTheta
+	theta3 := theta2 * sunTheta
+	light.zenithX = t*t*(0.00166*theta3-0.00375*theta2+0.00209*sunTheta) +
+		t*(-0.02903*theta3+0.06377*theta2-0.03202*sunTheta+0.00394) +
+		(0.11693*theta3 - 0.21196*theta2 + 0.06052*sunTheta + 0.25886)
+	light.zenithY = t*t*(0.00275*theta3-0.00610*theta2+0.00317*sunTheta) +
+		t*(-0.04214*theta3+0.08970*theta2-0.04153*sunTheta+0.00516) +
+		(0.15346*theta3 - 0.26756*theta2 + 0.06670*sunTheta + 0.26688)
+}
+
+// Returns the relative value of the Perez formula for a direction at the given cosine of its angle from the zenith
+// and angle in radians from the sun.
+func (coefficients perezCoefficients) evaluate(cosTheta, gamma float64) float64 {
+	a, b, c, d, e := coefficients[0], coefficients[1], coefficients[2], coefficients[3], coefficients[4]
+	cosGamma := math.Cos(gamma)
+	return (1 + a*math.Exp(b/cosTheta)) * (1 + c*math.Exp(d*gamma) + e*cosGamma*cosGamma)
+}
+
+// Returns the fraction of the sun's red, green and blue light that passes through the atmosphere with the given
+// turbidity when the sun is at the given angle in radians from the zenith, accounting for scattering by air molecules
+// (Rayleigh scattering) and by haze.
+func sunTransmittance(turbidity, sunTheta float64) shading.Color {
+	// Find the relative length of the path through the atmosphere, which grows steeply towards the horizon.
+	airMass := 1 / (math.Cos(sunTheta) + 0.15*math.Pow(93.885-sunTheta*180/math.Pi, -1.253))
+	beta := 0.04608365822050*turbidity - 0.04586025928522
+	transmittance := func(wavelength float64) float64 {
+		rayleigh := math.Exp(-0.008735 * math.Pow(wavelength, -4.08) * airMass)
+		aerosol := math.Exp(-beta * math.Pow(wavelength, -1.3) * airMass)
+		return rayleigh * aerosol
+	}
+
+	// Use representative wavelengths in micrometers for each component.
+	return shading.Color{transmittance(0.68), transmittance(0.55), transmittance(0.44)}
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewSkyLightInvalid(t *testing.T) {
+	zenith := geometry.Vector{0, 0, 1}
+	north := geometry.Vector{0, 1, 0}
+	albedo := shading.Color{0.2, 0.2, 0.2}
+
+	_, err := NewSkyLight(zenith, north, -1, 0, 3, albedo, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "sun elevation must be in [0, 90]")
+	}
+
+	_, err = NewSkyLight(zenith, north, 45, 0, 1, albedo, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "turbidity must be in [2, 10]")
+	}
+
+	_, err = NewSkyLight(zenith, north, 45, 0, 3, shading.Color{0.2, 1.1, 0.2}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "ground albedo must be in [0, 1]")
+	}
+
+	_, err = NewSkyLight(zenith, north, 45, 0, 3, albedo, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "intensity must be positive")
+	}
+
+	_, err = NewSkyLight(zenith, zenith, 45, 0, 3, albedo, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be perpendicular")
+	}
+}
+
+func TestSkyLight_Sun(t *testing.T) {
+	light, err := NewSkyLight(geometry.Vector{0, 0, 1}, geometry.Vector{0, 1, 0}, 30, 90, 3,
+		shading.Color{0.2, 0.2, 0.2}, 2)
+	assert.Nil(t, err)
+
+	// The sun should lie to the east of the azimuth reference, shining downwards.
+	sun := light.Sun()
+	geometry.AssertVectorEqual(t, geometry.Vector{-math.Sqrt(3) / 2, 0, -0.5},
+		sun.Direction(geometry.Point{}, sampling.NewFixedSampler(0, 1)))
+	assert.Equal(t, 1.0, sun.Color().R)
+	assert.Less(t, sun.Color().B, sun.Color().G)
+	assert.Less(t, sun.Intensity(geometry.Point{}), 2.0*solarIlluminance)
+
+	// The sun should be dimmer and redder when it is low in the sky, and the haze should dim it further.
+	lowLight, _ := NewSkyLight(geometry.Vector{0, 0, 1}, geometry.Vector{0, 1, 0}, 5, 90, 3,
+		shading.Color{0.2, 0.2, 0.2}, 2)
+	lowSun := lowLight.Sun()
+	assert.Less(t, lowSun.Intensity(geometry.Point{}), sun.Intensity(geometry.Point{}))
+	assert.Less(t, lowSun.Color().B, sun.Color().B)
+	hazyLight, _ := NewSkyLight(geometry.Vector{0, 0, 1}, geometry.Vector{0, 1, 0}, 30, 90, 8,
+		shading.Color{0.2, 0.2, 0.2}, 2)
+	assert.Less(t, hazyLight.Sun().Intensity(geometry.Point{}), sun.Intensity(geometry.Point{}))
+}
+
+func TestSkyLight_Radiance(t *testing.T) {
+	light, _ := NewSkyLight(geometry.Vector{0, 0, 1}, geometry.Vector{0, 1, 0}, 30, 90, 3,
+		shading.Color{0.5, 0, 0.5}, 2)
+
+	// The luminance at the zenith should follow the model's formula.
+	chi := (4.0/9 - 3.0/120) * (math.Pi - 2*math.Pi/3)
+	zenithLuminance := (4.0453*3-4.9710)*math.Tan(chi) - 0.2155*3 + 2.4192
+	zenith := light.Radiance(geometry.Vector{0, 0, 1})
+	assert.InDelta(t, 2*zenithLuminance, zenith.Luminance(), 1e-3)
+
+	// A clear sky should be blue, and brighter near the sun than away from it.
+	assert.Greater(t, zenith.B, zenith.R)
+	nearSun := light.Radiance(geometry.Vector{1, 0, 0.7})
+	awayFromSun := light.Radiance(geometry.Vector{-1, 0, 0.7})
+	assert.Greater(t, nearSun.Luminance(), 2*awayFromSun.Luminance())
+
+	// The ground should reflect the light of the sky and sun in proportion to its albedo.
+	ground := light.Radiance(geometry.Vector{0.3, 0.2, -1})
+	assert.Equal(t, ground, light.Radiance(geometry.Vector{0, 0, -1}))
+	assert.Equal(t, 0.0, ground.G)
+	assert.Greater(t, ground.B, ground.R)
+	sunIrradiance := light.Sun().Color().Multiply(light.Sun().Intensity(geometry.Point{}) * 0.5)
+	assert.Greater(t, ground.R, 0.5*sunIrradiance.R/math.Pi)
+}
+
+func TestSkyLight_Sample(t *testing.T) {
+	light, _ := NewSkyLight(geometry.Vector{0, 0, 1}, geometry.Vector{0, 1, 0}, 20, 45, 4,
+		shading.Color{0.3, 0.3, 0.3}, 0.5)
+	point := geometry.Point{1, 2, 3}
+	const numSamples = 400
+	for i := 0; i < numSamples; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, numSamples))
+		direction := sample.Direction.Multiply(-1)
+		assert.Equal(t, math.Inf(1), sample.Distance)
+		assert.InDelta(t, light.Pdf(direction), sample.Pdf, 1e-9)
+		assert.InDelta(t, 0.5/sample.Pdf, sample.Intensity, 1e-9)
+		radiance := light.Radiance(direction)
+		assert.InDelta(t, radiance.R, 0.5*sample.Color.R, 1e-9)
+		assert.InDelta(t, radiance.B, 0.5*sample.Color.B, 1e-9)
+	}
+
+	// The irradiance on the ground estimated from the samples should match that from integrating the sky directly.
+	var estimate, exact shading.Color
+	up := geometry.Vector{0, 0, 1}
+	for i := 0; i < 4*numSamples; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, 4*numSamples))
+		if cosTheta := -sample.Direction.Dot(up); cosTheta > 0 {
+			estimate = estimate.Add(sample.Color.Multiply(sample.Intensity * cosTheta / (4 * numSamples)))
+		}
+	}
+	const steps = 400
+	for i := 0; i < steps; i++ {
+		theta := (float64(i) + 0.5) / steps * math.Pi / 2
+		for j := 0; j < steps; j++ {
+			phi := (float64(j) + 0.5) / steps * 2 * math.Pi
+			direction := geometry.Vector{math.Sin(theta) * math.Cos(phi), math.Sin(theta) * math.Sin(phi),
+				math.Cos(theta)}
+			solidAngle := math.Sin(theta) * math.Pi / 2 / steps * 2 * math.Pi / steps
+			exact = exact.Add(light.Radiance(direction).Multiply(math.Cos(theta) * solidAngle))
+		}
+	}
+	assert.InEpsilon(t, exact.Luminance(), estimate.Luminance(), 0.05)
+}
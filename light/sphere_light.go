@@ -0,0 +1,103 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a spherical light source that emits light evenly from every point on its surface.
+type SphereLight struct {
+	center    geometry.Point // Point at the center of the sphere
+	radius    float64
+	color     shading.Color
+	intensity float64 // Total power of the light emitted by the source
+}
+
+// Returns a new spherical light, or an error if the parameters are invalid. The intensity is the total power emitted,
+// as for point lights, so that the light illuminates distant points exactly as a point light at its center would.
+func NewSphereLight(center geometry.Point, radius float64, color shading.Color,
+	intensity float64) (SphereLight, error) {
+	if intensity <= 0 {
+		return SphereLight{}, errors.New("intensity must be positive")
+	}
+	if radius <= 0 {
+		return SphereLight{}, errors.New("radius must be positive")
+	}
+
+	return SphereLight{
+		center:    center,
+		radius:    radius,
+		color:     color,
+		intensity: intensity,
+	}, nil
+}
+
+// Chooses a point at random from the cap of the sphere that is visible from the given point, since the rest of the
+// sphere is hidden behind it.
+func (light SphereLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	toPoint := light.center.VectorTo(point)
+	distance := toPoint.Norm()
+	if distance <= light.radius {
+		// The point is inside the sphere, which only emits light outwards.
+		return Sample{}
+	}
+	axis := toPoint.Multiply(1 / distance)
+
+	// Points are spread evenly over the cap's area if their height along its axis is chosen uniformly.
+	u1, u2 := sampler.Get2D()
+	cosMax := light.radius / distance
+	cosTheta := 1 - u1*(1-cosMax)
+	sinTheta := math.Sqrt(math.Max(1-cosTheta*cosTheta, 0))
+	phi := 2 * math.Pi * u2
+	uDirection, vDirection := axis.OrthonormalBasis()
+	normal := uDirection.Multiply(sinTheta * math.Cos(phi)).Add(vDirection.Multiply(sinTheta * math.Sin(phi))).
+		Add(axis.Multiply(cosTheta))
+	lightPoint := light.center.Translate(normal.Multiply(light.radius))
+	return areaSample(point, lightPoint, normal, light.color, light.Radiance(), 1/light.visibleArea(distance))
+}
+
+func (light SphereLight) Color() shading.Color {
+	return light.color
+}
+
+func (light SphereLight) Intersect(ray geometry.Ray) (float64, bool) {
+	direction := ray.Direction.ToUnit()
+	centerToOrigin := light.center.VectorTo(ray.Origin)
+	b := centerToOrigin.Dot(direction)
+	c := centerToOrigin.Dot(centerToOrigin) - light.radius*light.radius
+	if c <= 0 {
+		// The ray starts inside the sphere, which only emits light outwards.
+		return 0, false
+	}
+	discriminant := b*b - c
+	if discriminant < 0 {
+		return 0, false
+	}
+	distance := -b - math.Sqrt(discriminant)
+	return distance, distance > 0
+}
+
+// Returns the radiance of a surface emitting the light's total power evenly in every direction.
+func (light SphereLight) Radiance() float64 {
+	return light.intensity / (4 * math.Pi * math.Pi * light.radius * light.radius)
+}
+
+func (light SphereLight) Pdf(ray geometry.Ray) float64 {
+	distance, ok := light.Intersect(ray)
+	if !ok {
+		return 0
+	}
+	direction := ray.Direction.ToUnit()
+	normal := light.center.VectorTo(ray.Origin.Translate(direction.Multiply(distance))).Multiply(1 / light.radius)
+	return solidAnglePdf(1/light.visibleArea(light.center.DistanceTo(ray.Origin)), distance, -normal.Dot(direction))
+}
+
+// Returns the area of the cap of the sphere that is visible from a point at the given distance from its center.
+func (light SphereLight) visibleArea(distance float64) float64 {
+	return 2 * math.Pi * light.radius * light.radius * (1 - light.radius/distance)
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewSphereLight(t *testing.T) {
+	light, err := NewSphereLight(geometry.Point{1, 2, 3}, 0.5, shading.Color{0.1, 0.2, 0.3}, 254)
+	assert.Nil(t, err)
+
+	assert.Equal(t, shading.Color{0.1, 0.2, 0.3}, light.Color())
+	assert.InDelta(t, 254/(math.Pi*math.Pi), light.Radiance(), 1e-9)
+}
+
+func TestNewSphereLightInvalid(t *testing.T) {
+	_, err := NewSphereLight(geometry.Point{}, 1, shading.Color{}, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "intensity must be positive")
+	}
+
+	_, err = NewSphereLight(geometry.Point{}, 0, shading.Color{}, 1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "radius must be positive")
+	}
+}
+
+func TestSphereLight_Sample(t *testing.T) {
+	light, _ := NewSphereLight(geometry.Point{0, 0, 2}, 1, shading.Color{1, 1, 1}, 254)
+	point := geometry.Point{0, 0, 0}
+
+	// A sphere should illuminate points outside it exactly as a point light of the same power at its center would.
+	pointLight, _ := NewPointLight(geometry.Point{0, 0, 2}, shading.Color{1, 1, 1}, 254, 0)
+	const numSamples = 10000
+	irradiance := 0.0
+	for i := 0; i < numSamples; i++ {
+		sample := light.Sample(point, sampling.NewFixedSampler(i, numSamples))
+		assert.GreaterOrEqual(t, sample.Distance, 1.0)
+		assert.LessOrEqual(t, sample.Distance, math.Sqrt(3)+1e-9)
+		irradiance += sample.Intensity * -sample.Direction.Z / numSamples
+	}
+	assert.InEpsilon(t, pointLight.Intensity(point), irradiance, 0.01)
+
+	// No light should reach points inside the sphere.
+	assert.Equal(t, 0.0, light.Sample(geometry.Point{0, 0, 2.5}, sampling.NewFixedSampler(0, 1)).Intensity)
+}
+
+func TestSphereLight_Intersect(t *testing.T) {
+	light, _ := NewSphereLight(geometry.Point{0, 0, 2}, 1, shading.Color{1, 1, 1}, 1)
+
+	distance, ok := light.Intersect(geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{0, 0, 3}})
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, distance)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{0, 1, 1}})
+	assert.False(t, ok)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{0, 0, -1}})
+	assert.False(t, ok)
+	_, ok = light.Intersect(geometry.Ray{geometry.Point{0, 0, 2}, geometry.Vector{0, 0, 1}})
+	assert.False(t, ok)
+
+	assertPdfMatchesSample(t, light, geometry.Point{1, 2, -3})
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a light source located at a specific point emitting light in a cone around a given direction, which fades
+// out smoothly between an inner and an outer angle from the cone's axis (such as a stage light).
+type SpotLight struct {
+	pointLight    PointLight      // Omnidirectional light at the same location, which is restricted to the cone
+	direction     geometry.Vector // Unit vector along the axis of the cone, pointing away from the light
+	cosInnerAngle float64         // Cosine of the angle from the axis within which the light is at full intensity
+	cosOuterAngle float64         // Cosine of the angle from the axis beyond which there is no light
+}
+
+// Returns a new spot light, or an error if the parameters are invalid. The angles are measured in degrees from the
+// axis of the cone, and the intensity and radius have the same meaning as for a point light.
+func NewSpotLight(point geometry.Point, direction geometry.Vector, color shading.Color, intensity float64,
+	innerAngleDeg, outerAngleDeg float64, radius float64) (SpotLight, error) {
+	pointLight, err := NewPointLight(point, color, intensity, radius)
+	if err != nil {
+		return SpotLight{}, err
+	}
+	if direction.Norm() == 0 {
+		return SpotLight{}, errors.New("direction must be non-zero")
+	}
+	if innerAngleDeg < 0 || innerAngleDeg > outerAngleDeg || outerAngleDeg > 180 {
+		return SpotLight{}, errors.New("cone angles must satisfy 0 <= inner <= outer <= 180")
+	}
+
+	return SpotLight{
+		pointLight:    pointLight,
+		direction:     direction.ToUnit(),
+		cosInnerAngle: math.Cos(innerAngleDeg * math.Pi / 180),
+		cosOuterAngle: math.Cos(outerAngleDeg * math.Pi / 180),
+	}, nil
+}
+
+func (light SpotLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	sample := light.pointLight.Sample(point, sampler)
+	sample.Intensity *= light.falloff(point)
+	return sample
+}
+
+func (light SpotLight) Color() shading.Color {
+	return light.pointLight.Color()
+}
+
+// Returns the fraction of the light's full intensity that is emitted towards the given point, which eases smoothly
+// from one within the inner angle to zero beyond the outer angle.
+func (light SpotLight) falloff(point geometry.Point) float64 {
+	toPoint := light.pointLight.point.VectorTo(point)
+	if toPoint.Norm() == 0 {
+		return 0
+	}
+	cosAngle := toPoint.ToUnit().Dot(light.direction)
+	if cosAngle >= light.cosInnerAngle {
+		return 1
+	}
+	if cosAngle <= light.cosOuterAngle {
+		return 0
+	}
+	t := (cosAngle - light.cosOuterAngle) / (light.cosInnerAngle - light.cosOuterAngle)
+	return t * t * (3 - 2*t)
+}
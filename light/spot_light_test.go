@@ -0,0 +1,92 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewSpotLight(t *testing.T) {
+	light, err := NewSpotLight(geometry.Point{1, 2, 3}, geometry.Vector{0, 0, -2}, shading.Color{0.1, 0.2, 0.3}, 254,
+		20, 30, 0.5)
+	assert.Nil(t, err)
+
+	assert.Equal(t, shading.Color{0.1, 0.2, 0.3}, light.Color())
+	assert.Equal(t, 254.0/4/16/math.Pi,
+		light.Sample(geometry.Point{1, 2, -1}, sampling.NewFixedSampler(0, 1)).Intensity)
+}
+
+func TestNewSpotLightInvalid(t *testing.T) {
+	_, err := NewSpotLight(geometry.Point{}, geometry.Vector{0, 0, -1}, shading.Color{}, 0, 20, 30, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "intensity must be positive")
+	}
+
+	_, err = NewSpotLight(geometry.Point{}, geometry.Vector{0, 0, -1}, shading.Color{}, 1, 20, 30, -1)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "radius must be non-negative")
+	}
+
+	_, err = NewSpotLight(geometry.Point{}, geometry.Vector{}, shading.Color{}, 1, 20, 30, 0)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "direction must be non-zero")
+	}
+
+	for _, angles := range [][2]float64{{-1, 30}, {40, 30}, {20, 181}} {
+		_, err = NewSpotLight(geometry.Point{}, geometry.Vector{0, 0, -1}, shading.Color{}, 1, angles[0], angles[1],
+			0)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "cone angles must satisfy")
+		}
+	}
+}
+
+func TestSpotLight_Intensity(t *testing.T) {
+	light, _ := NewSpotLight(geometry.Point{0, 0, 0}, geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 20, 40,
+		0)
+	pointLight, _ := NewPointLight(geometry.Point{0, 0, 0}, shading.Color{1, 1, 1}, 1, 0)
+	atAngle := func(angleDeg float64) geometry.Point {
+		return geometry.Point{2 * math.Sin(angleDeg*math.Pi/180), 0, -2 * math.Cos(angleDeg*math.Pi/180)}
+	}
+	intensity := func(light Light, point geometry.Point) float64 {
+		return light.Sample(point, sampling.NewFixedSampler(0, 1)).Intensity
+	}
+
+	// Within the inner cone, the light should be as bright as a point light, and fade out smoothly beyond it.
+	assert.Equal(t, intensity(pointLight, atAngle(0)), intensity(light, atAngle(0)))
+	assert.InDelta(t, intensity(pointLight, atAngle(19.9)), intensity(light, atAngle(19.9)), 1e-12)
+	previousIntensity := intensity(light, atAngle(20))
+	for angle := 21.0; angle < 40; angle++ {
+		angleIntensity := intensity(light, atAngle(angle))
+		assert.Less(t, angleIntensity, previousIntensity)
+		assert.Greater(t, angleIntensity, 0.0)
+		previousIntensity = angleIntensity
+	}
+	assert.Equal(t, 0.0, intensity(light, atAngle(40.1)))
+	assert.Equal(t, 0.0, intensity(light, atAngle(180)))
+
+	// The falloff should be halfway through at the midpoint in cosine between the two angles.
+	midpointAngle := math.Acos((math.Cos(20*math.Pi/180)+math.Cos(40*math.Pi/180))/2) * 180 / math.Pi
+	assert.InDelta(t, intensity(pointLight, atAngle(0))/2, intensity(light, atAngle(midpointAngle)), 1e-12)
+
+	// The intensity should fall off with the square of the distance.
+	assert.InDelta(t, intensity(light, geometry.Point{0, 0, -1})/4, intensity(light, geometry.Point{0, 0, -2}), 1e-12)
+}
+
+func TestSpotLight_Sample(t *testing.T) {
+	light, _ := NewSpotLight(geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 10, 20,
+		0.1)
+	for i := 0; i < 10; i++ {
+		sample := light.Sample(geometry.Point{0, 0, 0}, sampling.NewFixedSampler(i, 10))
+		assert.InEpsilon(t, -1, sample.Direction.Z, 0.01)
+		assert.Equal(t, 1.0, sample.Distance)
+		assert.InDelta(t, 1/(4*math.Pi), sample.Intensity, 1e-12)
+		assert.Equal(t, 0.0, sample.Pdf)
+	}
+	assert.Equal(t, 0.0, light.Sample(geometry.Point{1, 0, 1}, sampling.NewFixedSampler(0, 1)).Intensity)
+}
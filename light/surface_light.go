@@ -0,0 +1,103 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"math"
+)
+
+// Represents one or more surfaces in the scene whose materials emit light (such as the triangles of a glowing mesh),
+// sampled as a single light source so that the light they cast is found directly rather than only by rays that happen
+// to hit them. Unlike an area light, the surfaces are part of the scene in their own right, and their emission is seen
+// by rays that hit them as for any other surface.
+type SurfaceLight struct {
+	surfaces  []surface.SampledSurface
+	areaCdf   []float64 // Cumulative probability of sampling each surface, in proportion to its area
+	totalArea float64
+	hierarchy *surface.BoundingVolumeHierarchy // Acceleration structure used to find the point that a ray hits
+}
+
+// Returns a new light that samples the given surfaces, or an error if they have no area or aren't all made of emissive
+// materials.
+func NewSurfaceLight(surfaces []surface.SampledSurface) (SurfaceLight, error) {
+	areaCdf := make([]float64, len(surfaces)+1)
+	hierarchySurfaces := make([]surface.Surface, len(surfaces))
+	for i, emissiveSurface := range surfaces {
+		if _, ok := emissiveSurface.Material().(shading.EmissiveMaterial); !ok {
+			return SurfaceLight{}, errors.New("surfaces must be made of emissive materials")
+		}
+		areaCdf[i+1] = areaCdf[i] + emissiveSurface.Area()
+		hierarchySurfaces[i] = emissiveSurface
+	}
+	totalArea := areaCdf[len(surfaces)]
+	if totalArea <= 0 {
+		return SurfaceLight{}, errors.New("emissive surfaces must have a positive area")
+	}
+	normalizeCdf(areaCdf)
+
+	return SurfaceLight{
+		surfaces:  surfaces,
+		areaCdf:   areaCdf,
+		totalArea: totalArea,
+		hierarchy: surface.NewBoundingVolumeHierarchy(hierarchySurfaces),
+	}, nil
+}
+
+// Chooses a point spread evenly over the total area of the surfaces, emitting light from whichever side faces the given
+// point.
+func (light SurfaceLight) Sample(point geometry.Point, sampler sampling.Sampler) Sample {
+	u1, u2 := sampler.Get2D()
+
+	// Choose the surface first, and then reuse the position of the random number within its interval.
+	position, index := sampleCdf(light.areaCdf, u1)
+	emissiveSurface := light.surfaces[index]
+	lightPoint, normal := emissiveSurface.SamplePoint(position-float64(index), u2)
+	if normal.Dot(lightPoint.VectorTo(point)) < 0 {
+		normal = normal.Multiply(-1)
+	}
+
+	interaction := shading.Interaction{Point: lightPoint, Normal: normal, Surface: emissiveSurface}
+	emission := emissiveSurface.Material().(shading.EmissiveMaterial).Emission(interaction,
+		lightPoint.VectorTo(point).ToUnit())
+	return areaSample(point, lightPoint, normal, emission, 1, 1/light.totalArea)
+}
+
+// Returns the color of the light emitted by the first surface, scaled so that its brightest component is one.
+func (light SurfaceLight) Color() shading.Color {
+	emissiveSurface := light.surfaces[0]
+	point, normal := emissiveSurface.SamplePoint(0.5, 0.5)
+	emission := emissiveSurface.Material().(shading.EmissiveMaterial).Emission(shading.Interaction{Point: point,
+		Normal: normal, Surface: emissiveSurface}, normal)
+	if emission.MaxComponent() == 0 {
+		return emission
+	}
+	return emission.Multiply(1 / emission.MaxComponent())
+}
+
+// Returns the distance along the given ray at which it first hits one of the surfaces, or false if it misses them.
+func (light SurfaceLight) Intersect(ray geometry.Ray) (float64, bool) {
+	intersection, _ := light.hierarchy.ClosestIntersection(ray)
+	if intersection == nil {
+		return 0, false
+	}
+	return intersection.Distance, true
+}
+
+// Returns the probability density, with respect to solid angle, of Sample choosing the point at which the given ray
+// first hits one of the surfaces when illuminating the ray's origin, or zero if it misses them.
+func (light SurfaceLight) Pdf(ray geometry.Ray) float64 {
+	intersection, _ := light.hierarchy.ClosestIntersection(ray)
+	if intersection == nil {
+		return 0
+	}
+	cosLight := math.Abs(intersection.Normal.Dot(ray.Direction.ToUnit()))
+	if cosLight == 0 {
+		return 0
+	}
+	return solidAnglePdf(1/light.totalArea, intersection.Distance, cosLight)
+}
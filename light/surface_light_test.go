@@ -0,0 +1,66 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package light
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewSurfaceLightInvalid(t *testing.T) {
+	_, err := NewSurfaceLight(nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must have a positive area")
+	}
+
+	plane, _ := surface.NewPlane(geometry.Point{-1, -1, 2}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 1})
+	_, err = NewSurfaceLight([]surface.SampledSurface{plane})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be made of emissive materials")
+	}
+}
+
+func TestSurfaceLight_Sample(t *testing.T) {
+	material := shading.DiffuseEmitter{Color: shading.Color{1, 0.5, 0}, Strength: 4}
+	plane, _ := surface.NewPlane(geometry.Point{-1, -1, 2}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		material)
+	sphere, _ := surface.NewSphere(geometry.Point{5, 0, 2}, 1, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		material)
+	light, err := NewSurfaceLight([]surface.SampledSurface{plane, sphere})
+	assert.Nil(t, err)
+	assert.Equal(t, shading.Color{1, 0.5, 0}, light.Color())
+
+	// Both sides of the plane should emit light, and the probability density for rays towards each sampled point
+	// should match that of the sample.
+	for _, point := range []geometry.Point{{0.5, 0, 0}, {0, 0.5, 4}} {
+		numPlaneSamples := 0
+		for i := 0; i < 100; i++ {
+			sample := light.Sample(point, sampling.NewFixedSampler(i, 100))
+			assert.Equal(t, shading.Color{4, 2, 0}, sample.Color)
+			ray := geometry.Ray{point, sample.Direction.Multiply(-1)}
+			if distance, ok := light.Intersect(ray); assert.True(t, ok) && distance < sample.Distance-1e-6 {
+				// The point on the far side of the sphere is hidden behind the near side.
+				continue
+			}
+			if sample.Intensity > 0 {
+				assert.InEpsilon(t, sample.Pdf, light.Pdf(ray), 1e-6)
+				assert.InEpsilon(t, 1/sample.Pdf, sample.Intensity, 1e-9)
+			}
+			lightPoint := point.Translate(sample.Direction.Multiply(-sample.Distance))
+			if math.Abs(lightPoint.Z-2) < 1e-9 && math.Abs(lightPoint.X) <= 1 {
+				numPlaneSamples++
+			}
+		}
+
+		// The surfaces should be chosen in proportion to their areas.
+		assert.InDelta(t, 100*4/(4+4*math.Pi), numPlaneSamples, 10)
+	}
+
+	assert.Equal(t, 0.0, light.Pdf(geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{0, 0, -1}}))
+}
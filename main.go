@@ -7,9 +7,12 @@ import (
 	"flag"
 	"fmt"
 	"github.com/patfair/raytracer/example"
+	"github.com/patfair/raytracer/hdr"
 	"github.com/patfair/raytracer/render"
+	"github.com/patfair/raytracer/scenefile"
 	"image/png"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,8 +20,33 @@ func main() {
 	width := flag.Int("width", 1920, "rendered image width in pixels")
 	height := flag.Int("height", 1080, "rendered image height in pixels")
 	draft := flag.Bool("draft", false, "whether to only render a rough draft without any multi-pass features enabled")
-	outputFilename := flag.String("output", "", "PNG file path to write the rendered image to")
+	outputFilename := flag.String("output", "",
+		"file path to write the rendered image to, whose extension determines the format: .png, .hdr or .exr")
 	frame := flag.Int("frame", 0, "frame number passed to the scene generation method for optional animation")
+	sceneFilename := flag.String("scene", "", "JSON scene file to render instead of the built-in example scene")
+	integratorName := flag.String("integrator", "",
+		"rendering algorithm to use instead of the scene's own: \"whitted\" or \"path\" (for path tracing)")
+	samplesPerPixel := flag.Int("samples", 0,
+		"number of samples per pixel when path tracing (0 for the default); not applicable to the Whitted integrator")
+	adaptiveThreshold := flag.Float64("adaptive-threshold", 0,
+		"if positive, stop sampling each pixel once the standard error of its color falls below this value")
+	heatMapFilename := flag.String("heatmap", "", "optional PNG file path to write a heat map of samples per pixel to")
+	toneMapName := flag.String("tonemap", "clamp", "tone mapping operator for PNG output: \"clamp\", \"reinhard\", "+
+		"\"aces\" or \"linear\" (clamped and written without sRGB encoding, as in older versions)")
+	exposureStops := flag.Float64("exposure", 0, "exposure adjustment in stops to apply before tone mapping")
+	whitePoint := flag.Float64("white-point", 0, "luminance that maps to white with the Reinhard operator (0 for none)")
+	exrFloat := flag.Bool("exr-float", false, "whether to store 32-bit rather than 16-bit values in OpenEXR output")
+	exrUncompressed := flag.Bool("exr-uncompressed", false, "whether to disable ZIP compression of OpenEXR output")
+	samplerName := flag.String("sampler", "",
+		"algorithm for spreading out each pixel's samples instead of the scene's own: \"stratified\", \"halton\" or "+
+			"\"sobol\"")
+	filterName := flag.String("filter", "",
+		"pixel reconstruction filter instead of the scene's own: \"box\", \"tent\", \"gaussian\", \"mitchell\" or "+
+			"\"lanczos\"")
+	filterRadius := flag.Float64("filter-radius", 0,
+		"radius in pixels of the reconstruction filter, or of the scene's own if -filter is unspecified (0 for the "+
+			"filter's default)")
+	seed := flag.Int64("seed", 0, "seed for the random numbers used in rendering; the same seed gives the same image")
 	flag.Parse()
 
 	renderType := render.RenderFinishPass
@@ -28,21 +56,124 @@ func main() {
 	if *outputFilename == "" {
 		handleError(errors.New("must specify output path"))
 	}
-	if !strings.HasSuffix(*outputFilename, ".png") {
-		handleError(errors.New("output path must end in .png"))
+	outputExtension := strings.ToLower(filepath.Ext(*outputFilename))
+	if outputExtension != ".png" && outputExtension != ".hdr" && outputExtension != ".exr" {
+		handleError(errors.New("output path must end in .png, .hdr or .exr"))
+	}
+	if *heatMapFilename != "" && !strings.HasSuffix(*heatMapFilename, ".png") {
+		handleError(errors.New("heat map path must end in .png"))
 	}
 
-	scene, err := example.SpheresScene(*frame)
+	var scene *render.Scene
+	var err error
+	if *sceneFilename != "" {
+		scene, err = scenefile.Load(*sceneFilename)
+	} else {
+		scene, err = example.SpheresScene(*frame)
+	}
 	handleError(err)
 
-	image, err := scene.Render(renderType, *width, *height)
+	// The Whitted integrator's sampling is configured by the scene's camera and lights instead.
+	samplesError := errors.New("samples can only be specified for path tracing (use -integrator path)")
+	switch *integratorName {
+	case "":
+		if *samplesPerPixel > 0 {
+			integrator, ok := scene.Integrator.(render.PathTracingIntegrator)
+			if !ok {
+				handleError(samplesError)
+			}
+			integrator.SamplesPerPixel = *samplesPerPixel
+			scene.Integrator = integrator
+		}
+	case "whitted":
+		if *samplesPerPixel > 0 {
+			handleError(samplesError)
+		}
+		scene.Integrator = render.WhittedIntegrator{}
+	case "path":
+		scene.Integrator = render.PathTracingIntegrator{SamplesPerPixel: *samplesPerPixel}
+	default:
+		handleError(fmt.Errorf("unknown integrator %q", *integratorName))
+	}
+
+	if *samplerName != "" {
+		scene.Sampler, err = scenefile.NewSampler(*samplerName)
+		handleError(err)
+	}
+
+	if *filterName == "" && *filterRadius != 0 {
+		// Override the radius of the scene's own filter.
+		var ok bool
+		if *filterName, ok = scenefile.FilterName(scene.Filter); !ok {
+			handleError(errors.New("can't override the radius of the scene's filter"))
+		}
+	}
+	if *filterName != "" {
+		scene.Filter, err = scenefile.NewFilter(*filterName, *filterRadius)
+		handleError(err)
+	}
+
+	var toneMapOperator hdr.ToneMapOperator
+	switch *toneMapName {
+	case "linear":
+		if *exposureStops != 0 {
+			handleError(errors.New("exposure can't be adjusted for linear output"))
+		}
+	case "clamp":
+		toneMapOperator = hdr.ClampOperator{}
+	case "reinhard":
+		toneMapOperator = hdr.ReinhardOperator{WhitePoint: *whitePoint}
+	case "aces":
+		toneMapOperator = hdr.AcesFilmicOperator{}
+	default:
+		handleError(fmt.Errorf("unknown tone mapping operator %q", *toneMapName))
+	}
+
+	if *adaptiveThreshold > 0 {
+		scene.AdaptiveSamplingThreshold = *adaptiveThreshold
+	}
+	scene.Seed = *seed
+
+	image, err := scene.RenderHdr(renderType, *width, *height)
 	handleError(err)
 
 	file, err := os.Create(*outputFilename)
 	handleError(err)
 
-	err = png.Encode(file, image)
+	switch outputExtension {
+	case ".png":
+		if toneMapOperator != nil {
+			err = png.Encode(file, image.ToneMap(toneMapOperator, *exposureStops))
+		} else {
+			err = png.Encode(file, image.ToRgba())
+		}
+	case ".hdr":
+		err = hdr.WriteRadianceHdr(file, image)
+	case ".exr":
+		pixelType := hdr.ExrHalf
+		if *exrFloat {
+			pixelType = hdr.ExrFloat
+		}
+		compression := hdr.ExrZipCompression
+		if *exrUncompressed {
+			compression = hdr.ExrNoCompression
+		}
+		err = hdr.WriteExr(file, image, pixelType, compression)
+	}
 	handleError(err)
+	handleError(file.Close())
+
+	if *heatMapFilename != "" {
+		heatMap, err := scene.SampleHeatMap()
+		handleError(err)
+
+		file, err := os.Create(*heatMapFilename)
+		handleError(err)
+
+		err = png.Encode(file, heatMap)
+		handleError(err)
+		handleError(file.Close())
+	}
 }
 
 func handleError(err error) {
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"github.com/patfair/raytracer/geometry"
 	"math"
-	"math/rand"
 )
 
 type Camera struct {
@@ -60,24 +59,20 @@ func NewCamera(viewCenter geometry.Ray, upDirection geometry.Vector, horizontalF
 	}, nil
 }
 
-func (camera *Camera) GetRay(width, height, x, y, depthOfFieldSampleIndex, depthOfFieldSamples, antiAliasIndexX,
-	antiAliasIndexY, antiAliasSamples int) geometry.Ray {
+// Returns the ray leaving the camera through the given position on an image of the given size, measured in pixels from
+// its top left corner, and through the given position on the lens, given as coordinates within the unit square of
+// which the origin maps to the middle of the lens.
+func (camera *Camera) GetRay(width, height int, x, y, lensU, lensV float64) geometry.Ray {
 	pixelSize := 2 * math.Tan(camera.HorizontalFovDeg*math.Pi/180/2) / float64(width)
-	w := (float64(height*antiAliasSamples)/2 - float64(y*antiAliasSamples+antiAliasIndexY+1) + 0.5) *
-		pixelSize / float64(antiAliasSamples)
-	u := (float64(x*antiAliasSamples+antiAliasIndexX) - float64(width*antiAliasSamples)/2 + 0.5) *
-		pixelSize / float64(antiAliasSamples)
+	w := (float64(height)/2 - y) * pixelSize
+	u := (x - float64(width)/2) * pixelSize
 	nominalRayDirection :=
 		camera.UVector.Multiply(u).Add(camera.WVector.Multiply(w)).Add(camera.VVector).ToUnit()
 	focalPlanePoint := camera.Point.Translate(nominalRayDirection.Multiply(camera.FocalDistance))
 
 	// Adjust the center ray to simulate a non-zero aperture, to produce a depth of field effect.
-	apertureRadius := camera.ApertureRadius
-	if depthOfFieldSamples == 1 {
-		apertureRadius = 0
-	}
-	r := apertureRadius * math.Sqrt(rand.Float64())
-	phi := (float64(depthOfFieldSampleIndex) + rand.Float64()) * 2 * math.Pi / float64(depthOfFieldSamples)
+	r := camera.ApertureRadius * math.Sqrt(lensU)
+	phi := 2 * math.Pi * lensV
 	deltaU := r * math.Cos(phi)
 	deltaW := r * math.Sin(phi)
 	modifiedOrigin :=
@@ -14,13 +14,13 @@ func TestNewCameraXY(t *testing.T) {
 	camera, err := NewCamera(viewDirection, upDirection, 90, 0, 1, 1, 1)
 	assert.Nil(t, err)
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{-0.5, 0.5, -1}.ToUnit()},
-		camera.GetRay(2, 2, 0, 0, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 0.5, 0.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{0.5, 0.5, -1}.ToUnit()},
-		camera.GetRay(2, 2, 1, 0, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 1.5, 0.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{-0.5, -0.5, -1}.ToUnit()},
-		camera.GetRay(2, 2, 0, 1, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 0.5, 1.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{0.5, -0.5, -1}.ToUnit()},
-		camera.GetRay(2, 2, 1, 1, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 1.5, 1.5, 0, 0))
 }
 
 func TestNewCameraRotated(t *testing.T) {
@@ -29,13 +29,13 @@ func TestNewCameraRotated(t *testing.T) {
 	camera, err := NewCamera(viewDirection, upDirection, 90, 0, 1, 1, 1)
 	assert.Nil(t, err)
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{1, -0.5, 0.5}.ToUnit()},
-		camera.GetRay(2, 2, 0, 0, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 0.5, 0.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{1, -0.5, -0.5}.ToUnit()},
-		camera.GetRay(2, 2, 1, 0, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 1.5, 0.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{1, 0.5, 0.5}.ToUnit()},
-		camera.GetRay(2, 2, 0, 1, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 0.5, 1.5, 0, 0))
 	geometry.AssertRayEqual(t, geometry.Ray{viewDirection.Origin, geometry.Vector{1, 0.5, -0.5}.ToUnit()},
-		camera.GetRay(2, 2, 1, 1, 0, 1, 0, 0, 1))
+		camera.GetRay(2, 2, 1.5, 1.5, 0, 0))
 }
 
 func TestNewCameraInvalid(t *testing.T) {
@@ -0,0 +1,180 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Weight below which a direction of perfect reflection or refraction is only followed by some of the photons that
+// reach it, in order to limit the number of branches that each photon's path splits into.
+const photonSplitWeight = 0.25
+
+// Traces the given number of photons from the scene's lights through the surfaces that can focus light, and returns a
+// map of where they come to rest after being reflected or refracted at least once. The photons' starting points are
+// spread out using the scene's sampler, and any other random choices are made using the given source of random
+// numbers.
+//
+// Rather than being sent out in every direction, the photons are aimed at points spread evenly over the focusing
+// surfaces, with each light sampled as for direct lighting to find the light arriving there, so that none are wasted
+// on the parts of the scene that the direct lighting already accounts for.
+func traceCausticPhotons(scene *Scene, numPhotons int, random *rand.Rand) *photonMap {
+	var causticSurfaces []surface.SampledSurface
+	for _, sceneSurface := range scene.Surfaces {
+		causticSurfaces = append(causticSurfaces, findSampledSurfaces(sceneSurface,
+			func(sampledSurface surface.SampledSurface) bool { return focusesLight(sampledSurface, random) })...)
+	}
+	areaCdf := make([]float64, len(causticSurfaces)+1)
+	for i, causticSurface := range causticSurfaces {
+		areaCdf[i+1] = areaCdf[i] + causticSurface.Area()
+	}
+	totalArea := areaCdf[len(causticSurfaces)]
+
+	lights := append([]light.Light{}, scene.Lights...)
+	for _, surfaceLight := range scene.surfaceLights {
+		lights = append(lights, surfaceLight)
+	}
+	if totalArea == 0 || len(lights) == 0 {
+		return newPhotonMap(nil)
+	}
+
+	// Share the photons evenly between the lights.
+	photonsPerLight := int(math.Ceil(float64(numPhotons) / float64(len(lights))))
+	sampler := sampling.NewSampler(scene.Sampler, scene.Seed)
+	var photons []photon
+	for lightIndex, sceneLight := range lights {
+		for i := 0; i < photonsPerLight; i++ {
+			sampler.StartSample(lightIndex, 0, i, photonsPerLight)
+			u := sampler.Get1D() * totalArea
+			index := sort.Search(len(causticSurfaces), func(j int) bool { return areaCdf[j+1] > u })
+			if index == len(causticSurfaces) {
+				index--
+			}
+			causticSurface := causticSurfaces[index]
+			point, normal := causticSurface.SamplePoint(sampler.Get2D())
+
+			// Find the light arriving at the point, which must reach it without passing through any other surfaces that
+			// bend it.
+			lightSample := sceneLight.Sample(point, sampler)
+			if lightSample.Intensity == 0 {
+				continue
+			}
+			transmittance := lightTransmittance(scene, point, lightSample, random)
+			direction := lightSample.Direction.ToUnit()
+			cosIn := math.Abs(normal.Dot(direction))
+			power := lightSample.Color.Filter(transmittance).Multiply(lightSample.Intensity * cosIn * totalArea /
+				float64(photonsPerLight))
+			if power.MaxComponent() == 0 {
+				continue
+			}
+			if normal.Dot(direction) > 0 {
+				normal = normal.Multiply(-1)
+			}
+
+			intersection := &geometry.Intersection{Point: point, Normal: normal}
+			tracePhoton(scene, causticSurface, intersection, direction, 1, power, 0, random, &photons)
+		}
+	}
+	return newPhotonMap(photons)
+}
+
+// Follows a photon that has reached the given intersection with the given surface travelling in the given direction
+// through a medium of the given refractive index, adding the photons left behind on surfaces that scatter light other
+// than by perfect reflection and refraction to the given slice. As in the WhittedIntegrator, each of the directions of
+// perfect reflection and refraction given by the same Fresnel calculation is followed in turn, except that those
+// carrying little of the light are randomly dropped using the given source of random numbers, with the photons that
+// survive boosted to compensate.
+func tracePhoton(scene *Scene, hitSurface surface.Surface, intersection *geometry.Intersection,
+	direction geometry.Vector, refractionIndex float64, power shading.Color, depth int, random *rand.Rand,
+	photons *[]photon) {
+	if depth == maxReflectionDepth {
+		return
+	}
+	material, interaction := newInteraction(scene, hitSurface, intersection, direction, refractionIndex, random)
+
+	totalWeight := 0.0
+	for _, sample := range material.SpecularSamples(interaction, direction.Multiply(-1)) {
+		if sample.Pdf > 0 || sample.Weight.MaxComponent() == 0 {
+			// Glossy reflection spreads the light out too much to form caustics.
+			continue
+		}
+		totalWeight += sample.Weight.MaxComponent()
+
+		samplePower := power.Filter(sample.Weight)
+		if survivalProbability := sample.Weight.MaxComponent() / photonSplitWeight; survivalProbability < 1 {
+			if random.Float64() >= survivalProbability {
+				continue
+			}
+			samplePower = samplePower.Multiply(1 / survivalProbability)
+		}
+
+		// Find the next surface in the photon's path, accounting for the light absorbed on the way to it.
+		ray := offsetRay(interaction, sample.Direction)
+		nextIntersection, nextSurface := scene.closestIntersection(ray)
+		if nextIntersection == nil {
+			continue
+		}
+		if medium := segmentMedium(scene, nextSurface, nextIntersection, sample.Direction); medium != nil {
+			samplePower = samplePower.Filter(medium.Transmittance(ray, 0, nextIntersection.Distance))
+		}
+		if absorbingMaterial, ok := nextSurface.Material().(shading.AbsorbingMaterial); ok &&
+			isLeaving(nextSurface, nextIntersection, sample.Direction) {
+			samplePower = samplePower.Filter(absorbingMaterial.InteriorTransmittance(nextIntersection.Distance))
+		}
+		tracePhoton(scene, nextSurface, nextIntersection, sample.Direction, sample.RefractiveIndex, samplePower,
+			depth+1, random, photons)
+	}
+
+	if depth > 0 && totalWeight < 1 {
+		// The surface scatters some of the light in other directions, which the integrator finds using the map.
+		*photons = append(*photons, photon{point: intersection.Point, direction: direction, power: power})
+	}
+}
+
+// Returns the light reflected in the given outgoing direction at the given point by the caustic photons around it,
+// whose power is spread over the disc within the scene's gather radius.
+func causticRadiance(scene *Scene, material shading.Material, interaction shading.Interaction,
+	outgoing geometry.Vector) shading.Color {
+	if scene.causticMap == nil {
+		return shading.Color{}
+	}
+	var radiance shading.Color
+	scene.causticMap.forEachWithin(interaction.Point, scene.CausticGatherRadius, func(photon photon) {
+		incoming := photon.direction.Multiply(-1)
+		if incoming.Dot(interaction.Normal) > 0 {
+			radiance = radiance.Add(material.Bsdf(interaction, outgoing, incoming).Filter(photon.power))
+		}
+	})
+	return radiance.Multiply(1 / (math.Pi * scene.CausticGatherRadius * scene.CausticGatherRadius))
+}
+
+// Returns whether the given surface's material focuses the light passing through or reflecting off of it, by bending
+// it or reflecting it like a mirror, using the given source of random numbers for any random choices made in checking.
+func focusesLight(sampledSurface surface.SampledSurface, random *rand.Rand) bool {
+	material := sampledSurface.Material()
+	if isRefractive(material) {
+		return true
+	}
+	point, normal := sampledSurface.SamplePoint(0.5, 0.5)
+	interaction := shading.Interaction{Point: point, Normal: normal, Surface: sampledSurface, RefractiveIndex: 1,
+		Random: random}
+	for _, sample := range material.SpecularSamples(interaction, normal) {
+		if sample.Pdf == 0 && sample.Weight.MaxComponent() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether the given material bends the light passing through it.
+func isRefractive(material shading.Material) bool {
+	refractiveMaterial, ok := material.(shading.RefractiveMaterial)
+	return ok && refractiveMaterial.IsRefractive()
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPhotonMap(t *testing.T) {
+	photons := make([]photon, 1000)
+	for i := range photons {
+		photons[i].point = geometry.Point{rand.Float64(), 2 * rand.Float64(), 0.5 * rand.Float64()}
+		photons[i].power = shading.Color{float64(i), 0, 0}
+	}
+	photonMap := newPhotonMap(append([]photon{}, photons...))
+	assert.Equal(t, 1000, photonMap.size())
+
+	// The photons found within each distance of a point should be exactly those that a search of all of them finds.
+	for _, radius := range []float64{0, 0.05, 0.2, 1, 5} {
+		point := geometry.Point{rand.Float64(), 2 * rand.Float64(), 0.5 * rand.Float64()}
+		expected := make(map[float64]bool)
+		for _, photon := range photons {
+			if point.VectorTo(photon.point).Norm() <= radius {
+				expected[photon.power.R] = true
+			}
+		}
+		found := make(map[float64]bool)
+		photonMap.forEachWithin(point, radius, func(photon photon) {
+			found[photon.power.R] = true
+		})
+		assert.Equal(t, expected, found, "radius: %v", radius)
+	}
+
+	newPhotonMap(nil).forEachWithin(geometry.Point{}, 1, func(photon photon) {
+		assert.Fail(t, "empty map shouldn't have any photons")
+	})
+}
+
+func TestCausticRadiance(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// A horizontal glass disc above the floor should let through the light that isn't reflected by its surface, and
+	// the light should be found by photons rather than shadow rays.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	disc, err := surface.NewDisc(geometry.Point{0, 0, 1}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0},
+		shading.DielectricMaterial{RefractiveIndex: 1.5})
+	assert.Nil(t, err)
+	scene.AddSurface(disc)
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+	scene.CausticPhotons = 50000
+	scene.CausticGatherRadius = 0.2
+	assert.Nil(t, scene.prepare())
+	assert.True(t, scene.causticMap.size() > 40000)
+
+	transmittance := 1 - math.Pow(0.5/2.5, 2)
+	ray := geometry.Ray{geometry.Point{3, 0, 0.5}, geometry.Vector{-3, 0, -0.5}}
+	radiance := WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+	assert.InEpsilon(t, 2*transmittance/math.Pi, radiance.R, 0.05)
+
+	// Outside of the disc's shadow, the light should be found directly instead.
+	ray = geometry.Ray{geometry.Point{3, 0, 0.5}, geometry.Vector{-1, 0, -0.5}}
+	shading.AssertColorEqual(t, shading.Color{2 / math.Pi, 2 / math.Pi, 2 / math.Pi},
+		WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+
+	// The path tracer finds caustics by itself, so photons shouldn't be traced for it.
+	scene.Integrator = PathTracingIntegrator{}
+	assert.Nil(t, scene.prepare())
+	assert.Nil(t, scene.causticMap)
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"math"
+	"sync"
+)
+
+// Smallest total weight of the samples around a pixel for its filtered value to be used. Filters with negative lobes
+// can make the weights nearly cancel out, in which case the box-filtered value of the pixel's own samples is used
+// instead of dividing by a tiny or negative number.
+const minFilmWeight = 1e-3
+
+// Accumulates the samples taken across an image, from which its pixels are reconstructed by weighting each sample's
+// contribution to the pixels around it using a filter. The contributions of the samples taken for each row of pixels
+// are collected separately and added together in row order as soon as every row before them has arrived, so that the
+// image doesn't depend on the order in which the rows finish, and only the rows that arrive early need to be held.
+type film struct {
+	width        int
+	height       int
+	filter       Filter
+	mutex        sync.Mutex
+	pendingRows  []*filmRow        // Rows that have arrived but not yet been added together, indexed by row
+	nextRow      int               // Index of the first row that hasn't been added together yet
+	weightedSums [][]shading.Color // Sums of the samples' colors multiplied by their weights, indexed by row and column
+	weights      [][]float64       // Sums of the samples' weights, indexed by row and column
+	boxSums      [][]shading.Color // Sums of the colors of the samples lying within each pixel
+	boxCounts    [][]int           // Numbers of samples lying within each pixel
+}
+
+// Holds the sums of the filter-weighted colors of the samples taken for a single row of pixels, and of their weights,
+// for each pixel that lies within the filter's radius of them.
+type filmRow struct {
+	film         *film
+	firstRow     int               // Index of the first row of pixels that the samples can contribute to
+	weightedSums [][]shading.Color // Sums of the samples' colors multiplied by their weights, indexed by row and column
+	weights      [][]float64       // Sums of the samples' weights, indexed by row and column
+	boxSums      []shading.Color   // Sums of the colors of the samples lying within each pixel of the row
+	boxCounts    []int             // Numbers of samples lying within each pixel of the row
+}
+
+// Returns a new film for an image of the given dimensions, reconstructed using the given filter.
+func newFilm(width, height int, filter Filter) *film {
+	film := &film{width: width, height: height, filter: filter, pendingRows: make([]*filmRow, height)}
+	film.weightedSums = make([][]shading.Color, height)
+	film.weights = make([][]float64, height)
+	film.boxSums = make([][]shading.Color, height)
+	film.boxCounts = make([][]int, height)
+	for i := 0; i < height; i++ {
+		film.weightedSums[i] = make([]shading.Color, width)
+		film.weights[i] = make([]float64, width)
+		film.boxSums[i] = make([]shading.Color, width)
+		film.boxCounts[i] = make([]int, width)
+	}
+	return film
+}
+
+// Returns a new empty set of contributions for the samples to be taken for the given row of pixels, to be added to the
+// film once they have all been taken.
+func (film *film) newRow(rowIndex int) *filmRow {
+	// Samples lie within [rowIndex, rowIndex + 1) vertically, so reach the rows whose centers are within the radius.
+	radius := film.filter.SupportRadius()
+	firstRow := int(math.Max(math.Floor(float64(rowIndex)-0.5-radius)+1, 0))
+	lastRow := int(math.Min(math.Ceil(float64(rowIndex)+0.5+radius)-1, float64(film.height-1)))
+	row := &filmRow{
+		film:      film,
+		firstRow:  firstRow,
+		boxSums:   make([]shading.Color, film.width),
+		boxCounts: make([]int, film.width),
+	}
+	for i := firstRow; i <= lastRow; i++ {
+		row.weightedSums = append(row.weightedSums, make([]shading.Color, film.width))
+		row.weights = append(row.weights, make([]float64, film.width))
+	}
+	return row
+}
+
+// Stores the given contributions of the samples taken for the given row of pixels, and adds together those of every
+// row that no longer has any unfinished rows before it, releasing them. Each row must only be added once, but different
+// rows may be added concurrently.
+func (film *film) addRow(rowIndex int, row *filmRow) {
+	film.mutex.Lock()
+	defer film.mutex.Unlock()
+	film.pendingRows[rowIndex] = row
+
+	// Add up the rows' contributions in order, since the result of floating-point addition depends on it.
+	for film.nextRow < film.height && film.pendingRows[film.nextRow] != nil {
+		film.accumulateRow(film.nextRow)
+		film.nextRow++
+	}
+}
+
+// Returns the pixels of the image, each of which is the weighted average of the samples within the filter's radius of
+// its center, or the average of its own samples if their weights nearly cancel out. Negative components, which filters
+// with negative lobes can produce next to sharp edges, are clamped to zero. Any rows still held up by an earlier row
+// that was never added are added in order first, so no more rows may be added afterwards.
+func (film *film) pixels() [][]shading.Color {
+	film.mutex.Lock()
+	defer film.mutex.Unlock()
+	for ; film.nextRow < film.height; film.nextRow++ {
+		if film.pendingRows[film.nextRow] != nil {
+			film.accumulateRow(film.nextRow)
+		}
+	}
+
+	pixels := make([][]shading.Color, film.height)
+	for i := range pixels {
+		pixels[i] = make([]shading.Color, film.width)
+		for j := range pixels[i] {
+			var pixel shading.Color
+			if film.weights[i][j] >= minFilmWeight {
+				pixel = film.weightedSums[i][j].Multiply(1 / film.weights[i][j])
+			} else if film.boxCounts[i][j] > 0 {
+				pixel = film.boxSums[i][j].Multiply(1 / float64(film.boxCounts[i][j]))
+			}
+			pixels[i][j] = shading.Color{math.Max(pixel.R, 0), math.Max(pixel.G, 0), math.Max(pixel.B, 0)}
+		}
+	}
+	return pixels
+}
+
+// Adds the contributions of the given pending row to the image's sums and releases it.
+func (film *film) accumulateRow(rowIndex int) {
+	row := film.pendingRows[rowIndex]
+	for i := range row.weightedSums {
+		for j := range row.weightedSums[i] {
+			film.weightedSums[row.firstRow+i][j] = film.weightedSums[row.firstRow+i][j].Add(row.weightedSums[i][j])
+			film.weights[row.firstRow+i][j] += row.weights[i][j]
+		}
+	}
+	for j := range row.boxSums {
+		film.boxSums[rowIndex][j] = film.boxSums[rowIndex][j].Add(row.boxSums[j])
+		film.boxCounts[rowIndex][j] += row.boxCounts[j]
+	}
+	film.pendingRows[rowIndex] = nil
+}
+
+// Adds the given color of a sample taken at the given position on the image, in pixels from its top left corner, to
+// each pixel whose center lies within the filter's radius of it, weighted by its distance from that center, and to the
+// unweighted sum for the pixel that it lies within.
+func (row *filmRow) addSample(x, y float64, color shading.Color) {
+	film := row.film
+	if column := int(x); column >= 0 && column < film.width {
+		row.boxSums[column] = row.boxSums[column].Add(color)
+		row.boxCounts[column]++
+	}
+
+	radius := film.filter.SupportRadius()
+	firstColumn := int(math.Max(math.Floor(x-0.5-radius)+1, 0))
+	lastColumn := int(math.Min(math.Floor(x-0.5+radius), float64(film.width-1)))
+	firstRow := int(math.Max(math.Floor(y-0.5-radius)+1, float64(row.firstRow)))
+	lastRow := int(math.Min(math.Floor(y-0.5+radius), float64(row.firstRow+len(row.weights)-1)))
+	for i := firstRow; i <= lastRow; i++ {
+		for j := firstColumn; j <= lastColumn; j++ {
+			weight := film.filter.Weight(float64(j)+0.5-x, float64(i)+0.5-y)
+			row.weightedSums[i-row.firstRow][j] = row.weightedSums[i-row.firstRow][j].Add(color.Multiply(weight))
+			row.weights[i-row.firstRow][j] += weight
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestFilm_BoxFilter(t *testing.T) {
+	film := newFilm(3, 2, BoxFilter{})
+	row := film.newRow(0)
+	assert.Equal(t, 0, row.firstRow)
+	assert.Equal(t, 1, len(row.weights))
+
+	// Each pixel should be the average of its own samples only.
+	row.addSample(0.2, 0.3, shading.Color{1, 0, 0})
+	row.addSample(0.9, 0.9, shading.Color{0, 1, 0})
+	row.addSample(1.5, 0, shading.Color{0, 0, 1})
+	film.addRow(0, row)
+	pixels := film.pixels()
+	assert.Equal(t, shading.Color{0.5, 0.5, 0}, pixels[0][0])
+	assert.Equal(t, shading.Color{0, 0, 1}, pixels[0][1])
+	assert.Equal(t, shading.Color{}, pixels[0][2])
+	assert.Equal(t, shading.Color{}, pixels[1][0])
+}
+
+func TestFilm_TentFilter(t *testing.T) {
+	film := newFilm(3, 3, TentFilter{})
+	row := film.newRow(1)
+	assert.Equal(t, 0, row.firstRow)
+	assert.Equal(t, 3, len(row.weights))
+
+	// A sample in the middle of a pixel only contributes to that pixel, while one on the corner between pixels is
+	// shared equally between the four of them.
+	row.addSample(1.5, 1.5, shading.Color{1, 1, 1})
+	row.addSample(1, 1, shading.Color{0, 0, 1})
+	film.addRow(1, row)
+	assert.Equal(t, 1.25, row.weights[1][1])
+	assert.Equal(t, 0.25, row.weights[0][0])
+	assert.Equal(t, 0.0, row.weights[2][2])
+
+	pixels := film.pixels()
+	assert.Equal(t, shading.Color{0, 0, 1}, pixels[0][0])
+	assert.Equal(t, shading.Color{0, 0, 1}, pixels[0][1])
+	assert.Equal(t, shading.Color{0.8, 0.8, 1}, pixels[1][1])
+	assert.Equal(t, shading.Color{}, pixels[2][2])
+}
+
+func TestFilm_RowOrder(t *testing.T) {
+	// Rows added in any order should give exactly the same image.
+	reconstruct := func(order []int) [][]shading.Color {
+		film := newFilm(4, 4, LanczosFilter{})
+		for _, i := range order {
+			row := film.newRow(i)
+			for j := 0; j < 4; j++ {
+				row.addSample(float64(j)+0.3, float64(i)+0.6, shading.Color{0.1 * float64(i+j), 0.3, 1})
+			}
+			film.addRow(i, row)
+		}
+		return film.pixels()
+	}
+	assert.Equal(t, reconstruct([]int{0, 1, 2, 3}), reconstruct([]int{3, 1, 0, 2}))
+
+	// The negative lobes of the filter shouldn't produce negative colors next to a bright sample.
+	film := newFilm(3, 1, LanczosFilter{})
+	row := film.newRow(0)
+	row.addSample(0.5, 0.5, shading.Color{0, 0, 0})
+	row.addSample(1.5, 0.5, shading.Color{0, 0, 0})
+	row.addSample(2.5, 0.5, shading.Color{0, 0, 10})
+	row.addSample(1.6, 0.5, shading.Color{0, 0, 10})
+	film.addRow(0, row)
+	for _, pixel := range film.pixels()[0] {
+		assert.True(t, pixel.B >= 0)
+	}
+}
+
+func TestFilm_StreamsRows(t *testing.T) {
+	film := newFilm(2, 3, TentFilter{})
+	rows := make([]*filmRow, 3)
+	for i := range rows {
+		rows[i] = film.newRow(i)
+		rows[i].addSample(0.5, float64(i)+0.5, shading.Color{1, 1, 1})
+	}
+
+	// A row that arrives early should be held until the rows before it have arrived, and then added and released.
+	film.addRow(1, rows[1])
+	assert.Equal(t, 0, film.nextRow)
+	assert.Equal(t, rows[1], film.pendingRows[1])
+	film.addRow(0, rows[0])
+	assert.Equal(t, 2, film.nextRow)
+	assert.Equal(t, []*filmRow{nil, nil, nil}, film.pendingRows)
+	film.addRow(2, rows[2])
+	assert.Equal(t, 3, film.nextRow)
+	assert.Equal(t, shading.Color{1, 1, 1}, film.pixels()[2][0])
+}
+
+func TestFilm_CancellingWeights(t *testing.T) {
+	// When the negative lobes of a filter cancel out the weights of the samples around a pixel, it should fall back to
+	// the average of its own samples rather than dividing by a sum of weights close to zero.
+	film := newFilm(2, 1, cancellingFilter{})
+	row := film.newRow(0)
+	row.addSample(0.5, 0.5, shading.Color{0.2, 0.4, 0.6})
+	row.addSample(1.5, 0.5, shading.Color{1, 1, 1})
+	film.addRow(0, row)
+	assert.Equal(t, 0.0, film.weights[0][0])
+	pixels := film.pixels()
+	assert.Equal(t, shading.Color{0.2, 0.4, 0.6}, pixels[0][0])
+	assert.Equal(t, shading.Color{1, 1, 1}, pixels[0][1])
+}
+
+// Filter that gives samples within a pixel a weight of one and those in the neighboring pixels a weight of minus one.
+type cancellingFilter struct{}
+
+func (filter cancellingFilter) SupportRadius() float64 {
+	return 1.5
+}
+
+func (filter cancellingFilter) Weight(x, y float64) float64 {
+	if math.Abs(x) < 0.5 && math.Abs(y) < 0.5 {
+		return 1
+	}
+	return -1
+}
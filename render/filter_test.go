@@ -0,0 +1,49 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFilter_SupportRadius(t *testing.T) {
+	assert.Equal(t, 0.5, BoxFilter{}.SupportRadius())
+	assert.Equal(t, 1.0, TentFilter{}.SupportRadius())
+	assert.Equal(t, 1.5, GaussianFilter{}.SupportRadius())
+	assert.Equal(t, 2.0, MitchellFilter{}.SupportRadius())
+	assert.Equal(t, 2.0, LanczosFilter{}.SupportRadius())
+	assert.Equal(t, 3.0, MitchellFilter{Radius: 3}.SupportRadius())
+}
+
+func TestFilter_Weight(t *testing.T) {
+	filters := []Filter{BoxFilter{Radius: 1.5}, TentFilter{Radius: 1.5}, GaussianFilter{Radius: 1.5},
+		MitchellFilter{Radius: 1.5}, LanczosFilter{Radius: 1.5}}
+	for _, filter := range filters {
+		// Every filter should be symmetric, and weight samples at the center of the pixel the most.
+		center := filter.Weight(0, 0)
+		assert.True(t, center > 0)
+		for _, offset := range []float64{0.2, 0.7, 1.2} {
+			assert.InDelta(t, filter.Weight(offset, 0.3), filter.Weight(-offset, -0.3), 1e-9)
+			assert.InDelta(t, filter.Weight(offset, 0.3), filter.Weight(0.3, offset), 1e-9)
+			assert.True(t, filter.Weight(offset, 0) <= center)
+		}
+	}
+
+	assert.Equal(t, 1.0, BoxFilter{}.Weight(0.4, -0.4))
+	assert.Equal(t, 0.25, TentFilter{}.Weight(0.5, -0.5))
+	assert.InDelta(t, 0.0, GaussianFilter{}.Weight(1.5, 0), 1e-9)
+	assert.InDelta(t, 0.0, TentFilter{}.Weight(0, 1), 1e-9)
+
+	// The Mitchell-Netravali filter has a small negative lobe, and falls to zero at its radius.
+	assert.InDelta(t, 8.0/9*8.0/9, MitchellFilter{}.Weight(0, 0), 1e-9)
+	assert.InDelta(t, 8.0/9/18, MitchellFilter{}.Weight(1, 0), 1e-9)
+	assert.True(t, MitchellFilter{}.Weight(1.5, 0) < 0)
+	assert.InDelta(t, 0.0, MitchellFilter{}.Weight(2, 0), 1e-9)
+
+	// The Lanczos filter is zero at whole numbers of pixels from the center, with a negative lobe in between.
+	assert.InDelta(t, 1.0, LanczosFilter{}.Weight(0, 0), 1e-9)
+	assert.InDelta(t, 0.0, LanczosFilter{}.Weight(1, 0), 1e-9)
+	assert.True(t, LanczosFilter{}.Weight(1.5, 0) < 0)
+	assert.InDelta(t, 0.0, LanczosFilter{}.Weight(2, 0), 1e-9)
+}
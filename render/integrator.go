@@ -0,0 +1,259 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"math"
+	"math/rand"
+)
+
+// Represents an algorithm for computing the color of the light arriving at the camera along a given ray.
+type Integrator interface {
+	// Returns the number of samples that should be averaged together for each pixel for the given type of pass. The
+	// number is rounded down to a perfect square so that the samples can be distributed evenly for anti-aliasing.
+	NumSamples(scene *Scene, renderType RenderType) int
+
+	// Returns the color of the light arriving along the given ray from the scene, given the sampler positioned at the
+	// sample being taken for the pixel being rendered and the source of random numbers to make any other random choices
+	// with. Scenes that haven't been rendered can be used, but their emissive surfaces aren't sampled as lights and
+	// no caustic photons are traced for them.
+	Radiance(scene *Scene, ray geometry.Ray, sampler sampling.Sampler, random *rand.Rand) shading.Color
+}
+
+// Returns the fraction of the light of each color in the given sample that reaches the given point, having been
+// filtered by any partially transparent surfaces in between and absorbed or scattered inside any closed ones and media
+// that it passes through. The given source of random numbers is used to dither the colors of the surfaces.
+func lightTransmittance(scene *Scene, point geometry.Point, lightSample light.Sample,
+	random *rand.Rand) shading.Color {
+	// Follow the ray from the point towards the light source through each surface in turn, since the light absorbed
+	// inside a closed surface depends on the distance between the points at which it enters and leaves.
+	direction := lightSample.Direction.Multiply(-1).ToUnit()
+	shadowRay := geometry.Ray{point, direction}
+	transmittance := shading.Color{1, 1, 1}
+	travelled := 0.0 // Distance from the point to the previous surface that the ray passed through
+	for {
+		// Start a minimum distance beyond the previous surface to prevent floating-point imprecision causing a surface
+		// to cast a shadow on itself.
+		lightRay := geometry.Ray{point.Translate(direction.Multiply(travelled + shadowBias)), direction}
+		intersection, closestSurface := scene.closestIntersection(lightRay)
+		distance := math.Inf(1)
+		if intersection != nil {
+			distance = travelled + shadowBias + intersection.Distance
+		}
+		medium := segmentMedium(scene, closestSurface, intersection, direction)
+
+		// Surfaces further away than the light source don't block it, and neither does the light source's own surface.
+		if distance >= lightSample.Distance-shadowBias {
+			// The fog isn't taken to dim lights that are infinitely far away, whose light is as it arrives through it.
+			if medium != nil && (medium != scene.Fog || !math.IsInf(lightSample.Distance, 1)) {
+				transmittance = transmittance.Filter(medium.Transmittance(shadowRay, travelled, lightSample.Distance))
+			}
+			return transmittance
+		}
+		if medium != nil {
+			transmittance = transmittance.Filter(medium.Transmittance(shadowRay, travelled, distance))
+		}
+
+		material := closestSurface.Material()
+		if scene.blocksRefractedLight && isRefractive(material) {
+			// The light is bent away from the shadow ray, and is found by tracing photons instead.
+			return shading.Color{}
+		}
+		if absorbingMaterial, ok := material.(shading.AbsorbingMaterial); ok &&
+			isLeaving(closestSurface, intersection, direction) {
+			// The ray is leaving a closed surface, having travelled through the inside of it from the previous one.
+			transmittance = transmittance.Filter(absorbingMaterial.InteriorTransmittance(distance - travelled))
+		}
+		transmittance = transmittance.Filter(material.Transmittance(shading.Interaction{
+			Point:           intersection.Point,
+			Normal:          intersection.Normal,
+			Surface:         closestSurface,
+			DitherVariation: scene.DitherVariation,
+			Random:          random,
+		}))
+
+		// Stop looking once the light is fully blocked.
+		if transmittance.MaxComponent() == 0 {
+			return transmittance
+		}
+		travelled = distance
+	}
+}
+
+// Returns true if a ray travelling in the given direction is leaving the inside of the given surface at the given
+// intersection with it, which is only possible if the surface is closed.
+func isLeaving(closestSurface surface.Surface, intersection *geometry.Intersection, direction geometry.Vector) bool {
+	closedSurface, ok := closestSurface.(surface.ClosedSurface)
+	return ok && closedSurface.IsClosed() && intersection.Normal.Dot(direction) > 0
+}
+
+// Returns the material of the given surface along with the details of the given intersection with it that are needed
+// to shade it, for a ray travelling in the given direction through a medium of the given refractive index. The normal
+// is the one that the material shades the point with, on the side of the surface that the ray arrived at, and the
+// given source of random numbers is used for any random choices made in shading it.
+func newInteraction(scene *Scene, surface surface.Surface, intersection *geometry.Intersection,
+	direction geometry.Vector, refractiveIndex float64, random *rand.Rand) (shading.Material, shading.Interaction) {
+	material := surface.Material()
+	interaction := shading.Interaction{
+		Point:           intersection.Point,
+		Normal:          intersection.Normal,
+		Surface:         surface,
+		RefractiveIndex: refractiveIndex,
+		DitherVariation: scene.DitherVariation,
+		Random:          random,
+	}
+	if bumpMappedMaterial, ok := material.(shading.BumpMappedMaterial); ok {
+		interaction.Normal = bumpMappedMaterial.ShadingNormal(interaction)
+	}
+	if interaction.Normal.Dot(direction) > 0 {
+		interaction.Normal = interaction.Normal.Multiply(-1)
+	}
+	return material, interaction
+}
+
+// Represents the way in which an integrator follows the directions chosen by materials, which can find area lights and
+// the environment as well as sampling them directly.
+type bsdfSampling int
+
+const (
+	specularSampling bsdfSampling = iota // Only the directions given by Material.SpecularSamples are followed
+	fullSampling                         // Directions chosen by Material.SampleBsdf are followed
+)
+
+// Returns the light arriving directly from the scene's lights at the given point that is scattered by the given
+// material in the given outgoing direction. The light from lights that can also be found by following directions chosen
+// by the material is weighted to account for both ways of finding it, using multiple importance sampling. The point on
+// each light is chosen using the next pair of dimensions from the given sampler.
+func directLighting(scene *Scene, material shading.Material, interaction shading.Interaction,
+	outgoing geometry.Vector, sampler sampling.Sampler, mode bsdfSampling) shading.Color {
+	highlightedMaterial, hasHighlights := material.(shading.HighlightedMaterial)
+	var color shading.Color
+	addLight := func(sceneLight light.Light) {
+		lightSample := sceneLight.Sample(interaction.Point, sampler)
+		if lightSample.Intensity == 0 {
+			return
+		}
+		transmittance := lightTransmittance(scene, interaction.Point, lightSample, interaction.Random)
+		if transmittance.MaxComponent() == 0 {
+			// The light is not reaching the point at all; skip calculating its contribution since it will just be
+			// black.
+			return
+		}
+
+		incoming := lightSample.Direction.Multiply(-1).ToUnit()
+		if cosIn := incoming.Dot(interaction.Normal); cosIn > 0 {
+			bsdf := material.Bsdf(interaction, outgoing, incoming)
+			if lightSample.Pdf > 0 {
+				if mode == fullSampling {
+					bsdf = bsdf.Multiply(powerHeuristic(lightSample.Pdf, material.Pdf(interaction, outgoing, incoming)))
+				} else if glossyMaterial, ok := material.(shading.GlossyMaterial); ok {
+					specularBsdf, specularPdf := glossyMaterial.SpecularBsdf(interaction, outgoing, incoming)
+					bsdf = bsdf.Add(specularBsdf.Multiply(powerHeuristic(lightSample.Pdf, specularPdf) - 1))
+				}
+			}
+			incidentLight := lightSample.Color.Filter(transmittance).Multiply(lightSample.Intensity * cosIn)
+			color = color.Add(bsdf.Filter(incidentLight))
+		}
+
+		// Highlights are only shown for lights that come from somewhere in particular. The Whitted integrator shows
+		// them at full brightness for lights that are only partly blocked, as it always has.
+		if _, isInfinite := sceneLight.(light.InfiniteLight); hasHighlights && !isInfinite {
+			highlightColor := lightSample.Color
+			if mode == fullSampling {
+				highlightColor = highlightColor.Filter(transmittance)
+			}
+			color = color.Add(highlightColor.Multiply(highlightedMaterial.Highlight(interaction, outgoing, incoming)))
+		}
+	}
+	for _, sceneLight := range scene.Lights {
+		addLight(sceneLight)
+	}
+	for _, surfaceLight := range scene.surfaceLights {
+		addLight(surfaceLight)
+	}
+	return color
+}
+
+// Returns a ray leaving the given point in the given direction, with its origin moved slightly off the surface to
+// the side that it leaves from in order to avoid immediate self-intersection.
+func offsetRay(interaction shading.Interaction, direction geometry.Vector) geometry.Ray {
+	bias := reflectionBias
+	if direction.Dot(interaction.Normal) < 0 {
+		bias = -reflectionBias
+	}
+	return geometry.Ray{interaction.Point.Translate(interaction.Normal.Multiply(bias)), direction}
+}
+
+// Returns the closest of the scene's area lights that the given ray hits, along with the distance to it, or nil if it
+// doesn't hit any.
+func closestAreaLight(scene *Scene, ray geometry.Ray) (light.AreaLight, float64) {
+	var closestLight light.AreaLight
+	closestDistance := math.Inf(1)
+	for _, sceneLight := range scene.Lights {
+		if areaLight, ok := sceneLight.(light.AreaLight); ok {
+			if distance, ok := areaLight.Intersect(ray); ok && distance < closestDistance {
+				closestLight, closestDistance = areaLight, distance
+			}
+		}
+	}
+	return closestLight, closestDistance
+}
+
+// Returns the light arriving from the given direction from outside the scene, which is that of the scene's infinite
+// light if it has one and otherwise the scene's background color, along with the light if there is one.
+func escapedRadiance(scene *Scene, direction geometry.Vector) (shading.Color, light.InfiniteLight) {
+	for _, sceneLight := range scene.Lights {
+		if infiniteLight, ok := sceneLight.(light.InfiniteLight); ok {
+			return infiniteLight.Radiance(direction), infiniteLight
+		}
+	}
+	return scene.BackgroundColor, nil
+}
+
+// Returns the light emitted by the given material from the given point back along the given ray, which hit it at the
+// given distance. If the ray's direction was chosen at random with the given probability density, the light is weighted
+// to account for the surface also being sampled as a light.
+func emission(scene *Scene, material shading.Material, interaction shading.Interaction, ray geometry.Ray, distance,
+	samplePdf float64) shading.Color {
+	emissiveMaterial, ok := material.(shading.EmissiveMaterial)
+	if !ok {
+		return shading.Color{}
+	}
+	emitted := emissiveMaterial.Emission(interaction, ray.Direction.Multiply(-1).ToUnit())
+	if samplePdf == 0 {
+		return emitted
+	}
+	lightPdf := 0.0
+	for _, surfaceLight := range scene.surfaceLights {
+		if lightDistance, ok := surfaceLight.Intersect(ray); ok && math.Abs(lightDistance-distance) < shadowBias {
+			lightPdf = surfaceLight.Pdf(ray)
+			break
+		}
+	}
+	return emitted.Multiply(powerHeuristic(samplePdf, lightPdf))
+}
+
+// Returns the light seen by a ray that hits the given area light.
+func areaLightRadiance(areaLight light.AreaLight) shading.Color {
+	return areaLight.Color().Multiply(areaLight.Radiance())
+}
+
+// Returns the weight given to light from the given area light or infinite light found by following a direction chosen
+// with the given probability density, or one if the direction wasn't chosen at random.
+func bsdfSampleWeight(samplePdf, lightPdf float64) float64 {
+	if samplePdf == 0 {
+		return 1
+	}
+	return powerHeuristic(samplePdf, lightPdf)
+}
+
+// Returns the weight given to a sample drawn from a strategy with the given probability density, when the sample could
+// also have been drawn from another strategy with the other given density, using Veach's power heuristic.
+func powerHeuristic(pdf, otherPdf float64) float64 {
+	return pdf * pdf / (pdf*pdf + otherPdf*otherPdf)
+}
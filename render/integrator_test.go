@@ -0,0 +1,56 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLightTransmittance(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// Set up a partially transparent pane above the origin, and above that a clear sphere that absorbs some colors of
+	// light more than others.
+	pane, err := surface.NewPlane(geometry.Point{-1, -1, 1}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 0.5, RefractiveIndex: 1})
+	assert.Nil(t, err)
+	sphere, err := surface.NewSphere(geometry.Point{0, 0, 3}, 0.5, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.DielectricMaterial{RefractiveIndex: 1.5, AbsorptionColor: shading.Color{1, 0.5, 0.25},
+			AbsorptionDistance: 1})
+	assert.Nil(t, err)
+	scene := Scene{}
+	scene.AddSurface(pane)
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+
+	// Light from between the pane and the sphere is only dimmed by the pane.
+	lightSample := light.Sample{Direction: geometry.Vector{0, 0, -1}, Distance: 2}
+	transmittance := lightTransmittance(&scene, geometry.Point{}, lightSample, random)
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.5, 0.5}, transmittance, 1e-9)
+
+	// Light from beyond the sphere is also filtered according to the distance it travels through the sphere.
+	lightSample.Distance = math.Inf(1)
+	transmittance = lightTransmittance(&scene, geometry.Point{}, lightSample, random)
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.25, 0.125}, transmittance, 1e-9)
+	lightSample.Direction = geometry.Vector{0, -0.1, -1}.ToUnit()
+	chord := 2 * math.Sqrt(0.25-math.Pow(3*math.Sin(math.Atan(0.1)), 2))
+	transmittance = lightTransmittance(&scene, geometry.Point{}, lightSample, random)
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.5 * math.Pow(0.5, chord), 0.5 * math.Pow(0.25, chord)},
+		transmittance, 1e-9)
+
+	// An opaque surface blocks the light entirely.
+	lightSample.Direction = geometry.Vector{0, 0, 1}
+	scene = Scene{}
+	floor, err := surface.NewPlane(geometry.Point{-1, -1, -1}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(floor)
+	assert.Nil(t, scene.prepare())
+	assert.Equal(t, shading.Color{}, lightTransmittance(&scene, geometry.Point{}, lightSample, random))
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"math/rand"
+)
+
+// Returns the medium that a ray travelling in the given direction passes through before reaching the given
+// intersection with the given surface, which is the one filling the surface if the ray is leaving it there and
+// otherwise the scene's fog. Returns nil if the ray passes through empty space.
+func segmentMedium(scene *Scene, closestSurface surface.Surface, intersection *geometry.Intersection,
+	direction geometry.Vector) *shading.Medium {
+	if intersection != nil && isLeaving(closestSurface, intersection, direction) {
+		if volumetricMaterial, ok := closestSurface.Material().(shading.VolumetricMaterial); ok {
+			return volumetricMaterial.InteriorMedium()
+		}
+	}
+	return scene.Fog
+}
+
+// Returns the light scattered towards the origin of the given ray by the given medium (if any) from the scene's lights
+// before the ray has travelled the given distance, along with the fraction of the light from beyond that distance
+// that reaches the origin. Only light scattered once on its way from the lights is included (single scattering), at a
+// point chosen using the given sampler. The given source of random numbers is used for any other random choices.
+func mediumRadiance(scene *Scene, medium *shading.Medium, ray geometry.Ray, distance float64,
+	sampler sampling.Sampler, random *rand.Rand) (shading.Color, shading.Color) {
+	if medium == nil {
+		return shading.Color{}, shading.Color{1, 1, 1}
+	}
+	transmittance := medium.Transmittance(ray, 0, distance)
+	scatterDistance, weight := medium.SampleScattering(ray, 0, distance, sampler.Get1D())
+	if weight.MaxComponent() == 0 {
+		return shading.Color{}, transmittance
+	}
+	weight = weight.Filter(medium.Transmittance(ray, 0, scatterDistance))
+
+	// Gather the light arriving directly from the scene's lights at the chosen point, as at a surface but with the
+	// phase function in place of the BSDF.
+	direction := ray.Direction.ToUnit()
+	point := ray.Origin.Translate(direction.Multiply(scatterDistance))
+	var scattered shading.Color
+	addLight := func(sceneLight light.Light) {
+		lightSample := sceneLight.Sample(point, sampler)
+		if lightSample.Intensity == 0 {
+			return
+		}
+		shadowTransmittance := lightTransmittance(scene, point, lightSample, random)
+		if shadowTransmittance.MaxComponent() == 0 {
+			return
+		}
+		phase := medium.Phase(lightSample.Direction.ToUnit().Dot(direction.Multiply(-1)))
+		scattered = scattered.Add(lightSample.Color.Filter(shadowTransmittance).Multiply(lightSample.Intensity * phase))
+	}
+	for _, sceneLight := range scene.Lights {
+		addLight(sceneLight)
+	}
+	for _, surfaceLight := range scene.surfaceLights {
+		addLight(surfaceLight)
+	}
+	return scattered.Filter(weight), transmittance
+}
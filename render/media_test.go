@@ -0,0 +1,77 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSegmentMedium(t *testing.T) {
+	fog := &shading.Medium{Scattering: shading.Color{0.1, 0.1, 0.1}}
+	smoke := &shading.Medium{Scattering: shading.Color{1, 1, 1}}
+	sphere, err := surface.NewSphere(geometry.Point{0, 0, 0}, 1, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.MediumBoundary{Medium: smoke})
+	assert.Nil(t, err)
+	scene := Scene{Fog: fog}
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+
+	// A ray travels through the fog on its way into the sphere and through the smoke on its way out of it.
+	ray := geometry.Ray{geometry.Point{0, 0, 2}, geometry.Vector{0, 0, -1}}
+	intersection, closestSurface := scene.surfaceHierarchy.ClosestIntersection(ray)
+	assert.Same(t, fog, segmentMedium(&scene, closestSurface, intersection, ray.Direction))
+	ray = geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{0, 0, -1}}
+	intersection, closestSurface = scene.surfaceHierarchy.ClosestIntersection(ray)
+	assert.Same(t, smoke, segmentMedium(&scene, closestSurface, intersection, ray.Direction))
+	assert.Same(t, fog, segmentMedium(&scene, nil, nil, ray.Direction))
+
+	// Other surfaces are filled with the fog.
+	sphere, err = surface.NewSphere(geometry.Point{0, 0, 0}, 1, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.DielectricMaterial{RefractiveIndex: 1.5})
+	assert.Nil(t, err)
+	scene = Scene{Fog: fog}
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+	intersection, closestSurface = scene.surfaceHierarchy.ClosestIntersection(ray)
+	assert.Same(t, fog, segmentMedium(&scene, closestSurface, intersection, ray.Direction))
+}
+
+func TestMediumRadiance(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	scene := Scene{}
+	assert.Nil(t, scene.prepare())
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, 1}}
+
+	// Without a medium, all of the light passes through and none is scattered.
+	scattered, transmittance := mediumRadiance(&scene, nil, ray, 2, sampling.NewFixedSampler(0, 1), random)
+	assert.Equal(t, shading.Color{}, scattered)
+	assert.Equal(t, shading.Color{1, 1, 1}, transmittance)
+
+	// Looking into endless fog, whatever is beyond it is hidden and the light of the distant light (which the fog
+	// doesn't dim) is scattered evenly in every direction.
+	fog := &shading.Medium{Absorption: shading.Color{0.1, 0.2, 0.3}, Scattering: shading.Color{0.3, 0.2, 0.1}}
+	scene.Fog = fog
+	for i := 0; i < 10; i++ {
+		scattered, transmittance = mediumRadiance(&scene, fog, ray, math.Inf(1), sampling.NewFixedSampler(i, 10),
+			random)
+		shading.AssertColorEqual(t, shading.Color{0.75, 0.5, 0.25}.Multiply(2/(4*math.Pi)), scattered, 1e-9)
+		assert.Equal(t, shading.Color{}, transmittance)
+	}
+
+	// Without any scattering, the fog just dims whatever is beyond it.
+	fog.Scattering = shading.Color{}
+	scattered, transmittance = mediumRadiance(&scene, fog, ray, 2, sampling.NewFixedSampler(0, 1), random)
+	assert.Equal(t, shading.Color{}, scattered)
+	shading.AssertColorEqual(t, shading.Color{math.Exp(-0.2), math.Exp(-0.4), math.Exp(-0.6)}, transmittance, 1e-9)
+}
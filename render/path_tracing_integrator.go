@@ -0,0 +1,124 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultPathTracingSamples  = 64   // Number of paths per pixel if not specified
+	russianRouletteDepth       = 3    // Number of bounces after which paths may be randomly terminated
+	maxRussianRouletteSurvival = 0.95 // Upper limit on the probability of a path surviving Russian roulette
+)
+
+// Renders the scene using unbiased Monte Carlo path tracing, which follows each camera ray as it bounces randomly
+// around the scene in order to capture indirect illumination such as color bleeding and caustics. At each bounce, the
+// light arriving directly from each of the scene's lights is added (next-event estimation) and the path continues in a
+// direction chosen at random by the surface's material. The scene's environment (or its background color, if it has
+// none) is treated as light arriving from every direction.
+//
+// Since each path is noisy, many more samples per pixel are needed than with the WhittedIntegrator to produce a clean
+// image.
+type PathTracingIntegrator struct {
+	SamplesPerPixel int // Number of paths to trace for each pixel in a finish pass; a default is used if zero
+	MaxDepth        int // Maximum number of bounces in each path; a default is used if zero
+}
+
+// Returns a single sample for a draft pass, or the configured number of samples per pixel otherwise.
+func (integrator PathTracingIntegrator) NumSamples(scene *Scene, renderType RenderType) int {
+	if renderType == RenderDraftPass {
+		return 1
+	}
+	if integrator.SamplesPerPixel <= 0 {
+		return defaultPathTracingSamples
+	}
+	return integrator.SamplesPerPixel
+}
+
+func (integrator PathTracingIntegrator) Radiance(scene *Scene, ray geometry.Ray, sampler sampling.Sampler,
+	random *rand.Rand) shading.Color {
+	maxDepth := integrator.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = maxReflectionDepth
+	}
+
+	var radiance shading.Color
+	throughput := shading.Color{1, 1, 1} // Fraction of the light arriving at the current vertex that reaches the camera
+	refractionIndex := 1.0
+	previousPdf := 0.0 // Probability density of the current ray's direction, or zero if it wasn't chosen at random
+	for depth := 0; depth < maxDepth; depth++ {
+		ray.Direction = ray.Direction.ToUnit()
+		intersection, closestSurface := scene.closestIntersection(ray)
+		areaLight, distance := closestAreaLight(scene, ray)
+		if intersection != nil && intersection.Distance <= distance {
+			areaLight, distance = nil, intersection.Distance
+		}
+
+		// Add the light scattered towards the path by the medium it passes through, which also dims whatever is
+		// beyond.
+		medium := segmentMedium(scene, closestSurface, intersection, ray.Direction)
+		scattered, transmittance := mediumRadiance(scene, medium, ray, distance, sampler, random)
+		radiance = radiance.Add(throughput.Filter(scattered))
+		throughput = throughput.Filter(transmittance)
+
+		if areaLight != nil {
+			// If the direction was chosen at random, the light could also have been found by next-event estimation at
+			// the previous bounce, so the two are weighted according to how likely each was to find it.
+			weight := bsdfSampleWeight(previousPdf, areaLight.Pdf(ray))
+			radiance = radiance.Add(throughput.Filter(areaLightRadiance(areaLight)).Multiply(weight))
+			break
+		}
+		if intersection == nil {
+			// Light from outside the scene is weighted in the same way if it can also be found by sampling.
+			background, infiniteLight := escapedRadiance(scene, ray.Direction)
+			weight := 1.0
+			if infiniteLight != nil {
+				weight = bsdfSampleWeight(previousPdf, infiniteLight.Pdf(ray.Direction))
+			}
+			radiance = radiance.Add(throughput.Filter(background).Multiply(weight))
+			break
+		}
+
+		material, interaction := newInteraction(scene, closestSurface, intersection, ray.Direction, refractionIndex,
+			random)
+		outgoing := ray.Direction.Multiply(-1)
+		if absorbingMaterial, ok := material.(shading.AbsorbingMaterial); ok &&
+			isLeaving(closestSurface, intersection, ray.Direction) {
+			// The path is leaving a closed surface, some of whose light has been absorbed on the way through it.
+			throughput = throughput.Filter(absorbingMaterial.InteriorTransmittance(intersection.Distance))
+		}
+
+		// Add the light emitted by the surface, and that arriving directly from the scene's lights (next-event
+		// estimation), which the sampled directions below are unlikely to find.
+		radiance = radiance.Add(throughput.Filter(emission(scene, material, interaction, ray, intersection.Distance,
+			previousPdf)))
+		radiance = radiance.Add(throughput.Filter(directLighting(scene, material, interaction, outgoing, sampler,
+			fullSampling)))
+
+		// Continue the path in a direction chosen by the material.
+		sample, ok := material.SampleBsdf(interaction, outgoing)
+		if !ok {
+			break
+		}
+		ray = offsetRay(interaction, sample.Direction)
+		refractionIndex = sample.RefractiveIndex
+		previousPdf = sample.Pdf
+		throughput = throughput.Filter(sample.Weight)
+
+		// Randomly terminate paths that are unlikely to contribute much, boosting the ones that survive to compensate.
+		if depth >= russianRouletteDepth {
+			survivalProbability := math.Min(throughput.MaxComponent(), maxRussianRouletteSurvival)
+			if random.Float64() >= survivalProbability {
+				break
+			}
+			throughput = throughput.Multiply(1 / survivalProbability)
+		}
+	}
+
+	return radiance
+}
@@ -0,0 +1,413 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPathTracingIntegrator_NumSamples(t *testing.T) {
+	scene := Scene{}
+	assert.Equal(t, 1, PathTracingIntegrator{SamplesPerPixel: 100}.NumSamples(&scene, RenderDraftPass))
+	assert.Equal(t, 100, PathTracingIntegrator{SamplesPerPixel: 100}.NumSamples(&scene, RenderFinishPass))
+	assert.Equal(t, defaultPathTracingSamples, PathTracingIntegrator{}.NumSamples(&scene, RenderFinishPass))
+}
+
+func TestPathTracingIntegrator_DirectLighting(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// With a black background and nothing else for light to bounce off of, only the direct lighting is seen.
+	scene := newPathTracingTestScene(t, shading.Color{0, 0, 0}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{0.5, 0.25, 1}},
+		Opacity:        1,
+	})
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}}
+	radiance := PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{1 / math.Pi, 0.5 / math.Pi, 2 / math.Pi}, radiance, 1e-9)
+
+	// The Whitted integrator should agree, since there is no indirect lighting.
+	radiance = WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{1 / math.Pi, 0.5 / math.Pi, 2 / math.Pi}, radiance, 1e-9)
+}
+
+func TestPathTracingIntegrator_BackgroundIllumination(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// Every bounced ray escapes to the uniformly colored background, so a diffuse surface reflects the background color
+	// filtered by its albedo.
+	scene := newPathTracingTestScene(t, shading.Color{1, 0.5, 1}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{0.5, 0.5, 0.2}},
+		Opacity:        1,
+	})
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}}
+	radiance := PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.25, 0.2}, radiance, 1e-9)
+
+	// A perfect mirror reflects the background.
+	scene = newPathTracingTestScene(t, shading.Color{1, 0.5, 1}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{0.5, 0.5, 0.2}},
+		Opacity:        1,
+		Reflectivity:   1,
+	})
+	ray = geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{1, 0, -1}}
+	radiance = PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{1, 0.5, 1}, radiance, 1e-9)
+}
+
+func TestPathTracingIntegrator_AreaLight(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// An area light should be seen directly and in perfect reflections by both integrators.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+		Reflectivity:   1,
+	})
+	sphereLight, err := light.NewSphereLight(geometry.Point{-2, 0, 2}, 0.5, shading.Color{1, 0.5, 0.25}, 100)
+	assert.Nil(t, err)
+	scene.AddLight(sphereLight)
+	lightRadiance := shading.Color{1, 0.5, 0.25}.Multiply(sphereLight.Radiance())
+
+	for _, ray := range []geometry.Ray{
+		{geometry.Point{-2, 0, 1}, geometry.Vector{0, 0, 1}}, {geometry.Point{2, 0, 2}, geometry.Vector{-1, 0, -1}},
+	} {
+		shading.AssertColorEqual(t, lightRadiance,
+			PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+		shading.AssertColorEqual(t, lightRadiance,
+			WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	}
+}
+
+func TestPathTracingIntegrator_EmissiveSurface(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// An emissive sphere illuminates a diffuse surface as a sphere light of the same radiance would, whether the
+	// integrator finds it by sampling it as a light, by following the scattered direction, or both.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	emissionStrength := 100 / (4 * math.Pi * math.Pi * 0.25)
+	sphere, err := surface.NewSphere(geometry.Point{0, 0, 2}, 0.5, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.DiffuseEmitter{Color: shading.Color{1, 0.5, 0.25}, Strength: emissionStrength})
+	assert.Nil(t, err)
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+	assert.Equal(t, 1, len(scene.surfaceLights))
+	expectedRadiance := shading.Color{1, 0.5, 0.25}.Multiply(100 / (16 * math.Pi) / math.Pi)
+
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}}
+	for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+		var averageRadiance shading.Color
+		numSamples := 4000
+		for i := 0; i < numSamples; i++ {
+			averageRadiance = averageRadiance.Add(integrator.Radiance(scene, ray,
+				sampling.NewFixedSampler(i, numSamples), random))
+		}
+		averageRadiance = averageRadiance.Multiply(1 / float64(numSamples))
+		assert.InEpsilon(t, expectedRadiance.R, averageRadiance.R, 0.03, "integrator: %T", integrator)
+		assert.InEpsilon(t, expectedRadiance.B, averageRadiance.B, 0.03, "integrator: %T", integrator)
+	}
+
+	// The surface should be seen to glow directly.
+	emission := shading.Color{1, 0.5, 0.25}.Multiply(emissionStrength)
+	ray = geometry.Ray{geometry.Point{0, 0, 0.5}, geometry.Vector{0, 0, 1}}
+	shading.AssertColorEqual(t, emission,
+		PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	shading.AssertColorEqual(t, emission,
+		WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+}
+
+func TestPathTracingIntegrator_Absorption(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// A clear sphere that absorbs some colors of light more than others should cast a tinted shadow, and tint what is
+	// seen through it, according to the distance that the light travels through it.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	sphere, err := surface.NewSphere(geometry.Point{0, 0, 2}, 0.5, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.DielectricMaterial{RefractiveIndex: 1, AbsorptionColor: shading.Color{1, 0.5, 0.25},
+			AbsorptionDistance: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+
+	for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+		ray := geometry.Ray{geometry.Point{1, 0, 1}, geometry.Vector{-1, 0, -1}}
+		radiance := integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+		shading.AssertColorEqual(t, shading.Color{1, 0.5, 0.25}.Multiply(1/math.Pi), radiance, 1e-9)
+
+		// The offsets that keep rays from hitting the surfaces they leave from shorten the distances very slightly.
+		ray = geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}
+		radiance = integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+		assert.InEpsilon(t, 1/math.Pi, radiance.R, 1e-3, "integrator: %T", integrator)
+		assert.InEpsilon(t, 0.25/math.Pi, radiance.G, 1e-3, "integrator: %T", integrator)
+		assert.InEpsilon(t, 0.0625/math.Pi, radiance.B, 2e-3, "integrator: %T", integrator)
+	}
+}
+
+func TestPathTracingIntegrator_AbsorptionClosedSurfaces(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// Boxes and closed meshes are made of two-sided surfaces, but should absorb light passing through their insides in
+	// the same way as spheres, whereas open surfaces have no inside to absorb light in.
+	material := shading.DielectricMaterial{RefractiveIndex: 1, AbsorptionColor: shading.Color{1, 0.5, 0.25},
+		AbsorptionDistance: 1}
+	box, err := surface.NewBox(geometry.Point{-0.5, -0.5, 2.5}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, -1,
+		material)
+	assert.Nil(t, err)
+	mesh := newTestCubeMesh(t, geometry.Point{-0.5, -0.5, 1.5}, material)
+	plane1, err := surface.NewPlane(geometry.Point{-0.5, -0.5, 2.5}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0},
+		material)
+	assert.Nil(t, err)
+	plane2, err := surface.NewPlane(geometry.Point{-0.5, -0.5, 1.5}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0},
+		material)
+	assert.Nil(t, err)
+
+	for _, testCase := range []struct {
+		surfaces []surface.Surface
+		absorbs  bool
+	}{
+		{[]surface.Surface{box[0], box[1], box[2], box[3], box[4], box[5]}, true},
+		{[]surface.Surface{mesh}, true},
+		{[]surface.Surface{plane1, plane2}, false},
+	} {
+		scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+			DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+			Opacity:        1,
+		})
+		for _, surface := range testCase.surfaces {
+			scene.AddSurface(surface)
+		}
+		assert.Nil(t, scene.prepare())
+		distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0)
+		assert.Nil(t, err)
+		scene.AddLight(distantLight)
+
+		for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+			ray := geometry.Ray{geometry.Point{0.1, 0.2, 3}, geometry.Vector{0, 0, -1}}
+			radiance := integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+			expected := shading.Color{1, 1, 1}
+			if testCase.absorbs {
+				expected = shading.Color{1, 0.25, 0.0625}
+			}
+			assert.InEpsilon(t, expected.R/math.Pi, radiance.R, 1e-3, "integrator: %T", integrator)
+			assert.InEpsilon(t, expected.G/math.Pi, radiance.G, 1e-3, "integrator: %T", integrator)
+			assert.InEpsilon(t, expected.B/math.Pi, radiance.B, 2e-3, "integrator: %T", integrator)
+		}
+	}
+}
+
+func TestPathTracingIntegrator_Volume(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// A smoke-filled sphere with an invisible boundary should dim both the light passing through it to the floor and
+	// the floor seen through it.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	sphere, err := surface.NewSphere(geometry.Point{0, 0, 2}, 0.5, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.MediumBoundary{Medium: &shading.Medium{Absorption: shading.Color{0.5, 0.5, 0.5}}})
+	assert.Nil(t, err)
+	scene.AddSurface(sphere)
+	assert.Nil(t, scene.prepare())
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+
+	for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+		ray := geometry.Ray{geometry.Point{1, 0, 1}, geometry.Vector{-1, 0, -1}}
+		radiance := integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+		assert.InEpsilon(t, 2/math.Pi*math.Exp(-0.5), radiance.R, 1e-3, "integrator: %T", integrator)
+		ray = geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}
+		radiance = integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+		assert.InEpsilon(t, 2/math.Pi*math.Exp(-1), radiance.R, 1e-3, "integrator: %T", integrator)
+
+		// The floor beside the sphere should be unaffected.
+		ray = geometry.Ray{geometry.Point{2, 0, 1}, geometry.Vector{0, 0, -1}}
+		shading.AssertColorEqual(t, shading.Color{2, 2, 2}.Multiply(1/math.Pi),
+			integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	}
+}
+
+func TestPathTracingIntegrator_VolumeInBox(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// A box made of separate planes is closed, so it should enclose its medium in the same way as a sphere.
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	box, err := surface.NewBox(geometry.Point{-0.5, -0.5, 2.5}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, -1,
+		shading.MediumBoundary{Medium: &shading.Medium{Absorption: shading.Color{0.5, 0.5, 0.5}}})
+	assert.Nil(t, err)
+	for _, plane := range box {
+		assert.True(t, plane.IsClosed())
+		scene.AddSurface(plane)
+	}
+	assert.Nil(t, scene.prepare())
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+
+	for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+		ray := geometry.Ray{geometry.Point{0.1, 0.2, 3}, geometry.Vector{0, 0, -1}}
+		radiance := integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random)
+		assert.InEpsilon(t, 2/math.Pi*math.Exp(-1), radiance.R, 1e-3, "integrator: %T", integrator)
+
+		// The floor beside the box should be unaffected.
+		ray = geometry.Ray{geometry.Point{2, 0, 1}, geometry.Vector{0, 0, -1}}
+		shading.AssertColorEqual(t, shading.Color{2, 2, 2}.Multiply(1/math.Pi),
+			integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	}
+
+	// A lone plane encloses nothing, so rays passing through it from behind its normal shouldn't enter a medium.
+	scene = newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	plane, err := surface.NewPlane(geometry.Point{-0.5, -0.5, 2}, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.MediumBoundary{Medium: &shading.Medium{Absorption: shading.Color{0.5, 0.5, 0.5}}})
+	assert.Nil(t, err)
+	assert.False(t, plane.IsClosed())
+	scene.AddSurface(plane)
+	assert.Nil(t, scene.prepare())
+	scene.AddLight(distantLight)
+
+	for _, integrator := range []Integrator{PathTracingIntegrator{}, WhittedIntegrator{}} {
+		ray := geometry.Ray{geometry.Point{0.1, 0.2, 3}, geometry.Vector{0, 0, -1}}
+		shading.AssertColorEqual(t, shading.Color{2, 2, 2}.Multiply(1/math.Pi),
+			integrator.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	}
+}
+
+func TestPathTracingIntegrator_EnvironmentLight(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	scene := newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{0.5, 0.5, 0.2}},
+		Opacity:        1,
+	})
+	environmentLight, err := light.NewEnvironmentLight([][]shading.Color{{{1, 0.5, 1}}}, geometry.Vector{0, 0, 1},
+		geometry.Vector{1, 0, 0}, 0, 1)
+	assert.Nil(t, err)
+	scene.AddLight(environmentLight)
+
+	// The environment should be seen directly and in perfect reflections, instead of the background color.
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, 1}}
+	shading.AssertColorEqual(t, shading.Color{1,
+		0.5, 1}, PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{1,
+		0.5, 1}, WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	scene = newPathTracingTestScene(t, shading.Color{}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+		Reflectivity:   1,
+	})
+	scene.AddLight(environmentLight)
+	ray = geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{1, 0, -1}}
+	shading.AssertColorEqual(t, shading.Color{1,
+		0.5, 1}, PathTracingIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{1,
+		0.5, 1}, WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random), 1e-9)
+}
+
+func TestPathTracingIntegrator_ColorBleeding(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	// Set up a white floor lit from directly above and a red wall that the light only grazes, so that the wall can
+	// only be lit by light bouncing off of the floor.
+	scene := newPathTracingTestScene(t, shading.Color{0, 0, 0}, shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	})
+	wall, err := surface.NewPlane(geometry.Point{-10, 0, 0}, geometry.Vector{20, 0, 0}, geometry.Vector{0, 0, 10},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}}, Opacity: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(wall)
+	assert.Nil(t, scene.prepare())
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+
+	ray := geometry.Ray{geometry.Point{0, -1, 0.5}, geometry.Vector{0, 1, 0}}
+	assert.Equal(t, shading.Color{0,
+		0, 0}, WhittedIntegrator{}.Radiance(scene, ray, sampling.NewFixedSampler(0, 1), random))
+
+	var averageRadiance shading.Color
+	numSamples := 1000
+	for i := 0; i < numSamples; i++ {
+		averageRadiance = averageRadiance.Add(PathTracingIntegrator{}.Radiance(scene, ray,
+			sampling.NewFixedSampler(i, numSamples), random))
+	}
+	averageRadiance = averageRadiance.Multiply(1 / float64(numSamples))
+	assert.Greater(t, averageRadiance.R, 0.05)
+	assert.Equal(t, 0.0, averageRadiance.G)
+	assert.Equal(t, 0.0, averageRadiance.B)
+}
+
+func TestPathTracingIntegrator_Render(t *testing.T) {
+	camera, err := NewCamera(geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}, geometry.Vector{0, 1, 0},
+		90, 0, 5, 1, 1)
+	assert.Nil(t, err)
+	scene := Scene{Camera: camera, BackgroundColor: shading.Color{0, 1, 0},
+		Integrator: PathTracingIntegrator{SamplesPerPixel: 4}}
+	plane, err := surface.NewPlane(geometry.Point{-1, -1, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 0, 1}}, Opacity: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(plane)
+
+	// The plane reflects none of the green background, and there are no lights to illuminate it.
+	image, err := scene.Render(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, image.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, image.RGBAAt(7, 4))
+}
+
+// Returns a scene containing a large horizontal plane at the origin, ready for calling integrators directly.
+func newPathTracingTestScene(t *testing.T, backgroundColor shading.Color,
+	material shading.Material) *Scene {
+	plane, err := surface.NewPlane(geometry.Point{-10, -10, 0}, geometry.Vector{20, 0, 0}, geometry.Vector{0, 20, 0},
+		material)
+	assert.Nil(t, err)
+	scene := Scene{BackgroundColor: backgroundColor}
+	scene.AddSurface(plane)
+	assert.Nil(t, scene.prepare())
+	return &scene
+}
+
+// Returns a unit cube mesh extending along each axis from the given corner, whose triangles are wound counterclockwise
+// as seen from outside.
+func newTestCubeMesh(t *testing.T, corner geometry.Point, material shading.Material) surface.Mesh {
+	vertex := func(x, y, z float64) geometry.Point {
+		return corner.Translate(geometry.Vector{x, y, z})
+	}
+	faces := [][4]geometry.Point{
+		{vertex(0, 0, 0), vertex(0, 1, 0), vertex(1, 1, 0), vertex(1, 0, 0)},
+		{vertex(0, 0, 1), vertex(1, 0, 1), vertex(1, 1, 1), vertex(0, 1, 1)},
+		{vertex(0, 0, 0), vertex(0, 0, 1), vertex(0, 1, 1), vertex(0, 1, 0)},
+		{vertex(1, 0, 0), vertex(1, 1, 0), vertex(1, 1, 1), vertex(1, 0, 1)},
+		{vertex(0, 0, 0), vertex(1, 0, 0), vertex(1, 0, 1), vertex(0, 0, 1)},
+		{vertex(0, 1, 0), vertex(0, 1, 1), vertex(1, 1, 1), vertex(1, 1, 0)},
+	}
+	var triangles []surface.Triangle
+	for _, face := range faces {
+		for _, corners := range [][3]geometry.Point{{face[0], face[1], face[2]}, {face[0], face[2], face[3]}} {
+			triangle, err := surface.NewTriangle(corners[0], corners[1], corners[2], material)
+			assert.Nil(t, err)
+			triangles = append(triangles, triangle)
+		}
+	}
+	mesh, err := surface.NewMesh(triangles)
+	assert.Nil(t, err)
+	return mesh
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"sort"
+)
+
+// Represents a packet of light that has been traced from a light source and come to rest on a surface.
+type photon struct {
+	point     geometry.Point
+	direction geometry.Vector // Unit direction in which the photon was travelling when it reached the point
+	power     shading.Color   // Light carried by the photon, as a share of the light source's total power
+}
+
+// Balanced kd-tree of photons, which allows the photons near a point to be found without checking every one of them.
+// The tree is stored implicitly: the photon in the middle of each range of the array splits the rest of the range in
+// two along one axis, with those on the near side before it and those on the far side after it.
+type photonMap struct {
+	photons []photon
+	axes    []int // Axis along which the photon at each index splits the range around it
+}
+
+// Builds a map of the given photons, reordering them in the process.
+func newPhotonMap(photons []photon) *photonMap {
+	photonMap := &photonMap{photons: photons, axes: make([]int, len(photons))}
+	photonMap.build(0, len(photons))
+	return photonMap
+}
+
+// Returns the number of photons in the map.
+func (photonMap *photonMap) size() int {
+	return len(photonMap.photons)
+}
+
+// Calls the given function for each photon lying within the given distance of the given point.
+func (photonMap *photonMap) forEachWithin(point geometry.Point, radius float64, visit func(photon photon)) {
+	photonMap.search(0, len(photonMap.photons), point, radius, radius*radius, visit)
+}
+
+// Arranges the photons in the given range of indices into a subtree, splitting them at the median along the axis in
+// which they are most spread out.
+func (photonMap *photonMap) build(start, end int) {
+	if end-start <= 1 {
+		return
+	}
+	photons := photonMap.photons[start:end]
+	box := geometry.EmptyBoundingBox()
+	for _, photon := range photons {
+		box = box.AddPoint(photon.point)
+	}
+	axis := box.LongestAxis()
+	sort.Slice(photons, func(i, j int) bool {
+		return photons[i].point.Coordinate(axis) < photons[j].point.Coordinate(axis)
+	})
+	middle := (start + end) / 2
+	photonMap.axes[middle] = axis
+	photonMap.build(start, middle)
+	photonMap.build(middle+1, end)
+}
+
+// Visits the photons within the given distance of the given point in the subtree over the given range of indices,
+// skipping the side of each split that lies entirely beyond the distance.
+func (photonMap *photonMap) search(start, end int, point geometry.Point, radius, radiusSquared float64,
+	visit func(photon photon)) {
+	if start >= end {
+		return
+	}
+	middle := (start + end) / 2
+	splitPhoton := &photonMap.photons[middle]
+	if offset := point.VectorTo(splitPhoton.point); offset.Dot(offset) <= radiusSquared {
+		visit(*splitPhoton)
+	}
+	axis := photonMap.axes[middle]
+	offset := point.Coordinate(axis) - splitPhoton.point.Coordinate(axis)
+	if offset <= radius {
+		photonMap.search(start, middle, point, radius, radiusSquared, visit)
+	}
+	if offset >= -radius {
+		photonMap.search(middle+1, end, point, radius, radiusSquared, visit)
+	}
+}
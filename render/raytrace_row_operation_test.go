@@ -0,0 +1,22 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestStandardError(t *testing.T) {
+	assert.True(t, math.IsInf(standardError(shading.Color{1, 1, 1}, shading.Color{1, 1, 1}, 1), 1))
+
+	// Identical samples have no error.
+	assert.Equal(t, 0.0, standardError(shading.Color{2, 1, 0}, shading.Color{1, 0.25, 0}, 4))
+
+	// Samples of 0 and 1 in the green channel have a variance of 1/3, giving a standard error of sqrt(1/3 / 4).
+	sum := shading.Color{2, 2, 2}
+	sumOfSquares := shading.Color{1, 2, 1}
+	assert.InDelta(t, math.Sqrt(1.0/12), standardError(sum, sumOfSquares, 4), 1e-9)
+}
@@ -4,13 +4,19 @@ package render
 
 import (
 	"errors"
+	"fmt"
 	"github.com/cheggaaa/pb/v3"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/hdr"
 	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
 	"github.com/patfair/raytracer/shading"
 	"github.com/patfair/raytracer/surface"
 	"image"
+	"math"
 	"math/rand"
 	"runtime"
+	"sync"
 )
 
 // Contains all the information required to render a particular view of a set.
@@ -21,41 +27,236 @@ type Scene struct {
 	Lights          []light.Light     // Virtual lights to illuminate surfaces in the scene and cast shadows
 	ShadowSamples   int               // The number of samples that should be used for producing soft shadows.
 	DitherVariation float64           // How much to randomly vary colors by to prevent color banding.
+	Integrator      Integrator        // Rendering algorithm to use; a WhittedIntegrator is used if not specified
+	Fog             *shading.Medium   // Optional medium filling the space outside of any closed surfaces
+
+	// If positive, the number of photons to trace from the lights through reflective and refractive surfaces before
+	// rendering with the WhittedIntegrator, in order to show the caustics that they focus light into. The path
+	// tracer finds caustics by itself, and doesn't use photons.
+	CausticPhotons int
+
+	// Distance around each shaded point within which caustic photons are gathered; larger values give smoother but
+	// blurrier caustics.
+	CausticGatherRadius float64
+
+	// If positive, enables adaptive sampling for finish passes: each pixel stops being sampled once the estimated
+	// standard error of its color falls below this value, rather than always taking the maximum number of samples.
+	AdaptiveSamplingThreshold float64
+
+	// Seed for the random numbers used in rendering; the same seed always gives the same image, regardless of the
+	// number of processor cores that it is rendered on.
+	Seed int64
+
+	// Algorithm used to spread out the positions of the samples taken for each pixel on the image, the lens and the
+	// lights; stratified sampling is used if not specified.
+	Sampler sampling.SamplerType
+
+	// Filter used to reconstruct the pixels from the samples taken around them; a box filter covering each pixel (so
+	// that each is the simple average of its own samples) is used if not specified.
+	Filter Filter
+
+	surfaceHierarchy *surface.BoundingVolumeHierarchy // Acceleration structure built from Surfaces on first use
+	hierarchyOnce    sync.Once                        // Guards building the acceleration structure
+	surfaceLights    []light.SurfaceLight             // Emissive surfaces found before rendering, sampled as lights
+	causticMap       *photonMap                       // Caustic photons traced before rendering, if enabled
+	sampleCounts     [][]int                          // Number of samples taken for each pixel in the last render
+	maxSampleCount   int                              // Maximum number of samples per pixel in the last render
+
+	// Whether refractive surfaces block shadow rays completely, with the light passing through them found by tracing
+	// caustic photons instead
+	blocksRefractedLight bool
 }
 
 func (scene *Scene) AddSurface(surface surface.Surface) {
 	scene.Surfaces = append(scene.Surfaces, surface)
+
+	// Have the acceleration structure rebuilt to include the new surface.
+	scene.hierarchyOnce = sync.Once{}
 }
 
 func (scene *Scene) AddLight(light light.Light) {
 	scene.Lights = append(scene.Lights, light)
 }
 
-// Executes the raytracing algorithm on the scene and returns the result as an image.
+// Builds the acceleration structure, finds the emissive surfaces to sample as lights and traces the caustic photons,
+// in case surfaces were added since the last render. Returns an error if the emissive surfaces can't be sampled.
+func (scene *Scene) prepare() error {
+	scene.hierarchyOnce = sync.Once{}
+	scene.hierarchy()
+	scene.surfaceLights = nil
+	for i, sceneSurface := range scene.Surfaces {
+		if emissiveSurfaces := findSampledSurfaces(sceneSurface, isEmissive); len(emissiveSurfaces) > 0 {
+			surfaceLight, err := light.NewSurfaceLight(emissiveSurfaces)
+			if err != nil {
+				return fmt.Errorf("surface %d: %v", i, err)
+			}
+			scene.surfaceLights = append(scene.surfaceLights, surfaceLight)
+		}
+	}
+
+	_, isWhitted := scene.integrator().(WhittedIntegrator)
+	scene.blocksRefractedLight = isWhitted && scene.CausticPhotons > 0 && scene.CausticGatherRadius > 0
+	scene.causticMap = nil
+	if scene.blocksRefractedLight {
+		scene.causticMap = traceCausticPhotons(scene, scene.CausticPhotons, rand.New(rand.NewSource(scene.Seed)))
+	}
+	return nil
+}
+
+// Returns the details of the closest intersection of the given ray with the scene's surfaces along with the surface
+// that it hit, or nil if it doesn't hit any.
+func (scene *Scene) closestIntersection(ray geometry.Ray) (*geometry.Intersection, surface.Surface) {
+	return scene.hierarchy().ClosestIntersection(ray)
+}
+
+// Returns the acceleration structure over the scene's surfaces, building it first if it hasn't been built since the
+// last surface was added. Only one goroutine builds it, with any others waiting until it is done.
+func (scene *Scene) hierarchy() *surface.BoundingVolumeHierarchy {
+	scene.hierarchyOnce.Do(func() {
+		scene.surfaceHierarchy = surface.NewBoundingVolumeHierarchy(scene.Surfaces)
+	})
+	return scene.surfaceHierarchy
+}
+
+// Returns the parts of the given surface that can be sampled and match the given condition, looking inside meshes and
+// groups. Other kinds of surfaces (such as instances and solids) are never included.
+func findSampledSurfaces(sceneSurface surface.Surface,
+	condition func(surface.SampledSurface) bool) []surface.SampledSurface {
+	var sampledSurfaces []surface.SampledSurface
+	switch typedSurface := sceneSurface.(type) {
+	case surface.Mesh:
+		for _, triangle := range typedSurface.Triangles() {
+			sampledSurfaces = append(sampledSurfaces, findSampledSurfaces(triangle, condition)...)
+		}
+	case surface.Group:
+		for _, groupSurface := range typedSurface.Surfaces() {
+			sampledSurfaces = append(sampledSurfaces, findSampledSurfaces(groupSurface, condition)...)
+		}
+	case surface.SampledSurface:
+		if condition(typedSurface) {
+			sampledSurfaces = append(sampledSurfaces, typedSurface)
+		}
+	}
+	return sampledSurfaces
+}
+
+// Returns whether the given surface emits light, in which case it is sampled as a light. Emissive surfaces that can't
+// be sampled are still seen to glow by rays that hit them.
+func isEmissive(sampledSurface surface.SampledSurface) bool {
+	_, ok := sampledSurface.Material().(shading.EmissiveMaterial)
+	return ok
+}
+
+// Returns the scene's rendering algorithm, falling back to the default if none was specified.
+func (scene *Scene) integrator() Integrator {
+	if scene.Integrator == nil {
+		return WhittedIntegrator{}
+	}
+	return scene.Integrator
+}
+
+// Returns the scene's pixel reconstruction filter, falling back to a box filter covering each pixel if none was
+// specified.
+func (scene *Scene) filter() Filter {
+	if scene.Filter == nil {
+		return BoxFilter{}
+	}
+	return scene.Filter
+}
+
+// Returns the number of samples to take along each axis of a pixel, which is the square root of the maximum number of
+// samples per pixel after rounding it down to a perfect square to be compatible with anti-aliasing.
+func (scene *Scene) numDirectionalSamples(renderType RenderType) int {
+	numDirectionalSamples := int(math.Sqrt(float64(scene.integrator().NumSamples(scene, renderType))))
+	if numDirectionalSamples < 1 {
+		return 1
+	}
+	return numDirectionalSamples
+}
+
+// Executes the raytracing algorithm on the scene and returns the result as an image, with each color component clamped
+// to [0, 1].
 func (scene *Scene) Render(renderType RenderType, width, height int) (*image.RGBA, error) {
+	img, err := scene.RenderHdr(renderType, width, height)
+	if err != nil {
+		return nil, err
+	}
+	return img.ToRgba(), nil
+}
+
+// Executes the raytracing algorithm on the scene and returns the result as a high dynamic range image, in which color
+// components can exceed 1.
+func (scene *Scene) RenderHdr(renderType RenderType, width, height int) (*hdr.Image, error) {
 	if width <= 0 || height <= 0 {
 		return nil, errors.New("width and height must be positive numbers")
 	}
+	for i, sceneSurface := range scene.Surfaces {
+		// Surfaces that weren't built by their constructors (such as zero values) have no material to shade them with.
+		if sceneSurface.Material() == nil {
+			return nil, fmt.Errorf("surface %d has no material", i)
+		}
+	}
+	numInfiniteLights := 0
+	for _, sceneLight := range scene.Lights {
+		if _, ok := sceneLight.(light.InfiniteLight); ok {
+			numInfiniteLights++
+		}
+	}
+	if numInfiniteLights > 1 {
+		return nil, errors.New("scene cannot have more than one environment light")
+	}
+
+	if err := scene.prepare(); err != nil {
+		return nil, err
+	}
+
+	var roughPassPixels [][]shading.Color
+	if renderType == RenderFinishPass && scene.AdaptiveSamplingThreshold > 0 {
+		// Adaptive sampling uses a quick rough pass to find the edges in the image.
+		roughPassPixels, _ = scene.renderPixels(RenderDraftPass, width, height, nil)
+	}
+	pixels, sampleCounts := scene.renderPixels(renderType, width, height, roughPassPixels)
+	scene.sampleCounts = sampleCounts
+	numDirectionalSamples := scene.numDirectionalSamples(renderType)
+	scene.maxSampleCount = numDirectionalSamples * numDirectionalSamples
 
-	pixels := scene.renderPixels(renderType, width, height)
+	return &hdr.Image{Pixels: pixels}, nil
+}
+
+// Returns an image visualizing the number of samples taken for each pixel in the last render as a heat map, ranging
+// from blue for a single sample to red for the maximum number. Useful for tuning adaptive sampling.
+func (scene *Scene) SampleHeatMap() (*image.RGBA, error) {
+	if scene.sampleCounts == nil {
+		return nil, errors.New("scene must be rendered before generating a sample heat map")
+	}
+
+	height := len(scene.sampleCounts)
+	width := len(scene.sampleCounts[0])
 	img := image.NewRGBA(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
-			img.Set(x, y, pixels[y][x].ToRgba())
+			fraction := 0.0
+			if scene.maxSampleCount > 1 {
+				fraction = float64(scene.sampleCounts[y][x]-1) / float64(scene.maxSampleCount-1)
+			}
+			img.Set(x, y, heatMapColor(fraction).ToRgba())
 		}
 	}
 
 	return img, nil
 }
 
-// Executes the raytracing algorithm on the scene and returns the result as a two-dimensional array of pixels.
-func (scene *Scene) renderPixels(renderType RenderType, width, height int) [][]shading.Color {
+// Executes the raytracing algorithm on the scene and returns the result as a two-dimensional array of pixels, along
+// with the number of samples taken for each. The output of a rough pass must be given for adaptive sampling to be used.
+func (scene *Scene) renderPixels(renderType RenderType, width, height int,
+	roughPassPixels [][]shading.Color) ([][]shading.Color, [][]int) {
 	// Set up progress bar for the console.
 	progress := pb.Full.Start(width * height)
 
-	outputPixels := make([][]shading.Color, height)
+	film := newFilm(width, height, scene.filter())
+	sampleCounts := make([][]int, height)
 	for i := 0; i < height; i++ {
-		outputPixels[i] = make([]shading.Color, width)
+		sampleCounts[i] = make([]int, width)
 	}
 
 	// Set up parallel operations to take advantage of multiple processor cores.
@@ -63,7 +264,7 @@ func (scene *Scene) renderPixels(renderType RenderType, width, height int) [][]s
 	operationsChannel := make(chan RaytraceRowOperation, numOperations)
 	doneChannel := make(chan struct{}, numOperations)
 	operations := make([]RaytraceRowOperation, numOperations)
-	shufflePositions := rand.Perm(numOperations)
+	shufflePositions := rand.New(rand.NewSource(scene.Seed)).Perm(numOperations)
 
 	// Create the pool of worker goroutines.
 	numWorkers := runtime.NumCPU()
@@ -78,14 +279,16 @@ func (scene *Scene) renderPixels(renderType RenderType, width, height int) [][]s
 	for i := 0; i < height; i++ {
 		// Shuffle the operations to make progress more linear and predicted end time more accurate.
 		operations[shufflePositions[i]] = RaytraceRowOperation{
-			Scene:        scene,
-			RenderType:   renderType,
-			Width:        width,
-			Height:       height,
-			RowIndex:     i,
-			OutputPixels: outputPixels,
-			Progress:     progress,
-			DoneChannel:  doneChannel,
+			Scene:           scene,
+			RenderType:      renderType,
+			Width:           width,
+			Height:          height,
+			RowIndex:        i,
+			RoughPassPixels: roughPassPixels,
+			Film:            film,
+			SampleCounts:    sampleCounts,
+			Progress:        progress,
+			DoneChannel:     doneChannel,
 		}
 	}
 
@@ -101,5 +304,15 @@ func (scene *Scene) renderPixels(renderType RenderType, width, height int) [][]s
 	}
 
 	progress.Finish()
-	return outputPixels
+	return film.pixels(), sampleCounts
+}
+
+// Returns the color representing the given fraction in [0, 1] on a heat map that runs from blue through cyan, green
+// and yellow to red.
+func heatMapColor(fraction float64) shading.Color {
+	gradient := []shading.Color{{0, 0, 1}, {0, 1, 1}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}}
+	position := math.Max(math.Min(fraction, 1), 0) * float64(len(gradient)-1)
+	index := int(math.Min(position, float64(len(gradient)-2)))
+	weight := position - float64(index)
+	return gradient[index].Multiply(1 - weight).Add(gradient[index+1].Multiply(weight))
 }
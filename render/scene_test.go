@@ -3,12 +3,14 @@
 package render
 
 import (
+	"github.com/cheggaaa/pb/v3"
 	"github.com/patfair/raytracer/geometry"
 	"github.com/patfair/raytracer/light"
 	"github.com/patfair/raytracer/shading"
 	"github.com/patfair/raytracer/surface"
 	"github.com/stretchr/testify/assert"
 	"image/color"
+	"sync"
 	"testing"
 )
 
@@ -54,3 +56,178 @@ func TestScene(t *testing.T) {
 	assert.Equal(t, color.RGBA{0, 255, 0, 255}, image.RGBAAt(15, 8))
 	assert.Equal(t, color.RGBA{255, 255, 0, 255}, image.RGBAAt(7, 4))
 }
+
+func TestScene_RenderHdr(t *testing.T) {
+	camera, err := NewCamera(geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}, geometry.Vector{0, 1, 0},
+		90, 0, 5, 1, 1)
+	assert.Nil(t, err)
+	scene := Scene{Camera: camera, BackgroundColor: shading.Color{4, 2, 0.5}}
+
+	_, err = scene.RenderHdr(RenderDraftPass, 0, 3)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be positive")
+	}
+
+	// A surface that has no material can't be shaded.
+	scene.AddSurface(surface.Plane{})
+	_, err = scene.RenderHdr(RenderDraftPass, 16, 9)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "surface 0 has no material")
+	}
+	scene.Surfaces = nil
+
+	// Only one environment can surround the scene.
+	environmentLight, err := light.NewEnvironmentLight([][]shading.Color{{{1, 1, 1}}}, geometry.Vector{0, 0, 1},
+		geometry.Vector{1, 0, 0}, 0, 1)
+	assert.Nil(t, err)
+	scene.AddLight(environmentLight)
+	scene.AddLight(environmentLight)
+	_, err = scene.RenderHdr(RenderDraftPass, 16, 9)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "more than one environment light")
+	}
+	scene.Lights = nil
+
+	// Colors brighter than white should be preserved rather than clamped.
+	img, err := scene.RenderHdr(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, img.Width())
+	assert.Equal(t, 9, img.Height())
+	assert.Equal(t, shading.Color{4, 2, 0.5}, img.Pixels[0][0])
+	assert.Equal(t, shading.Color{4, 2, 0.5}, img.Pixels[8][15])
+}
+
+func TestScene_AdaptiveSampling(t *testing.T) {
+	camera, err := NewCamera(geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}, geometry.Vector{0, 1, 0},
+		90, 0, 5, 1, 4)
+	assert.Nil(t, err)
+	scene := Scene{Camera: camera, AdaptiveSamplingThreshold: 0.01}
+
+	_, err = scene.SampleHeatMap()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be rendered")
+	}
+
+	// A uniformly lit plane covering the left half of the view creates a single vertical edge.
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 1, 0)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+	plane, err := surface.NewPlane(geometry.Point{-10, -10, 0}, geometry.Vector{10, 0, 0}, geometry.Vector{0, 20, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{Color: shading.Color{1, 1, 1}}, Opacity: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(plane)
+
+	image, err := scene.Render(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{81, 81, 81, 255}, image.RGBAAt(0, 4))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, image.RGBAAt(15, 4))
+
+	// Pixels away from the edge have no variance, so should only need the first batch of samples, while those on
+	// either side of the edge should be fully sampled.
+	assert.Equal(t, 16, scene.maxSampleCount)
+	assert.Equal(t, minAdaptiveBatchSize, scene.sampleCounts[4][0])
+	assert.Equal(t, minAdaptiveBatchSize, scene.sampleCounts[4][15])
+	assert.Equal(t, 16, scene.sampleCounts[4][7])
+	assert.Equal(t, 16, scene.sampleCounts[4][8])
+
+	heatMap, err := scene.SampleHeatMap()
+	assert.Nil(t, err)
+	assert.Equal(t, 16, heatMap.Bounds().Dx())
+	assert.Equal(t, 9, heatMap.Bounds().Dy())
+	assert.Equal(t, color.RGBA{0, 204, 255, 255}, heatMap.RGBAAt(0, 4))
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, heatMap.RGBAAt(7, 4))
+
+	// Without adaptive sampling, every pixel should be fully sampled.
+	scene.AdaptiveSamplingThreshold = 0
+	_, err = scene.Render(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, scene.sampleCounts[4][0])
+	assert.Equal(t, 16, scene.sampleCounts[4][15])
+}
+
+func TestScene_Seed(t *testing.T) {
+	camera, err := NewCamera(geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}, geometry.Vector{0, 1, 0},
+		90, 0.1, 5, 2, 2)
+	assert.Nil(t, err)
+	scene := Scene{Camera: camera, BackgroundColor: shading.Color{0.5, 0.5, 0.5}, DitherVariation: 0.1,
+		Integrator: PathTracingIntegrator{SamplesPerPixel: 4}, Seed: 42, Filter: MitchellFilter{}}
+	distantLight, err := light.NewDistantLight(geometry.Vector{1, 0, -1}, shading.Color{1, 1, 1}, 1, 0.2)
+	assert.Nil(t, err)
+	scene.AddLight(distantLight)
+	plane, err := surface.NewPlane(geometry.Point{-1, -1, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{Color: shading.Color{1, 1, 1}}, Opacity: 1})
+	assert.Nil(t, err)
+	scene.AddSurface(plane)
+
+	// Rendering again with the same seed should give exactly the same image.
+	first, err := scene.RenderHdr(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	second, err := scene.RenderHdr(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, first.Pixels, second.Pixels)
+
+	// The image shouldn't depend on the order in which the rows are rendered, as it would if they shared a stream of
+	// random numbers or if the samples that the filter spreads across rows were added in the order they were taken.
+	film := newFilm(16, 9, scene.filter())
+	doneChannel := make(chan struct{}, 9)
+	for i := 8; i >= 0; i-- {
+		operation := RaytraceRowOperation{Scene: &scene, RenderType: RenderFinishPass, Width: 16, Height: 9,
+			RowIndex: i, Film: film, Progress: pb.New(16 * 9), DoneChannel: doneChannel}
+		operation.Run()
+	}
+	assert.Equal(t, first.Pixels, film.pixels())
+
+	// A different seed should give a different image.
+	scene.Seed = 43
+	third, err := scene.RenderHdr(RenderFinishPass, 16, 9)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Pixels, third.Pixels)
+}
+
+func TestScene_ClosestIntersection(t *testing.T) {
+	shadingProperties := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 1}
+	far, err := surface.NewPlane(geometry.Point{-1, -1, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shadingProperties)
+	assert.Nil(t, err)
+	near, err := surface.NewPlane(geometry.Point{-1, -1, 1}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shadingProperties)
+	assert.Nil(t, err)
+	ray := geometry.Ray{geometry.Point{0, 0, 3}, geometry.Vector{0, 0, -1}}
+
+	// The acceleration structure of an unprepared scene should be built once even when it is first used by several
+	// goroutines at once.
+	scene := Scene{}
+	scene.AddSurface(far)
+	var wg sync.WaitGroup
+	distances := make([]float64, 8)
+	for i := range distances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if intersection, _ := scene.closestIntersection(ray); intersection != nil {
+				distances[i] = intersection.Distance
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, distance := range distances {
+		assert.Equal(t, 3.0, distance)
+	}
+
+	// Adding a surface afterwards should have it included.
+	scene.AddSurface(near)
+	intersection, closestSurface := scene.closestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 2.0, intersection.Distance)
+		assert.Equal(t, near, closestSurface)
+	}
+}
+
+func TestHeatMapColor(t *testing.T) {
+	assert.Equal(t, shading.Color{0, 0, 1}, heatMapColor(0))
+	assert.Equal(t, shading.Color{0, 0.5, 1}, heatMapColor(0.125))
+	assert.Equal(t, shading.Color{0, 1, 0}, heatMapColor(0.5))
+	assert.Equal(t, shading.Color{1, 0, 0}, heatMapColor(1))
+	assert.Equal(t, shading.Color{1, 0, 0}, heatMapColor(1.5))
+	assert.Equal(t, shading.Color{0, 0, 1}, heatMapColor(-1))
+}
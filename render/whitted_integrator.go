@@ -0,0 +1,96 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"math"
+	"math/rand"
+)
+
+const (
+	maxReflectionDepth = 20
+	reflectionBias     = 0.001
+	shadowBias         = 0.001
+)
+
+// Renders the scene using Whitted-style raytracing, in which rays are followed recursively only along the directions of
+// perfect reflection and refraction, and surfaces are otherwise shaded using only the light arriving directly from the
+// scene's lights. It's fast, but doesn't capture indirect diffuse illumination such as color bleeding.
+type WhittedIntegrator struct{}
+
+// Returns a single sample for a draft pass, or otherwise enough samples to satisfy each of the depth of field,
+// anti-aliasing and soft shadow settings.
+func (integrator WhittedIntegrator) NumSamples(scene *Scene, renderType RenderType) int {
+	if renderType == RenderDraftPass {
+		return 1
+	}
+	depthOfFieldSamples := scene.Camera.DepthOfFieldSamples
+	antiAliasSamples := scene.Camera.AntiAliasSamples * scene.Camera.AntiAliasSamples
+	shadowSamples := scene.ShadowSamples
+	return int(math.Max(math.Max(float64(depthOfFieldSamples), float64(antiAliasSamples)), float64(shadowSamples)))
+}
+
+func (integrator WhittedIntegrator) Radiance(scene *Scene, ray geometry.Ray, sampler sampling.Sampler,
+	random *rand.Rand) shading.Color {
+	return integrator.castRay(scene, ray, 0, 1, 0, sampler, random)
+}
+
+// Returns the color that the given ray is pointing at. Contains the main logic of the raytracer. The sample pdf is the
+// probability density with which the ray's direction was chosen at random, or zero if it wasn't; area lights and the
+// environment seen along randomly chosen directions are weighted against the direct lighting, which also includes them.
+func (integrator WhittedIntegrator) castRay(scene *Scene, ray geometry.Ray, depth int, refractionIndex float64,
+	samplePdf float64, sampler sampling.Sampler, random *rand.Rand) shading.Color {
+	// Limit recursion caused by reflecting rays off multiple surfaces.
+	if depth == maxReflectionDepth {
+		return scene.BackgroundColor
+	}
+
+	// Find the closest surface in the scene that the ray intersects, if any, and account for the light absorbed and
+	// scattered by the medium that the ray passes through on the way to it.
+	closestIntersection, closestSurface := scene.closestIntersection(ray)
+	areaLight, distance := closestAreaLight(scene, ray)
+	if closestIntersection != nil && closestIntersection.Distance <= distance {
+		areaLight, distance = nil, closestIntersection.Distance
+	}
+	medium := segmentMedium(scene, closestSurface, closestIntersection, ray.Direction)
+	scattered, transmittance := mediumRadiance(scene, medium, ray, distance, sampler, random)
+	if areaLight != nil {
+		radiance := areaLightRadiance(areaLight).Multiply(bsdfSampleWeight(samplePdf, areaLight.Pdf(ray)))
+		return scattered.Add(radiance.Filter(transmittance))
+	}
+	if closestIntersection == nil {
+		background, infiniteLight := escapedRadiance(scene, ray.Direction)
+		if infiniteLight != nil {
+			background = background.Multiply(bsdfSampleWeight(samplePdf, infiniteLight.Pdf(ray.Direction)))
+		}
+		return scattered.Add(background.Filter(transmittance))
+	}
+
+	ray.Direction = ray.Direction.ToUnit()
+	material, interaction := newInteraction(scene, closestSurface, closestIntersection, ray.Direction,
+		refractionIndex, random)
+	outgoing := ray.Direction.Multiply(-1)
+
+	// Add the light emitted by the surface, that arriving directly from the scene's lights and that focused onto it by
+	// other surfaces.
+	pixelColor := emission(scene, material, interaction, ray, closestIntersection.Distance, samplePdf).
+		Add(directLighting(scene, material, interaction, outgoing, sampler, specularSampling)).
+		Add(causticRadiance(scene, material, interaction, outgoing))
+
+	// Follow the directions of reflection and refraction recursively.
+	for _, sample := range material.SpecularSamples(interaction, outgoing) {
+		scatteredColor := integrator.castRay(scene, offsetRay(interaction, sample.Direction), depth+1,
+			sample.RefractiveIndex, sample.Pdf, sampler, random)
+		pixelColor = pixelColor.Add(scatteredColor.Filter(sample.Weight))
+	}
+
+	if absorbingMaterial, ok := material.(shading.AbsorbingMaterial); ok &&
+		isLeaving(closestSurface, closestIntersection, ray.Direction) {
+		// The ray is leaving a closed surface, some of whose light is absorbed on the way through it.
+		pixelColor = pixelColor.Filter(absorbingMaterial.InteriorTransmittance(closestIntersection.Distance))
+	}
+	return scattered.Add(pixelColor.Filter(transmittance))
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package render
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestWhittedIntegrator_PartialShadowHighlight(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	floor, err := surface.NewPlane(geometry.Point{-10, -10, 0}, geometry.Vector{20, 0, 0}, geometry.Vector{0, 20, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 1, SpecularIntensity: 1,
+			SpecularExponent: 1})
+	assert.Nil(t, err)
+	pane, err := surface.NewPlane(geometry.Point{-1, -1, 1.5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 0.5, RefractiveIndex: 1})
+	assert.Nil(t, err)
+	distantLight, err := light.NewDistantLight(geometry.Vector{0, 0, -1}, shading.Color{1, 1, 1}, 2, 0)
+	assert.Nil(t, err)
+	scene := Scene{}
+	scene.AddSurface(floor)
+	scene.AddSurface(pane)
+	scene.AddLight(distantLight)
+
+	// The highlight of a light shining through a partially transparent surface is shown at full brightness, unlike
+	// when path tracing.
+	ray := geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}}
+	radiance := WhittedIntegrator{}.Radiance(&scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{1, 1, 1}, radiance, 1e-9)
+	radiance = PathTracingIntegrator{}.Radiance(&scene, ray, sampling.NewFixedSampler(0, 1), random)
+	shading.AssertColorEqual(t, shading.Color{0.5, 0.5, 0.5}, radiance, 1e-9)
+}
+
+func TestWhittedIntegrator_Spheres(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	floor, err := surface.NewPlane(geometry.Point{-50, -50, -3}, geometry.Vector{100, 0, 0},
+		geometry.Vector{0, 100, 0}, shading.ShadingProperties{
+			DiffuseTexture: shading.CheckerboardTexture{shading.Color{1, 0, 0}, shading.Color{0, 0, 1}, 2, 2},
+			Opacity:        1,
+		})
+	assert.Nil(t, err)
+	opaqueSphere, err := surface.NewSphere(geometry.Point{-3, 0, 0}, 1, geometry.Vector{0, 0, 1},
+		geometry.Vector{1, 0, 0}, shading.ShadingProperties{
+			DiffuseTexture:    shading.SolidTexture{shading.Color{1, 1, 1}},
+			Opacity:           1,
+			SpecularIntensity: 0.5,
+			SpecularExponent:  10,
+		})
+	assert.Nil(t, err)
+	mirrorSphere, err := surface.NewSphere(geometry.Point{3, 0, 0}, 1, geometry.Vector{0, 0, 1},
+		geometry.Vector{1, 0, 0}, shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 1,
+			Reflectivity: 1})
+	assert.Nil(t, err)
+	glassSphere, err := surface.NewSphere(geometry.Point{0, 0, 0}, 1, geometry.Vector{0, 0, 1},
+		geometry.Vector{1, 0, 0}, shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{}, Opacity: 0,
+			RefractiveIndex: 1.5})
+	assert.Nil(t, err)
+	distantLight, err := light.NewDistantLight(geometry.Vector{-1, 0, -2}, shading.Color{1, 1, 1}, 1, 0)
+	assert.Nil(t, err)
+	scene := Scene{BackgroundColor: shading.Color{0, 1, 0}}
+	scene.AddSurface(floor)
+	scene.AddSurface(opaqueSphere)
+	scene.AddSurface(mirrorSphere)
+	scene.AddSurface(glassSphere)
+	scene.AddLight(distantLight)
+	radiance := func(x, y float64) shading.Color {
+		return WhittedIntegrator{}.Radiance(&scene, geometry.Ray{geometry.Point{x, y, 5}, geometry.Vector{0, 0, -1}},
+			sampling.NewFixedSampler(0, 1), random)
+	}
+
+	// Spheres seen from outside should be shaded as they always have been.
+	shading.AssertColorEqual(t, shading.Color{0.448545017366871, 0.448545017366871, 0.448545017366871},
+		radiance(-3, 0), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0.27249810275966596, 0.27249810275966596, 0.27249810275966596},
+		radiance(-2.5, 0.5), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0, 1, 0}, radiance(3.5, 0), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0.28470501736687087, 0, 0}, radiance(3.8, 0.2), 1e-9)
+
+	// Light refracted into a glass sphere should leave through its far side and show the floor behind it, along with a
+	// faint reflection of the background.
+	for _, color := range []shading.Color{radiance(0, 0), radiance(0.3, 0.2)} {
+		assert.Greater(t, color.R, 0.25)
+		assert.InDelta(t, 0.04, color.G, 0.001)
+		assert.Equal(t, 0.0, color.B)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import "math"
+
+// Number of dimensions drawn from the Halton sequence, each using the next prime as its base, beyond which the bases
+// are too large for the points to be spread evenly and independent random values are returned instead.
+const haltonDimensions = 64
+
+// Prime bases of the Halton sequence's dimensions.
+var haltonBases = firstPrimes(haltonDimensions)
+
+// Generates samples from the Halton sequence, whose value in each dimension is the radical inverse of the sample's
+// index in a different prime base (reflecting its digits about the radix point). Each pixel and dimension permutes the
+// digits differently at random, which keeps the points spread evenly while preventing neighboring pixels from seeing
+// the same pattern and large bases from producing long runs of correlated values.
+type HaltonSampler struct {
+	samplerState
+}
+
+// Returns a new Halton sampler whose digit permutations are derived from the given seed.
+func NewHaltonSampler(seed int64) *HaltonSampler {
+	return &HaltonSampler{samplerState{seed: uint64(seed)}}
+}
+
+func (sampler *HaltonSampler) Get1D() float64 {
+	dimension := sampler.dimension
+	dimensionHash := sampler.nextDimensionHash()
+	if dimension >= haltonDimensions {
+		return hashToFloat(hash(dimensionHash, uint64(sampler.sampleIndex)))
+	}
+	return scrambledRadicalInverse(haltonBases[dimension], uint64(sampler.sampleIndex), sampler.numSamples,
+		dimensionHash)
+}
+
+func (sampler *HaltonSampler) Get2D() (float64, float64) {
+	return sampler.Get1D(), sampler.Get1D()
+}
+
+// Returns the radical inverse of the given index in the given base, with each of its digits permuted at random by the
+// given seed (differently for each digit position). Only as many digits as are needed to tell apart the given number
+// of samples are permuted, beyond which the value is placed at random within the interval that they select.
+func scrambledRadicalInverse(base int, index uint64, numSamples int, seed uint64) float64 {
+	jitter := hashToFloat(hash(seed, ^index))
+	inverseBase := 1 / float64(base)
+	value := 0.0
+	scale := 1.0 // Width of the interval selected by the digits so far
+	for digitIndex := uint64(0); index > 0 || scale*float64(numSamples) > 1; digitIndex++ {
+		scale *= inverseBase
+		digit := permutationElement(uint32(index%uint64(base)), uint32(base), uint32(hash(seed, digitIndex)))
+		value += float64(digit) * scale
+		index /= uint64(base)
+	}
+	value += jitter * scale
+	return math.Min(value, math.Nextafter(1, 0))
+}
+
+// Returns the given number of smallest prime numbers.
+func firstPrimes(count int) []int {
+	var primes []int
+	for candidate := 2; len(primes) < count; candidate++ {
+		isPrime := true
+		for _, prime := range primes {
+			if prime*prime > candidate {
+				break
+			}
+			if candidate%prime == 0 {
+				isPrime = false
+				break
+			}
+		}
+		if isPrime {
+			primes = append(primes, candidate)
+		}
+	}
+	return primes
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHaltonSampler(t *testing.T) {
+	sampler := NewHaltonSampler(0)
+
+	// The first dimensions use bases 2, 3 and 5, so a number of samples equal to a power of the base should be spread
+	// evenly over the intervals of that size in each.
+	firstStrata := make(map[int]bool)
+	secondStrata := make(map[int]bool)
+	thirdStrata := make(map[int]bool)
+	for i := 0; i < 25; i++ {
+		sampler.StartSample(1, 2, i, 25)
+		u1, u2 := sampler.Get2D()
+		u3 := sampler.Get1D()
+		if i < 16 {
+			firstStrata[int(u1*16)] = true
+		}
+		if i < 9 {
+			secondStrata[int(u2*9)] = true
+		}
+		thirdStrata[int(u3*25)] = true
+	}
+	assert.Equal(t, 16, len(firstStrata))
+	assert.Equal(t, 9, len(secondStrata))
+	assert.Equal(t, 25, len(thirdStrata))
+
+	// Dimensions beyond the tabulated bases should still give values in [0, 1).
+	for i := 0; i < haltonDimensions+10; i++ {
+		u := sampler.Get1D()
+		assert.True(t, u >= 0 && u < 1)
+	}
+}
+
+func TestScrambledRadicalInverse(t *testing.T) {
+	// The digits should be permuted consistently, so that the samples sharing a leading digit share an interval.
+	for base := 2; base <= 7; base++ {
+		intervals := make(map[int]int)
+		for index := 0; index < base*base; index++ {
+			value := scrambledRadicalInverse(base, uint64(index), base*base, 42)
+			assert.True(t, value >= 0 && value < 1)
+			intervals[int(value*float64(base))] += 1
+		}
+		for interval := 0; interval < base; interval++ {
+			assert.Equal(t, base, intervals[interval], "base: %d", base)
+		}
+	}
+	assert.Equal(t, []int{2, 3, 5, 7, 11, 13}, firstPrimes(6))
+}
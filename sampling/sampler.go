@@ -0,0 +1,142 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import "math/bits"
+
+// Represents a source of the values in [0, 1) from which the choices made in rendering a pixel (such as the point on
+// the image and on the lens that a camera ray passes through, and the point on each light that it is lit from) are
+// derived. Each sample taken for a pixel draws a sequence of values, its dimensions, in the same order, and the sampler
+// spreads the values in each dimension more evenly over the pixel's samples than independent random numbers would.
+type Sampler interface {
+	// Begins the sample having the given index out of the given number taken for the given pixel, starting again from
+	// the first dimension.
+	StartSample(x, y, sampleIndex, numSamples int)
+
+	// Returns the number of samples being taken for the current pixel.
+	NumSamples() int
+
+	// Returns the value of the next dimension of the current sample.
+	Get1D() float64
+
+	// Returns the values of the next two dimensions of the current sample, which are spread evenly over the unit
+	// square together.
+	Get2D() (float64, float64)
+}
+
+// Identifies an algorithm for generating samples.
+type SamplerType int
+
+const (
+	StratifiedSampling SamplerType = iota
+	HaltonSampling
+	SobolSampling
+)
+
+// Returns a new sampler of the given type, which scrambles its samples differently for each seed and pixel.
+func NewSampler(samplerType SamplerType, seed int64) Sampler {
+	switch samplerType {
+	case HaltonSampling:
+		return NewHaltonSampler(seed)
+	case SobolSampling:
+		return NewSobolSampler(seed)
+	default:
+		return NewStratifiedSampler(seed)
+	}
+}
+
+// Returns a sampler already started at the given sample out of the given number, for drawing the values of a single
+// sample outside of rendering a pixel, such as when sampling a light directly. The values are the same every time.
+func NewFixedSampler(sampleIndex, numSamples int) Sampler {
+	sampler := NewStratifiedSampler(0)
+	sampler.StartSample(0, 0, sampleIndex, numSamples)
+	return sampler
+}
+
+// Holds the position within the sequence of samples that is common to every type of sampler.
+type samplerState struct {
+	seed        uint64
+	pixelHash   uint64 // Hash of the seed and the current pixel, from which each dimension's scramble is derived
+	sampleIndex int
+	numSamples  int
+	dimension   int // Index of the next dimension to be drawn from the current sample
+}
+
+func (state *samplerState) StartSample(x, y, sampleIndex, numSamples int) {
+	state.pixelHash = hash(state.seed, uint64(x), uint64(y))
+	state.sampleIndex = sampleIndex
+	state.numSamples = numSamples
+	state.dimension = 0
+}
+
+func (state *samplerState) NumSamples() int {
+	return state.numSamples
+}
+
+// Returns a hash of the current pixel and the next dimension, which is then consumed.
+func (state *samplerState) nextDimensionHash() uint64 {
+	dimensionHash := hash(state.pixelHash, uint64(state.dimension))
+	state.dimension++
+	return dimensionHash
+}
+
+// Returns a well-mixed combination of the given values, from which independent scrambles can be derived.
+func hash(values ...uint64) uint64 {
+	h := uint64(0)
+	for _, value := range values {
+		h = mix((h ^ value) + 0x9e3779b97f4a7c15)
+	}
+	return h
+}
+
+// Scrambles the bits of the given value using the finalizer of the SplitMix64 generator, so that every bit of the
+// result depends on every bit of the value.
+func mix(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// Converts the given hash into a value in [0, 1).
+func hashToFloat(h uint64) float64 {
+	return float64(h>>11) / (1 << 53)
+}
+
+// Converts the given 32-bit fixed-point fraction into a value in [0, 1).
+func fractionToFloat(fraction uint32) float64 {
+	return float64(fraction) / (1 << 32)
+}
+
+// Returns the element at the given index of a pseudo-random permutation of [0, length) chosen by the given seed,
+// without needing to store the permutation, using Kensler's hash-based method from "Correlated Multi-Jittered
+// Sampling".
+func permutationElement(index, length, seed uint32) uint32 {
+	if length <= 1 {
+		return 0
+	}
+	mask := uint32(1)<<bits.Len32(length-1) - 1
+	for {
+		index ^= seed
+		index *= 0xe170893d
+		index ^= seed >> 16
+		index ^= (index & mask) >> 4
+		index ^= seed >> 8
+		index *= 0x0929eb3f
+		index ^= seed >> 23
+		index ^= (index & mask) >> 1
+		index *= 1 | seed>>27
+		index *= 0x6935fa69
+		index ^= (index & mask) >> 11
+		index *= 0x74dcb303
+		index ^= (index & mask) >> 2
+		index *= 0x9e501cc3
+		index ^= (index & mask) >> 2
+		index *= 0xc860a3df
+		index &= mask
+		index ^= index >> 5
+		if index < length {
+			break
+		}
+	}
+	return (index + seed) % length
+}
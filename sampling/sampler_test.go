@@ -0,0 +1,105 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewSampler(t *testing.T) {
+	assert.IsType(t, &StratifiedSampler{}, NewSampler(StratifiedSampling, 0))
+	assert.IsType(t, &HaltonSampler{}, NewSampler(HaltonSampling, 0))
+	assert.IsType(t, &SobolSampler{}, NewSampler(SobolSampling, 0))
+}
+
+func TestNewFixedSampler(t *testing.T) {
+	// The sampler should give the values of the given sample, as a stratified sampler would.
+	sampler := NewFixedSampler(5, 16)
+	expected := NewStratifiedSampler(0)
+	expected.StartSample(0, 0, 5, 16)
+	assert.Equal(t, 16, sampler.NumSamples())
+	assert.Equal(t, expected.Get1D(), sampler.Get1D())
+	u1, v1 := expected.Get2D()
+	u2, v2 := sampler.Get2D()
+	assert.Equal(t, u1, u2)
+	assert.Equal(t, v1, v2)
+}
+
+func TestSampler_Deterministic(t *testing.T) {
+	for _, samplerType := range []SamplerType{StratifiedSampling, HaltonSampling, SobolSampling} {
+		// The same sample should always give the same values, regardless of the order in which they're taken.
+		sampler := NewSampler(samplerType, 5)
+		sampler.StartSample(3, 4, 7, 16)
+		u1 := sampler.Get1D()
+		u2, u3 := sampler.Get2D()
+		sampler.StartSample(0, 0, 0, 16)
+		sampler.Get2D()
+		sampler.StartSample(3, 4, 7, 16)
+		assert.Equal(t, u1, sampler.Get1D(), "sampler: %d", samplerType)
+		v2, v3 := sampler.Get2D()
+		assert.Equal(t, u2, v2, "sampler: %d", samplerType)
+		assert.Equal(t, u3, v3, "sampler: %d", samplerType)
+		assert.Equal(t, 16, sampler.NumSamples())
+
+		// Other seeds and pixels should give different values.
+		sampler.StartSample(4, 3, 7, 16)
+		assert.NotEqual(t, u1, sampler.Get1D(), "sampler: %d", samplerType)
+		sampler = NewSampler(samplerType, 6)
+		sampler.StartSample(3, 4, 7, 16)
+		assert.NotEqual(t, u1, sampler.Get1D(), "sampler: %d", samplerType)
+	}
+}
+
+func TestSampler_LowerError(t *testing.T) {
+	// Estimating the integral of a smooth function over the unit square should be much more accurate with each sampler
+	// than with independent random samples.
+	integrand := func(x, y float64) float64 {
+		return math.Sin(math.Pi*x) * math.Exp(y)
+	}
+	expected := 2 / math.Pi * (math.E - 1)
+	const numSamples, numPixels = 64, 200
+
+	random := rand.New(rand.NewSource(0))
+	randomError := 0.0
+	for pixel := 0; pixel < numPixels; pixel++ {
+		estimate := 0.0
+		for i := 0; i < numSamples; i++ {
+			estimate += integrand(random.Float64(), random.Float64()) / numSamples
+		}
+		randomError += (estimate - expected) * (estimate - expected) / numPixels
+	}
+
+	for _, samplerType := range []SamplerType{StratifiedSampling, HaltonSampling, SobolSampling} {
+		sampler := NewSampler(samplerType, 0)
+		samplerError := 0.0
+		for pixel := 0; pixel < numPixels; pixel++ {
+			estimate := 0.0
+			for i := 0; i < numSamples; i++ {
+				sampler.StartSample(pixel, 0, i, numSamples)
+				// Skip a few dimensions to check those further along too.
+				sampler.Get2D()
+				sampler.Get1D()
+				estimate += integrand(sampler.Get2D()) / numSamples
+			}
+			samplerError += (estimate - expected) * (estimate - expected) / numPixels
+		}
+		assert.Less(t, samplerError, randomError/10, "sampler: %d", samplerType)
+	}
+}
+
+func TestPermutationElement(t *testing.T) {
+	for _, length := range []uint32{1, 2, 7, 16, 100} {
+		for _, seed := range []uint32{0, 1, 123456789} {
+			seen := make(map[uint32]bool)
+			for i := uint32(0); i < length; i++ {
+				element := permutationElement(i, length, seed)
+				assert.Less(t, element, length)
+				seen[element] = true
+			}
+			assert.Equal(t, int(length), len(seen), "length: %d, seed: %d", length, seed)
+		}
+	}
+}
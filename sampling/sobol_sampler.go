@@ -0,0 +1,64 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import "math/bits"
+
+// Generates samples from the first two dimensions of the Sobol sequence with Owen scrambling, using Burley's
+// hash-based method from "Practical Hash-based Owen Scrambling". Every power-of-two-sized block of the sequence's
+// points remains spread evenly over the unit square after scrambling, so each pair of dimensions is stratified however
+// it's divided into halves. Each pair of dimensions shuffles the order of the points differently, so that they aren't
+// correlated with each other.
+type SobolSampler struct {
+	samplerState
+}
+
+// Returns a new Sobol sampler whose scrambling is derived from the given seed.
+func NewSobolSampler(seed int64) *SobolSampler {
+	return &SobolSampler{samplerState{seed: uint64(seed)}}
+}
+
+func (sampler *SobolSampler) Get1D() float64 {
+	dimensionHash := sampler.nextDimensionHash()
+	index := nestedUniformScramble(uint32(sampler.sampleIndex), uint32(dimensionHash))
+	return fractionToFloat(nestedUniformScramble(bits.Reverse32(index), uint32(dimensionHash>>32)))
+}
+
+func (sampler *SobolSampler) Get2D() (float64, float64) {
+	dimensionHash := sampler.nextDimensionHash()
+	sampler.dimension++
+	index := nestedUniformScramble(uint32(sampler.sampleIndex), uint32(dimensionHash))
+	x := nestedUniformScramble(bits.Reverse32(index), uint32(dimensionHash>>32))
+	y := nestedUniformScramble(sobolSecondDimension(index), uint32(hash(dimensionHash)))
+	return fractionToFloat(x), fractionToFloat(y)
+}
+
+// Returns the second dimension of the Sobol point having the given index, as a 32-bit fixed-point fraction. (The first
+// dimension is simply the index with its bits reversed.)
+func sobolSecondDimension(index uint32) uint32 {
+	var result uint32
+	for direction := uint32(1) << 31; index != 0; index >>= 1 {
+		if index&1 != 0 {
+			result ^= direction
+		}
+		direction ^= direction >> 1
+	}
+	return result
+}
+
+// Applies Owen scrambling to the given 32-bit fixed-point fraction, so that each of its bits is flipped or not
+// depending at random (as chosen by the given seed) on the bits before it.
+func nestedUniformScramble(fraction, seed uint32) uint32 {
+	return bits.Reverse32(laineKarrasPermutation(bits.Reverse32(fraction), seed))
+}
+
+// Returns a hash of the given value in which each bit depends only on the bits below it, as described by Laine and
+// Karras in "Stratified Sampling for Stochastic Transparency", with the constants improved by Burley.
+func laineKarrasPermutation(x, seed uint32) uint32 {
+	x += seed
+	x ^= x * 0x6c50b47c
+	x ^= x * 0xb82f1e52
+	x ^= x * 0xc7afe638
+	x ^= x * 0x8d22f6e6
+	return x
+}
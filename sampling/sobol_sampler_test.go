@@ -0,0 +1,44 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSobolSampler(t *testing.T) {
+	sampler := NewSobolSampler(0)
+
+	// Sixteen samples should have one in each elementary interval of the square of area 1/16, for each pair of
+	// dimensions.
+	for _, shape := range [][2]int{{1, 16}, {2, 8}, {4, 4}, {8, 2}, {16, 1}} {
+		for pair := 0; pair < 3; pair++ {
+			cells := make(map[[2]int]bool)
+			for i := 0; i < 16; i++ {
+				sampler.StartSample(5, 6, i, 16)
+				for j := 0; j < pair; j++ {
+					sampler.Get2D()
+				}
+				x, y := sampler.Get2D()
+				cells[[2]int{int(x * float64(shape[0])), int(y * float64(shape[1]))}] = true
+			}
+			assert.Equal(t, 16, len(cells), "shape: %v, pair: %d", shape, pair)
+		}
+	}
+
+	// Single dimensions should be stratified too.
+	strata := make(map[int]bool)
+	for i := 0; i < 32; i++ {
+		sampler.StartSample(5, 6, i, 32)
+		strata[int(sampler.Get1D()*32)] = true
+	}
+	assert.Equal(t, 32, len(strata))
+}
+
+func TestSobolSecondDimension(t *testing.T) {
+	expected := []float64{0, 0.5, 0.75, 0.25, 0.625, 0.125, 0.375, 0.875}
+	for index, value := range expected {
+		assert.Equal(t, value, fractionToFloat(sobolSecondDimension(uint32(index))))
+	}
+}
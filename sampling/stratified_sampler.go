@@ -0,0 +1,46 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import "math"
+
+// Generates jittered stratified samples: each dimension (or pair of dimensions) is divided into as many equal strata as
+// there are samples, and each sample is placed at a random position within a different stratum. The strata are
+// assigned to the samples in a different random order for each dimension and pixel, so that the dimensions aren't
+// correlated with each other.
+type StratifiedSampler struct {
+	samplerState
+}
+
+// Returns a new stratified sampler whose random choices are derived from the given seed.
+func NewStratifiedSampler(seed int64) *StratifiedSampler {
+	return &StratifiedSampler{samplerState{seed: uint64(seed)}}
+}
+
+func (sampler *StratifiedSampler) Get1D() float64 {
+	dimensionHash := sampler.nextDimensionHash()
+	numStrata := sampler.numSamples
+	if numStrata < 1 {
+		numStrata = 1
+	}
+	stratum := permutationElement(uint32(sampler.sampleIndex%numStrata), uint32(numStrata), uint32(dimensionHash))
+	jitter := hashToFloat(hash(dimensionHash, uint64(sampler.sampleIndex)))
+	return (float64(stratum) + jitter) / float64(numStrata)
+}
+
+func (sampler *StratifiedSampler) Get2D() (float64, float64) {
+	dimensionHash := sampler.nextDimensionHash()
+	sampler.dimension++
+
+	// Divide the square into the largest grid that doesn't have more cells than there are samples.
+	gridSize := int(math.Sqrt(float64(sampler.numSamples)))
+	if gridSize < 1 {
+		gridSize = 1
+	}
+	numCells := gridSize * gridSize
+	cell := int(permutationElement(uint32(sampler.sampleIndex%numCells), uint32(numCells), uint32(dimensionHash)))
+	jitterX := hashToFloat(hash(dimensionHash, uint64(sampler.sampleIndex), 0))
+	jitterY := hashToFloat(hash(dimensionHash, uint64(sampler.sampleIndex), 1))
+	return (float64(cell%gridSize) + jitterX) / float64(gridSize),
+		(float64(cell/gridSize) + jitterY) / float64(gridSize)
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package sampling
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStratifiedSampler(t *testing.T) {
+	sampler := NewStratifiedSampler(0)
+
+	// Each sample should fall within its own stratum of each dimension, and its own cell of the grid for each pair.
+	strata := make(map[int]bool)
+	cells := make(map[[2]int]bool)
+	for i := 0; i < 16; i++ {
+		sampler.StartSample(2, 3, i, 16)
+		u := sampler.Get1D()
+		x, y := sampler.Get2D()
+		strata[int(u*16)] = true
+		cells[[2]int{int(x * 4), int(y * 4)}] = true
+	}
+	assert.Equal(t, 16, len(strata))
+	assert.Equal(t, 16, len(cells))
+
+	// The strata should be assigned differently in each dimension.
+	sampler.StartSample(2, 3, 0, 16)
+	first := int(sampler.Get1D() * 16)
+	sampler.Get2D()
+	different := false
+	for i := 0; i < 4; i++ {
+		different = different || int(sampler.Get1D()*16) != first
+	}
+	assert.True(t, different)
+
+	// A single sample should be anywhere in the square.
+	sampler.StartSample(0, 0, 0, 1)
+	x, y := sampler.Get2D()
+	assert.True(t, x >= 0 && x < 1 && y >= 0 && y < 1)
+}
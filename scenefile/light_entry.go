@@ -0,0 +1,221 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package scenefile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/hdr"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/render"
+)
+
+// Holds the JSON representation of a point light, mirroring the parameters of light.NewPointLight.
+type pointLightEntry struct {
+	Type      string  `json:"type"`
+	Point     triple  `json:"point"`
+	Color     triple  `json:"color"`
+	Intensity float64 `json:"intensity"`
+	Radius    float64 `json:"radius"`
+}
+
+// Holds the JSON representation of a distant light, mirroring the parameters of light.NewDistantLight.
+type distantLightEntry struct {
+	Type               string  `json:"type"`
+	Direction          triple  `json:"direction"`
+	Color              triple  `json:"color"`
+	Intensity          float64 `json:"intensity"`
+	DirectionVariation float64 `json:"directionVariation"`
+}
+
+// Holds the JSON representation of a spot light, mirroring the parameters of light.NewSpotLight.
+type spotLightEntry struct {
+	Type          string  `json:"type"`
+	Point         triple  `json:"point"`
+	Direction     triple  `json:"direction"`
+	Color         triple  `json:"color"`
+	Intensity     float64 `json:"intensity"`
+	InnerAngleDeg float64 `json:"innerAngleDeg"`
+	OuterAngleDeg float64 `json:"outerAngleDeg"`
+	Radius        float64 `json:"radius"`
+}
+
+// Holds the JSON representation of a rectangular area light, mirroring the parameters of light.NewRectLight.
+type rectLightEntry struct {
+	Type             string  `json:"type"`
+	BottomLeftCorner triple  `json:"bottomLeftCorner"`
+	Width            triple  `json:"width"`
+	Height           triple  `json:"height"`
+	Color            triple  `json:"color"`
+	Intensity        float64 `json:"intensity"`
+}
+
+// Holds the JSON representation of a circular area light, mirroring the parameters of light.NewDiscLight.
+type discLightEntry struct {
+	Type      string  `json:"type"`
+	Center    triple  `json:"center"`
+	Width     triple  `json:"width"`
+	Height    triple  `json:"height"`
+	Color     triple  `json:"color"`
+	Intensity float64 `json:"intensity"`
+}
+
+// Holds the JSON representation of a spherical area light, mirroring the parameters of light.NewSphereLight.
+type sphereLightEntry struct {
+	Type      string  `json:"type"`
+	Center    triple  `json:"center"`
+	Radius    float64 `json:"radius"`
+	Color     triple  `json:"color"`
+	Intensity float64 `json:"intensity"`
+}
+
+// Holds the JSON representation of an environment light loaded from an equirectangular image file, mirroring the
+// parameters of light.NewEnvironmentLight.
+type environmentLightEntry struct {
+	Type             string  `json:"type"`
+	Path             string  `json:"path"`
+	ZenithReference  triple  `json:"zenithReference"`
+	AzimuthReference triple  `json:"azimuthReference"`
+	RotationDeg      float64 `json:"rotationDeg"`
+	Intensity        float64 `json:"intensity"`
+}
+
+// Holds the JSON representation of a daylight sky and the sun within it, mirroring the parameters of
+// light.NewSkyLight.
+type skyLightEntry struct {
+	Type             string  `json:"type"`
+	ZenithReference  triple  `json:"zenithReference"`
+	AzimuthReference triple  `json:"azimuthReference"`
+	SunElevationDeg  float64 `json:"sunElevationDeg"`
+	SunAzimuthDeg    float64 `json:"sunAzimuthDeg"`
+	Turbidity        float64 `json:"turbidity"`
+	GroundAlbedo     triple  `json:"groundAlbedo"`
+	Intensity        float64 `json:"intensity"`
+}
+
+// Decodes the given light entry and adds the light it describes to the scene.
+func (parser *sceneParser) addLight(scene *render.Scene, value locatedValue) error {
+	lightType, err := parser.entryType(value)
+	if err != nil {
+		return err
+	}
+
+	switch lightType {
+	case "point":
+		var entry pointLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		pointLight, err := light.NewPointLight(entry.Point.toPoint(), entry.Color.toColor(), entry.Intensity,
+			entry.Radius)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(pointLight)
+	case "distant":
+		var entry distantLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		distantLight, err := light.NewDistantLight(entry.Direction.toVector(), entry.Color.toColor(), entry.Intensity,
+			entry.DirectionVariation)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(distantLight)
+	case "spot":
+		var entry spotLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		spotLight, err := light.NewSpotLight(entry.Point.toPoint(), entry.Direction.toVector(), entry.Color.toColor(),
+			entry.Intensity, entry.InnerAngleDeg, entry.OuterAngleDeg, entry.Radius)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(spotLight)
+	case "rect":
+		var entry rectLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		rectLight, err := light.NewRectLight(entry.BottomLeftCorner.toPoint(), entry.Width.toVector(),
+			entry.Height.toVector(), entry.Color.toColor(), entry.Intensity)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(rectLight)
+	case "disc":
+		var entry discLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		discLight, err := light.NewDiscLight(entry.Center.toPoint(), entry.Width.toVector(), entry.Height.toVector(),
+			entry.Color.toColor(), entry.Intensity)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(discLight)
+	case "sphere":
+		var entry sphereLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		sphereLight, err := light.NewSphereLight(entry.Center.toPoint(), entry.Radius, entry.Color.toColor(),
+			entry.Intensity)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(sphereLight)
+	case "environment":
+		var entry environmentLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		if entry.Path == "" {
+			return parser.errorAt(value, errors.New("environment image path must be specified"))
+		}
+		if hasEnvironment(scene) {
+			return parser.errorAt(value, errors.New("scene can only have one environment"))
+		}
+		img, err := hdr.LoadImage(parser.resolvePath(entry.Path), true)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		environmentLight, err := light.NewEnvironmentLight(img.Pixels, entry.ZenithReference.toVector(),
+			entry.AzimuthReference.toVector(), entry.RotationDeg, entry.Intensity)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(environmentLight)
+	case "sky":
+		var entry skyLightEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return err
+		}
+		if hasEnvironment(scene) {
+			return parser.errorAt(value, errors.New("scene can only have one environment"))
+		}
+		skyLight, err := light.NewSkyLight(entry.ZenithReference.toVector(), entry.AzimuthReference.toVector(),
+			entry.SunElevationDeg, entry.SunAzimuthDeg, entry.Turbidity, entry.GroundAlbedo.toColor(), entry.Intensity)
+		if err != nil {
+			return parser.errorAt(value, err)
+		}
+		scene.AddLight(skyLight)
+		scene.AddLight(skyLight.Sun())
+	default:
+		return parser.errorAt(value, fmt.Errorf("unknown light type %q", lightType))
+	}
+
+	return nil
+}
+
+// Returns whether the given scene already has a light surrounding it, such as an environment or a sky.
+func hasEnvironment(scene *render.Scene) bool {
+	for _, sceneLight := range scene.Lights {
+		if _, ok := sceneLight.(light.InfiniteLight); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,563 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+// Package scenefile loads scenes from JSON files, so that they can be changed without recompiling the raytracer.
+//
+// A scene file is a JSON object with a required "camera" object, an optional "backgroundColor", "shadowSamples",
+// "ditherVariation", "adaptiveSamplingThreshold", "integrator", "sampler", "filter", "fog" and "caustics", and
+// "surfaces" and "lights" arrays. Points, vectors and colors are written as arrays of three numbers. Each surface,
+// light and texture is an object with a "type" field naming its kind, and the remaining fields corresponding to the
+// parameters of its constructor, for example:
+//
+//	{"type": "sphere", "center": [0, 0, 1], "radius": 1, "zenithReference": [0, 0, 1], "azimuthReference": [1, 0, 0],
+//	 "shading": {"diffuseTexture": {"type": "solid", "color": [1, 0, 0]}, "opacity": 1}}
+//
+// Errors are reported with the line and column of the offending entry within the file.
+package scenefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/render"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Represents a point, vector or color as it appears in the scene file.
+type triple [3]float64
+
+// Represents a pair of (U, V) texture coordinate values as it appears in the scene file.
+type pair [2]float64
+
+// Holds the JSON representation of the camera, mirroring the parameters of render.NewCamera.
+type cameraEntry struct {
+	Origin              triple  `json:"origin"`
+	Direction           triple  `json:"direction"`
+	Up                  triple  `json:"up"`
+	HorizontalFovDeg    float64 `json:"horizontalFovDeg"`
+	ApertureRadius      float64 `json:"apertureRadius"`
+	FocalDistance       float64 `json:"focalDistance"`
+	DepthOfFieldSamples int     `json:"depthOfFieldSamples"`
+	AntiAliasSamples    int     `json:"antiAliasSamples"`
+}
+
+// Holds the JSON representation of the scene's integrator, whose type is either "whitted" or "pathTracing".
+type integratorEntry struct {
+	Type            string `json:"type"`
+	SamplesPerPixel int    `json:"samplesPerPixel"`
+	MaxDepth        int    `json:"maxDepth"`
+}
+
+// Holds the JSON representation of the scene's pixel reconstruction filter, whose type is one of "box", "tent",
+// "gaussian", "mitchell" or "lanczos".
+type filterEntry struct {
+	Type   string  `json:"type"`
+	Radius float64 `json:"radius"`
+}
+
+// Holds the JSON representation of the settings for tracing caustic photons.
+type causticsEntry struct {
+	Photons      int     `json:"photons"`
+	GatherRadius float64 `json:"gatherRadius"`
+}
+
+// Represents a single JSON value within the scene file, along with where it came from for the purpose of reporting
+// errors.
+type locatedValue struct {
+	raw    json.RawMessage
+	offset int64  // Byte offset of the start of the value within the file
+	path   string // Description of the value's position in the document, such as "surfaces[2]"
+}
+
+// Holds the state needed while converting the contents of a scene file into a scene.
+type sceneParser struct {
+	data          []byte
+	name          string // Name of the file, for error messages
+	baseDirectory string // Directory against which relative paths within the file are resolved
+}
+
+// Loads the scene described by the JSON file at the given path. Relative paths within the file (such as those of mesh
+// models) are resolved against the directory containing it.
+func Load(path string) (*render.Scene, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, path, filepath.Dir(path))
+}
+
+// Parses the given JSON scene description, using the given name to identify it in error messages and resolving
+// relative paths within it against the given directory.
+func Parse(data []byte, name, baseDirectory string) (*render.Scene, error) {
+	parser := sceneParser{data: data, name: name, baseDirectory: baseDirectory}
+	fields, arrays, err := parser.readTopLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	scene := render.Scene{}
+	cameraValue, ok := fields["camera"]
+	if !ok {
+		return nil, fmt.Errorf("%s: scene must have a camera", name)
+	}
+	var camera cameraEntry
+	if err = parser.decode(cameraValue, &camera); err != nil {
+		return nil, err
+	}
+	scene.Camera, err = render.NewCamera(geometry.Ray{camera.Origin.toPoint(), camera.Direction.toVector()},
+		camera.Up.toVector(), camera.HorizontalFovDeg, camera.ApertureRadius, camera.FocalDistance,
+		camera.DepthOfFieldSamples, camera.AntiAliasSamples)
+	if err != nil {
+		return nil, parser.errorAt(cameraValue, err)
+	}
+
+	if value, ok := fields["backgroundColor"]; ok {
+		var color triple
+		if err = parser.decode(value, &color); err != nil {
+			return nil, err
+		}
+		scene.BackgroundColor = color.toColor()
+	}
+	if value, ok := fields["shadowSamples"]; ok {
+		if err = parser.decode(value, &scene.ShadowSamples); err != nil {
+			return nil, err
+		}
+		if scene.ShadowSamples < 0 {
+			return nil, parser.errorAt(value, errors.New("shadow samples must be non-negative"))
+		}
+	}
+	if value, ok := fields["ditherVariation"]; ok {
+		if err = parser.decode(value, &scene.DitherVariation); err != nil {
+			return nil, err
+		}
+		if scene.DitherVariation < 0 {
+			return nil, parser.errorAt(value, errors.New("dither variation must be non-negative"))
+		}
+	}
+	if value, ok := fields["adaptiveSamplingThreshold"]; ok {
+		if err = parser.decode(value, &scene.AdaptiveSamplingThreshold); err != nil {
+			return nil, err
+		}
+		if scene.AdaptiveSamplingThreshold < 0 {
+			return nil, parser.errorAt(value, errors.New("adaptive sampling threshold must be non-negative"))
+		}
+	}
+
+	if value, ok := fields["integrator"]; ok {
+		var integrator integratorEntry
+		if err = parser.decode(value, &integrator); err != nil {
+			return nil, err
+		}
+		switch integrator.Type {
+		case "whitted":
+			if integrator.SamplesPerPixel != 0 || integrator.MaxDepth != 0 {
+				return nil, parser.errorAt(value, errors.New("whitted integrator has no parameters"))
+			}
+			scene.Integrator = render.WhittedIntegrator{}
+		case "pathTracing":
+			if integrator.SamplesPerPixel < 0 || integrator.MaxDepth < 0 {
+				return nil, parser.errorAt(value, errors.New("samples per pixel and max depth must be non-negative"))
+			}
+			scene.Integrator = render.PathTracingIntegrator{
+				SamplesPerPixel: integrator.SamplesPerPixel,
+				MaxDepth:        integrator.MaxDepth,
+			}
+		default:
+			return nil, parser.errorAt(value, fmt.Errorf("unknown integrator type %q", integrator.Type))
+		}
+	}
+
+	if value, ok := fields["fog"]; ok {
+		var entry mediumEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if scene.Fog, err = parser.medium(value, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := fields["sampler"]; ok {
+		var samplerName string
+		if err = parser.decode(value, &samplerName); err != nil {
+			return nil, err
+		}
+		if scene.Sampler, err = NewSampler(samplerName); err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+	}
+
+	if value, ok := fields["filter"]; ok {
+		var filter filterEntry
+		if err = parser.decode(value, &filter); err != nil {
+			return nil, err
+		}
+		if scene.Filter, err = NewFilter(filter.Type, filter.Radius); err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+	}
+
+	if value, ok := fields["caustics"]; ok {
+		var caustics causticsEntry
+		if err = parser.decode(value, &caustics); err != nil {
+			return nil, err
+		}
+		if caustics.Photons <= 0 || caustics.GatherRadius <= 0 {
+			return nil, parser.errorAt(value, errors.New("caustic photons and gather radius must be positive"))
+		}
+		scene.CausticPhotons = caustics.Photons
+		scene.CausticGatherRadius = caustics.GatherRadius
+	}
+
+	for _, value := range arrays["surfaces"] {
+		if err = parser.addSurfaces(&scene, value); err != nil {
+			return nil, err
+		}
+	}
+	for _, value := range arrays["lights"] {
+		if err = parser.addLight(&scene, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return &scene, nil
+}
+
+// Returns the sampler type with the given name, which is one of "stratified", "halton" or "sobol" as in the scene file,
+// or an error if there is no such type.
+func NewSampler(name string) (sampling.SamplerType, error) {
+	switch name {
+	case "stratified":
+		return sampling.StratifiedSampling, nil
+	case "halton":
+		return sampling.HaltonSampling, nil
+	case "sobol":
+		return sampling.SobolSampling, nil
+	default:
+		return 0, fmt.Errorf("unknown sampler %q", name)
+	}
+}
+
+// Returns a new pixel reconstruction filter of the type with the given name, which is one of "box", "tent",
+// "gaussian", "mitchell" or "lanczos" as in the scene file, and the given radius in pixels (zero for the filter's
+// default), or an error if the parameters are invalid.
+func NewFilter(name string, radius float64) (render.Filter, error) {
+	if radius < 0 {
+		return nil, errors.New("filter radius must be non-negative")
+	}
+	switch name {
+	case "box":
+		return render.BoxFilter{Radius: radius}, nil
+	case "tent":
+		return render.TentFilter{Radius: radius}, nil
+	case "gaussian":
+		return render.GaussianFilter{Radius: radius}, nil
+	case "mitchell":
+		return render.MitchellFilter{Radius: radius}, nil
+	case "lanczos":
+		return render.LanczosFilter{Radius: radius}, nil
+	default:
+		return nil, fmt.Errorf("unknown filter type %q", name)
+	}
+}
+
+// Returns the name by which the given filter's type is known in the scene file, or false if it isn't one of those that
+// NewFilter creates. A scene without a filter uses a box filter.
+func FilterName(filter render.Filter) (string, bool) {
+	switch filter.(type) {
+	case nil, render.BoxFilter:
+		return "box", true
+	case render.TentFilter:
+		return "tent", true
+	case render.GaussianFilter:
+		return "gaussian", true
+	case render.MitchellFilter:
+		return "mitchell", true
+	case render.LanczosFilter:
+		return "lanczos", true
+	default:
+		return "", false
+	}
+}
+
+// Reads the top-level JSON object of the file, returning its scalar fields and the elements of its array fields
+// separately so that each can be located in error messages.
+func (parser *sceneParser) readTopLevel() (map[string]locatedValue, map[string][]locatedValue, error) {
+	decoder := json.NewDecoder(bytes.NewReader(parser.data))
+	fields := make(map[string]locatedValue)
+	arrays := make(map[string][]locatedValue)
+
+	if err := parser.expectDelimiter(decoder, '{', "scene must be a JSON object"); err != nil {
+		return nil, nil, err
+	}
+	for decoder.More() {
+		keyOffset := parser.skipSeparators(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, parser.syntaxError(decoder, err)
+		}
+		key := token.(string)
+		_, isField := fields[key]
+		_, isArray := arrays[key]
+		if isField || isArray {
+			return nil, nil, parser.errorAtOffset(keyOffset, fmt.Errorf("duplicate field %q", key))
+		}
+
+		switch key {
+		case "camera", "backgroundColor", "shadowSamples", "ditherVariation", "adaptiveSamplingThreshold", "integrator",
+			"sampler", "filter", "fog", "caustics":
+			value, err := parser.readValue(decoder, key)
+			if err != nil {
+				return nil, nil, err
+			}
+			fields[key] = value
+		case "surfaces", "lights":
+			if err = parser.expectDelimiter(decoder, '[', fmt.Sprintf("%s must be an array", key)); err != nil {
+				return nil, nil, err
+			}
+			values := []locatedValue{}
+			for decoder.More() {
+				value, err := parser.readValue(decoder, fmt.Sprintf("%s[%d]", key, len(values)))
+				if err != nil {
+					return nil, nil, err
+				}
+				values = append(values, value)
+			}
+			if _, err = decoder.Token(); err != nil {
+				return nil, nil, parser.syntaxError(decoder, err)
+			}
+			arrays[key] = values
+		default:
+			return nil, nil, parser.errorAtOffset(keyOffset, fmt.Errorf("unknown field %q", key))
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, parser.syntaxError(decoder, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, parser.errorAtOffset(decoder.InputOffset(), errors.New("unexpected data after scene object"))
+	}
+
+	return fields, arrays, nil
+}
+
+// Reads the next value from the decoder, recording where in the file it starts.
+func (parser *sceneParser) readValue(decoder *json.Decoder, path string) (locatedValue, error) {
+	offset := parser.skipSeparators(decoder.InputOffset())
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return locatedValue{}, parser.syntaxError(decoder, err)
+	}
+	return locatedValue{raw: raw, offset: offset, path: path}, nil
+}
+
+// Returns the value of the given field of the given object value, located at its own position within the file so that
+// errors in nested entries point at the entry itself rather than at the object containing it.
+func (parser *sceneParser) nestedValue(value locatedValue, key, path string) (locatedValue, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(value.raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return locatedValue{}, false
+	}
+	var nested locatedValue
+	found := false
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return locatedValue{}, false
+		}
+		offset := parser.skipSeparators(value.offset + decoder.InputOffset())
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			return locatedValue{}, false
+		}
+		// Keep looking after a match, since the last of any duplicate fields is the one that takes effect.
+		if token == key {
+			nested = locatedValue{raw: raw, offset: offset, path: path}
+			found = true
+		}
+	}
+	return nested, found
+}
+
+// Returns the elements of the given array value, each located at its own position within the file.
+func (parser *sceneParser) nestedElements(value locatedValue) []locatedValue {
+	decoder := json.NewDecoder(bytes.NewReader(value.raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil
+	}
+	var elements []locatedValue
+	for decoder.More() {
+		offset := parser.skipSeparators(value.offset + decoder.InputOffset())
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil
+		}
+		elements = append(elements,
+			locatedValue{raw: raw, offset: offset, path: fmt.Sprintf("%s[%d]", value.path, len(elements))})
+	}
+	return elements
+}
+
+// Reads the next token from the decoder and returns an error with the given message if it isn't the given delimiter.
+func (parser *sceneParser) expectDelimiter(decoder *json.Decoder, delimiter json.Delim, message string) error {
+	offset := parser.skipSeparators(decoder.InputOffset())
+	token, err := decoder.Token()
+	if err != nil {
+		return parser.syntaxError(decoder, err)
+	}
+	if token != delimiter {
+		return parser.errorAtOffset(offset, errors.New(message))
+	}
+	return nil
+}
+
+// Strictly decodes the given value into the given target, rejecting any fields that the target doesn't have so that
+// typos don't go unnoticed.
+func (parser *sceneParser) decode(value locatedValue, target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(value.raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		if typeError, ok := err.(*json.UnmarshalTypeError); ok {
+			field := typeError.Field
+			if field == "" {
+				field = "value"
+			}
+			err = fmt.Errorf("%s must be %s, not %s", field, describeType(typeError.Type), typeError.Value)
+		} else {
+			// Strip the package prefix from errors such as those for unknown fields, as it means nothing to the user.
+			err = errors.New(strings.TrimPrefix(err.Error(), "json: "))
+		}
+		return parser.errorAt(value, err)
+	}
+	return nil
+}
+
+// Returns the type named by the "type" field of the given object value.
+func (parser *sceneParser) entryType(value locatedValue) (string, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(value.raw, &header); err != nil {
+		return "", parser.errorAt(value, err)
+	}
+	if header.Type == "" {
+		return "", parser.errorAt(value, errors.New("type must be specified"))
+	}
+	return header.Type, nil
+}
+
+// Resolves the given path from the scene file against the directory containing it.
+func (parser *sceneParser) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(parser.baseDirectory, path)
+}
+
+// Returns an error annotated with the location of the given value.
+func (parser *sceneParser) errorAt(value locatedValue, err error) error {
+	line, column := parser.lineAndColumn(value.offset)
+	return fmt.Errorf("%s:%d:%d: %s: %v", parser.name, line, column, value.path, err)
+}
+
+// Returns an error annotated with the location of the given byte offset.
+func (parser *sceneParser) errorAtOffset(offset int64, err error) error {
+	line, column := parser.lineAndColumn(offset)
+	return fmt.Errorf("%s:%d:%d: %v", parser.name, line, column, err)
+}
+
+// Returns an error for a failure to decode the structure of the file, annotated with where it occurred.
+func (parser *sceneParser) syntaxError(decoder *json.Decoder, err error) error {
+	if syntaxError, ok := err.(*json.SyntaxError); ok {
+		return parser.errorAtOffset(syntaxError.Offset, err)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return parser.errorAtOffset(int64(len(parser.data)), errors.New("unexpected end of file"))
+	}
+	return parser.errorAtOffset(decoder.InputOffset(), err)
+}
+
+// Returns the offset of the first character at or after the given offset that isn't whitespace or a separator.
+func (parser *sceneParser) skipSeparators(offset int64) int64 {
+	for offset < int64(len(parser.data)) {
+		switch parser.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// Converts the given byte offset into one-based line and column numbers.
+func (parser *sceneParser) lineAndColumn(offset int64) (int, int) {
+	if offset > int64(len(parser.data)) {
+		offset = int64(len(parser.data))
+	}
+	preceding := parser.data[:offset]
+	line := bytes.Count(preceding, []byte("\n")) + 1
+	column := int(offset) - (bytes.LastIndexByte(preceding, '\n') + 1) + 1
+	return line, column
+}
+
+// Returns a user-friendly description of the JSON value expected for the given Go type.
+func describeType(valueType reflect.Type) string {
+	switch valueType.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Array:
+		return fmt.Sprintf("an array of %d values", valueType.Len())
+	case reflect.Slice:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// Decodes the triple from a JSON array, requiring that it have exactly three elements since the standard decoding of
+// fixed-size arrays would silently zero-fill any that are missing.
+func (t *triple) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != 3 {
+		return fmt.Errorf("expected an array of three numbers but found %s", data)
+	}
+	copy(t[:], values)
+	return nil
+}
+
+// Decodes the pair from a JSON array, requiring that it have exactly two elements for the same reason as for triples.
+func (p *pair) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != 2 {
+		return fmt.Errorf("expected an array of two numbers but found %s", data)
+	}
+	copy(p[:], values)
+	return nil
+}
+
+func (t triple) toPoint() geometry.Point {
+	return geometry.Point{t[0], t[1], t[2]}
+}
+
+func (t triple) toVector() geometry.Vector {
+	return geometry.Vector{t[0], t[1], t[2]}
+}
+
+func (t triple) toColor() shading.Color {
+	return shading.Color{t[0], t[1], t[2]}
+}
@@ -0,0 +1,637 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package scenefile
+
+import (
+	"github.com/patfair/raytracer/example"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/light"
+	"github.com/patfair/raytracer/render"
+	"github.com/patfair/raytracer/sampling"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const minimalCamera = `"camera": {"origin": [0, 0, 0], "direction": [0, 1, 0], "up": [0, 0, 1], "horizontalFovDeg": 40,
+	"apertureRadius": 0, "focalDistance": 1, "depthOfFieldSamples": 1, "antiAliasSamples": 1}`
+
+func TestLoad(t *testing.T) {
+	scene, err := Load("../example/all_elements_scene.json")
+	assert.Nil(t, err)
+
+	// The file should describe exactly the same scene as the hard-coded version.
+	expectedScene, err := example.AllElementsScene(0)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedScene, scene)
+
+	_, err = Load("nonexistent.json")
+	assert.NotNil(t, err)
+}
+
+func TestParse(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"shadowSamples": 16,
+	"ditherVariation": 0.05,
+	"adaptiveSamplingThreshold": 0.002,
+	"integrator": {"type": "pathTracing", "samplesPerPixel": 256},
+	"sampler": "sobol",
+	"filter": {"type": "mitchell", "radius": 1.5},
+	"caustics": {"photons": 100000, "gatherRadius": 0.05},
+	"surfaces": [
+		{"type": "triangle", "vertices": [[0, 0, 0], [1, 0, 0], [0, 1, 0]],
+			"shading": {"diffuseTexture": {"type": "solid", "color": [1, 0, 0]}}},
+		{"type": "triangle", "vertices": [[0, 0, 0], [1, 0, 0], [0, 1, 0]],
+			"normals": [[0, 0, 1], [1, 0, 1], [0, 1, 1]],
+			"shading": {"diffuseTexture": {"type": "solid", "color": [1, 0, 0]}}},
+		{"type": "mesh", "path": "pyramid.obj", "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}},
+		{"type": "instance", "transforms": [{"scale": [2, 1, 1]}, {"rotate": {"axis": [0, 0, 1], "angleDeg": 90}},
+			{"translate": [0, 0, 5]}],
+			"surface": {"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+				"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}}},
+		{"type": "instance", "transforms": [],
+			"surface": {"type": "box", "frontBottomLeftCorner": [0, 0, 0], "width": [1, 0, 0], "height": [0, 1, 0],
+				"depth": 1, "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}}},
+		{"type": "difference", "surfaces": [
+			{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+				"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}},
+			{"type": "box", "frontBottomLeftCorner": [0, -2, -2], "width": [2, 0, 0], "height": [0, 4, 0],
+				"depth": 4, "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}},
+			{"type": "union", "surfaces": [
+				{"type": "sphere", "center": [-1, 0, 0], "radius": 0.5, "zenithReference": [0, 0, 1],
+					"azimuthReference": [1, 0, 0],
+					"shading": {"diffuseTexture": {"type": "solid", "color": [1, 0, 0]}}},
+				{"type": "sphere", "center": [-1, 0, 1], "radius": 0.5, "zenithReference": [0, 0, 1],
+					"azimuthReference": [1, 0, 0],
+					"shading": {"diffuseTexture": {"type": "solid", "color": [0, 0, 1]}}}
+			]}
+		]}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", "../surface/testdata")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 16, scene.ShadowSamples)
+	assert.Equal(t, 0.05, scene.DitherVariation)
+	assert.Equal(t, 0.002, scene.AdaptiveSamplingThreshold)
+	assert.Equal(t, render.PathTracingIntegrator{SamplesPerPixel: 256}, scene.Integrator)
+	assert.Equal(t, sampling.SobolSampling, scene.Sampler)
+	assert.Equal(t, render.MitchellFilter{Radius: 1.5}, scene.Filter)
+	assert.Equal(t, 100000, scene.CausticPhotons)
+	assert.Equal(t, 0.05, scene.CausticGatherRadius)
+	assert.Equal(t, shading.Color{}, scene.BackgroundColor)
+	assert.Empty(t, scene.Lights)
+	if assert.Equal(t, 6, len(scene.Surfaces)) {
+		expectedShadingProperties := shading.ShadingProperties{
+			DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}},
+			Opacity:        1,
+		}
+		expectedTriangle, _ := surface.NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0},
+			geometry.Point{0, 1, 0}, expectedShadingProperties)
+		assert.Equal(t, expectedTriangle, scene.Surfaces[0])
+		expectedTriangle, _ = surface.NewSmoothTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0},
+			geometry.Point{0, 1, 0}, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 1}, geometry.Vector{0, 1, 1},
+			expectedShadingProperties)
+		assert.Equal(t, expectedTriangle, scene.Surfaces[1])
+
+		mesh, ok := scene.Surfaces[2].(surface.Mesh)
+		if assert.True(t, ok) {
+			assert.Equal(t, 6, len(mesh.Triangles()))
+		}
+
+		// Transforms should be applied in the order given.
+		instance, ok := scene.Surfaces[3].(surface.Instance)
+		if assert.True(t, ok) {
+			assert.IsType(t, surface.Sphere{}, instance.Surface())
+			geometry.AssertPointEqual(t, geometry.Point{0, 2, 5},
+				instance.Transform().TransformPoint(geometry.Point{1, 0, 0}))
+		}
+		instance, ok = scene.Surfaces[4].(surface.Instance)
+		if assert.True(t, ok) {
+			group, ok := instance.Surface().(surface.Group)
+			if assert.True(t, ok) {
+				assert.Equal(t, 6, len(group.Surfaces()))
+			}
+			assert.Equal(t, geometry.IdentityMatrix(), instance.Transform())
+		}
+
+		// Solids should be combined from left to right.
+		csg, ok := scene.Surfaces[5].(surface.CsgSolid)
+		if assert.True(t, ok) {
+			assert.Equal(t, surface.CsgDifference, csg.Operation())
+			left, right := csg.Operands()
+			assert.IsType(t, surface.CsgSolid{}, left)
+			assert.Equal(t, surface.CsgDifference, left.(surface.CsgSolid).Operation())
+			_, box := left.(surface.CsgSolid).Operands()
+			assert.IsType(t, surface.SolidBox{}, box)
+			if assert.IsType(t, surface.CsgSolid{}, right) {
+				assert.Equal(t, surface.CsgUnion, right.(surface.CsgSolid).Operation())
+			}
+		}
+	}
+}
+
+func TestParseImageTexture(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "image", "path": "texture.png",
+				"mapping": "spherical", "addressing": "mirror", "filter": "nearest", "scale": [2, 3],
+				"offset": [0.5, 0.25]}}},
+		{"type": "plane", "bottomLeftCorner": [0, 0, 0], "width": [1, 0, 0], "height": [0, 1, 0],
+			"shading": {"diffuseTexture": {"type": "image", "path": "texture.png"}}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", "../surface/testdata")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(scene.Surfaces)) {
+		texture, ok := scene.Surfaces[0].Material().(shading.ShadingProperties).DiffuseTexture.(shading.ImageTexture)
+		if assert.True(t, ok) {
+			assert.Equal(t, shading.SphericalMapping, texture.Mapping)
+			assert.Equal(t, shading.MirrorAddressing, texture.Addressing)
+			assert.Equal(t, shading.NearestFilter, texture.Filter)
+			assert.Equal(t, 2.0, texture.UScale)
+			assert.Equal(t, 3.0, texture.VScale)
+			assert.Equal(t, 0.5, texture.UOffset)
+			assert.Equal(t, 0.25, texture.VOffset)
+		}
+
+		// The defaults should tile the image once per unit along each axis of the plane.
+		texture, ok = scene.Surfaces[1].Material().(shading.ShadingProperties).DiffuseTexture.(shading.ImageTexture)
+		if assert.True(t, ok) {
+			assert.Equal(t, shading.UvMapping, texture.Mapping)
+			assert.Equal(t, shading.RepeatAddressing, texture.Addressing)
+			assert.Equal(t, shading.BilinearFilter, texture.Filter)
+			assert.Equal(t, shading.Color{1, 0, 0}, texture.AlbedoAt(0.25, 0.75, 0, nil))
+			assert.Equal(t, shading.Color{1, 1, 1}, texture.AlbedoAt(1.75, -0.75, 0, nil))
+		}
+	}
+}
+
+func TestParseNoiseTexture(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "noise", "pattern": "marble",
+				"space": "point", "scale": 4, "octaves": 6, "lacunarity": 2.5, "gain": 0.4, "distortion": 3,
+				"ramp": [{"position": 0, "color": [1, 1, 1]}, {"position": 1, "color": [0.2, 0.2, 0.3]}]}}},
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "noise", "scale": 1,
+				"ramp": [{"position": 0, "color": [0, 0, 0]}]}}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(scene.Surfaces)) {
+		assert.Equal(t, shading.NoiseTexture{
+			Pattern:    shading.MarblePattern,
+			Space:      shading.PointSpace,
+			Scale:      4,
+			Octaves:    6,
+			Lacunarity: 2.5,
+			Gain:       0.4,
+			Distortion: 3,
+			Ramp:       shading.ColorRamp{{0, shading.Color{1, 1, 1}}, {1, shading.Color{0.2, 0.2, 0.3}}},
+		}, scene.Surfaces[0].Material().(shading.ShadingProperties).DiffuseTexture)
+		assert.Equal(t, shading.NoiseTexture{
+			Pattern:    shading.PerlinPattern,
+			Space:      shading.UvSpace,
+			Scale:      1,
+			Lacunarity: 2,
+			Gain:       0.5,
+			Ramp:       shading.ColorRamp{{0, shading.Color{0, 0, 0}}},
+		}, scene.Surfaces[1].Material().(shading.ShadingProperties).DiffuseTexture)
+	}
+}
+
+func TestParseNormalPerturbation(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "plane", "bottomLeftCorner": [0, 0, 0], "width": [1, 0, 0], "height": [0, 1, 0],
+			"shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}, "bumpScale": 0.1,
+				"bumpMap": {"type": "noise", "scale": 2, "ramp": [{"position": 0, "color": [1, 1, 1]}]}}},
+		{"type": "plane", "bottomLeftCorner": [0, 0, 0], "width": [1, 0, 0], "height": [0, 1, 0],
+			"shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]},
+				"normalMap": {"type": "image", "path": "texture.png"}}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", "../surface/testdata")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(scene.Surfaces)) {
+		shadingProperties := scene.Surfaces[0].Material().(shading.ShadingProperties)
+		assert.IsType(t, shading.NoiseTexture{}, shadingProperties.BumpMap)
+		assert.Equal(t, 0.1, shadingProperties.BumpScale)
+		assert.Nil(t, shadingProperties.NormalMap)
+
+		shadingProperties = scene.Surfaces[1].Material().(shading.ShadingProperties)
+		assert.Nil(t, shadingProperties.BumpMap)
+		normalMap, ok := shadingProperties.NormalMap.(shading.ImageTexture)
+		if assert.True(t, ok) {
+			assert.Equal(t, shading.Color{0, 0, 1}, normalMap.AlbedoAt(0.25, 0.25, 0, nil))
+			assert.Equal(t, shading.Color{0, 1, 0}, normalMap.AlbedoAt(0.75, 0.75, 0, nil))
+		}
+	}
+}
+
+func TestParseMicrofacetShading(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"type": "microfacet",
+				"baseColorTexture": {"type": "solid", "color": [1, 0.8, 0.3]}, "roughness": 0.3, "metallic": 1}},
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]},
+				"specularExponent": 10}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(scene.Surfaces)) {
+		assert.Equal(t, shading.MicrofacetMaterial{
+			BaseColorTexture: shading.SolidTexture{shading.Color{1, 0.8, 0.3}},
+			Roughness:        0.3,
+			Metallic:         1,
+		}, scene.Surfaces[0].Material())
+		assert.IsType(t, shading.ShadingProperties{}, scene.Surfaces[1].Material())
+	}
+}
+
+func TestParseEmitterShading(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"type": "emitter", "color": [1, 0.5, 0], "strength": 3}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(scene.Surfaces)) {
+		assert.Equal(t, shading.DiffuseEmitter{Color: shading.Color{1, 0.5, 0}, Strength: 3},
+			scene.Surfaces[0].Material())
+	}
+}
+
+func TestParseDielectricShading(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"type": "dielectric", "refractiveIndex": 1.5,
+				"absorptionColor": [0.2, 0.6, 0.8], "absorptionDistance": 2}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(scene.Surfaces)) {
+		expectedMaterial := shading.DielectricMaterial{
+			RefractiveIndex:    1.5,
+			AbsorptionColor:    shading.Color{0.2, 0.6, 0.8},
+			AbsorptionDistance: 2,
+		}
+		assert.Equal(t, expectedMaterial, scene.Surfaces[0].Material())
+	}
+}
+
+func TestParseLights(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"lights": [
+		{"type": "rect", "bottomLeftCorner": [-1, 5, -1], "width": [2, 0, 0], "height": [0, 0, 2],
+			"color": [1, 0.9, 0.8], "intensity": 100},
+		{"type": "disc", "center": [0, 5, 0], "width": [1, 0, 0], "height": [0, 0, 1], "color": [1, 1, 1],
+			"intensity": 50},
+		{"type": "sphere", "center": [0, 5, 0], "radius": 0.5, "color": [1, 1, 1], "intensity": 25},
+		{"type": "spot", "point": [0, 0, 5], "direction": [0, 0, -1], "color": [1, 1, 1], "intensity": 100,
+			"innerAngleDeg": 15, "outerAngleDeg": 25, "radius": 0.1}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 4, len(scene.Lights)) {
+		expectedRectLight, _ := light.NewRectLight(geometry.Point{-1, 5, -1}, geometry.Vector{2, 0, 0},
+			geometry.Vector{0, 0, 2}, shading.Color{1, 0.9, 0.8}, 100)
+		assert.Equal(t, expectedRectLight, scene.Lights[0])
+		expectedDiscLight, _ := light.NewDiscLight(geometry.Point{0, 5, 0}, geometry.Vector{1, 0, 0},
+			geometry.Vector{0, 0, 1}, shading.Color{1, 1, 1}, 50)
+		assert.Equal(t, expectedDiscLight, scene.Lights[1])
+		expectedSphereLight, _ := light.NewSphereLight(geometry.Point{0, 5, 0}, 0.5, shading.Color{1, 1, 1}, 25)
+		assert.Equal(t, expectedSphereLight, scene.Lights[2])
+		expectedSpotLight, _ := light.NewSpotLight(geometry.Point{0, 0, 5}, geometry.Vector{0, 0, -1},
+			shading.Color{1, 1, 1}, 100, 15, 25, 0.1)
+		assert.Equal(t, expectedSpotLight, scene.Lights[3])
+	}
+}
+
+func TestParseEnvironmentLight(t *testing.T) {
+	data := `{
+	` + minimalCamera + `,
+	"lights": [
+		{"type": "environment", "path": "texture.png", "zenithReference": [0, 0, 1], "azimuthReference": [1, 0, 0],
+			"rotationDeg": 90, "intensity": 2}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", "../surface/testdata")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(scene.Lights)) {
+		environmentLight, ok := scene.Lights[0].(light.EnvironmentLight)
+		if assert.True(t, ok) {
+			assert.Greater(t, environmentLight.Radiance(geometry.Vector{1, 0, 0}).MaxComponent(), 0.0)
+		}
+	}
+
+	environmentJson := `{"type": "environment", "path": "texture.png", "zenithReference": [0, 0, 1],
+		"azimuthReference": [1, 0, 0], "intensity": 1}`
+	data = "{" + minimalCamera + ", \"lights\": [" + environmentJson + ", " + environmentJson + "]}"
+	_, err = Parse([]byte(data), "test.json", "../surface/testdata")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "lights[1]: scene can only have one environment")
+	}
+}
+
+func TestParseSkyLight(t *testing.T) {
+	skyJson := `{"type": "sky", "zenithReference": [0, 0, 1], "azimuthReference": [0, 1, 0], "sunElevationDeg": 30,
+		"sunAzimuthDeg": 90, "turbidity": 3, "groundAlbedo": [0.2, 0.2, 0.2], "intensity": 0.01}`
+	scene, err := Parse([]byte("{"+minimalCamera+", \"lights\": ["+skyJson+"]}"), "test.json", ".")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(scene.Lights)) {
+		skyLight, ok := scene.Lights[0].(light.SkyLight)
+		if assert.True(t, ok) {
+			assert.Greater(t, skyLight.Radiance(geometry.Vector{0, 0, 1}).B, 0.0)
+			assert.Equal(t, skyLight.Sun(), scene.Lights[1])
+		}
+	}
+
+	environmentJson := `{"type": "environment", "path": "texture.png", "zenithReference": [0, 0, 1],
+		"azimuthReference": [1, 0, 0], "intensity": 1}`
+	data := "{" + minimalCamera + ", \"lights\": [" + environmentJson + ", " + skyJson + "]}"
+	_, err = Parse([]byte(data), "test.json", "../surface/testdata")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "lights[1]: scene can only have one environment")
+	}
+}
+
+func TestParseMedia(t *testing.T) {
+	directory, err := ioutil.TempDir("", "scenefile")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "smoke.raw"), []byte{0, 255}, 0644))
+
+	data := `{
+	` + minimalCamera + `,
+	"fog": {"absorption": [0.01, 0.01, 0.01], "scattering": [0.02, 0.02, 0.03], "asymmetry": 0.5},
+	"surfaces": [
+		{"type": "sphere", "center": [0, 0, 0], "radius": 1, "zenithReference": [0, 0, 1],
+			"azimuthReference": [1, 0, 0], "shading": {"type": "medium", "scattering": [1, 1, 1], "density": {
+				"path": "smoke.raw", "resolution": [2, 1, 1], "min": [-1, -1, -1], "max": [1, 1, 1]}}}
+	]
+}`
+	scene, err := Parse([]byte(data), "test.json", directory)
+	assert.Nil(t, err)
+	if assert.NotNil(t, scene.Fog) {
+		assert.Equal(t, shading.Color{0.02, 0.02, 0.03}, scene.Fog.Scattering)
+		assert.Equal(t, 0.5, scene.Fog.Asymmetry)
+	}
+	if assert.Equal(t, 1, len(scene.Surfaces)) {
+		medium := scene.Surfaces[0].Material().(shading.MediumBoundary).Medium
+		if assert.NotNil(t, medium) && assert.NotNil(t, medium.Density) {
+			assert.Equal(t, shading.Color{1, 1, 1}, medium.Scattering)
+			assert.Equal(t, 1.0, medium.Density.DensityAt(geometry.Point{0.5, 0, 0}))
+		}
+	}
+
+	_, err = Parse([]byte("{"+minimalCamera+", \"fog\": {\"asymmetry\": 1}}"), "test.json", directory)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "fog: medium asymmetry must be in (-1, 1)")
+	}
+	data = "{" + minimalCamera + ", \"fog\": {\"density\": {\"path\": \"smoke.raw\", \"resolution\": [3, 1, 1], " +
+		"\"min\": [0, 0, 0], \"max\": [1, 1, 1]}}}"
+	_, err = Parse([]byte(data), "test.json", directory)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "fog: density grid file")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	shadingJson := `"shading": {"diffuseTexture": {"type": "solid", "color": [1, 1, 1]}}`
+	testCases := []struct {
+		data          string
+		expectedError string
+	}{
+		{`[]`, "test.json:1:1: scene must be a JSON object"},
+		{`{"camera": {`, "test.json:1:13: unexpected end of file"},
+		{`{"surfaces": []}`, "test.json: scene must have a camera"},
+		{"{\n  \"cameras\": {}}", "test.json:2:3: unknown field \"cameras\""},
+		{"{\n\"camera\": {\"origin\": [0, 0, 0], \"direction\": [0, 1, 0], \"up\": [0, 1, 1]}}",
+			"test.json:2:11: camera: camera view and up direction vectors must be perpendicular"},
+		{"{" + minimalCamera + ", \"camera\": {}}", "duplicate field \"camera\""},
+		{"{" + minimalCamera + ", \"shadowSamples\": -1}", "shadowSamples: shadow samples must be non-negative"},
+		{"{" + minimalCamera + ", \"adaptiveSamplingThreshold\": -0.1}",
+			"adaptiveSamplingThreshold: adaptive sampling threshold must be non-negative"},
+		{"{" + minimalCamera + ", \"surfaces\": {}}", "surfaces must be an array"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [\n  {\"type\": \"plane\"," + shadingJson + "},\n  " +
+			"{\"type\": \"plane\", " +
+			"\"bottomLeftCorner\": [0, 0, 0], \"width\": [1, 0, 0], \"height\": [1, 1, 0], " + shadingJson + "}\n]}",
+			"test.json:5:3: surfaces[1]: plane width and height must be perpendicular"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"cone\"}]}",
+			"test.json:3:14: surfaces[0]: unknown surface type \"cone\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"radius\": 1}]}",
+			"test.json:3:14: surfaces[0]: type must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radiu\": 1}]}",
+			"surfaces[0]: unknown field \"radiu\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": \"1\"}]}",
+			"surfaces[0]: radius must be a number, not string"},
+		{"{\"camera\": {\"origin\": [0, 0]}}", "camera: expected an array of three numbers but found [0, 0]"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1}]}",
+			"surfaces[0]: diffuse texture must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"checkerboard\", \"color1\": [1, 1, 1]}}}]}",
+			"surfaces[0].shading.diffuseTexture: checkerboard pitch must be positive"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, \"shading\": {\n" +
+			"  \"opacity\": 1,\n  \"diffuseTexture\": {\"type\": \"solid\", \"colour\": [1, 1, 1]}}}]}",
+			"test.json:5:21: surfaces[0].shading.diffuseTexture: unknown field \"colour\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"marble\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown texture type \"marble\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: image texture path must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"missing.png\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: open missing.png: no such file or directory"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"texture.png\", " +
+			"\"mapping\": \"cubic\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown texture mapping \"cubic\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"texture.png\", " +
+			"\"addressing\": \"wrap\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown texture addressing \"wrap\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"texture.png\", " +
+			"\"filter\": \"trilinear\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown texture filter \"trilinear\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"texture.png\", " +
+			"\"scale\": [1, 0]}}}]}",
+			"surfaces[0].shading.diffuseTexture: texture scale must be non-zero"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"image\", \"path\": \"texture.png\", " +
+			"\"offset\": [1]}}}]}",
+			"surfaces[0].shading.diffuseTexture: expected an array of two numbers but found [1]"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\", \"pattern\": \"granite\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown noise pattern \"granite\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\", \"space\": \"object\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: unknown noise space \"object\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\"}}}]}",
+			"surfaces[0].shading.diffuseTexture: noise scale must be positive"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\", \"scale\": 1, \"octaves\": -1}}}]}",
+			"surfaces[0].shading.diffuseTexture: noise octaves must be non-negative"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\", \"scale\": 1}}}]}",
+			"surfaces[0].shading.diffuseTexture: color ramp must have at least one color"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"noise\", \"scale\": 1, \"ramp\": " +
+			"[{\"position\": 1, \"color\": [1, 1, 1]}, {\"position\": 0, \"color\": [0, 0, 0]}]}}}]}",
+			"surfaces[0].shading.diffuseTexture: color ramp positions must be in increasing order"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"solid\"}, \"normalMap\": {\"type\": \"image\"}}}]}",
+			"surfaces[0].shading.normalMap: image texture path must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"diffuseTexture\": {\"type\": \"solid\"}, \"bumpMap\": {\"type\": \"solid\"}, " +
+			"\"normalMap\": {\"type\": \"solid\"}}}]}",
+			"surfaces[0]: bump map and normal map cannot both be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"blinn\", \"diffuseTexture\": {\"type\": \"solid\"}}}]}",
+			"surfaces[0].shading: unknown shading type \"blinn\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"microfacet\", \"diffuseTexture\": {\"type\": \"solid\"}}}]}",
+			"surfaces[0].shading: unknown field \"diffuseTexture\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"microfacet\", \"roughness\": 0.5}}]}",
+			"surfaces[0]: base color texture must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"microfacet\", \"baseColorTexture\": {\"type\": \"solid\"}, " +
+			"\"roughness\": 2}}]}",
+			"surfaces[0]: roughness must be in [0, 1]"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"emitter\", \"color\": [1, 1, 1]}}]}",
+			"surfaces[0]: emission strength must be positive"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"sphere\", \"radius\": 1, " +
+			"\"shading\": {\"type\": \"dielectric\", \"refractiveIndex\": 1.5, \"absorptionDistance\": -1}}]}",
+			"surfaces[0]: absorption distance must be non-negative"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"mesh\", \"path\": \"pyramid.obj\", " +
+			"\"shading\": {\"type\": \"microfacet\", \"baseColorTexture\": {\"type\": \"solid\"}}}]}",
+			"surfaces[0]: mesh shading must use the phong type"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"mesh\", " + shadingJson + "}]}",
+			"surfaces[0]: mesh path must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\"}]}",
+			"surfaces[0]: instanced surface must be specified"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\",\n  \"surface\": {\"type\": \"cone\"}}]}",
+			"test.json:4:14: surfaces[0].surface: unknown surface type \"cone\""},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\", \"transforms\": [{}], " +
+			"\"surface\": {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "}}]}",
+			"surfaces[0].transforms[0]: transform must have exactly one of translate, scale or rotate"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\", " +
+			"\"transforms\": [{\"rotate\": {\"axis\": [0, 0, 0], \"angleDeg\": 90}}], " +
+			"\"surface\": {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "}}]}",
+			"surfaces[0].transforms[0]: rotation axis must be non-zero"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\",\n" +
+			"\"transforms\": [{\"scale\": [2, 2, 2]},\n  {\"translate\": [1, 1, 1], \"scale\": [2, 2, 2]}],\n" +
+			"  \"surface\": {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "}}]}",
+			"test.json:5:3: surfaces[0].transforms[1]: transform must have exactly one"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"instance\", \"transforms\": [{\"scale\": [1, 0, 1]}], " +
+			"\"surface\": {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "}}]}",
+			"surfaces[0]: matrix is not invertible"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"union\", \"surfaces\": [{\"type\": \"sphere\", " +
+			"\"radius\": 1, " + shadingJson + "}]}]}",
+			"surfaces[0]: union must combine at least two surfaces"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"intersection\", \"surfaces\": [{\"type\": \"sphere\", " +
+			"\"radius\": 1, " + shadingJson + "}, {\"type\": \"plane\", \"bottomLeftCorner\": [0, 0, 0], " +
+			"\"width\": [1, 0, 0], \"height\": [0, 1, 0], " + shadingJson + "}]}]}",
+			"surfaces[0].surfaces[1]: plane does not enclose a volume and cannot be combined"},
+		{"{" + minimalCamera + ",\n\"surfaces\": [{\"type\": \"union\", \"surfaces\": [\n" +
+			"  {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "},\n  {\"type\": \"union\", \"surfaces\": [\n" +
+			"    {\"type\": \"sphere\", \"radius\": 1, " + shadingJson + "},\n    {\"type\": \"cone\"}]}]}]}",
+			"test.json:7:5: surfaces[0].surfaces[1].surfaces[1]: unknown surface type \"cone\""},
+		{"{" + minimalCamera + ",\n\"lights\": [\n  {\"type\": \"point\", \"intensity\": 0}]}",
+			"test.json:4:3: lights[0]: intensity must be positive"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"rect\", \"width\": [1, 0, 0], \"height\": [1, 1, 0], " +
+			"\"intensity\": 1}]}", "lights[0]: light width and height must be perpendicular"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"sphere\", \"intensity\": 1}]}",
+			"lights[0]: radius must be positive"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"spot\", \"direction\": [0, 0, -1], \"intensity\": 1, " +
+			"\"innerAngleDeg\": 30, \"outerAngleDeg\": 20}]}", "lights[0]: cone angles must satisfy"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"environment\", \"intensity\": 1}]}",
+			"lights[0]: environment image path must be specified"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"environment\", \"path\": \"missing.hdr\", " +
+			"\"intensity\": 1}]}", "lights[0]: open missing.hdr"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"sky\", \"zenithReference\": [0, 0, 1], " +
+			"\"azimuthReference\": [0, 1, 0], \"sunElevationDeg\": 30, \"intensity\": 1}]}",
+			"lights[0]: turbidity must be in [2, 10]"},
+		{"{" + minimalCamera + ",\n\"lights\": [{\"type\": \"laser\"}]}", "lights[0]: unknown light type \"laser\""},
+		{"{" + minimalCamera + ", \"integrator\": {\"type\": \"photon\"}}", "unknown integrator type \"photon\""},
+		{"{" + minimalCamera + ", \"integrator\": {\"type\": \"whitted\", \"maxDepth\": 3}}",
+			"integrator: whitted integrator has no parameters"},
+		{"{" + minimalCamera + ", \"sampler\": \"random\"}", "sampler: unknown sampler \"random\""},
+		{"{" + minimalCamera + ", \"filter\": {\"type\": \"sinc\"}}", "filter: unknown filter type \"sinc\""},
+		{"{" + minimalCamera + ", \"filter\": {\"type\": \"box\", \"radius\": -1}}",
+			"filter: filter radius must be non-negative"},
+		{"{" + minimalCamera + ", \"caustics\": {\"photons\": 1000}}",
+			"caustics: caustic photons and gather radius must be positive"},
+		{"{" + minimalCamera + "} {}", "unexpected data after scene object"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Parse([]byte(testCase.data), "test.json", ".")
+		if assert.NotNil(t, err, testCase.data) {
+			assert.Contains(t, err.Error(), testCase.expectedError)
+		}
+	}
+}
+
+func TestNewSamplerAndFilter(t *testing.T) {
+	sampler, err := NewSampler("halton")
+	assert.Nil(t, err)
+	assert.Equal(t, sampling.HaltonSampling, sampler)
+	_, err = NewSampler("random")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unknown sampler \"random\"", err.Error())
+	}
+
+	filter, err := NewFilter("mitchell", 2.5)
+	assert.Nil(t, err)
+	assert.Equal(t, render.MitchellFilter{Radius: 2.5}, filter)
+	_, err = NewFilter("sinc", 1)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unknown filter type \"sinc\"", err.Error())
+	}
+	_, err = NewFilter("box", -1)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "filter radius must be non-negative", err.Error())
+	}
+
+	// Each filter's name should give back a filter of the same type.
+	for _, name := range []string{"box", "tent", "gaussian", "mitchell", "lanczos"} {
+		filter, _ := NewFilter(name, 1)
+		filterName, ok := FilterName(filter)
+		assert.True(t, ok)
+		assert.Equal(t, name, filterName)
+	}
+	filterName, ok := FilterName(nil)
+	assert.True(t, ok)
+	assert.Equal(t, "box", filterName)
+}
@@ -0,0 +1,392 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package scenefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/hdr"
+	"github.com/patfair/raytracer/shading"
+)
+
+// Holds the JSON representation of a surface's Phong shading properties. The opacity defaults to 1 (fully opaque) if
+// omitted, since that is what's wanted for the vast majority of surfaces.
+type shadingEntry struct {
+	Type              string          `json:"type"`
+	DiffuseTexture    json.RawMessage `json:"diffuseTexture"`
+	SpecularExponent  float64         `json:"specularExponent"`
+	SpecularIntensity float64         `json:"specularIntensity"`
+	Opacity           *float64        `json:"opacity"`
+	Reflectivity      float64         `json:"reflectivity"`
+	RefractiveIndex   float64         `json:"refractiveIndex"`
+	BumpMap           json.RawMessage `json:"bumpMap"`
+	BumpScale         float64         `json:"bumpScale"`
+	NormalMap         json.RawMessage `json:"normalMap"`
+}
+
+// Holds the JSON representation of a surface's microfacet shading properties.
+type microfacetEntry struct {
+	Type             string          `json:"type"`
+	BaseColorTexture json.RawMessage `json:"baseColorTexture"`
+	Roughness        float64         `json:"roughness"`
+	Metallic         float64         `json:"metallic"`
+}
+
+// Holds the JSON representation of a surface that glows with light of the given color, multiplied by the strength.
+type emitterEntry struct {
+	Type     string  `json:"type"`
+	Color    triple  `json:"color"`
+	Strength float64 `json:"strength"`
+}
+
+// Holds the JSON representation of a clear material such as glass, which absorbs light passing through the inside of
+// it if given an absorption color and distance.
+type dielectricEntry struct {
+	Type               string  `json:"type"`
+	RefractiveIndex    float64 `json:"refractiveIndex"`
+	AbsorptionColor    triple  `json:"absorptionColor"`
+	AbsorptionDistance float64 `json:"absorptionDistance"`
+}
+
+// Holds the JSON representation of a participating medium, whose density is one everywhere unless given by a grid.
+type mediumEntry struct {
+	Absorption triple            `json:"absorption"`
+	Scattering triple            `json:"scattering"`
+	Asymmetry  float64           `json:"asymmetry"`
+	Density    *densityGridEntry `json:"density"`
+}
+
+// Holds the JSON representation of an invisible surface filled with a participating medium.
+type mediumBoundaryEntry struct {
+	Type string `json:"type"`
+	mediumEntry
+}
+
+// Holds the JSON representation of a grid of densities loaded from a raw file, filling the box between the given
+// corners.
+type densityGridEntry struct {
+	Path       string `json:"path"`
+	Resolution [3]int `json:"resolution"`
+	Min        triple `json:"min"`
+	Max        triple `json:"max"`
+}
+
+// Holds the JSON representation of a solid texture.
+type solidTextureEntry struct {
+	Type  string `json:"type"`
+	Color triple `json:"color"`
+}
+
+// Holds the JSON representation of a checkerboard texture.
+type checkerboardTextureEntry struct {
+	Type   string  `json:"type"`
+	Color1 triple  `json:"color1"`
+	Color2 triple  `json:"color2"`
+	UPitch float64 `json:"uPitch"`
+	VPitch float64 `json:"vPitch"`
+}
+
+// Holds the JSON representation of an image texture, whose mapping ("uv", "spherical" or "polar"), addressing
+// ("repeat", "clamp" or "mirror") and filter ("bilinear" or "nearest") default to the first option if omitted.
+type imageTextureEntry struct {
+	Type       string `json:"type"`
+	Path       string `json:"path"`
+	Mapping    string `json:"mapping"`
+	Addressing string `json:"addressing"`
+	Filter     string `json:"filter"`
+	Scale      *pair  `json:"scale"`
+	Offset     pair   `json:"offset"`
+}
+
+// Holds the JSON representation of a procedural noise texture, whose pattern ("perlin", "fbm", "turbulence", "marble"
+// or "wood") and space ("uv" or "point") default to the first option if omitted. The lacunarity and gain default to 2
+// and 0.5 respectively, which give the conventional halving of each successive octave.
+type noiseTextureEntry struct {
+	Type       string           `json:"type"`
+	Pattern    string           `json:"pattern"`
+	Space      string           `json:"space"`
+	Scale      float64          `json:"scale"`
+	Octaves    int              `json:"octaves"`
+	Lacunarity *float64         `json:"lacunarity"`
+	Gain       *float64         `json:"gain"`
+	Distortion float64          `json:"distortion"`
+	Ramp       []colorStopEntry `json:"ramp"`
+}
+
+// Holds the JSON representation of a single color within a noise texture's color ramp.
+type colorStopEntry struct {
+	Position float64 `json:"position"`
+	Color    triple  `json:"color"`
+}
+
+// Maps the names used in image texture and noise texture entries onto the corresponding options.
+var (
+	textureMappings = map[string]shading.TextureMapping{
+		"": shading.UvMapping, "uv": shading.UvMapping, "spherical": shading.SphericalMapping,
+		"polar": shading.PolarMapping,
+	}
+	textureAddressings = map[string]shading.TextureAddressing{
+		"": shading.RepeatAddressing, "repeat": shading.RepeatAddressing, "clamp": shading.ClampAddressing,
+		"mirror": shading.MirrorAddressing,
+	}
+	textureFilters = map[string]shading.TextureFilter{
+		"": shading.BilinearFilter, "bilinear": shading.BilinearFilter, "nearest": shading.NearestFilter,
+	}
+	noisePatterns = map[string]shading.NoisePattern{
+		"": shading.PerlinPattern, "perlin": shading.PerlinPattern, "fbm": shading.FbmPattern,
+		"turbulence": shading.TurbulencePattern, "marble": shading.MarblePattern, "wood": shading.WoodPattern,
+	}
+	noiseSpaces = map[string]shading.NoiseSpace{"": shading.UvSpace, "uv": shading.UvSpace, "point": shading.PointSpace}
+)
+
+// Decodes the shading entry of the given surface value into the material it describes. The type ("phong", "microfacet",
+// "emitter", "dielectric" or "medium") defaults to the first option if omitted.
+func (parser *sceneParser) material(value locatedValue) (shading.Material, error) {
+	shadingValue, ok := parser.nestedValue(value, "shading", value.path+".shading")
+	if !ok {
+		return nil, parser.errorAt(value, errors.New("diffuse texture must be specified"))
+	}
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(shadingValue.raw, &header); err != nil {
+		return nil, parser.errorAt(shadingValue, err)
+	}
+
+	switch header.Type {
+	case "", "phong":
+		return parser.shadingProperties(value, shadingValue)
+	case "microfacet":
+		var entry microfacetEntry
+		if err := parser.decode(shadingValue, &entry); err != nil {
+			return nil, err
+		}
+		if entry.BaseColorTexture == nil {
+			return nil, parser.errorAt(value, errors.New("base color texture must be specified"))
+		}
+		textureValue, _ := parser.nestedValue(shadingValue, "baseColorTexture", shadingValue.path+".baseColorTexture")
+		texture, err := parser.texture(textureValue, true)
+		if err != nil {
+			return nil, err
+		}
+		return shading.MicrofacetMaterial{
+			BaseColorTexture: texture,
+			Roughness:        entry.Roughness,
+			Metallic:         entry.Metallic,
+		}, nil
+	case "emitter":
+		var entry emitterEntry
+		if err := parser.decode(shadingValue, &entry); err != nil {
+			return nil, err
+		}
+		return shading.DiffuseEmitter{Color: entry.Color.toColor(), Strength: entry.Strength}, nil
+	case "dielectric":
+		var entry dielectricEntry
+		if err := parser.decode(shadingValue, &entry); err != nil {
+			return nil, err
+		}
+		return shading.DielectricMaterial{
+			RefractiveIndex:    entry.RefractiveIndex,
+			AbsorptionColor:    entry.AbsorptionColor.toColor(),
+			AbsorptionDistance: entry.AbsorptionDistance,
+		}, nil
+	case "medium":
+		var entry mediumBoundaryEntry
+		if err := parser.decode(shadingValue, &entry); err != nil {
+			return nil, err
+		}
+		medium, err := parser.medium(value, entry.mediumEntry)
+		if err != nil {
+			return nil, err
+		}
+		return shading.MediumBoundary{Medium: medium}, nil
+	default:
+		return nil, parser.errorAt(shadingValue, fmt.Errorf("unknown shading type %q", header.Type))
+	}
+}
+
+// Decodes the given Phong shading entry belonging to the given surface value into shading properties.
+func (parser *sceneParser) shadingProperties(value, shadingValue locatedValue) (shading.ShadingProperties, error) {
+	var entry shadingEntry
+	if err := parser.decode(shadingValue, &entry); err != nil {
+		return shading.ShadingProperties{}, err
+	}
+	shadingProperties := shading.ShadingProperties{
+		SpecularExponent:  entry.SpecularExponent,
+		SpecularIntensity: entry.SpecularIntensity,
+		Opacity:           1,
+		Reflectivity:      entry.Reflectivity,
+		RefractiveIndex:   entry.RefractiveIndex,
+	}
+	if entry.Opacity != nil {
+		shadingProperties.Opacity = *entry.Opacity
+	}
+
+	if entry.DiffuseTexture == nil {
+		return shading.ShadingProperties{}, parser.errorAt(value, errors.New("diffuse texture must be specified"))
+	}
+	textureValue, _ := parser.nestedValue(shadingValue, "diffuseTexture", shadingValue.path+".diffuseTexture")
+	texture, err := parser.texture(textureValue, true)
+	if err != nil {
+		return shading.ShadingProperties{}, err
+	}
+	shadingProperties.DiffuseTexture = texture
+
+	// Bump and normal maps hold data rather than colors, so image files aren't decoded from sRGB.
+	if entry.BumpMap != nil {
+		bumpMapValue, _ := parser.nestedValue(shadingValue, "bumpMap", shadingValue.path+".bumpMap")
+		if shadingProperties.BumpMap, err = parser.texture(bumpMapValue, false); err != nil {
+			return shading.ShadingProperties{}, err
+		}
+		shadingProperties.BumpScale = entry.BumpScale
+	}
+	if entry.NormalMap != nil {
+		normalMapValue, _ := parser.nestedValue(shadingValue, "normalMap", shadingValue.path+".normalMap")
+		if shadingProperties.NormalMap, err = parser.texture(normalMapValue, false); err != nil {
+			return shading.ShadingProperties{}, err
+		}
+	}
+
+	return shadingProperties, nil
+}
+
+// Converts the given medium entry belonging to the given value into a medium, loading its density grid if it has one.
+func (parser *sceneParser) medium(value locatedValue, entry mediumEntry) (*shading.Medium, error) {
+	medium := shading.Medium{
+		Absorption: entry.Absorption.toColor(),
+		Scattering: entry.Scattering.toColor(),
+		Asymmetry:  entry.Asymmetry,
+	}
+	if entry.Density != nil {
+		if entry.Density.Path == "" {
+			return nil, parser.errorAt(value, errors.New("density grid path must be specified"))
+		}
+		grid, err := shading.LoadDensityGrid(parser.resolvePath(entry.Density.Path), entry.Density.Resolution,
+			entry.Density.Min.toPoint(), entry.Density.Max.toPoint())
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		medium.Density = grid
+	}
+	if err := medium.Validate(); err != nil {
+		return nil, parser.errorAt(value, err)
+	}
+	return &medium, nil
+}
+
+// Decodes the given texture entry, which holds colors (as opposed to other data such as normals) if specified.
+func (parser *sceneParser) texture(value locatedValue, isColor bool) (shading.Texture, error) {
+	textureType, err := parser.entryType(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch textureType {
+	case "solid":
+		var entry solidTextureEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		return shading.SolidTexture{Color: entry.Color.toColor()}, nil
+	case "checkerboard":
+		var entry checkerboardTextureEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.UPitch <= 0 || entry.VPitch <= 0 {
+			return nil, parser.errorAt(value, errors.New("checkerboard pitch must be positive"))
+		}
+		return shading.CheckerboardTexture{
+			Color1: entry.Color1.toColor(),
+			Color2: entry.Color2.toColor(),
+			UPitch: entry.UPitch,
+			VPitch: entry.VPitch,
+		}, nil
+	case "image":
+		var entry imageTextureEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.Path == "" {
+			return nil, parser.errorAt(value, errors.New("image texture path must be specified"))
+		}
+		mapping, ok := textureMappings[entry.Mapping]
+		if !ok {
+			return nil, parser.errorAt(value, fmt.Errorf("unknown texture mapping %q", entry.Mapping))
+		}
+		addressing, ok := textureAddressings[entry.Addressing]
+		if !ok {
+			return nil, parser.errorAt(value, fmt.Errorf("unknown texture addressing %q", entry.Addressing))
+		}
+		filter, ok := textureFilters[entry.Filter]
+		if !ok {
+			return nil, parser.errorAt(value, fmt.Errorf("unknown texture filter %q", entry.Filter))
+		}
+		scale := pair{1, 1}
+		if entry.Scale != nil {
+			if entry.Scale[0] == 0 || entry.Scale[1] == 0 {
+				return nil, parser.errorAt(value, errors.New("texture scale must be non-zero"))
+			}
+			scale = *entry.Scale
+		}
+
+		img, err := hdr.LoadImage(parser.resolvePath(entry.Path), isColor)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		texture, err := shading.NewImageTexture(img.Pixels)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		texture.Mapping = mapping
+		texture.Addressing = addressing
+		texture.Filter = filter
+		texture.UScale, texture.VScale = scale[0], scale[1]
+		texture.UOffset, texture.VOffset = entry.Offset[0], entry.Offset[1]
+		return texture, nil
+	case "noise":
+		var entry noiseTextureEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		texture := shading.NoiseTexture{
+			Scale:      entry.Scale,
+			Octaves:    entry.Octaves,
+			Lacunarity: 2,
+			Gain:       0.5,
+			Distortion: entry.Distortion,
+		}
+		var ok bool
+		if texture.Pattern, ok = noisePatterns[entry.Pattern]; !ok {
+			return nil, parser.errorAt(value, fmt.Errorf("unknown noise pattern %q", entry.Pattern))
+		}
+		if texture.Space, ok = noiseSpaces[entry.Space]; !ok {
+			return nil, parser.errorAt(value, fmt.Errorf("unknown noise space %q", entry.Space))
+		}
+		if entry.Scale <= 0 {
+			return nil, parser.errorAt(value, errors.New("noise scale must be positive"))
+		}
+		if entry.Octaves < 0 {
+			return nil, parser.errorAt(value, errors.New("noise octaves must be non-negative"))
+		}
+		if entry.Lacunarity != nil {
+			texture.Lacunarity = *entry.Lacunarity
+		}
+		if entry.Gain != nil {
+			texture.Gain = *entry.Gain
+		}
+		if len(entry.Ramp) == 0 {
+			return nil, parser.errorAt(value, errors.New("color ramp must have at least one color"))
+		}
+		for i, stop := range entry.Ramp {
+			if i > 0 && stop.Position < entry.Ramp[i-1].Position {
+				return nil, parser.errorAt(value, errors.New("color ramp positions must be in increasing order"))
+			}
+			texture.Ramp = append(texture.Ramp, shading.ColorStop{Position: stop.Position, Color: stop.Color.toColor()})
+		}
+		return texture, nil
+	default:
+		return nil, parser.errorAt(value, fmt.Errorf("unknown texture type %q", textureType))
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package scenefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/render"
+	"github.com/patfair/raytracer/shading"
+	"github.com/patfair/raytracer/surface"
+)
+
+// Holds the JSON representation of a plane, mirroring the parameters of surface.NewPlane.
+type planeEntry struct {
+	Type             string          `json:"type"`
+	BottomLeftCorner triple          `json:"bottomLeftCorner"`
+	Width            triple          `json:"width"`
+	Height           triple          `json:"height"`
+	Shading          json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of a sphere, mirroring the parameters of surface.NewSphere.
+type sphereEntry struct {
+	Type             string          `json:"type"`
+	Center           triple          `json:"center"`
+	Radius           float64         `json:"radius"`
+	ZenithReference  triple          `json:"zenithReference"`
+	AzimuthReference triple          `json:"azimuthReference"`
+	Shading          json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of a disc, mirroring the parameters of surface.NewDisc.
+type discEntry struct {
+	Type    string          `json:"type"`
+	Center  triple          `json:"center"`
+	Width   triple          `json:"width"`
+	Height  triple          `json:"height"`
+	Shading json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of a box, mirroring the parameters of surface.NewBox.
+type boxEntry struct {
+	Type                  string          `json:"type"`
+	FrontBottomLeftCorner triple          `json:"frontBottomLeftCorner"`
+	Width                 triple          `json:"width"`
+	Height                triple          `json:"height"`
+	Depth                 float64         `json:"depth"`
+	Shading               json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of a triangle, which is smooth-shaded if vertex normals are given.
+type triangleEntry struct {
+	Type     string          `json:"type"`
+	Vertices [3]triple       `json:"vertices"`
+	Normals  *[3]triple      `json:"normals"`
+	Shading  json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of a mesh loaded from a Wavefront OBJ file, mirroring the parameters of
+// surface.LoadWavefrontObj.
+type meshEntry struct {
+	Type    string          `json:"type"`
+	Path    string          `json:"path"`
+	Shading json.RawMessage `json:"shading"`
+}
+
+// Holds the JSON representation of an instance of another surface entry, transformed by each of the given steps in
+// turn.
+type instanceEntry struct {
+	Type       string           `json:"type"`
+	Surface    json.RawMessage  `json:"surface"`
+	Transforms []transformEntry `json:"transforms"`
+}
+
+// Holds the JSON representation of a single step of an instance's transformation, of which exactly one field must be
+// given.
+type transformEntry struct {
+	Translate *triple        `json:"translate"`
+	Scale     *triple        `json:"scale"`
+	Rotate    *rotationEntry `json:"rotate"`
+}
+
+// Holds the JSON representation of a rotation, mirroring the parameters of geometry.NewRotationMatrix.
+type rotationEntry struct {
+	Axis     triple  `json:"axis"`
+	AngleDeg float64 `json:"angleDeg"`
+}
+
+// Holds the JSON representation of a constructive solid geometry combination of other surface entries, whose type is
+// "union", "intersection" or "difference" and which are combined in turn from left to right.
+type csgEntry struct {
+	Type     string            `json:"type"`
+	Surfaces []json.RawMessage `json:"surfaces"`
+}
+
+// Decodes the given surface entry and adds the surface(s) it describes to the scene.
+func (parser *sceneParser) addSurfaces(scene *render.Scene, value locatedValue) error {
+	surfaces, err := parser.surfaces(value)
+	if err != nil {
+		return err
+	}
+	for _, surface := range surfaces {
+		scene.AddSurface(surface)
+	}
+	return nil
+}
+
+// Decodes the given surface entry, returning the surface(s) it describes.
+func (parser *sceneParser) surfaces(value locatedValue) ([]surface.Surface, error) {
+	surfaceType, err := parser.entryType(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch surfaceType {
+	case "plane":
+		var entry planeEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		plane, err := surface.NewPlane(entry.BottomLeftCorner.toPoint(), entry.Width.toVector(),
+			entry.Height.toVector(), material)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{plane}, nil
+	case "sphere":
+		var entry sphereEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		sphere, err := surface.NewSphere(entry.Center.toPoint(), entry.Radius, entry.ZenithReference.toVector(),
+			entry.AzimuthReference.toVector(), material)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{sphere}, nil
+	case "disc":
+		var entry discEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		disc, err := surface.NewDisc(entry.Center.toPoint(), entry.Width.toVector(), entry.Height.toVector(),
+			material)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{disc}, nil
+	case "box":
+		var entry boxEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		planes, err := surface.NewBox(entry.FrontBottomLeftCorner.toPoint(), entry.Width.toVector(),
+			entry.Height.toVector(), entry.Depth, material)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		surfaces := make([]surface.Surface, len(planes))
+		for i, plane := range planes {
+			surfaces[i] = plane
+		}
+		return surfaces, nil
+	case "triangle":
+		var entry triangleEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		var triangle surface.Triangle
+		if entry.Normals == nil {
+			triangle, err = surface.NewTriangle(entry.Vertices[0].toPoint(), entry.Vertices[1].toPoint(),
+				entry.Vertices[2].toPoint(), material)
+		} else {
+			triangle, err = surface.NewSmoothTriangle(entry.Vertices[0].toPoint(), entry.Vertices[1].toPoint(),
+				entry.Vertices[2].toPoint(), entry.Normals[0].toVector(), entry.Normals[1].toVector(),
+				entry.Normals[2].toVector(), material)
+		}
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{triangle}, nil
+	case "mesh":
+		var entry meshEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.Path == "" {
+			return nil, parser.errorAt(value, errors.New("mesh path must be specified"))
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		// The mesh's own materials, which its default shading is combined with, use the Phong model.
+		shadingProperties, ok := material.(shading.ShadingProperties)
+		if !ok {
+			return nil, parser.errorAt(value, errors.New("mesh shading must use the phong type"))
+		}
+		mesh, err := surface.LoadWavefrontObj(parser.resolvePath(entry.Path), shadingProperties)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{mesh}, nil
+	case "instance":
+		var entry instanceEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.Surface == nil {
+			return nil, parser.errorAt(value, errors.New("instanced surface must be specified"))
+		}
+		surfaceValue, _ := parser.nestedValue(value, "surface", value.path+".surface")
+		surfaces, err := parser.surfaces(surfaceValue)
+		if err != nil {
+			return nil, err
+		}
+
+		// Entries such as boxes that describe several surfaces are grouped so that they can be instanced together.
+		instancedSurface := surfaces[0]
+		if len(surfaces) > 1 {
+			if instancedSurface, err = surface.NewGroup(surfaces); err != nil {
+				return nil, parser.errorAt(value, err)
+			}
+		}
+
+		transformsValue, _ := parser.nestedValue(value, "transforms", value.path+".transforms")
+		stepValues := parser.nestedElements(transformsValue)
+		transform := geometry.IdentityMatrix()
+		for i, step := range entry.Transforms {
+			stepValue := stepValues[i]
+			var stepTransform geometry.Matrix
+			numSteps := 0
+			if step.Translate != nil {
+				stepTransform = geometry.NewTranslationMatrix(step.Translate.toVector())
+				numSteps++
+			}
+			if step.Scale != nil {
+				stepTransform = geometry.NewScalingMatrix(step.Scale[0], step.Scale[1], step.Scale[2])
+				numSteps++
+			}
+			if step.Rotate != nil {
+				if step.Rotate.Axis.toVector().Norm() == 0 {
+					return nil, parser.errorAt(stepValue, errors.New("rotation axis must be non-zero"))
+				}
+				stepTransform = geometry.NewRotationMatrix(step.Rotate.Axis.toVector(), step.Rotate.AngleDeg)
+				numSteps++
+			}
+			if numSteps != 1 {
+				return nil, parser.errorAt(stepValue,
+					errors.New("transform must have exactly one of translate, scale or rotate"))
+			}
+			transform = stepTransform.Multiply(transform)
+		}
+
+		instance, err := surface.NewInstance(instancedSurface, transform)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return []surface.Surface{instance}, nil
+	case "union", "intersection", "difference":
+		var entry csgEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		if len(entry.Surfaces) < 2 {
+			return nil, parser.errorAt(value, fmt.Errorf("%s must combine at least two surfaces", surfaceType))
+		}
+		surfacesValue, _ := parser.nestedValue(value, "surfaces", value.path+".surfaces")
+		solids := make([]surface.Solid, len(entry.Surfaces))
+		for i, surfaceValue := range parser.nestedElements(surfacesValue) {
+			solids[i], err = parser.solid(surfaceValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		combination := solids[0]
+		for _, solid := range solids[1:] {
+			switch surfaceType {
+			case "union":
+				combination = surface.NewUnion(combination, solid)
+			case "intersection":
+				combination = surface.NewIntersect(combination, solid)
+			default:
+				combination = surface.NewDifference(combination, solid)
+			}
+		}
+		return []surface.Surface{combination}, nil
+	default:
+		return nil, parser.errorAt(value, fmt.Errorf("unknown surface type %q", surfaceType))
+	}
+}
+
+// Decodes the given surface entry, which must describe a single solid, for use in constructive solid geometry. Boxes
+// are converted into a solid box rather than separate planes.
+func (parser *sceneParser) solid(value locatedValue) (surface.Solid, error) {
+	surfaceType, err := parser.entryType(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if surfaceType == "box" {
+		var entry boxEntry
+		if err = parser.decode(value, &entry); err != nil {
+			return nil, err
+		}
+		material, err := parser.material(value)
+		if err != nil {
+			return nil, err
+		}
+		box, err := surface.NewSolidBox(entry.FrontBottomLeftCorner.toPoint(), entry.Width.toVector(),
+			entry.Height.toVector(), entry.Depth, material)
+		if err != nil {
+			return nil, parser.errorAt(value, err)
+		}
+		return box, nil
+	}
+
+	surfaces, err := parser.surfaces(value)
+	if err != nil {
+		return nil, err
+	}
+	if solid, ok := surfaces[0].(surface.Solid); ok && len(surfaces) == 1 {
+		return solid, nil
+	}
+	return nil, parser.errorAt(value, fmt.Errorf("%s does not enclose a volume and cannot be combined", surfaceType))
+}
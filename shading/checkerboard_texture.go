@@ -4,6 +4,7 @@ package shading
 
 import (
 	"math"
+	"math/rand"
 )
 
 // Represents a texture that has two colors appearing in a checkerboard pattern.
@@ -15,11 +16,11 @@ type CheckerboardTexture struct {
 }
 
 // Returns either of the texture's colors, depending on the given coordinates.
-func (texture CheckerboardTexture) AlbedoAt(u, v, ditherVariation float64) Color {
+func (texture CheckerboardTexture) AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color {
 	if getToggleValue(u, texture.UPitch) == getToggleValue(v, texture.VPitch) {
-		return texture.Color1.Dither(ditherVariation)
+		return texture.Color1.Dither(ditherVariation, random)
 	}
-	return texture.Color2.Dither(ditherVariation)
+	return texture.Color2.Dither(ditherVariation, random)
 }
 
 func (texture CheckerboardTexture) NeedsTextureCoordinates() bool {
@@ -4,6 +4,7 @@ package shading
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math/rand"
 	"testing"
 )
 
@@ -13,24 +14,25 @@ func TestCheckerboardTexture_AlbedoAt(t *testing.T) {
 	uPitch := 1.0
 	vPitch := 2.0
 	texture := CheckerboardTexture{color1, color2, uPitch, vPitch}
+	random := rand.New(rand.NewSource(0))
 	ditherVariation := 0.02
 	assert.True(t, texture.NeedsTextureCoordinates())
 
-	assertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(0.4, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(0.6, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(0.9, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(1.1, 0.1, ditherVariation), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(0.4, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(0.6, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(0.9, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(1.1, 0.1, ditherVariation, random), ditherVariation)
 
-	assertColorEqual(t, color2, texture.AlbedoAt(-0.1, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(-0.4, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(-0.6, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(-0.9, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(-1.1, 0.1, ditherVariation), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(-0.1, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(-0.4, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(-0.6, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(-0.9, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(-1.1, 0.1, ditherVariation, random), ditherVariation)
 
-	assertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.9, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(0.1, 1.1, ditherVariation), ditherVariation)
-	assertColorEqual(t, color2, texture.AlbedoAt(0.1, 1.9, ditherVariation), ditherVariation)
-	assertColorEqual(t, color1, texture.AlbedoAt(0.1, 2.1, ditherVariation), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(0.1, 0.9, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(0.1, 1.1, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color2, texture.AlbedoAt(0.1, 1.9, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color1, texture.AlbedoAt(0.1, 2.1, ditherVariation, random), ditherVariation)
 }
@@ -15,6 +15,20 @@ type Color struct {
 	B float64 // Blue component as a float in [0, 1]
 }
 
+// Returns the linear sRGB color having the given CIE xy chromaticity and luminance Y.
+func ColorFromChromaticity(x, y, luminance float64) Color {
+	if y == 0 {
+		return Color{}
+	}
+	X := x * luminance / y
+	Z := (1 - x - y) * luminance / y
+	return Color{
+		R: 3.2406*X - 1.5372*luminance - 0.4986*Z,
+		G: -0.9689*X + 1.8758*luminance + 0.0415*Z,
+		B: 0.0557*X - 0.2040*luminance + 1.0570*Z,
+	}
+}
+
 // Returns the color's RGBA representation (with A always set to 255).
 func (color Color) ToRgba() imagecolor.RGBA {
 	return imagecolor.RGBA{
@@ -25,10 +39,43 @@ func (color Color) ToRgba() imagecolor.RGBA {
 	}
 }
 
-// Returns a copy of the color with each component varied randomly up to plus or minus the given variation.
-func (color Color) Dither(variation float64) Color {
-	r := math.Max(math.Min(color.R+(2*rand.Float64()-1)*variation, 1), 0)
-	g := math.Max(math.Min(color.G+(2*rand.Float64()-1)*variation, 1), 0)
-	b := math.Max(math.Min(color.B+(2*rand.Float64()-1)*variation, 1), 0)
+// Returns a copy of the color with each component varied randomly up to plus or minus the given variation, using the
+// given source of random numbers (which may be nil if the variation is zero).
+func (color Color) Dither(variation float64, random *rand.Rand) Color {
+	var offsetR, offsetG, offsetB float64
+	if variation != 0 {
+		offsetR = (2*random.Float64() - 1) * variation
+		offsetG = (2*random.Float64() - 1) * variation
+		offsetB = (2*random.Float64() - 1) * variation
+	}
+	r := math.Max(math.Min(color.R+offsetR, 1), 0)
+	g := math.Max(math.Min(color.G+offsetG, 1), 0)
+	b := math.Max(math.Min(color.B+offsetB, 1), 0)
 	return Color{r, g, b}
 }
+
+// Returns a color comprising the component-wise sum of this color and the given other one.
+func (color Color) Add(other Color) Color {
+	return Color{color.R + other.R, color.G + other.G, color.B + other.B}
+}
+
+// Returns a copy of the color with each component multiplied by the given factor.
+func (color Color) Multiply(factor float64) Color {
+	return Color{color.R * factor, color.G * factor, color.B * factor}
+}
+
+// Returns a color comprising the component-wise product of this color and the given other one, representing the light
+// of this color being filtered by (e.g. reflected off of) a surface of the other color.
+func (color Color) Filter(other Color) Color {
+	return Color{color.R * other.R, color.G * other.G, color.B * other.B}
+}
+
+// Returns the brightness of the color as perceived by the eye, using the Rec. 709 weights of its components.
+func (color Color) Luminance() float64 {
+	return 0.2126*color.R + 0.7152*color.G + 0.0722*color.B
+}
+
+// Returns the largest of the color's components.
+func (color Color) MaxComponent() float64 {
+	return math.Max(color.R, math.Max(color.G, color.B))
+}
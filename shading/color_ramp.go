@@ -0,0 +1,32 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+// Represents a gradient between colors at given positions, used to turn a scalar value into a color.
+type ColorRamp []ColorStop
+
+// Represents a single color within a ramp.
+type ColorStop struct {
+	Position float64 // Value at which the ramp has exactly this color
+	Color    Color
+}
+
+// Returns the color of the ramp at the given value, interpolating linearly between the stops on either side of it. The
+// stops must be in order of increasing position. Values beyond the first or last stop take the color of that stop, and
+// an empty ramp is black everywhere.
+func (ramp ColorRamp) At(value float64) Color {
+	if len(ramp) == 0 {
+		return Color{}
+	}
+	if value <= ramp[0].Position {
+		return ramp[0].Color
+	}
+	for i := 1; i < len(ramp); i++ {
+		if value < ramp[i].Position {
+			previous := ramp[i-1]
+			fraction := (value - previous.Position) / (ramp[i].Position - previous.Position)
+			return previous.Color.Multiply(1 - fraction).Add(ramp[i].Color.Multiply(fraction))
+		}
+	}
+	return ramp[len(ramp)-1].Color
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestColorRamp_At(t *testing.T) {
+	assert.Equal(t, Color{}, ColorRamp{}.At(0.5))
+
+	ramp := ColorRamp{{0.2, Color{1, 0, 0}}, {0.6, Color{0, 0, 1}}, {0.8, Color{1, 1, 1}}}
+	assert.Equal(t, Color{1, 0, 0}, ramp.At(-1))
+	assert.Equal(t, Color{1, 0, 0}, ramp.At(0.2))
+	AssertColorEqual(t, Color{0.75, 0, 0.25}, ramp.At(0.3), 1e-9)
+	assert.Equal(t, Color{0, 0, 1}, ramp.At(0.6))
+	AssertColorEqual(t, Color{0.5, 0.5, 1}, ramp.At(0.7), 1e-9)
+	assert.Equal(t, Color{1, 1, 1}, ramp.At(0.8))
+	assert.Equal(t, Color{1, 1, 1}, ramp.At(2))
+
+	// Coincident stops should produce a hard edge.
+	ramp = ColorRamp{{0.5, Color{1, 0, 0}}, {0.5, Color{0, 1, 0}}}
+	assert.Equal(t, Color{1, 0, 0}, ramp.At(0.49))
+	assert.Equal(t, Color{0, 1, 0}, ramp.At(0.51))
+}
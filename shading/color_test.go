@@ -5,6 +5,7 @@ package shading
 import (
 	"github.com/stretchr/testify/assert"
 	imagecolor "image/color"
+	"math/rand"
 	"testing"
 )
 
@@ -16,18 +17,38 @@ func TestColor_ToRgba(t *testing.T) {
 	assert.Equal(t, imagecolor.RGBA{255, 255, 255, 255}, Color{1.01, 2, 50}.ToRgba())
 }
 
+func TestColorFromChromaticity(t *testing.T) {
+	// The D65 white point should give a neutral color with the same luminance.
+	color := ColorFromChromaticity(0.3127, 0.3290, 2)
+	AssertColorEqual(t, Color{2, 2, 2}, color, 1e-3)
+	assert.InDelta(t, 2, color.Luminance(), 1e-3)
+
+	// The primaries should give pure red, green and blue.
+	AssertColorEqual(t, Color{1, 0, 0}, ColorFromChromaticity(0.64, 0.33, 0.2126), 1e-3)
+	AssertColorEqual(t, Color{0, 1, 0}, ColorFromChromaticity(0.30, 0.60, 0.7152), 1e-3)
+	AssertColorEqual(t, Color{0, 0, 1}, ColorFromChromaticity(0.15, 0.06, 0.0722), 1e-3)
+	assert.Equal(t, Color{}, ColorFromChromaticity(0.3, 0, 1))
+}
+
 func TestColor_Dither(t *testing.T) {
 	color := Color{0.25, 0.5, 0.75}
-	ditherColor := color.Dither(0.1)
-	assertColorEqual(t, color, ditherColor, 0.1)
+	ditherColor := color.Dither(0.1, rand.New(rand.NewSource(1)))
+	AssertColorEqual(t, color, ditherColor, 0.1)
 	assert.NotEqual(t, color.R, ditherColor.R)
 	assert.NotEqual(t, color.R, ditherColor.G)
 	assert.NotEqual(t, color.R, ditherColor.B)
+
+	// The same source of random numbers should give the same color.
+	assert.Equal(t, ditherColor, color.Dither(0.1, rand.New(rand.NewSource(1))))
+	assert.Equal(t, color, color.Dither(0, nil))
 }
 
-// Asserts equality of the two given colors, within a small allowable error.
-func assertColorEqual(t *testing.T, expected, actual Color, ditherVariance float64) {
-	assert.InDelta(t, expected.R, actual.R, ditherVariance, "X expected: %v, actual: %v", expected.R, actual.R)
-	assert.InDelta(t, expected.G, actual.G, ditherVariance, "Y expected: %v, actual: %v", expected.G, actual.G)
-	assert.InDelta(t, expected.B, actual.B, ditherVariance, "Z expected: %v, actual: %v", expected.B, actual.B)
+func TestColor_Arithmetic(t *testing.T) {
+	color1 := Color{0.25, 0.5, 0.75}
+	color2 := Color{2, 0, -1}
+	assert.Equal(t, Color{2.25, 0.5, -0.25}, color1.Add(color2))
+	assert.Equal(t, Color{0.5, 1, 1.5}, color1.Multiply(2))
+	assert.Equal(t, Color{0.5, 0, -0.75}, color1.Filter(color2))
+	assert.Equal(t, 0.75, color1.MaxComponent())
+	assert.Equal(t, 2.0, color2.MaxComponent())
 }
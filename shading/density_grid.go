@@ -0,0 +1,161 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/geometry"
+	"io/ioutil"
+	"math"
+)
+
+// Represents the density of a medium that varies from point to point, such as smoke, given by values at the centers of
+// the cells of a regular grid filling an axis-aligned box and blended smoothly between them. The density is zero
+// outside the box.
+type DensityGrid struct {
+	values     []float64 // Densities indexed by X first, then Y, then Z
+	resolution [3]int    // Number of cells along each axis
+	box        geometry.BoundingBox
+	maxDensity float64
+	stepSize   float64 // Distance between the points at which the density is sampled when integrating along a ray
+}
+
+// Returns a new grid with the given number of cells along each axis and densities indexed by X first, then Y, then Z,
+// filling the box between the given minimum and maximum corners, or an error if the parameters are invalid.
+func NewDensityGrid(values []float64, resolution [3]int, min, max geometry.Point) (*DensityGrid, error) {
+	if resolution[0] <= 0 || resolution[1] <= 0 || resolution[2] <= 0 {
+		return nil, errors.New("density grid resolution must be positive")
+	}
+	if len(values) != resolution[0]*resolution[1]*resolution[2] {
+		return nil, fmt.Errorf("density grid must have %d values, but has %d",
+			resolution[0]*resolution[1]*resolution[2], len(values))
+	}
+	if min.X >= max.X || min.Y >= max.Y || min.Z >= max.Z {
+		return nil, errors.New("density grid maximum corner must be greater than the minimum corner")
+	}
+
+	maxDensity := 0.0
+	for _, value := range values {
+		if value < 0 {
+			return nil, errors.New("densities must be non-negative")
+		}
+		maxDensity = math.Max(maxDensity, value)
+	}
+
+	// Sample at least twice per cell so that the blending between cells is captured.
+	stepSize := math.Min(math.Min((max.X-min.X)/float64(resolution[0]), (max.Y-min.Y)/float64(resolution[1])),
+		(max.Z-min.Z)/float64(resolution[2])) / 2
+	return &DensityGrid{
+		values:     values,
+		resolution: resolution,
+		box:        geometry.BoundingBox{Min: min, Max: max},
+		maxDensity: maxDensity,
+		stepSize:   stepSize,
+	}, nil
+}
+
+// Loads a grid from the raw file at the given path, which holds nothing but the densities indexed by X first, then Y,
+// then Z, either as unsigned bytes (which are divided by 255 to give densities in [0, 1]) or as little-endian 32-bit
+// floating-point numbers. The format is determined from the size of the file.
+func LoadDensityGrid(path string, resolution [3]int, min, max geometry.Point) (*DensityGrid, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	numValues := resolution[0] * resolution[1] * resolution[2]
+	values := make([]float64, numValues)
+	switch len(data) {
+	case numValues:
+		for i, value := range data {
+			values[i] = float64(value) / 255
+		}
+	case 4 * numValues:
+		for i := range values {
+			values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:])))
+		}
+	default:
+		return nil, fmt.Errorf("density grid file %s has %d bytes, which doesn't match a resolution of %v", path,
+			len(data), resolution)
+	}
+	return NewDensityGrid(values, resolution, min, max)
+}
+
+// Returns the density at the given point, blended trilinearly between the centers of the surrounding cells.
+func (grid *DensityGrid) DensityAt(point geometry.Point) float64 {
+	min, max := grid.box.Min, grid.box.Max
+	if point.X < min.X || point.X > max.X || point.Y < min.Y || point.Y > max.Y || point.Z < min.Z ||
+		point.Z > max.Z {
+		return 0
+	}
+
+	// Find the cells surrounding the point and how far it lies between their centers, extending the edge cells out to
+	// the sides of the box.
+	var indices [3][2]int
+	var fractions [3]float64
+	for axis, position := range [3]float64{
+		(point.X - min.X) / (max.X - min.X), (point.Y - min.Y) / (max.Y - min.Y), (point.Z - min.Z) / (max.Z - min.Z),
+	} {
+		cell := position*float64(grid.resolution[axis]) - 0.5
+		lower := math.Floor(cell)
+		fractions[axis] = cell - lower
+		indices[axis][0] = clampCell(int(lower), grid.resolution[axis])
+		indices[axis][1] = clampCell(int(lower)+1, grid.resolution[axis])
+	}
+
+	density := 0.0
+	for corner := 0; corner < 8; corner++ {
+		weight := 1.0
+		index := 0
+		stride := 1
+		for axis := 0; axis < 3; axis++ {
+			side := corner >> axis & 1
+			if side == 1 {
+				weight *= fractions[axis]
+			} else {
+				weight *= 1 - fractions[axis]
+			}
+			index += indices[axis][side] * stride
+			stride *= grid.resolution[axis]
+		}
+		density += weight * grid.values[index]
+	}
+	return density
+}
+
+// Returns the integral of the density between the given distances along the given ray, found by sampling it at regular
+// intervals.
+func (grid *DensityGrid) integrate(ray geometry.Ray, start, end float64) float64 {
+	start, end, ok := grid.clip(ray, start, end)
+	if !ok {
+		return 0
+	}
+	numSteps := int(math.Ceil((end - start) / grid.stepSize))
+	step := (end - start) / float64(numSteps)
+	direction := ray.Direction.ToUnit()
+	total := 0.0
+	for i := 0; i < numSteps; i++ {
+		total += grid.DensityAt(ray.Origin.Translate(direction.Multiply(start + (float64(i)+0.5)*step)))
+	}
+	return total * step
+}
+
+// Narrows the given distances along the given ray to the part lying inside the grid's box, returning false if none of
+// it does.
+func (grid *DensityGrid) clip(ray geometry.Ray, start, end float64) (float64, float64, bool) {
+	near, far, ok := grid.box.Intersection(ray)
+	start, end = math.Max(start, near), math.Min(end, far)
+	return start, end, ok && start < end
+}
+
+// Returns the given cell index limited to the given number of cells.
+func clampCell(index, resolution int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= resolution {
+		return resolution - 1
+	}
+	return index
+}
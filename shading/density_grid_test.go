@@ -0,0 +1,97 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"encoding/binary"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDensityGridInvalid(t *testing.T) {
+	min := geometry.Point{0, 0, 0}
+	max := geometry.Point{1, 1, 1}
+	_, err := NewDensityGrid([]float64{1}, [3]int{1, 0, 1}, min, max)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "resolution must be positive")
+	}
+
+	_, err = NewDensityGrid([]float64{1, 2}, [3]int{1, 1, 1}, min, max)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must have 1 values, but has 2")
+	}
+
+	_, err = NewDensityGrid([]float64{1}, [3]int{1, 1, 1}, max, min)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be greater than the minimum corner")
+	}
+
+	_, err = NewDensityGrid([]float64{-1}, [3]int{1, 1, 1}, min, max)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "densities must be non-negative")
+	}
+}
+
+func TestDensityGrid_DensityAt(t *testing.T) {
+	grid, err := NewDensityGrid([]float64{0, 1, 2, 3, 4, 5, 6, 7}, [3]int{2, 2, 2}, geometry.Point{0, 0, 0},
+		geometry.Point{2, 4, 8})
+	assert.Nil(t, err)
+
+	// The cell centers should have their own densities, with those in between blended together.
+	assert.InDelta(t, 0, grid.DensityAt(geometry.Point{0.5, 1, 2}), 1e-9)
+	assert.InDelta(t, 1, grid.DensityAt(geometry.Point{1.5, 1, 2}), 1e-9)
+	assert.InDelta(t, 6, grid.DensityAt(geometry.Point{0.5, 3, 6}), 1e-9)
+	assert.InDelta(t, 3.5, grid.DensityAt(geometry.Point{1, 2, 4}), 1e-9)
+	assert.InDelta(t, 0.25, grid.DensityAt(geometry.Point{0.75, 1, 2}), 1e-9)
+
+	// The edge cells should extend out to the sides of the box, beyond which there is nothing.
+	assert.InDelta(t, 7, grid.DensityAt(geometry.Point{2, 4, 8}), 1e-9)
+	assert.Equal(t, 0.0, grid.DensityAt(geometry.Point{2.1, 3, 6}))
+	assert.Equal(t, 0.0, grid.DensityAt(geometry.Point{1, -0.1, 6}))
+
+	// A medium using the grid should be denser where the grid is.
+	medium := Medium{Absorption: Color{1, 1, 1}, Density: grid}
+	ray := geometry.Ray{geometry.Point{-1, 1, 2}, geometry.Vector{1, 0, 0}}
+	assert.InDelta(t, math.Exp(-1), medium.Transmittance(ray, 0, 10).R, 1e-3)
+	assert.Equal(t, Color{1, 1, 1}, medium.Transmittance(geometry.Ray{geometry.Point{-1, 5, 2},
+		geometry.Vector{1, 0, 0}}, 0, math.Inf(1)))
+}
+
+func TestLoadDensityGrid(t *testing.T) {
+	directory, err := ioutil.TempDir("", "density")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	min := geometry.Point{0, 0, 0}
+	max := geometry.Point{2, 1, 1}
+
+	bytePath := filepath.Join(directory, "byte.raw")
+	assert.Nil(t, ioutil.WriteFile(bytePath, []byte{0, 255}, 0644))
+	grid, err := LoadDensityGrid(bytePath, [3]int{2, 1, 1}, min, max)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0.0, grid.DensityAt(geometry.Point{0.5, 0.5, 0.5}))
+		assert.Equal(t, 1.0, grid.DensityAt(geometry.Point{1.5, 0.5, 0.5}))
+	}
+
+	floatData := make([]byte, 8)
+	binary.LittleEndian.PutUint32(floatData, math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(floatData[4:], math.Float32bits(3))
+	floatPath := filepath.Join(directory, "float.raw")
+	assert.Nil(t, ioutil.WriteFile(floatPath, floatData, 0644))
+	grid, err = LoadDensityGrid(floatPath, [3]int{2, 1, 1}, min, max)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0.5, grid.DensityAt(geometry.Point{0.5, 0.5, 0.5}))
+		assert.Equal(t, 3.0, grid.DensityAt(geometry.Point{1.5, 0.5, 0.5}))
+	}
+
+	_, err = LoadDensityGrid(floatPath, [3]int{3, 1, 1}, min, max)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "doesn't match a resolution of [3 1 1]")
+	}
+	_, err = LoadDensityGrid(filepath.Join(directory, "nonexistent.raw"), [3]int{2, 1, 1}, min, max)
+	assert.NotNil(t, err)
+}
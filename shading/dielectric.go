@@ -0,0 +1,93 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"math"
+)
+
+// Represents a smooth, clear material such as glass or water, which reflects and refracts light in the proportions
+// given by the Fresnel equations. Closed surfaces made of it can also absorb some of the light passing through them,
+// following the Beer-Lambert law, so that thick parts of an object look more deeply colored than thin ones.
+type DielectricMaterial struct {
+	RefractiveIndex float64 // How fast light travels through the material, which determines how much it bends
+
+	// For a closed surface, the color that white light takes on after travelling the absorption distance through the
+	// inside of it
+	AbsorptionColor Color
+
+	// Distance over which light passing through the inside of the surface is filtered by the absorption color, or zero
+	// if no light is absorbed
+	AbsorptionDistance float64
+}
+
+func (material DielectricMaterial) Validate() error {
+	if material.RefractiveIndex < 1 {
+		return errors.New("refractive index must be at least 1")
+	}
+	if material.AbsorptionDistance < 0 {
+		return errors.New("absorption distance must be non-negative")
+	}
+	if material.AbsorptionColor.R < 0 || material.AbsorptionColor.R > 1 || material.AbsorptionColor.G < 0 ||
+		material.AbsorptionColor.G > 1 || material.AbsorptionColor.B < 0 || material.AbsorptionColor.B > 1 {
+		return errors.New("absorption color must be in [0, 1]")
+	}
+	return nil
+}
+
+func (material DielectricMaterial) Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color {
+	return Color{}
+}
+
+// Chooses between reflection and refraction at random in proportion to the Fresnel reflectance, so that the sample's
+// weight doesn't need to be adjusted for the choice.
+func (material DielectricMaterial) SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool) {
+	etaIn, etaOut := refractiveIndices(interaction, material.RefractiveIndex)
+	reflectance, refraction := refraction(interaction, outgoing, etaIn, etaOut)
+	if interaction.Random.Float64() < reflectance {
+		return reflection(interaction, outgoing, 1), true
+	}
+	refraction.Weight = Color{1, 1, 1}
+	return refraction, true
+}
+
+func (material DielectricMaterial) Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	return 0
+}
+
+// Returns the directions of perfect reflection and refraction, weighted by the Fresnel reflectance.
+func (material DielectricMaterial) SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample {
+	etaIn, etaOut := refractiveIndices(interaction, material.RefractiveIndex)
+	reflectance, refraction := refraction(interaction, outgoing, etaIn, etaOut)
+	var samples []BsdfSample
+	if reflectance < 1 {
+		refraction.Weight = Color{1 - reflectance, 1 - reflectance, 1 - reflectance}
+		samples = append(samples, refraction)
+	}
+	if reflectance > 0 {
+		samples = append(samples, reflection(interaction, outgoing, reflectance))
+	}
+	return samples
+}
+
+// Lets all light through, since any that is absorbed is accounted for by the interior transmittance.
+func (material DielectricMaterial) Transmittance(interaction Interaction) Color {
+	return Color{1, 1, 1}
+}
+
+func (material DielectricMaterial) IsRefractive() bool {
+	return material.RefractiveIndex != 1
+}
+
+// Follows the Beer-Lambert law, in which the light of each color decays exponentially with distance, at the rate that
+// leaves the absorption color after the absorption distance.
+func (material DielectricMaterial) InteriorTransmittance(distance float64) Color {
+	if material.AbsorptionDistance == 0 {
+		return Color{1, 1, 1}
+	}
+	exponent := distance / material.AbsorptionDistance
+	return Color{math.Pow(material.AbsorptionColor.R, exponent), math.Pow(material.AbsorptionColor.G, exponent),
+		math.Pow(material.AbsorptionColor.B, exponent)}
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDielectricMaterial_Validate(t *testing.T) {
+	material := DielectricMaterial{RefractiveIndex: 1.5, AbsorptionColor: Color{1, 0.5, 0}, AbsorptionDistance: 2}
+	err := material.Validate()
+	assert.Nil(t, err)
+
+	material.RefractiveIndex = 0.9
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "refractive index must be at least 1")
+	}
+	material.RefractiveIndex = 1.5
+
+	material.AbsorptionDistance = -1
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "absorption distance must be non-negative")
+	}
+	material.AbsorptionDistance = 0
+
+	material.AbsorptionColor = Color{0.5, 1.5, 0.5}
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "absorption color must be in [0, 1]")
+	}
+}
+
+func TestDielectricMaterial_SpecularSamples(t *testing.T) {
+	// Light passing into the material should be split between refraction and reflection.
+	interaction := Interaction{Normal: geometry.Vector{0, 1, 0}, RefractiveIndex: 1}
+	material := DielectricMaterial{RefractiveIndex: 1.5}
+	samples := material.SpecularSamples(interaction, interaction.Normal)
+	reflectance, _ := fresnelReflectance(1, 1, 1.5)
+	if assert.Equal(t, 2, len(samples)) {
+		geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 0}, samples[0].Direction)
+		AssertColorEqual(t, Color{1 - reflectance, 1 - reflectance, 1 - reflectance}, samples[0].Weight, 1e-9)
+		assert.Equal(t, 1.5, samples[0].RefractiveIndex)
+		geometry.AssertVectorEqual(t, geometry.Vector{0, 1, 0}, samples[1].Direction)
+		AssertColorEqual(t, Color{reflectance, reflectance, reflectance}, samples[1].Weight, 1e-9)
+		assert.Equal(t, 1.0, samples[1].RefractiveIndex)
+	}
+
+	// Light leaving the material should bend away from the normal into the empty space outside it.
+	interaction.RefractiveIndex = 1.5
+	outgoing := geometry.Vector{0.5, 1, 0}.ToUnit()
+	samples = material.SpecularSamples(interaction, outgoing)
+	if assert.Equal(t, 2, len(samples)) {
+		sinOut := math.Abs(samples[0].Direction.X)
+		assert.InDelta(t, 1.5*math.Abs(outgoing.X), sinOut, 1e-9)
+		assert.Less(t, samples[0].Direction.Y, 0.0)
+		assert.Equal(t, 1.0, samples[0].RefractiveIndex)
+	}
+
+	// Light leaving the material at a grazing angle should be totally internally reflected.
+	samples = material.SpecularSamples(interaction, geometry.Vector{3, 1, 0}.ToUnit())
+	if assert.Equal(t, 1, len(samples)) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-3, 1, 0}.ToUnit(), samples[0].Direction)
+		assert.Equal(t, Color{1, 1, 1}, samples[0].Weight)
+		assert.Equal(t, 1.5, samples[0].RefractiveIndex)
+	}
+}
+
+func TestDielectricMaterial_SampleBsdf(t *testing.T) {
+	// Each sample should be one of the two specular directions, with a weight of one since the choice between them
+	// follows the reflectance.
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	material := DielectricMaterial{RefractiveIndex: 1.5}
+	specularSamples := material.SpecularSamples(interaction, outgoing)
+	for i := 0; i < 100; i++ {
+		sample, ok := material.SampleBsdf(interaction, outgoing)
+		if assert.True(t, ok) {
+			assert.Equal(t, Color{1, 1, 1}, sample.Weight)
+			assert.Equal(t, 0.0, sample.Pdf)
+			if sample.Direction.Z > 0 {
+				geometry.AssertVectorEqual(t, specularSamples[1].Direction, sample.Direction)
+			} else {
+				geometry.AssertVectorEqual(t, specularSamples[0].Direction, sample.Direction)
+			}
+		}
+	}
+	assert.Equal(t, Color{}, material.Bsdf(interaction, outgoing, geometry.Vector{-1, 0, 1}.ToUnit()))
+	assert.Equal(t, 0.0, material.Pdf(interaction, outgoing, geometry.Vector{-1, 0, 1}.ToUnit()))
+}
+
+func TestDielectricMaterial_IsRefractive(t *testing.T) {
+	assert.True(t, DielectricMaterial{RefractiveIndex: 1.5}.IsRefractive())
+	assert.False(t, DielectricMaterial{RefractiveIndex: 1}.IsRefractive())
+}
+
+func TestDielectricMaterial_InteriorTransmittance(t *testing.T) {
+	material := DielectricMaterial{RefractiveIndex: 1.5}
+	assert.Equal(t, Color{1, 1, 1}, material.Transmittance(Interaction{}))
+	assert.Equal(t, Color{1, 1, 1}, material.InteriorTransmittance(10))
+
+	// The light should take on the absorption color after the absorption distance, and decay exponentially.
+	material.AbsorptionColor = Color{1, 0.5, 0}
+	material.AbsorptionDistance = 2
+	assert.Equal(t, Color{1, 1, 1}, material.InteriorTransmittance(0))
+	AssertColorEqual(t, Color{1, 0.5, 0}, material.InteriorTransmittance(2), 1e-9)
+	AssertColorEqual(t, Color{1, 0.25, 0}, material.InteriorTransmittance(4), 1e-9)
+	AssertColorEqual(t, Color{1, math.Sqrt(0.5), 0}, material.InteriorTransmittance(1), 1e-9)
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+)
+
+// Represents a surface that glows evenly in every direction from both of its sides, such as a light panel or a lamp
+// shade, and absorbs any light that arrives at it. Surfaces made of it are sampled as lights where possible.
+type DiffuseEmitter struct {
+	Color    Color   // Color of the light emitted by the surface
+	Strength float64 // Radiance of the light emitted by the surface, by which the color is multiplied
+}
+
+func (emitter DiffuseEmitter) Validate() error {
+	if emitter.Color.R < 0 || emitter.Color.G < 0 || emitter.Color.B < 0 {
+		return errors.New("emission color must be non-negative")
+	}
+	if emitter.Strength <= 0 {
+		return errors.New("emission strength must be positive")
+	}
+	return nil
+}
+
+func (emitter DiffuseEmitter) Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color {
+	return Color{}
+}
+
+func (emitter DiffuseEmitter) SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool) {
+	return BsdfSample{}, false
+}
+
+func (emitter DiffuseEmitter) Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	return 0
+}
+
+func (emitter DiffuseEmitter) SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample {
+	return nil
+}
+
+func (emitter DiffuseEmitter) Transmittance(interaction Interaction) Color {
+	return Color{}
+}
+
+func (emitter DiffuseEmitter) Emission(interaction Interaction, outgoing geometry.Vector) Color {
+	return emitter.Color.Multiply(emitter.Strength)
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffuseEmitter_Validate(t *testing.T) {
+	emitter := DiffuseEmitter{Color: Color{1, 0.5, 0}, Strength: 2}
+	err := emitter.Validate()
+	assert.Nil(t, err)
+
+	emitter.Strength = 0
+	err = emitter.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "emission strength must be positive")
+	}
+	emitter.Strength = 2
+
+	emitter.Color = Color{1, -0.5, 1}
+	err = emitter.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "emission color must be non-negative")
+	}
+}
+
+func TestDiffuseEmitter_Emission(t *testing.T) {
+	// The same light should be emitted in every direction from both sides of the surface.
+	emitter := DiffuseEmitter{Color: Color{1, 0.5, 0.25}, Strength: 4}
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1}
+	assert.Equal(t, Color{4, 2, 1}, emitter.Emission(interaction, geometry.Vector{0, 0, 1}))
+	assert.Equal(t, Color{4, 2, 1}, emitter.Emission(interaction, geometry.Vector{1, 0, 1}.ToUnit()))
+	assert.Equal(t, Color{4, 2, 1}, emitter.Emission(interaction, geometry.Vector{0, 0, -1}))
+}
+
+func TestDiffuseEmitter_Scattering(t *testing.T) {
+	// All of the light arriving at the surface should be absorbed.
+	emitter := DiffuseEmitter{Color: Color{1, 1, 1}, Strength: 1}
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	incoming := geometry.Vector{-1, 0, 1}.ToUnit()
+	assert.Equal(t, Color{}, emitter.Bsdf(interaction, outgoing, incoming))
+	assert.Equal(t, 0.0, emitter.Pdf(interaction, outgoing, incoming))
+	_, ok := emitter.SampleBsdf(interaction, outgoing)
+	assert.False(t, ok)
+	assert.Empty(t, emitter.SpecularSamples(interaction, outgoing))
+	assert.Equal(t, Color{}, emitter.Transmittance(interaction))
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// Represents a way of converting the texture coordinates produced by a surface into coordinates within an image.
+type TextureMapping int
+
+const (
+	// Uses the coordinates as they are for meshes (whose coordinates usually already lie within [0, 1]), and divides
+	// those of planes (which are distances from the corner) by the plane's width and height so that the image covers
+	// it once.
+	UvMapping TextureMapping = iota
+
+	// Converts the (theta, phi) spherical coordinates produced by spheres into [0, 1], such that an equirectangular
+	// image wraps once around the sphere with its top edge at the zenith.
+	SphericalMapping
+
+	// Converts the (r, phi) polar coordinates produced by discs into Cartesian coordinates along the disc's width and
+	// height vectors, such that the image is centered on the disc and just covers it.
+	PolarMapping
+)
+
+// Represents the way in which image coordinates lying outside [0, 1] are brought back into the image.
+type TextureAddressing int
+
+const (
+	RepeatAddressing TextureAddressing = iota // The image is tiled indefinitely
+	ClampAddressing                           // The edge pixels of the image are extended indefinitely
+	MirrorAddressing                          // The image is tiled with every other copy flipped
+)
+
+// Represents the way in which an image is sampled between the centers of its pixels.
+type TextureFilter int
+
+const (
+	BilinearFilter TextureFilter = iota // The four closest pixels are blended according to their distance
+	NearestFilter                       // The closest pixel is used as-is, giving a blocky appearance when magnified
+)
+
+// Represents a texture whose diffuse color is taken from an image.
+type ImageTexture struct {
+	pixels     [][]Color // Linear pixel colors indexed by row and then column, with the first row at the top
+	Mapping    TextureMapping
+	Addressing TextureAddressing
+	Filter     TextureFilter
+	UScale     float64 // Factor by which the U-coordinate is multiplied after mapping to find the image X-coordinate
+	VScale     float64 // Factor by which the V-coordinate is multiplied after mapping to find the image Y-coordinate
+	UOffset    float64 // Amount added to the U-coordinate after scaling, with 1 representing the width of the image
+	VOffset    float64 // Amount added to the V-coordinate after scaling, with 1 representing the height of the image
+	uSize      float64 // Size of the surface along its U-axis that the image is fitted to, or zero if it isn't sized
+	vSize      float64 // Size of the surface along its V-axis that the image is fitted to, or zero if it isn't sized
+}
+
+// Returns a new texture using the given linear pixel colors, indexed by row and then column with the first row at the
+// top, or an error if there are none. The texture initially repeats the image once across each unit of U and V, with
+// bilinear filtering.
+func NewImageTexture(pixels [][]Color) (ImageTexture, error) {
+	if len(pixels) == 0 || len(pixels[0]) == 0 {
+		return ImageTexture{}, errors.New("texture image must not be empty")
+	}
+	for _, row := range pixels {
+		if len(row) != len(pixels[0]) {
+			return ImageTexture{}, errors.New("texture image rows must all be the same width")
+		}
+	}
+	return ImageTexture{pixels: pixels, UScale: 1, VScale: 1}, nil
+}
+
+// Returns the color of the image at the given coordinates, with U running from left to right and V from bottom to top.
+func (texture ImageTexture) AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color {
+	u, v = texture.toImageCoordinates(u, v)
+	width, height := len(texture.pixels[0]), len(texture.pixels)
+	x := u * float64(width)
+	y := (1 - v) * float64(height)
+
+	if texture.Filter == NearestFilter {
+		return texture.pixel(int(math.Floor(x)), int(math.Floor(y))).Dither(ditherVariation, random)
+	}
+
+	// Blend the four pixels whose centers surround the point.
+	x -= 0.5
+	y -= 0.5
+	left, top := math.Floor(x), math.Floor(y)
+	xFraction, yFraction := x-left, y-top
+	topColor := texture.pixel(int(left), int(top)).Multiply(1 - xFraction).
+		Add(texture.pixel(int(left)+1, int(top)).Multiply(xFraction))
+	bottomColor := texture.pixel(int(left), int(top)+1).Multiply(1 - xFraction).
+		Add(texture.pixel(int(left)+1, int(top)+1).Multiply(xFraction))
+	return topColor.Multiply(1-yFraction).Add(bottomColor.Multiply(yFraction)).Dither(ditherVariation, random)
+}
+
+func (texture ImageTexture) NeedsTextureCoordinates() bool {
+	return true
+}
+
+// Returns a copy of the texture whose planar and polar mappings fit the image to a surface having the given size along
+// each of its texture axes.
+func (texture ImageTexture) fittedTo(uSize, vSize float64) ImageTexture {
+	texture.uSize, texture.vSize = uSize, vSize
+	return texture
+}
+
+// Returns the given surface texture coordinates converted into image coordinates, which lie within [0, 1] for points
+// within the image.
+func (texture ImageTexture) toImageCoordinates(u, v float64) (float64, float64) {
+	switch texture.Mapping {
+	case UvMapping:
+		if texture.uSize > 0 && texture.vSize > 0 {
+			u, v = u/texture.uSize, v/texture.vSize
+		}
+	case SphericalMapping:
+		u, v = (u+math.Pi)/(2*math.Pi), 1-v/math.Pi
+	case PolarMapping:
+		u, v = u*math.Cos(v), u*math.Sin(v)
+		if texture.uSize > 0 && texture.vSize > 0 {
+			u, v = 0.5+u/texture.uSize, 0.5+v/texture.vSize
+		}
+	}
+	return u*texture.UScale + texture.UOffset, v*texture.VScale + texture.VOffset
+}
+
+// Returns the color of the pixel at the given column and row, which are first brought within the image according to
+// the texture's addressing.
+func (texture ImageTexture) pixel(x, y int) Color {
+	return texture.pixels[texture.address(y, len(texture.pixels))][texture.address(x, len(texture.pixels[0]))]
+}
+
+// Returns the index within [0, size) that the given pixel index corresponds to.
+func (texture ImageTexture) address(index, size int) int {
+	switch texture.Addressing {
+	case ClampAddressing:
+		if index < 0 {
+			return 0
+		}
+		if index >= size {
+			return size - 1
+		}
+		return index
+	case MirrorAddressing:
+		index %= 2 * size
+		if index < 0 {
+			index += 2 * size
+		}
+		if index >= size {
+			return 2*size - 1 - index
+		}
+		return index
+	default:
+		index %= size
+		if index < 0 {
+			index += size
+		}
+		return index
+	}
+}
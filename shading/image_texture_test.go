@@ -0,0 +1,124 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewImageTextureInvalid(t *testing.T) {
+	_, err := NewImageTexture(nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "texture image must not be empty", err.Error())
+	}
+	_, err = NewImageTexture([][]Color{{{1, 1, 1}, {1, 1, 1}}, {{1, 1, 1}}})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "texture image rows must all be the same width", err.Error())
+	}
+}
+
+func TestImageTexture_AlbedoAt(t *testing.T) {
+	red, green, blue, white := Color{1, 0, 0}, Color{0, 1, 0}, Color{0, 0, 1}, Color{1, 1, 1}
+	texture, err := NewImageTexture([][]Color{{red, green}, {blue, white}})
+	assert.Nil(t, err)
+	assert.True(t, texture.NeedsTextureCoordinates())
+
+	// V should run from the bottom of the image to the top.
+	texture.Filter = NearestFilter
+	AssertColorEqual(t, red, texture.AlbedoAt(0.1, 0.9, 0, nil), 0)
+	AssertColorEqual(t, green, texture.AlbedoAt(0.6, 0.9, 0, nil), 0)
+	AssertColorEqual(t, blue, texture.AlbedoAt(0.4, 0.1, 0, nil), 0)
+	AssertColorEqual(t, white, texture.AlbedoAt(0.9, 0.4, 0, nil), 0)
+
+	// Pixel centers should be reproduced exactly, with blending in between.
+	texture.Filter = BilinearFilter
+	AssertColorEqual(t, red, texture.AlbedoAt(0.25, 0.75, 0, nil), 0)
+	AssertColorEqual(t, white, texture.AlbedoAt(0.75, 0.25, 0, nil), 0)
+	AssertColorEqual(t, Color{0.5, 0.5, 0.5}, texture.AlbedoAt(0.5, 0.5, 0, nil), 1e-9)
+	AssertColorEqual(t, Color{0.75, 0.25, 0}, texture.AlbedoAt(0.375, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, Color{0.3, 0, 0.7}, texture.AlbedoAt(0.25, 0.4, 0.02, rand.New(rand.NewSource(0))), 0.02)
+
+	// Coordinates outside the image should be handled according to the addressing.
+	AssertColorEqual(t, Color{0.5, 0.5, 0}, texture.AlbedoAt(0, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, red, texture.AlbedoAt(-1.75, 2.75, 0, nil), 1e-9)
+	texture.Addressing = ClampAddressing
+	AssertColorEqual(t, red, texture.AlbedoAt(0, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, white, texture.AlbedoAt(5, -3, 0, nil), 1e-9)
+	texture.Addressing = MirrorAddressing
+	AssertColorEqual(t, red, texture.AlbedoAt(0, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, green, texture.AlbedoAt(1.25, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, red, texture.AlbedoAt(-0.25, 0.75, 0, nil), 1e-9)
+	AssertColorEqual(t, red, texture.AlbedoAt(-1.75, 0.75, 0, nil), 1e-9)
+	texture.Addressing = RepeatAddressing
+	AssertColorEqual(t, red, texture.AlbedoAt(1.25, 0.75, 0, nil), 1e-9)
+
+	// Scale and offset should be applied after the coordinates are mapped.
+	texture.UScale = 0.5
+	texture.VOffset = 0.5
+	AssertColorEqual(t, white, texture.AlbedoAt(1.5, -0.25, 0, nil), 1e-9)
+}
+
+func TestImageTexture_Mapping(t *testing.T) {
+	texture, _ := NewImageTexture([][]Color{{{1, 1, 1}}})
+	u, v := texture.toImageCoordinates(0.3, 0.4)
+	assert.Equal(t, 0.3, u)
+	assert.Equal(t, 0.4, v)
+
+	// The azimuth reference and zenith of a sphere should map to the center and top of the image respectively.
+	texture.Mapping = SphericalMapping
+	u, v = texture.toImageCoordinates(0, math.Pi/2)
+	assert.Equal(t, 0.5, u)
+	assert.Equal(t, 0.5, v)
+	u, v = texture.toImageCoordinates(-math.Pi/2, 0)
+	assert.Equal(t, 0.25, u)
+	assert.Equal(t, 1.0, v)
+
+	// A plane should be fitted to the image by its width and height.
+	texture.Mapping = UvMapping
+	fitted := texture.fittedTo(4, 2)
+	u, v = fitted.toImageCoordinates(1, 2)
+	assert.Equal(t, 0.25, u)
+	assert.Equal(t, 1.0, v)
+
+	// A disc of radius 2 should be fitted to the image, with its center at the center of the image.
+	texture.Mapping = PolarMapping
+	fitted = texture.fittedTo(4, 4)
+	u, v = fitted.toImageCoordinates(2, math.Pi/2)
+	assert.InDelta(t, 0.5, u, 1e-9)
+	assert.InDelta(t, 1, v, 1e-9)
+	u, v = fitted.toImageCoordinates(1, math.Pi)
+	assert.InDelta(t, 0.25, u, 1e-9)
+	assert.InDelta(t, 0.5, v, 1e-9)
+	u, v = fitted.toImageCoordinates(0, 0)
+	assert.InDelta(t, 0.5, u, 1e-9)
+	assert.InDelta(t, 0.5, v, 1e-9)
+
+	// The scale and offset are applied after fitting.
+	fitted.UScale, fitted.UOffset = 2, 0.1
+	u, _ = fitted.toImageCoordinates(1, math.Pi)
+	assert.InDelta(t, 0.6, u, 1e-9)
+}
+
+func TestFitTextures(t *testing.T) {
+	texture, _ := NewImageTexture([][]Color{{{1, 0, 0}, {0, 1, 0}}, {{0, 0, 1}, {1, 1, 1}}})
+	texture.Filter = NearestFilter
+	properties := ShadingProperties{DiffuseTexture: texture, NormalMap: texture, Opacity: 1}
+
+	// The image is stretched across the whole surface rather than repeated across each unit of its coordinates.
+	fitted := properties.FitTextures(UvMapping, 4, 2).(ShadingProperties)
+	AssertColorEqual(t, Color{0, 0, 1}, fitted.DiffuseTexture.AlbedoAt(1, 0.5, 0, nil), 1e-9)
+	AssertColorEqual(t, Color{0, 1, 0}, fitted.DiffuseTexture.AlbedoAt(3, 1.5, 0, nil), 1e-9)
+	assert.Equal(t, fitted.DiffuseTexture, fitted.NormalMap)
+
+	// Textures using other mappings, and other kinds of texture, are left as they are.
+	assert.Equal(t, properties, properties.FitTextures(PolarMapping, 4, 2))
+	solid := ShadingProperties{DiffuseTexture: SolidTexture{Color{0.5, 0.5, 0.5}}, Opacity: 1}
+	assert.Equal(t, solid, solid.FitTextures(UvMapping, 4, 2))
+
+	microfacet := MicrofacetMaterial{BaseColorTexture: texture}
+	fittedMicrofacet := microfacet.FitTextures(UvMapping, 4, 2).(MicrofacetMaterial)
+	assert.Equal(t, fitted.DiffuseTexture, fittedMicrofacet.BaseColorTexture)
+}
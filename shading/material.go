@@ -0,0 +1,231 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"math"
+	"math/rand"
+)
+
+// Represents the way in which a surface scatters light, which determines its appearance. Materials that also emit
+// light, show highlights or perturb the surface normal implement the corresponding optional interfaces below.
+type Material interface {
+	// Returns an error if the material's parameters are invalid.
+	Validate() error
+
+	// Returns the bidirectional scattering distribution function (BSDF) at the given point, which is the fraction of
+	// the light arriving from the incoming direction that is scattered in the outgoing direction, per unit solid angle.
+	// Perfectly specular reflection and refraction, which scatter light only in single directions, are excluded.
+	Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color
+
+	// Returns a direction from which light arriving at the given point is scattered in the given outgoing direction,
+	// chosen at random in proportion to the BSDF including any perfectly specular components, or false if the light is
+	// absorbed instead.
+	SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool)
+
+	// Returns the probability density, with respect to solid angle, of SampleBsdf choosing the given incoming
+	// direction, which excludes perfectly specular directions in the same way as the BSDF.
+	Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64
+
+	// Returns the directions of specular reflection and refraction from which light arriving at the given point is
+	// scattered in the given outgoing direction, for integrators that follow only these directions and otherwise rely
+	// on the light arriving directly from the scene's lights. The directions of glossy (as opposed to perfectly
+	// specular) scattering may be chosen at random.
+	SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample
+
+	// Returns the fraction of the light of each color arriving at the given point that passes straight through the
+	// surface, for the purposes of casting shadows.
+	Transmittance(interaction Interaction) Color
+}
+
+// Represents a material that emits light of its own, in addition to scattering the light arriving at it.
+type EmissiveMaterial interface {
+	Material
+
+	// Returns the light emitted from the given point in the given outgoing direction.
+	Emission(interaction Interaction, outgoing geometry.Vector) Color
+}
+
+// Represents a material that, in addition to the light given by its BSDF, shows non-physical specular highlights of the
+// lights that shine on it, whose brightness depends only on the color of each light rather than its intensity.
+type HighlightedMaterial interface {
+	Material
+
+	// Returns the brightness of the highlight seen in the given outgoing direction of a light shining on the given
+	// point from the given incoming direction.
+	Highlight(interaction Interaction, outgoing, incoming geometry.Vector) float64
+}
+
+// Represents a material whose SpecularSamples chooses directions at random (such as for glossy reflection), which can
+// find the lights that are also sampled directly.
+type GlossyMaterial interface {
+	Material
+
+	// Returns the part of the BSDF from which SpecularSamples chooses directions at random, along with the probability
+	// density, with respect to solid angle, of it choosing the given incoming direction.
+	SpecularBsdf(interaction Interaction, outgoing, incoming geometry.Vector) (Color, float64)
+}
+
+// Represents a material that light can pass through.
+type RefractiveMaterial interface {
+	Material
+
+	// Returns whether light passing through the surface is bent by refraction, in which case it can be focused into
+	// caustics rather than only passing straight through.
+	IsRefractive() bool
+}
+
+// Represents a material that absorbs some of the light passing through the inside of a closed surface made of it.
+type AbsorbingMaterial interface {
+	Material
+
+	// Returns the fraction of the light of each color that remains after travelling the given distance through the
+	// inside of a closed surface made of the material, the rest having been absorbed.
+	InteriorTransmittance(distance float64) Color
+}
+
+// Represents a material whose closed surfaces are filled with a participating medium, such as smoke, through which
+// light travelling inside them passes.
+type VolumetricMaterial interface {
+	Material
+
+	// Returns the medium filling the inside of a closed surface made of the material.
+	InteriorMedium() *Medium
+}
+
+// Represents a material that simulates fine detail such as bumps by shading points with a normal that differs from
+// the geometric one.
+type BumpMappedMaterial interface {
+	Material
+
+	// Returns the normal that should be used for shading the given point, in place of the geometric normal given in the
+	// interaction.
+	ShadingNormal(interaction Interaction) geometry.Vector
+}
+
+// Represents a surface whose points can be converted into texture coordinates.
+type TexturedSurface interface {
+	ToTextureCoordinates(point geometry.Point) (float64, float64)
+	ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector)
+}
+
+// Represents a material whose image textures can be fitted to the size of the surface that it is bound to.
+type TexturedMaterial interface {
+	Material
+
+	// Returns a copy of the material whose image textures using the given mapping are fitted to a surface having the
+	// given size along each of its texture axes.
+	FitTextures(mapping TextureMapping, uSize, vSize float64) Material
+}
+
+// Holds the details of a point on a surface that a material needs in order to shade it.
+type Interaction struct {
+	Point           geometry.Point
+	Normal          geometry.Vector // Unit normal to shade the point with, on the side that the light leaves from
+	Surface         TexturedSurface // Surface on which the point lies
+	RefractiveIndex float64         // Refractive index of the medium through which the light leaves
+	DitherVariation float64         // Amount of random variation to add to texture colors
+	Random          *rand.Rand      // Source of the random numbers used to dither colors and choose directions
+}
+
+// Holds a direction from which light is scattered by a surface, chosen by a material.
+type BsdfSample struct {
+	Direction geometry.Vector // Unit direction pointing away from the surface towards where the light comes from
+
+	// Factor by which the light arriving from the direction is multiplied to give its contribution to the scattered
+	// light, which for randomly chosen directions is the BSDF multiplied by the cosine of the direction's angle from
+	// the normal and divided by the probability density of choosing it
+	Weight Color
+
+	// Refractive index of the medium that the direction passes through, which differs from that of the interaction if
+	// the light is refracted
+	RefractiveIndex float64
+
+	// Probability density, with respect to solid angle, of choosing the direction, or zero if it is one of the
+	// directions of perfect reflection or refraction
+	Pdf float64
+}
+
+// Returns the color of the given texture at the given point.
+func textureColor(texture Texture, interaction Interaction) Color {
+	if spatialTexture, ok := texture.(SpatialTexture); ok && spatialTexture.IsSpatial() {
+		return spatialTexture.AlbedoAtPoint(interaction.Point, interaction.DitherVariation, interaction.Random)
+	}
+	var u, v float64
+	if texture.NeedsTextureCoordinates() {
+		// For optimization, don't bother translating coordinates if the color doesn't depend on them (e.g. for solid
+		// color); just use (0, 0).
+		u, v = interaction.Surface.ToTextureCoordinates(interaction.Point)
+	}
+	return texture.AlbedoAt(u, v, interaction.DitherVariation, interaction.Random)
+}
+
+// Returns the given texture fitted to a surface of the given size if it is an image texture using the given mapping, or
+// otherwise the texture as-is.
+func fitTexture(texture Texture, mapping TextureMapping, uSize, vSize float64) Texture {
+	if imageTexture, ok := texture.(ImageTexture); ok && imageTexture.Mapping == mapping {
+		return imageTexture.fittedTo(uSize, vSize)
+	}
+	return texture
+}
+
+// Returns the fraction of light that is reflected rather than transmitted when passing from a medium of refractive
+// index etaIn into one of etaOut at the given angle of incidence, along with the cosine of the angle of the transmitted
+// light (which is zero in the case of total internal reflection).
+func fresnelReflectance(cosIn, etaIn, etaOut float64) (float64, float64) {
+	sinOut := etaIn / etaOut * math.Sqrt(math.Max(1-cosIn*cosIn, 0))
+	if sinOut >= 1 {
+		return 1, 0
+	}
+	cosOut := math.Sqrt(math.Max(1-sinOut*sinOut, 0))
+	rParallel := ((etaOut * cosIn) - (etaIn * cosOut)) / ((etaOut * cosIn) + (etaIn * cosOut))
+	rPerpendicular := ((etaIn * cosIn) - (etaOut * cosOut)) / ((etaIn * cosIn) + (etaOut * cosOut))
+	return (rParallel*rParallel + rPerpendicular*rPerpendicular) / 2, cosOut
+}
+
+// Returns a direction in the hemisphere around the given unit normal, distributed in proportion to the cosine of its
+// angle from the normal, given two random numbers in [0, 1).
+func sampleCosineHemisphere(normal geometry.Vector, u1, u2 float64) geometry.Vector {
+	r := math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	uDirection, vDirection := normal.OrthonormalBasis()
+	return uDirection.Multiply(r * math.Cos(phi)).Add(vDirection.Multiply(r * math.Sin(phi))).
+		Add(normal.Multiply(math.Sqrt(math.Max(1-u1, 0)))).ToUnit()
+}
+
+// Returns the direction of perfect reflection for light leaving the given point in the given outgoing direction, with
+// the given weight.
+func reflection(interaction Interaction, outgoing geometry.Vector,
+	weight float64) BsdfSample {
+	normal := interaction.Normal
+	direction := normal.Multiply(2 * normal.Dot(outgoing)).Add(outgoing.Multiply(-1)).ToUnit()
+	return BsdfSample{Direction: direction, Weight: Color{weight, weight, weight},
+		RefractiveIndex: interaction.RefractiveIndex}
+}
+
+// Returns the refractive indices of the media on the outgoing and incoming sides of a surface made of a material having
+// the given refractive index, for light leaving it through the medium given in the interaction.
+func refractiveIndices(interaction Interaction, materialIndex float64) (float64, float64) {
+	if interaction.RefractiveIndex > 1 {
+		// If the refractive index of the medium isn't 1, the light is passing out of the material instead of in.
+		return interaction.RefractiveIndex, 1
+	}
+	return interaction.RefractiveIndex, materialIndex
+}
+
+// Returns the fraction of light that is reflected rather than refracted at the given point for light leaving in the
+// given outgoing direction, along with the (unweighted) direction of refraction, given the refractive indices of the
+// media on the outgoing and incoming sides of the surface.
+func refraction(interaction Interaction, outgoing geometry.Vector, etaIn,
+	etaOut float64) (float64, BsdfSample) {
+	normal := interaction.Normal
+	cosIn := normal.Dot(outgoing)
+	reflectance, cosOut := fresnelReflectance(cosIn, etaIn, etaOut)
+	if reflectance == 1 {
+		return 1, BsdfSample{}
+	}
+	eta := etaIn / etaOut
+	direction := outgoing.Multiply(-eta).Add(normal.Multiply(eta*cosIn - cosOut)).ToUnit()
+	return reflectance, BsdfSample{Direction: direction, RefractiveIndex: etaOut}
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFresnelReflectance(t *testing.T) {
+	// At normal incidence, the reflectance is ((n1 - n2) / (n1 + n2))^2.
+	reflectance, cosOut := fresnelReflectance(1, 1, 1.5)
+	assert.InDelta(t, 0.04, reflectance, 1e-9)
+	assert.InDelta(t, 1, cosOut, 1e-9)
+
+	// Total internal reflection
+	reflectance, cosOut = fresnelReflectance(0.1, 1.5, 1)
+	assert.Equal(t, 1.0, reflectance)
+	assert.Equal(t, 0.0, cosOut)
+}
+
+func TestSampleCosineHemisphere(t *testing.T) {
+	normal := geometry.Vector{1, -2, 3}.ToUnit()
+	geometry.AssertVectorEqual(t, normal, sampleCosineHemisphere(normal, 0, 0.5))
+	var averageCosine float64
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			direction := sampleCosineHemisphere(normal, (float64(i)+0.5)/100, (float64(j)+0.5)/100)
+			assert.InDelta(t, 1, direction.Norm(), 1e-9)
+			assert.GreaterOrEqual(t, direction.Dot(normal), 0.0)
+			averageCosine += direction.Dot(normal) / 10000
+		}
+	}
+
+	// The expected value of the cosine under a cosine-weighted distribution is 2/3.
+	assert.InDelta(t, 2.0/3, averageCosine, 1e-3)
+}
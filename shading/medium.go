@@ -0,0 +1,108 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"math"
+)
+
+// Represents a participating medium, such as fog or smoke, that absorbs some of the light travelling through it and
+// scatters some of it in other directions. The coefficients give the fraction of the light of each color that is
+// absorbed or scattered per unit distance where the medium has a density of one, and the direction in which light is
+// scattered follows the Henyey-Greenstein phase function.
+type Medium struct {
+	Absorption Color        // Fraction of the light of each color absorbed per unit distance at unit density
+	Scattering Color        // Fraction of the light of each color scattered per unit distance at unit density
+	Asymmetry  float64      // In (-1, 1); positive values scatter light mostly onwards and negative values mostly back
+	Density    *DensityGrid // Optional grid giving the density at each point, which is one everywhere if nil
+}
+
+func (medium Medium) Validate() error {
+	if medium.Absorption.R < 0 || medium.Absorption.G < 0 || medium.Absorption.B < 0 {
+		return errors.New("medium absorption must be non-negative")
+	}
+	if medium.Scattering.R < 0 || medium.Scattering.G < 0 || medium.Scattering.B < 0 {
+		return errors.New("medium scattering must be non-negative")
+	}
+	if medium.Asymmetry <= -1 || medium.Asymmetry >= 1 {
+		return errors.New("medium asymmetry must be in (-1, 1)")
+	}
+	return nil
+}
+
+// Returns the fraction of the light of each color that passes through the medium between the given distances along
+// the given ray without being absorbed or scattered. The end distance may be infinite.
+func (medium Medium) Transmittance(ray geometry.Ray, start, end float64) Color {
+	if end <= start {
+		return Color{1, 1, 1}
+	}
+	opticalDepth := end - start
+	if medium.Density != nil {
+		opticalDepth = medium.Density.integrate(ray, start, end)
+	}
+	extinction := medium.extinction()
+	transmittance := func(coefficient float64) float64 {
+		if coefficient == 0 {
+			// Avoid multiplying zero by an infinite depth.
+			return 1
+		}
+		return math.Exp(-coefficient * opticalDepth)
+	}
+	return Color{transmittance(extinction.R), transmittance(extinction.G), transmittance(extinction.B)}
+}
+
+// Chooses a distance between the given distances along the given ray at which to find the light scattered towards the
+// ray's origin, given a random number in [0, 1). Distances are chosen in proportion to how much light would reach them
+// through a medium of the greatest density. Returns the distance along with the medium's scattering coefficients there
+// divided by the probability density of choosing it, which is zero if the medium doesn't scatter any light there.
+func (medium Medium) SampleScattering(ray geometry.Ray, start, end, u float64) (float64, Color) {
+	maxDensity := 1.0
+	if medium.Density != nil {
+		var ok bool
+		if start, end, ok = medium.Density.clip(ray, start, end); !ok {
+			return start, Color{}
+		}
+		maxDensity = medium.Density.maxDensity
+	}
+	rate := medium.extinction().MaxComponent() * maxDensity
+	if rate == 0 || end <= start || medium.Scattering.MaxComponent() == 0 {
+		return start, Color{}
+	}
+
+	// Sample the exponential distribution of distances, truncated to the end of the interval if it has one.
+	var distance, pdf float64
+	if math.IsInf(end, 1) {
+		distance = -math.Log1p(-u) / rate
+		pdf = rate * math.Exp(-rate*distance)
+	} else {
+		normalization := -math.Expm1(-rate * (end - start))
+		distance = -math.Log1p(-u*normalization) / rate
+		pdf = rate * math.Exp(-rate*distance) / normalization
+	}
+	point := ray.Origin.Translate(ray.Direction.ToUnit().Multiply(start + distance))
+	return start + distance, medium.Scattering.Multiply(medium.density(point) / pdf)
+}
+
+// Returns the Henyey-Greenstein phase function, which gives the fraction of the light scattered at a point that leaves
+// in a direction at the given cosine of its angle from the direction in which the light was travelling, per unit solid
+// angle.
+func (medium Medium) Phase(cosTheta float64) float64 {
+	g := medium.Asymmetry
+	denominator := 1 + g*g - 2*g*cosTheta
+	return (1 - g*g) / (4 * math.Pi * denominator * math.Sqrt(denominator))
+}
+
+// Returns the fraction of the light of each color absorbed or scattered per unit distance at unit density.
+func (medium Medium) extinction() Color {
+	return medium.Absorption.Add(medium.Scattering)
+}
+
+// Returns the density of the medium at the given point.
+func (medium Medium) density(point geometry.Point) float64 {
+	if medium.Density == nil {
+		return 1
+	}
+	return medium.Density.DensityAt(point)
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+)
+
+// Represents an invisible surface that light passes straight through, whose only purpose is to enclose a participating
+// medium such as a volume of smoke.
+type MediumBoundary struct {
+	Medium *Medium // Medium filling the inside of a closed surface made of the material
+}
+
+func (boundary MediumBoundary) Validate() error {
+	if boundary.Medium == nil {
+		return errors.New("medium must be specified")
+	}
+	return boundary.Medium.Validate()
+}
+
+func (boundary MediumBoundary) Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color {
+	return Color{}
+}
+
+func (boundary MediumBoundary) SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool) {
+	return boundary.passThrough(interaction, outgoing), true
+}
+
+func (boundary MediumBoundary) Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	return 0
+}
+
+func (boundary MediumBoundary) SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample {
+	return []BsdfSample{boundary.passThrough(interaction, outgoing)}
+}
+
+func (boundary MediumBoundary) Transmittance(interaction Interaction) Color {
+	return Color{1, 1, 1}
+}
+
+func (boundary MediumBoundary) InteriorMedium() *Medium {
+	return boundary.Medium
+}
+
+// Returns the sample continuing the light arriving at the given point in the given outgoing direction unchanged.
+func (boundary MediumBoundary) passThrough(interaction Interaction, outgoing geometry.Vector) BsdfSample {
+	return BsdfSample{Direction: outgoing.Multiply(-1), Weight: Color{1, 1, 1},
+		RefractiveIndex: interaction.RefractiveIndex}
+}
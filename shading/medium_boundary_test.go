@@ -0,0 +1,43 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMediumBoundary_Validate(t *testing.T) {
+	err := MediumBoundary{}.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "medium must be specified")
+	}
+
+	// A boundary containing an invalid medium should also be invalid.
+	medium := Medium{Asymmetry: 1}
+	boundary := MediumBoundary{Medium: &medium}
+	err = boundary.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "medium asymmetry must be in (-1, 1)")
+	}
+	medium.Asymmetry = 0.5
+	assert.Nil(t, boundary.Validate())
+	assert.Equal(t, &medium, boundary.InteriorMedium())
+}
+
+func TestMediumBoundary_Scattering(t *testing.T) {
+	// All of the light arriving at the surface should pass straight through it.
+	boundary := MediumBoundary{Medium: &Medium{}}
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	expectedSample := BsdfSample{Direction: outgoing.Multiply(-1), Weight: Color{1, 1, 1}, RefractiveIndex: 1}
+	sample, ok := boundary.SampleBsdf(interaction, outgoing)
+	if assert.True(t, ok) {
+		assert.Equal(t, expectedSample, sample)
+	}
+	assert.Equal(t, []BsdfSample{expectedSample}, boundary.SpecularSamples(interaction, outgoing))
+	assert.Equal(t, Color{}, boundary.Bsdf(interaction, outgoing, geometry.Vector{-1, 0, 1}.ToUnit()))
+	assert.Equal(t, 0.0, boundary.Pdf(interaction, outgoing, geometry.Vector{-1, 0, 1}.ToUnit()))
+	assert.Equal(t, Color{1, 1, 1}, boundary.Transmittance(interaction))
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestMediumInvalid(t *testing.T) {
+	medium := Medium{Absorption: Color{0.1, -0.1, 0.1}}
+	err := medium.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "medium absorption must be non-negative")
+	}
+
+	medium = Medium{Scattering: Color{0.1, 0.1, -0.1}}
+	err = medium.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "medium scattering must be non-negative")
+	}
+
+	medium = Medium{Asymmetry: 1}
+	err = medium.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "medium asymmetry must be in (-1, 1)")
+	}
+}
+
+func TestMedium_Transmittance(t *testing.T) {
+	medium := Medium{Absorption: Color{0.1, 0.2, 0}, Scattering: Color{0.1, 0, 0}}
+	ray := geometry.Ray{geometry.Point{1, 2, 3}, geometry.Vector{0, 0, 2}}
+	AssertColorEqual(t, Color{math.Exp(-1), math.Exp(-1), 1}, medium.Transmittance(ray, 1, 6), 1e-9)
+	assert.Equal(t, Color{1, 1, 1}, medium.Transmittance(ray, 1, 1))
+
+	// Colors that aren't absorbed or scattered at all should pass through an infinite distance.
+	assert.Equal(t, Color{0, 0, 1}, medium.Transmittance(ray, 0, math.Inf(1)))
+}
+
+func TestMedium_SampleScattering(t *testing.T) {
+	// The average of the scattering coefficient divided by the probability density, multiplied by the transmittance,
+	// should give the fraction of the light scattered over the interval.
+	ray := geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}}
+	for _, end := range []float64{3, math.Inf(1)} {
+		medium := Medium{Absorption: Color{0.1, 0.1, 0.1}, Scattering: Color{0.4, 0.2, 0}}
+		var total Color
+		const numSamples = 1000
+		for i := 0; i < numSamples; i++ {
+			distance, weight := medium.SampleScattering(ray, 1, end, (float64(i)+0.5)/numSamples)
+			assert.True(t, distance >= 1 && distance <= end)
+			total = total.Add(weight.Filter(medium.Transmittance(ray, 1, distance)))
+		}
+		scattered := func(scattering, extinction float64) float64 {
+			return scattering / extinction * -math.Expm1(-extinction*(end-1))
+		}
+		AssertColorEqual(t, Color{scattered(0.4, 0.5), scattered(0.2, 0.3), 0}, total.Multiply(1.0/numSamples),
+			5e-3)
+	}
+
+	// A medium that doesn't scatter any light should give no weight.
+	_, weight := Medium{Absorption: Color{0.1, 0.1, 0.1}}.SampleScattering(ray, 0, 1, 0.5)
+	assert.Equal(t, Color{}, weight)
+}
+
+func TestMedium_Phase(t *testing.T) {
+	// The phase function should integrate to one over the sphere of directions, and prefer forward scattering when the
+	// asymmetry is positive.
+	for _, asymmetry := range []float64{-0.5, 0, 0.3, 0.8} {
+		medium := Medium{Asymmetry: asymmetry}
+		total := 0.0
+		const steps = 10000
+		for i := 0; i < steps; i++ {
+			cosTheta := -1 + 2*(float64(i)+0.5)/steps
+			total += medium.Phase(cosTheta) * 2 * math.Pi * 2 / steps
+		}
+		assert.InDelta(t, 1, total, 1e-3)
+	}
+	assert.Equal(t, 1/(4*math.Pi), Medium{}.Phase(0.3))
+	medium := Medium{Asymmetry: 0.5}
+	assert.Greater(t, medium.Phase(1), medium.Phase(-1))
+}
@@ -0,0 +1,222 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"math"
+)
+
+const (
+	dielectricReflectance = 0.04 // Reflectance at normal incidence of typical non-metals such as plastic and paint
+	minMicrofacetAlpha    = 1e-3 // Lower limit on the GGX width, which keeps perfectly smooth surfaces stable
+)
+
+// Represents a surface shaded using the metallic/roughness microfacet model, in which the surface is treated as a
+// multitude of tiny mirrors whose orientations follow the GGX distribution, with Smith shadowing and Schlick's
+// approximation of Fresnel reflectance. Reflections blur as the roughness increases, and no more light is reflected
+// than arrives.
+type MicrofacetMaterial struct {
+	BaseColorTexture Texture // Color of the diffuse light from a non-metal, or of the reflections from a metal
+	Roughness        float64 // How blurred reflections are as a value in [0, 1]
+	Metallic         float64 // How metal-like the surface is as a value in [0, 1]
+}
+
+func (material MicrofacetMaterial) Validate() error {
+	if material.BaseColorTexture == nil {
+		return errors.New("base color texture must be specified")
+	}
+	if material.Roughness < 0 || material.Roughness > 1 {
+		return errors.New("roughness must be in [0, 1]")
+	}
+	if material.Metallic < 0 || material.Metallic > 1 {
+		return errors.New("metallic must be in [0, 1]")
+	}
+	return nil
+}
+
+func (material MicrofacetMaterial) Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color {
+	albedo := textureColor(material.BaseColorTexture, interaction)
+	diffuse, specular := material.Reflectance(albedo, interaction.Normal, outgoing, incoming)
+	return diffuse.Add(specular)
+}
+
+// Draws the direction from either the specular or the diffuse part of the BRDF, and weights it by the combined
+// probability density of choosing it either way.
+func (material MicrofacetMaterial) SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool) {
+	albedo := textureColor(material.BaseColorTexture, interaction)
+	normal := interaction.Normal
+	specularProbability := material.SpecularProbability(albedo, normal, outgoing)
+	var direction geometry.Vector
+	if interaction.Random.Float64() < specularProbability {
+		direction = material.SampleDirection(normal, outgoing, interaction.Random.Float64(),
+			interaction.Random.Float64())
+	} else {
+		direction = sampleCosineHemisphere(normal, interaction.Random.Float64(), interaction.Random.Float64())
+	}
+	cosIn := direction.Dot(normal)
+	if cosIn <= 0 {
+		// The light has been blocked by the microfacets.
+		return BsdfSample{}, false
+	}
+	pdf := specularProbability*material.DirectionPdf(normal, outgoing, direction) +
+		(1-specularProbability)*cosIn/math.Pi
+	diffuse, specular := material.Reflectance(albedo, normal, outgoing, direction)
+	return BsdfSample{direction, diffuse.Add(specular).Multiply(cosIn / pdf), interaction.RefractiveIndex, pdf}, true
+}
+
+func (material MicrofacetMaterial) Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	normal := interaction.Normal
+	cosIn := incoming.Dot(normal)
+	if cosIn <= 0 {
+		return 0
+	}
+	albedo := textureColor(material.BaseColorTexture, interaction)
+	specularProbability := material.SpecularProbability(albedo, normal, outgoing)
+	return specularProbability*material.DirectionPdf(normal, outgoing, incoming) + (1-specularProbability)*cosIn/math.Pi
+}
+
+// Returns a single direction of glossy reflection chosen at random according to the roughness, so that glossy
+// reflections become smooth as the samples for each pixel are averaged.
+func (material MicrofacetMaterial) SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample {
+	u1, u2 := interaction.Random.Float64(), interaction.Random.Float64()
+	if sample, ok := material.specularSample(interaction, outgoing, u1, u2); ok {
+		return []BsdfSample{sample}
+	}
+	return nil
+}
+
+func (material MicrofacetMaterial) SpecularBsdf(interaction Interaction, outgoing,
+	incoming geometry.Vector) (Color, float64) {
+	normal := interaction.Normal
+	if normal.Dot(incoming) <= 0 {
+		return Color{}, 0
+	}
+	albedo := textureColor(material.BaseColorTexture, interaction)
+	_, specular := material.Reflectance(albedo, normal, outgoing, incoming)
+	return specular, material.DirectionPdf(normal, outgoing, incoming)
+}
+
+func (material MicrofacetMaterial) Transmittance(interaction Interaction) Color {
+	return Color{}
+}
+
+func (material MicrofacetMaterial) FitTextures(mapping TextureMapping, uSize, vSize float64) Material {
+	material.BaseColorTexture = fitTexture(material.BaseColorTexture, mapping, uSize, vSize)
+	return material
+}
+
+// Returns the diffuse and specular parts of the BRDF of the material where its base color is the given albedo, for
+// light arriving from the given incoming direction and leaving in the given outgoing direction. Both directions and
+// the normal must be unit vectors, with the directions pointing away from the surface.
+func (material MicrofacetMaterial) Reflectance(albedo Color, normal, outgoing,
+	incoming geometry.Vector) (Color, Color) {
+	cosOut := normal.Dot(outgoing)
+	cosIn := normal.Dot(incoming)
+	if cosOut <= 0 || cosIn <= 0 {
+		return Color{}, Color{}
+	}
+	halfway := outgoing.Add(incoming).ToUnit()
+	alpha := material.alpha()
+	fresnel := material.fresnel(albedo, outgoing.Dot(halfway))
+
+	specular := fresnel.Multiply(ggxDistribution(normal.Dot(halfway), alpha) * smithMasking(cosIn, alpha) *
+		smithMasking(cosOut, alpha) / (4 * cosIn * cosOut))
+
+	// Metals absorb any light that isn't reflected at the surface, whereas other materials scatter it diffusely.
+	diffuse := albedo.Filter(Color{1 - fresnel.R, 1 - fresnel.G, 1 - fresnel.B}).
+		Multiply((1 - material.Metallic) / math.Pi)
+	return diffuse, specular
+}
+
+// Returns an incoming direction for the given outgoing direction, found by reflecting it off a microfacet whose normal
+// is drawn from the GGX distribution, given two random numbers in [0, 1). The direction may lie below the surface, in
+// which case the light is considered to be blocked.
+func (material MicrofacetMaterial) SampleDirection(normal, outgoing geometry.Vector, u1, u2 float64) geometry.Vector {
+	// Invert the cumulative distribution of microfacet angles, weighted by their projected area.
+	alpha := material.alpha()
+	cosTheta := math.Sqrt((1 - u1) / (1 + (alpha*alpha-1)*u1))
+	sinTheta := math.Sqrt(math.Max(1-cosTheta*cosTheta, 0))
+	phi := 2 * math.Pi * u2
+	uDirection, vDirection := normal.OrthonormalBasis()
+	microfacetNormal := uDirection.Multiply(sinTheta * math.Cos(phi)).
+		Add(vDirection.Multiply(sinTheta * math.Sin(phi))).Add(normal.Multiply(cosTheta))
+	return microfacetNormal.Multiply(2 * outgoing.Dot(microfacetNormal)).Add(outgoing.Multiply(-1)).ToUnit()
+}
+
+// Returns the probability density, with respect to solid angle, of SampleDirection producing the given incoming
+// direction.
+func (material MicrofacetMaterial) DirectionPdf(normal, outgoing, incoming geometry.Vector) float64 {
+	halfway := outgoing.Add(incoming)
+	if halfway.Norm() == 0 {
+		return 0
+	}
+	halfway = halfway.ToUnit()
+	cosHalf := normal.Dot(halfway)
+	cosOutHalf := outgoing.Dot(halfway)
+	if cosHalf <= 0 || cosOutHalf <= 0 {
+		return 0
+	}
+	return ggxDistribution(cosHalf, material.alpha()) * cosHalf / (4 * cosOutHalf)
+}
+
+// Returns the probability with which the specular part of the microfacet BRDF should be sampled rather than the
+// diffuse part, which is in proportion to an estimate of how much light each reflects in the given outgoing direction.
+func (material MicrofacetMaterial) SpecularProbability(albedo Color, normal, outgoing geometry.Vector) float64 {
+	specular := material.fresnel(albedo, normal.Dot(outgoing)).MaxComponent()
+	diffuse := (1 - material.Metallic) * albedo.MaxComponent()
+	if specular+diffuse == 0 {
+		return 1
+	}
+	return specular / (specular + diffuse)
+}
+
+// Returns the direction of glossy reflection given by SampleDirection for the given two random numbers in [0, 1),
+// weighted by the specular part of the BRDF, or false if the light is blocked by the microfacets.
+func (material MicrofacetMaterial) specularSample(interaction Interaction, outgoing geometry.Vector, u1,
+	u2 float64) (BsdfSample, bool) {
+	normal := interaction.Normal
+	direction := material.SampleDirection(normal, outgoing, u1, u2)
+	cosIn := direction.Dot(normal)
+	pdf := material.DirectionPdf(normal, outgoing, direction)
+	if cosIn <= 0 || pdf == 0 {
+		return BsdfSample{}, false
+	}
+	albedo := textureColor(material.BaseColorTexture, interaction)
+	_, specular := material.Reflectance(albedo, normal, outgoing, direction)
+	return BsdfSample{direction, specular.Multiply(cosIn / pdf), interaction.RefractiveIndex, pdf}, true
+}
+
+// Returns the width parameter of the GGX distribution corresponding to the surface's roughness, using the conventional
+// squaring so that the roughness changes the appearance of the surface roughly linearly.
+func (material MicrofacetMaterial) alpha() float64 {
+	return math.Max(material.Roughness*material.Roughness, minMicrofacetAlpha)
+}
+
+// Returns Schlick's approximation of the fraction of light reflected by a microfacet at the given angle, for which the
+// reflectance at normal incidence is tinted by the albedo in the case of metals.
+func (material MicrofacetMaterial) fresnel(albedo Color, cosTheta float64) Color {
+	normalReflectance := Color{dielectricReflectance, dielectricReflectance, dielectricReflectance}.
+		Multiply(1 - material.Metallic).Add(albedo.Multiply(material.Metallic))
+	weight := math.Pow(1-math.Max(math.Min(cosTheta, 1), 0), 5)
+	return normalReflectance.Multiply(1 - weight).Add(Color{weight, weight, weight})
+}
+
+// Returns the density of microfacets oriented at the given angle to the surface normal according to the GGX
+// distribution with the given width.
+func ggxDistribution(cosTheta, alpha float64) float64 {
+	if cosTheta <= 0 {
+		return 0
+	}
+	alphaSquared := alpha * alpha
+	denominator := cosTheta*cosTheta*(alphaSquared-1) + 1
+	return alphaSquared / (math.Pi * denominator * denominator)
+}
+
+// Returns the fraction of microfacets visible from the given angle that aren't hidden behind other microfacets,
+// according to Smith's model for the GGX distribution with the given width.
+func smithMasking(cosTheta, alpha float64) float64 {
+	alphaSquared := alpha * alpha
+	return 2 * cosTheta / (cosTheta + math.Sqrt(alphaSquared+(1-alphaSquared)*cosTheta*cosTheta))
+}
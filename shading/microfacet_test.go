@@ -0,0 +1,255 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGgxDistribution(t *testing.T) {
+	// The projected area of the microfacets should add up to that of the surface, whatever their width.
+	for _, alpha := range []float64{0.05, 0.3, 1} {
+		const steps = 100000
+		total := 0.0
+		for i := 0; i < steps; i++ {
+			theta := (float64(i) + 0.5) / steps * math.Pi / 2
+			total += ggxDistribution(math.Cos(theta), alpha) * math.Cos(theta) * 2 * math.Pi * math.Sin(theta) *
+				math.Pi / 2 / steps
+		}
+		assert.InDelta(t, 1, total, 1e-3, "alpha: %v", alpha)
+	}
+
+	assert.Equal(t, 0.0, ggxDistribution(-0.5, 0.3))
+	assert.Greater(t, ggxDistribution(1, 0.1), ggxDistribution(1, 0.5))
+}
+
+func TestSmithMasking(t *testing.T) {
+	assert.InDelta(t, 1, smithMasking(1, 0.5), 1e-9)
+	assert.InDelta(t, 1, smithMasking(0.5, 0), 1e-9)
+	assert.Less(t, smithMasking(0.1, 0.5), smithMasking(0.5, 0.5))
+	assert.Equal(t, 0.0, smithMasking(0, 0.5))
+}
+
+func TestMicrofacetMaterial_Validate(t *testing.T) {
+	material := MicrofacetMaterial{BaseColorTexture: SolidTexture{Color{1, 1, 1}}, Roughness: 0.5, Metallic: 1}
+	err := material.Validate()
+	assert.Nil(t, err)
+
+	material.Roughness = -0.1
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "roughness must be in [0, 1]")
+	}
+	material.Roughness = 0.5
+
+	material.Metallic = 1.1
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "metallic must be in [0, 1]")
+	}
+	material.Metallic = 1
+
+	material.BaseColorTexture = nil
+	err = material.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "base color texture must be specified")
+	}
+}
+
+func TestMicrofacetMaterial_Reflectance(t *testing.T) {
+	normal := geometry.Vector{0, 0, 1}
+	material := MicrofacetMaterial{Roughness: 0.5}
+	albedo := Color{0.8, 0.4, 0.2}
+
+	// A dielectric should scatter the light that isn't reflected at the surface diffusely.
+	diffuse, specular := material.Reflectance(albedo, normal, normal, normal)
+	AssertColorEqual(t, albedo.Multiply(0.96/math.Pi), diffuse, 1e-9)
+	expectedSpecular := 0.04 * ggxDistribution(1, 0.25) / 4
+	AssertColorEqual(t, Color{expectedSpecular, expectedSpecular, expectedSpecular}, specular, 1e-9)
+
+	// A metal should tint the reflection and have no diffuse part.
+	material.Metallic = 1
+	diffuse, specular = material.Reflectance(albedo, normal, normal, normal)
+	AssertColorEqual(t, Color{}, diffuse, 1e-9)
+	AssertColorEqual(t, albedo.Multiply(ggxDistribution(1, 0.25)/4), specular, 1e-9)
+
+	// Nothing should be reflected from or towards the underside of the surface.
+	diffuse, specular = material.Reflectance(albedo, normal, normal, geometry.Vector{1, 0, -1}.ToUnit())
+	assert.Equal(t, Color{}, diffuse)
+	assert.Equal(t, Color{}, specular)
+}
+
+func TestMicrofacetMaterial_EnergyConservation(t *testing.T) {
+	normal := geometry.Vector{0, 0, 1}
+	for _, roughness := range []float64{0, 0.2, 0.5, 1} {
+		for _, metallic := range []float64{0, 1} {
+			material := MicrofacetMaterial{Roughness: roughness, Metallic: metallic}
+			for _, outgoing := range []geometry.Vector{{0, 0, 1}, {1, 0, 1}, {3, 1, 1}} {
+				outgoing = outgoing.ToUnit()
+
+				// Estimate the fraction of light reflected from a uniformly white environment by sampling the specular
+				// part and integrating the diffuse part analytically.
+				const steps = 300
+				specularTotal := 0.0
+				for i := 0; i < steps; i++ {
+					for j := 0; j < steps; j++ {
+						incoming := material.SampleDirection(normal, outgoing, (float64(i)+0.5)/steps,
+							(float64(j)+0.5)/steps)
+						pdf := material.DirectionPdf(normal, outgoing, incoming)
+						if cosIn := incoming.Dot(normal); cosIn > 0 && pdf > 0 {
+							_, specular := material.Reflectance(Color{1, 1, 1}, normal, outgoing, incoming)
+							specularTotal += specular.R * cosIn / pdf / (steps * steps)
+						}
+					}
+				}
+				assert.LessOrEqual(t, specularTotal, 1.0, "roughness: %v, metallic: %v", roughness, metallic)
+				if metallic == 1 && roughness == 0 {
+					// A smooth metal should reflect everything.
+					assert.InDelta(t, 1, specularTotal, 1e-3)
+				} else if metallic == 0 {
+					assert.Less(t, specularTotal, 0.5, "roughness: %v", roughness)
+				}
+			}
+		}
+	}
+}
+
+func TestMicrofacetMaterial_SampleDirection(t *testing.T) {
+	normal := geometry.Vector{0, 1, 0}
+	outgoing := geometry.Vector{1, 1, 0}.ToUnit()
+
+	// A perfectly smooth surface should behave like a mirror.
+	material := MicrofacetMaterial{}
+	for _, u := range []float64{0, 0.3, 0.9} {
+		incoming := material.SampleDirection(normal, outgoing, u, u)
+		assert.Greater(t, incoming.Dot(geometry.Vector{-1, 1, 0}.ToUnit()), 0.999)
+	}
+
+	// The sampled directions should spread out as the roughness increases.
+	spread := func(roughness float64) float64 {
+		material.Roughness = roughness
+		return material.SampleDirection(normal, outgoing, 0.9, 0.25).Dot(geometry.Vector{-1, 1, 0}.ToUnit())
+	}
+	assert.Greater(t, spread(0.1), spread(0.5))
+	assert.Greater(t, spread(0.5), spread(0.9))
+
+	// The probability density should integrate to one when viewed head-on, since every microfacet faces the viewer.
+	material.Roughness = 0.5
+	const steps = 1000
+	total := 0.0
+	for i := 0; i < steps; i++ {
+		for j := 0; j < steps; j++ {
+			theta := (float64(i) + 0.5) / steps * math.Pi
+			phi := (float64(j) + 0.5) / steps * 2 * math.Pi
+			incoming := geometry.Vector{math.Sin(theta) * math.Cos(phi), math.Cos(theta),
+				math.Sin(theta) * math.Sin(phi)}
+			total += material.DirectionPdf(normal, normal, incoming) * math.Sin(theta) * math.Pi / steps * 2 *
+				math.Pi / steps
+		}
+	}
+	assert.InDelta(t, 1, total, 1e-2)
+}
+
+func TestMicrofacetMaterial_SpecularProbability(t *testing.T) {
+	normal := geometry.Vector{0, 0, 1}
+	material := MicrofacetMaterial{Roughness: 0.5, Metallic: 1}
+	assert.Equal(t, 1.0, material.SpecularProbability(Color{0.5, 0.5, 0.5}, normal, normal))
+
+	material.Metallic = 0
+	assert.InDelta(t, 0.04/0.54, material.SpecularProbability(Color{0.5, 0.5, 0.5}, normal, normal), 1e-9)
+	assert.Equal(t, 1.0, material.SpecularProbability(Color{}, normal, geometry.Vector{0, 1, 0}))
+}
+
+func TestMicrofacetMaterial_Bsdf(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	incoming := geometry.Vector{-1, 1, 2}.ToUnit()
+	material := MicrofacetMaterial{BaseColorTexture: SolidTexture{Color{0.8, 0.4, 0.2}}, Roughness: 0.4}
+	diffuse, specular := material.Reflectance(Color{0.8, 0.4, 0.2}, interaction.Normal, outgoing, incoming)
+	AssertColorEqual(t, diffuse.Add(specular), material.Bsdf(interaction, outgoing, incoming), 1e-9)
+	assert.Equal(t, Color{}, material.Bsdf(interaction, outgoing, geometry.Vector{1, 0, -1}.ToUnit()))
+}
+
+func TestMicrofacetMaterial_SampleBsdf(t *testing.T) {
+	// Under uniform white light, a white surface should reflect at most all of the light, and nearly all of it when
+	// smooth or when diffusely scattering the light that isn't reflected.
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{-1, 0, 2}.ToUnit()
+	for _, testCase := range []struct {
+		roughness     float64
+		metallic      float64
+		minimumWeight float64
+	}{{0, 1, 0.999}, {0.5, 1, 0.8}, {0.3, 0, 0.9}} {
+		material := MicrofacetMaterial{
+			BaseColorTexture: SolidTexture{Color{1, 1, 1}},
+			Roughness:        testCase.roughness,
+			Metallic:         testCase.metallic,
+		}
+		var averageWeight Color
+		const numSamples = 2000
+		for i := 0; i < numSamples; i++ {
+			if sample, ok := material.SampleBsdf(interaction, outgoing); ok {
+				assert.Greater(t, sample.Direction.Dot(interaction.Normal), 0.0)
+				assert.Equal(t, 1.0, sample.RefractiveIndex)
+				averageWeight = averageWeight.Add(sample.Weight.Multiply(1.0 / numSamples))
+			}
+		}
+		assert.Greater(t, averageWeight.R, testCase.minimumWeight, "test case: %v", testCase)
+		assert.LessOrEqual(t, averageWeight.R, 1.01, "test case: %v", testCase)
+	}
+}
+
+func TestMicrofacetMaterial_Pdf(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	material := MicrofacetMaterial{BaseColorTexture: SolidTexture{Color{0.5, 0.5, 0.5}}, Roughness: 0.4}
+	for i := 0; i < 100; i++ {
+		if sample, ok := material.SampleBsdf(interaction, outgoing); ok {
+			assert.InDelta(t, sample.Pdf, material.Pdf(interaction, outgoing, sample.Direction), 1e-9)
+		}
+	}
+	assert.Equal(t, 0.0, material.Pdf(interaction, outgoing, geometry.Vector{1, 0, -1}.ToUnit()))
+}
+
+func TestMicrofacetMaterial_SpecularSamples(t *testing.T) {
+	// A smooth metal should reflect all of the light in the direction of perfect reflection, tinted by its base color.
+	interaction := Interaction{Normal: geometry.Vector{0, 1, 0}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 1, 0}.ToUnit()
+	material := MicrofacetMaterial{BaseColorTexture: SolidTexture{Color{1, 0.5, 0.25}}, Metallic: 1}
+	samples := material.SpecularSamples(interaction, outgoing)
+	if assert.Equal(t, 1, len(samples)) {
+		assert.Greater(t, samples[0].Direction.Dot(geometry.Vector{-1, 1, 0}.ToUnit()), 0.999)
+		AssertColorEqual(t, Color{1, 0.5, 0.25}, samples[0].Weight, 1e-2)
+		assert.Equal(t, 1.0, samples[0].RefractiveIndex)
+	}
+}
+
+func TestMicrofacetMaterial_SpecularBsdf(t *testing.T) {
+	// The glossy reflection sampled by SpecularSamples should be consistent with its BSDF and probability density.
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	material := MicrofacetMaterial{BaseColorTexture: SolidTexture{Color{1, 0.5, 0.25}}, Roughness: 0.3, Metallic: 1}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if sample, ok := material.specularSample(interaction, outgoing, random.Float64(), random.Float64()); ok {
+			bsdf, pdf := material.SpecularBsdf(interaction, outgoing, sample.Direction)
+			assert.InDelta(t, sample.Pdf, pdf, 1e-9)
+			AssertColorEqual(t, bsdf.Multiply(sample.Direction.Dot(interaction.Normal)/pdf), sample.Weight, 1e-9)
+		}
+	}
+
+	bsdf, pdf := material.SpecularBsdf(interaction, outgoing, geometry.Vector{1, 0, -1}.ToUnit())
+	assert.Equal(t, Color{}, bsdf)
+	assert.Equal(t, 0.0, pdf)
+}
+
+func TestMicrofacetMaterial_Transmittance(t *testing.T) {
+	assert.Equal(t, Color{}, MicrofacetMaterial{}.Transmittance(Interaction{}))
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"math"
+	"math/rand"
+)
+
+// Represents the pattern that a noise texture produces from gradient noise.
+type NoisePattern int
+
+const (
+	PerlinPattern     NoisePattern = iota // A single octave of smooth noise
+	FbmPattern                            // Several octaves of noise summed together (fractal Brownian motion)
+	TurbulencePattern                     // Several octaves of the absolute value of noise, giving billowing creases
+	MarblePattern                         // Stripes along the X-axis, distorted by turbulence into veins
+	WoodPattern                           // Rings around the Z-axis, distorted by turbulence into an irregular grain
+)
+
+// Represents the coordinates at which a noise texture is evaluated.
+type NoiseSpace int
+
+const (
+	// Evaluates the noise at the surface's (U, V) texture coordinates, so that the pattern follows the surface's
+	// parameterization.
+	UvSpace NoiseSpace = iota
+
+	// Evaluates the noise at the point in space being shaded, so that the pattern is continuous across seams and
+	// between surfaces, as though the object were carved out of a solid block of material.
+	PointSpace
+)
+
+// Represents a procedural texture whose color is derived from gradient noise, by way of a color ramp.
+type NoiseTexture struct {
+	Pattern    NoisePattern
+	Space      NoiseSpace
+	Scale      float64   // Factor by which the coordinates are multiplied, such that larger values give finer detail
+	Octaves    int       // Number of layers of noise summed together, for all but the Perlin pattern
+	Lacunarity float64   // Factor by which the frequency of each octave increases over the previous one
+	Gain       float64   // Factor by which the amplitude of each octave decreases from the previous one
+	Distortion float64   // Amount of turbulence added to the stripes or rings of the marble and wood patterns
+	Ramp       ColorRamp // Colors corresponding to each value of the pattern, which lies within [0, 1]
+}
+
+// Returns the color of the pattern at the point (u, v, 0).
+func (texture NoiseTexture) AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color {
+	return texture.AlbedoAtPoint(geometry.Point{u, v, 0}, ditherVariation, random)
+}
+
+func (texture NoiseTexture) NeedsTextureCoordinates() bool {
+	return texture.Space == UvSpace
+}
+
+func (texture NoiseTexture) AlbedoAtPoint(point geometry.Point, ditherVariation float64, random *rand.Rand) Color {
+	return texture.Ramp.At(texture.value(point)).Dither(ditherVariation, random)
+}
+
+func (texture NoiseTexture) IsSpatial() bool {
+	return texture.Space == PointSpace
+}
+
+// Returns the value of the pattern at the given point, in [0, 1].
+func (texture NoiseTexture) value(point geometry.Point) float64 {
+	point = geometry.Point{point.X * texture.Scale, point.Y * texture.Scale, point.Z * texture.Scale}
+	var value float64
+	switch texture.Pattern {
+	case FbmPattern:
+		value = 0.5 + 0.5*fractalNoise(point, texture.Octaves, texture.Lacunarity, texture.Gain)
+	case TurbulencePattern:
+		value = turbulence(point, texture.Octaves, texture.Lacunarity, texture.Gain)
+	case MarblePattern:
+		distortion := texture.Distortion * turbulence(point, texture.Octaves, texture.Lacunarity, texture.Gain)
+		value = 0.5 + 0.5*math.Sin(2*math.Pi*(point.X+distortion))
+	case WoodPattern:
+		distortion := texture.Distortion * turbulence(point, texture.Octaves, texture.Lacunarity, texture.Gain)
+		_, value = math.Modf(math.Hypot(point.X, point.Y) + distortion)
+	default:
+		value = 0.5 + 0.5*perlinNoise(point)
+	}
+	return math.Max(math.Min(value, 1), 0)
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNoiseTexture(t *testing.T) {
+	ramp := ColorRamp{{0, Color{0, 0, 0}}, {1, Color{1, 1, 1}}}
+	texture := NoiseTexture{Scale: 2, Octaves: 4, Lacunarity: 2, Gain: 0.5, Distortion: 1, Ramp: ramp}
+	var _ SpatialTexture = texture
+	point := geometry.Point{0.31, -0.72, 1.05}
+	scaledPoint := geometry.Point{0.62, -1.44, 2.1}
+
+	assert.True(t, texture.NeedsTextureCoordinates())
+	assert.False(t, texture.IsSpatial())
+	texture.Space = PointSpace
+	assert.False(t, texture.NeedsTextureCoordinates())
+	assert.True(t, texture.IsSpatial())
+
+	texture.Pattern = PerlinPattern
+	expected := 0.5 + 0.5*perlinNoise(scaledPoint)
+	AssertColorEqual(t, Color{expected, expected, expected}, texture.AlbedoAtPoint(point, 0, nil), 1e-9)
+	expected = 0.5 + 0.5*perlinNoise(geometry.Point{0.62, -1.44, 0})
+	AssertColorEqual(t, Color{expected, expected, expected}, texture.AlbedoAt(0.31, -0.72, 0, nil), 1e-9)
+
+	texture.Pattern = FbmPattern
+	expected = 0.5 + 0.5*fractalNoise(scaledPoint, 4, 2, 0.5)
+	AssertColorEqual(t, Color{expected, expected, expected}, texture.AlbedoAtPoint(point, 0, nil), 1e-9)
+
+	texture.Pattern = TurbulencePattern
+	expected = turbulence(scaledPoint, 4, 2, 0.5)
+	AssertColorEqual(t, Color{expected, expected, expected}, texture.AlbedoAtPoint(point, 0, nil), 1e-9)
+
+	// Without distortion, marble should be a sinusoid along X and wood should be concentric rings around Z.
+	texture.Distortion = 0
+	texture.Pattern = MarblePattern
+	AssertColorEqual(t, Color{0.5, 0.5, 0.5}, texture.AlbedoAtPoint(geometry.Point{0, 5, 3}, 0, nil), 1e-9)
+	AssertColorEqual(t, Color{1, 1, 1}, texture.AlbedoAtPoint(geometry.Point{0.125, -2, 7}, 0, nil), 1e-9)
+	AssertColorEqual(t, Color{0, 0, 0}, texture.AlbedoAtPoint(geometry.Point{0.375, 1, 0}, 0, nil), 1e-9)
+	texture.Pattern = WoodPattern
+	for _, z := range []float64{-3, 0, 10} {
+		AssertColorEqual(t, Color{0.5, 0.5, 0.5}, texture.AlbedoAtPoint(geometry.Point{0.15, 0.2, z}, 0, nil), 1e-9)
+		AssertColorEqual(t, Color{0.5, 0.5, 0.5}, texture.AlbedoAtPoint(geometry.Point{0, -0.75, z}, 0, nil), 1e-9)
+	}
+
+	// Distortion should perturb the patterns while keeping them within the ramp.
+	texture.Distortion = 2
+	for _, pattern := range []NoisePattern{MarblePattern, WoodPattern} {
+		texture.Pattern = pattern
+		varies := false
+		for i := 0; i < 100; i++ {
+			color := texture.AlbedoAtPoint(geometry.Point{0.15, 0.2, float64(i) * 0.1}, 0, nil)
+			assert.True(t, color.R >= 0 && color.R <= 1)
+			if math.Abs(color.R-0.5) > 0.01 {
+				varies = true
+			}
+		}
+		assert.True(t, varies)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import "github.com/patfair/raytracer/geometry"
+
+// Distance in texture coordinates over which the rates of change of bump map heights and texture mappings are
+// estimated.
+const textureDifferentiationStep = 1e-4
+
+// Returns whether the shading normal differs from the geometric one due to a bump or normal map.
+func (properties ShadingProperties) PerturbsNormal() bool {
+	return properties.BumpMap != nil || properties.NormalMap != nil
+}
+
+// Returns the normal that should be used for shading the given point, which is the given geometric normal perturbed by
+// the bump or normal map if there is one. The texture coordinates of the point and the rates of change of the point
+// with respect to them must also be given. The geometric normal is returned as-is where the tangents are degenerate.
+func (properties ShadingProperties) PerturbNormal(point geometry.Point, normal geometry.Vector, u, v float64,
+	uTangent, vTangent geometry.Vector) geometry.Vector {
+	var perturbedNormal geometry.Vector
+	if properties.BumpMap != nil {
+		perturbedNormal = bumpMappedNormal(properties.BumpMap, properties.BumpScale, point, normal, u, v, uTangent,
+			vTangent)
+	} else if properties.NormalMap != nil {
+		perturbedNormal = normalMappedNormal(properties.NormalMap, normal, u, v, uTangent, vTangent)
+	} else {
+		return normal
+	}
+
+	if perturbedNormal.Norm() == 0 {
+		return normal
+	}
+	return perturbedNormal.ToUnit()
+}
+
+// Returns the (unnormalized) normal of the surface after displacing it along the given normal by the height given by
+// the bump map, estimated from the rates of change of the height in each direction.
+func bumpMappedNormal(bumpMap Texture, scale float64, point geometry.Point, normal geometry.Vector, u, v float64,
+	uTangent, vTangent geometry.Vector) geometry.Vector {
+	height := func(du, dv float64) float64 {
+		var color Color
+		if spatialTexture, ok := bumpMap.(SpatialTexture); ok && spatialTexture.IsSpatial() {
+			offsetPoint := point.Translate(uTangent.Multiply(du).Add(vTangent.Multiply(dv)))
+			color = spatialTexture.AlbedoAtPoint(offsetPoint, 0, nil)
+		} else {
+			color = bumpMap.AlbedoAt(u+du, v+dv, 0, nil)
+		}
+		return scale * (color.R + color.G + color.B) / 3
+	}
+	baseHeight := height(0, 0)
+	uSlope := (height(textureDifferentiationStep, 0) - baseHeight) / textureDifferentiationStep
+	vSlope := (height(0, textureDifferentiationStep) - baseHeight) / textureDifferentiationStep
+
+	// The displaced surface's tangents each gain a component along the normal in proportion to the slope, and their
+	// cross product gives the new normal.
+	perturbedNormal := uTangent.Add(normal.Multiply(uSlope)).Cross(vTangent.Add(normal.Multiply(vSlope)))
+	if perturbedNormal.Dot(normal) < 0 {
+		perturbedNormal = perturbedNormal.Multiply(-1)
+	}
+	return perturbedNormal
+}
+
+// Returns the (unnormalized) normal encoded by the normal map, whose red, green and blue components map from [0, 1] to
+// [-1, 1] along the directions of increasing image X, increasing image Y and the geometric normal respectively.
+func normalMappedNormal(normalMap Texture, normal geometry.Vector, u, v float64,
+	uTangent, vTangent geometry.Vector) geometry.Vector {
+	// An image texture may map the surface's coordinates onto the image in a way that changes the directions of its
+	// axes, so find the tangents along the image's own axes by inverting the rates of change of the mapping.
+	if imageTexture, ok := normalMap.(ImageTexture); ok {
+		x, y := imageTexture.toImageCoordinates(u, v)
+		xAfterU, yAfterU := imageTexture.toImageCoordinates(u+textureDifferentiationStep, v)
+		xAfterV, yAfterV := imageTexture.toImageCoordinates(u, v+textureDifferentiationStep)
+		dxdu, dydu := (xAfterU-x)/textureDifferentiationStep, (yAfterU-y)/textureDifferentiationStep
+		dxdv, dydv := (xAfterV-x)/textureDifferentiationStep, (yAfterV-y)/textureDifferentiationStep
+		determinant := dxdu*dydv - dxdv*dydu
+		if determinant == 0 {
+			return geometry.Vector{}
+		}
+		uTangent, vTangent = uTangent.Multiply(dydv/determinant).Add(vTangent.Multiply(-dydu/determinant)),
+			uTangent.Multiply(-dxdv/determinant).Add(vTangent.Multiply(dxdu/determinant))
+	}
+
+	// Build an orthonormal frame around the normal, aligned as closely as possible with the tangents.
+	tangent := uTangent.Add(normal.Multiply(-uTangent.Dot(normal)))
+	if tangent.Norm() == 0 {
+		return geometry.Vector{}
+	}
+	tangent = tangent.ToUnit()
+	bitangent := normal.Cross(tangent)
+	if bitangent.Dot(vTangent) < 0 {
+		bitangent = bitangent.Multiply(-1)
+	}
+
+	color := normalMap.AlbedoAt(u, v, 0, nil)
+	return tangent.Multiply(2*color.R - 1).Add(bitangent.Multiply(2*color.G - 1)).Add(normal.Multiply(2*color.B - 1))
+}
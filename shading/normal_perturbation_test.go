@@ -0,0 +1,107 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestShadingProperties_PerturbNormalWithoutMap(t *testing.T) {
+	properties := ShadingProperties{}
+	assert.False(t, properties.PerturbsNormal())
+	normal := properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, geometry.Vector{1, 0, 0},
+		geometry.Vector{0, 1, 0})
+	assert.Equal(t, geometry.Vector{0, 0, 1}, normal)
+}
+
+func TestShadingProperties_PerturbNormalWithBumpMap(t *testing.T) {
+	uTangent, vTangent := geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}
+
+	// A bump map of constant height shouldn't change the normal.
+	properties := ShadingProperties{BumpMap: SolidTexture{Color{0.5, 0.5, 0.5}}, BumpScale: 1}
+	assert.True(t, properties.PerturbsNormal())
+	normal := properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0, 1}, normal)
+
+	// A bump map whose height increases along U should tilt the normal back towards decreasing U.
+	properties.BumpMap = gradientTexture{}
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{-1, 0, 1}.ToUnit(), normal)
+
+	// The tilt should be proportional to the scale, and in the opposite direction for negative scales.
+	properties.BumpScale = -2
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{2, 0, 1}.ToUnit(), normal)
+
+	// The slope should account for the size of the tangents.
+	properties.BumpScale = 1
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent.Multiply(2),
+		vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{-1, 0, 2}.ToUnit(), normal)
+
+	// A spatial bump map should be evaluated at points displaced along the tangents rather than at other coordinates.
+	properties.BumpMap = gradientTexture{spatial: true}
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 1}.ToUnit(), normal)
+
+	// The geometric normal should be used where the tangents are degenerate.
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, geometry.Vector{},
+		geometry.Vector{})
+	assert.Equal(t, geometry.Vector{0, 0, 1}, normal)
+}
+
+func TestShadingProperties_PerturbNormalWithNormalMap(t *testing.T) {
+	normalMap, _ := NewImageTexture([][]Color{{{0.8, 0.5, 0.9}}})
+	properties := ShadingProperties{NormalMap: normalMap}
+	assert.True(t, properties.PerturbsNormal())
+
+	// Red should tilt the normal along the U tangent.
+	uTangent, vTangent := geometry.Vector{0, 1, 0}, geometry.Vector{-1, 0, 0}
+	normal := properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0.6, 0.8}, normal)
+
+	// Tangents that aren't perpendicular to the normal should be projected onto the surface.
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4,
+		geometry.Vector{0, 3, 5}, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0.6, 0.8}, normal)
+
+	// Green should tilt the normal along the image's Y axis, which runs opposite to V when the image is flipped.
+	normalMap.pixels = [][]Color{{{0.5, 0.8, 0.9}}}
+	normalMap.VScale = -1
+	properties.NormalMap = normalMap
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0.6, 0, 0.8}, normal)
+
+	// The geometric normal should be used where the tangents are degenerate.
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, geometry.Vector{0, 0, 2},
+		vTangent)
+	assert.Equal(t, geometry.Vector{0, 0, 1}, normal)
+	normalMap.VScale = 0
+	properties.NormalMap = normalMap
+	normal = properties.PerturbNormal(geometry.Point{}, geometry.Vector{0, 0, 1}, 0.3, 0.4, uTangent, vTangent)
+	assert.Equal(t, geometry.Vector{0, 0, 1}, normal)
+}
+
+// Texture whose brightness equals the U-coordinate, or the Y-coordinate of the point if it is spatial.
+type gradientTexture struct {
+	spatial bool
+}
+
+func (texture gradientTexture) AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color {
+	return Color{u, u, u}
+}
+
+func (texture gradientTexture) NeedsTextureCoordinates() bool {
+	return true
+}
+
+func (texture gradientTexture) AlbedoAtPoint(point geometry.Point, ditherVariation float64, random *rand.Rand) Color {
+	return Color{point.Y, point.Y, point.Y}
+}
+
+func (texture gradientTexture) IsSpatial() bool {
+	return texture.spatial
+}
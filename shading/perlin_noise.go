@@ -0,0 +1,122 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"math"
+)
+
+// Ken Perlin's reference permutation of the integers in [0, 256), repeated so that lookups can be chained without
+// wrapping.
+var perlinPermutation = func() [512]int {
+	values := [256]int{
+		151, 160, 137, 91, 90, 15, 131, 13, 201, 95, 96, 53, 194, 233, 7, 225, 140, 36, 103, 30, 69, 142, 8, 99, 37,
+		240, 21, 10, 23, 190, 6, 148, 247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32, 57, 177,
+		33, 88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175, 74, 165, 71, 134, 139, 48, 27, 166, 77, 146,
+		158, 231, 83, 111, 229, 122, 60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244, 102, 143, 54, 65, 25,
+		63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169, 200, 196, 135, 130, 116, 188, 159, 86, 164, 100,
+		109, 198, 173, 186, 3, 64, 52, 217, 226, 250, 124, 123, 5, 202, 38, 147, 118, 126, 255, 82, 85, 212, 207, 206,
+		59, 227, 47, 16, 58, 17, 182, 189, 28, 42, 223, 183, 170, 213, 119, 248, 152, 2, 44, 154, 163, 70, 221, 153,
+		101, 155, 167, 43, 172, 9, 129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104, 218, 246,
+		97, 228, 251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241, 81, 51, 145, 235, 249, 14, 239, 107, 49, 192,
+		214, 31, 181, 199, 106, 157, 184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254, 138, 236, 205, 93, 222, 114,
+		67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180,
+	}
+	var permutation [512]int
+	for i := range permutation {
+		permutation[i] = values[i%256]
+	}
+	return permutation
+}()
+
+// Returns the value of Perlin's improved gradient noise at the given point, which varies smoothly between roughly -1
+// and 1 with features about one unit apart, and is zero at every point with integer coordinates.
+func perlinNoise(point geometry.Point) float64 {
+	// Find the unit cube containing the point, and the point's position within it.
+	floorX, floorY, floorZ := math.Floor(point.X), math.Floor(point.Y), math.Floor(point.Z)
+	x, y, z := point.X-floorX, point.Y-floorY, point.Z-floorZ
+	cubeX, cubeY, cubeZ := int(floorX)&255, int(floorY)&255, int(floorZ)&255
+
+	// Hash the coordinates of each of the cube's corners to pick a pseudo-random gradient for it.
+	p := &perlinPermutation
+	a := p[cubeX] + cubeY
+	aa, ab := p[a]+cubeZ, p[a+1]+cubeZ
+	b := p[cubeX+1] + cubeY
+	ba, bb := p[b]+cubeZ, p[b+1]+cubeZ
+
+	// Blend the contributions of the corners' gradients using a curve with continuous first and second derivatives.
+	u, v, w := fade(x), fade(y), fade(z)
+	return lerp(w,
+		lerp(v,
+			lerp(u, gradient(p[aa], x, y, z), gradient(p[ba], x-1, y, z)),
+			lerp(u, gradient(p[ab], x, y-1, z), gradient(p[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, gradient(p[aa+1], x, y, z-1), gradient(p[ba+1], x-1, y, z-1)),
+			lerp(u, gradient(p[ab+1], x, y-1, z-1), gradient(p[bb+1], x-1, y-1, z-1))))
+}
+
+// Returns the sum of the given number of octaves of noise at the given point, each having its frequency multiplied by
+// the lacunarity and its amplitude by the gain relative to the previous one, and normalized back into roughly [-1, 1].
+// This is known as fractal Brownian motion.
+func fractalNoise(point geometry.Point, octaves int, lacunarity, gain float64) float64 {
+	return sumOctaves(point, octaves, lacunarity, gain, perlinNoise)
+}
+
+// Returns the same as fractalNoise but summing the absolute value of each octave, giving a result in [0, 1] with sharp
+// creases where the noise crosses zero.
+func turbulence(point geometry.Point, octaves int, lacunarity, gain float64) float64 {
+	return sumOctaves(point, octaves, lacunarity, gain, func(point geometry.Point) float64 {
+		return math.Abs(perlinNoise(point))
+	})
+}
+
+// Returns the normalized sum of octaves of the given noise function, always including at least one.
+func sumOctaves(point geometry.Point, octaves int, lacunarity, gain float64,
+	noise func(point geometry.Point) float64) float64 {
+	sum, totalAmplitude := 0.0, 0.0
+	amplitude, frequency := 1.0, 1.0
+	for i := 0; i < octaves || i == 0; i++ {
+		sum += amplitude * noise(geometry.Point{point.X * frequency, point.Y * frequency, point.Z * frequency})
+		totalAmplitude += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	if totalAmplitude == 0 {
+		return 0
+	}
+	return sum / totalAmplitude
+}
+
+// Returns the smoothstep-like curve 6t^5 - 15t^4 + 10t^3 used to blend between lattice points.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// Returns the linear interpolation between the two given values at the given fraction of the way between them.
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// Returns the dot product of the given offset from a lattice point with one of twelve gradient vectors pointing to
+// the midpoints of a cube's edges, selected using the low bits of the given hash.
+func gradient(hash int, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestPerlinNoise(t *testing.T) {
+	// The noise should be zero at lattice points, including negative ones.
+	for _, point := range []geometry.Point{{0, 0, 0}, {1, 2, 3}, {-5, 7, -300}} {
+		assert.Equal(t, 0.0, perlinNoise(point))
+	}
+
+	// The noise should be deterministic, bounded and continuous, and should actually vary.
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for i := 0; i < 10000; i++ {
+		point := geometry.Point{float64(i) * 0.0137, float64(i%97) * -0.31, float64(i%13) * 0.77}
+		value := perlinNoise(point)
+		assert.Equal(t, value, perlinNoise(point))
+		assert.True(t, value >= -1 && value <= 1, "value: %v", value)
+		assert.InDelta(t, value, perlinNoise(point.Translate(geometry.Vector{1e-6, 1e-6, 1e-6})), 1e-4)
+		minValue = math.Min(minValue, value)
+		maxValue = math.Max(maxValue, value)
+	}
+	assert.Less(t, minValue, -0.5)
+	assert.Greater(t, maxValue, 0.5)
+
+	// The lattice should repeat every 256 units.
+	assert.InDelta(t, perlinNoise(geometry.Point{0.3, 0.6, 0.9}), perlinNoise(geometry.Point{256.3, -255.4, 512.9}),
+		1e-9)
+}
+
+func TestFractalNoise(t *testing.T) {
+	point := geometry.Point{1.3, 2.7, -0.4}
+
+	// A single octave should be plain noise, regardless of the other parameters.
+	assert.Equal(t, perlinNoise(point), fractalNoise(point, 1, 2, 0.5))
+	assert.Equal(t, perlinNoise(point), fractalNoise(point, 0, 2, 0.5))
+	assert.Equal(t, math.Abs(perlinNoise(point)), turbulence(point, 1, 2, 0.5))
+
+	// Additional octaves should be added in at increasing frequency and decreasing amplitude.
+	doubled := geometry.Point{2.6, 5.4, -0.8}
+	assert.InDelta(t, (perlinNoise(point)+0.5*perlinNoise(doubled))/1.5, fractalNoise(point, 2, 2, 0.5), 1e-9)
+	assert.InDelta(t, (math.Abs(perlinNoise(point))+0.5*math.Abs(perlinNoise(doubled)))/1.5,
+		turbulence(point, 2, 2, 0.5), 1e-9)
+	for i := 0; i < 1000; i++ {
+		point := geometry.Point{float64(i) * 0.173, float64(i) * 0.071, 0}
+		value := turbulence(point, 5, 2, 0.5)
+		assert.True(t, value >= 0 && value <= 1, "value: %v", value)
+	}
+}
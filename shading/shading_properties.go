@@ -2,9 +2,14 @@
 
 package shading
 
-import "errors"
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"math"
+)
 
-// Holds all the properties necessary for determining how a surface should be shaded.
+// Holds all the properties necessary for determining how a surface should be shaded, as a material made up of diffuse,
+// reflective and refractive components.
 type ShadingProperties struct {
 	DiffuseTexture    Texture // Interface for determining the albedo (color) of the surface at a given point.
 	SpecularExponent  float64 // Dimensionless property for tuning the size of the specular reflection
@@ -12,6 +17,9 @@ type ShadingProperties struct {
 	Opacity           float64 // What proportion of light that the surface blocks as a value in [0, 1]
 	Reflectivity      float64 // What proportion of light that the surface reflects as a value in [0, 1]
 	RefractiveIndex   float64 // For a surface that is not fully opaque, specifies how fast light travels through it
+	BumpMap           Texture // Optional texture whose brightness gives the height of small bumps in the surface
+	BumpScale         float64 // Height of the bumps in the surface corresponding to a fully white bump map
+	NormalMap         Texture // Optional texture whose color encodes the surface normal relative to the tangents
 }
 
 func (properties ShadingProperties) Validate() error {
@@ -30,6 +38,121 @@ func (properties ShadingProperties) Validate() error {
 	if properties.Opacity < 1 && properties.RefractiveIndex < 1 {
 		return errors.New("refractive index must be at least 1 if not fully opaque")
 	}
+	if properties.BumpMap != nil && properties.NormalMap != nil {
+		return errors.New("bump map and normal map cannot both be specified")
+	}
 
 	return nil
 }
+
+func (properties ShadingProperties) Bsdf(interaction Interaction, outgoing, incoming geometry.Vector) Color {
+	if interaction.Normal.Dot(incoming) <= 0 {
+		return Color{}
+	}
+	albedo := textureColor(properties.DiffuseTexture, interaction)
+	return albedo.Multiply(properties.Opacity * (1 - properties.Reflectivity) / math.Pi)
+}
+
+// Chooses between the refractive, reflective and diffuse components at random in proportion to their weights, so that
+// the sample's weight doesn't need to be adjusted for the choice.
+func (properties ShadingProperties) SampleBsdf(interaction Interaction, outgoing geometry.Vector) (BsdfSample, bool) {
+	kRefraction, kReflection := properties.specularWeights()
+	choice := interaction.Random.Float64()
+	if choice < kRefraction {
+		etaIn, etaOut := refractiveIndices(interaction, properties.RefractiveIndex)
+		reflectance, refraction := refraction(interaction, outgoing, etaIn, etaOut)
+		if interaction.Random.Float64() < reflectance {
+			return reflection(interaction, outgoing, 1), true
+		}
+		refraction.Weight = Color{1, 1, 1}
+		return refraction, true
+	}
+	if choice < kRefraction+kReflection {
+		return reflection(interaction, outgoing, 1), true
+	}
+
+	// Since the directions are distributed in proportion to the cosine term of the rendering equation, the weight of
+	// the Lambertian BRDF reduces to just the albedo.
+	direction := sampleCosineHemisphere(interaction.Normal, interaction.Random.Float64(), interaction.Random.Float64())
+	return BsdfSample{direction, textureColor(properties.DiffuseTexture, interaction), interaction.RefractiveIndex,
+		(1 - kRefraction - kReflection) * direction.Dot(interaction.Normal) / math.Pi}, true
+}
+
+func (properties ShadingProperties) Pdf(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	cosIn := incoming.Dot(interaction.Normal)
+	if cosIn <= 0 {
+		return 0
+	}
+	kRefraction, kReflection := properties.specularWeights()
+	return (1 - kRefraction - kReflection) * cosIn / math.Pi
+}
+
+// Returns the directions of perfect reflection and refraction, weighted by their components.
+func (properties ShadingProperties) SpecularSamples(interaction Interaction, outgoing geometry.Vector) []BsdfSample {
+	var samples []BsdfSample
+	kRefraction, kReflection := properties.specularWeights()
+	if kRefraction > 0 {
+		etaIn, etaOut := interaction.RefractiveIndex, properties.RefractiveIndex
+		if etaIn > 1 {
+			// If the refractive index of the medium isn't 1, the light is passing out of the material instead of in.
+			// The indices are swapped rather than taking the outside to be empty, so that light passes straight out of
+			// the material in the direction it travelled through it, as it always has in the Whitted integrator.
+			etaIn, etaOut = etaOut, etaIn
+		}
+
+		// Some of the light that would be refracted is reflected instead, depending on the angle.
+		reflectance, refraction := refraction(interaction, outgoing, etaIn, etaOut)
+		kReflection += reflectance * kRefraction
+		kRefraction -= reflectance * kRefraction
+		if kRefraction > 0 {
+			refraction.Weight = Color{kRefraction, kRefraction, kRefraction}
+			samples = append(samples, refraction)
+		}
+	}
+	if kReflection > 0 {
+		samples = append(samples, reflection(interaction, outgoing, kReflection))
+	}
+	return samples
+}
+
+func (properties ShadingProperties) Transmittance(interaction Interaction) Color {
+	transparency := 1 - properties.Opacity
+	return Color{transparency, transparency, transparency}
+}
+
+func (properties ShadingProperties) IsRefractive() bool {
+	return properties.Opacity < 1 && properties.RefractiveIndex != 1
+}
+
+// Returns the brightness of the Phong specular highlight, which is centered on the direction of perfect reflection.
+func (properties ShadingProperties) Highlight(interaction Interaction, outgoing, incoming geometry.Vector) float64 {
+	if properties.SpecularIntensity == 0 {
+		return 0
+	}
+	normal := interaction.Normal
+	reflectedDirection := normal.Multiply(2 * normal.Dot(outgoing)).Add(outgoing.Multiply(-1))
+	return properties.SpecularIntensity *
+		math.Pow(math.Max(reflectedDirection.Dot(incoming), 0), properties.SpecularExponent)
+}
+
+func (properties ShadingProperties) ShadingNormal(interaction Interaction) geometry.Vector {
+	if !properties.PerturbsNormal() {
+		return interaction.Normal
+	}
+	u, v := interaction.Surface.ToTextureCoordinates(interaction.Point)
+	uTangent, vTangent := interaction.Surface.ToTextureTangents(interaction.Point)
+	return properties.PerturbNormal(interaction.Point, interaction.Normal, u, v, uTangent, vTangent)
+}
+
+func (properties ShadingProperties) FitTextures(mapping TextureMapping, uSize, vSize float64) Material {
+	properties.DiffuseTexture = fitTexture(properties.DiffuseTexture, mapping, uSize, vSize)
+	properties.BumpMap = fitTexture(properties.BumpMap, mapping, uSize, vSize)
+	properties.NormalMap = fitTexture(properties.NormalMap, mapping, uSize, vSize)
+	return properties
+}
+
+// Returns the weights of the refractive and perfectly reflective components of the surface, before accounting for the
+// light that is reflected rather than refracted at the surface.
+func (properties ShadingProperties) specularWeights() (float64, float64) {
+	return 1 - properties.Opacity, properties.Reflectivity * properties.Opacity
+}
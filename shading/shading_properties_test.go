@@ -3,7 +3,10 @@
 package shading
 
 import (
+	"github.com/patfair/raytracer/geometry"
 	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -67,4 +70,148 @@ func TestShadingProperties_Validate(t *testing.T) {
 		assert.Contains(t, err.Error(), "index must be at least 1")
 	}
 	shadingProperties.RefractiveIndex = 1
+
+	shadingProperties.BumpMap = SolidTexture{}
+	err = shadingProperties.Validate()
+	assert.Nil(t, err)
+	shadingProperties.NormalMap = SolidTexture{}
+	err = shadingProperties.Validate()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "bump map and normal map cannot both be specified")
+	}
+	shadingProperties.BumpMap = nil
+	err = shadingProperties.Validate()
+	assert.Nil(t, err)
+}
+
+func TestShadingProperties_Bsdf(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{0, 0, 1}
+	properties := ShadingProperties{DiffuseTexture: SolidTexture{Color{0.5, 1, 0.25}}, Opacity: 1}
+	AssertColorEqual(t, Color{0.5, 1, 0.25}.Multiply(1/math.Pi),
+		properties.Bsdf(interaction, outgoing, geometry.Vector{1, 0, 1}.ToUnit()), 1e-9)
+	assert.Equal(t, Color{}, properties.Bsdf(interaction, outgoing, geometry.Vector{1, 0, -1}.ToUnit()))
+
+	// Light that is reflected or refracted perfectly shouldn't be included.
+	properties.Reflectivity = 0.5
+	properties.Opacity = 0.5
+	AssertColorEqual(t, Color{0.5, 1, 0.25}.Multiply(0.25/math.Pi),
+		properties.Bsdf(interaction, outgoing, geometry.Vector{1, 0, 1}.ToUnit()), 1e-9)
+}
+
+func TestShadingProperties_SpecularSamples(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 1, 0}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 1, 0}.ToUnit()
+	properties := ShadingProperties{DiffuseTexture: SolidTexture{}, Opacity: 1}
+	assert.Empty(t, properties.SpecularSamples(interaction, outgoing))
+
+	properties.Reflectivity = 0.75
+	samples := properties.SpecularSamples(interaction, outgoing)
+	if assert.Equal(t, 1, len(samples)) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-1, 1, 0}.ToUnit(), samples[0].Direction)
+		assert.Equal(t, Color{0.75, 0.75, 0.75}, samples[0].Weight)
+		assert.Equal(t, 1.0, samples[0].RefractiveIndex)
+	}
+
+	// Light passing into a transparent material should be split between refraction and reflection.
+	properties = ShadingProperties{DiffuseTexture: SolidTexture{}, RefractiveIndex: 1.5}
+	samples = properties.SpecularSamples(interaction, interaction.Normal)
+	reflectance, _ := fresnelReflectance(1, 1, 1.5)
+	if assert.Equal(t, 2, len(samples)) {
+		geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 0}, samples[0].Direction)
+		AssertColorEqual(t, Color{1 - reflectance, 1 - reflectance, 1 - reflectance}, samples[0].Weight, 1e-9)
+		assert.Equal(t, 1.5, samples[0].RefractiveIndex)
+		geometry.AssertVectorEqual(t, geometry.Vector{0, 1, 0}, samples[1].Direction)
+		AssertColorEqual(t, Color{reflectance, reflectance, reflectance}, samples[1].Weight, 1e-9)
+	}
+
+	// Light leaving the material should pass straight out of it.
+	interaction.RefractiveIndex = 1.5
+	samples = properties.SpecularSamples(interaction, outgoing)
+	if assert.Equal(t, 1, len(samples)) {
+		geometry.AssertVectorEqual(t, outgoing.Multiply(-1), samples[0].Direction)
+		AssertColorEqual(t, Color{1, 1, 1}, samples[0].Weight, 1e-9)
+		assert.Equal(t, 1.5, samples[0].RefractiveIndex)
+	}
+
+	// Light passing into a material of lower refractive index at a grazing angle should be totally internally
+	// reflected.
+	interaction.RefractiveIndex = 1.2
+	samples = properties.SpecularSamples(interaction, geometry.Vector{3, 1, 0}.ToUnit())
+	if assert.Equal(t, 1, len(samples)) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-3, 1, 0}.ToUnit(), samples[0].Direction)
+		assert.Equal(t, Color{1, 1, 1}, samples[0].Weight)
+		assert.Equal(t, 1.2, samples[0].RefractiveIndex)
+	}
+}
+
+func TestShadingProperties_SampleBsdf(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	properties := ShadingProperties{DiffuseTexture: SolidTexture{Color{0.5, 1, 0.25}}, Opacity: 1}
+	for i := 0; i < 100; i++ {
+		sample, ok := properties.SampleBsdf(interaction, outgoing)
+		if assert.True(t, ok) {
+			assert.Greater(t, sample.Direction.Dot(interaction.Normal), 0.0)
+			assert.Equal(t, Color{0.5, 1, 0.25}, sample.Weight)
+		}
+	}
+
+	properties.Reflectivity = 1
+	sample, ok := properties.SampleBsdf(interaction, outgoing)
+	if assert.True(t, ok) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-1, 0, 1}.ToUnit(), sample.Direction)
+		assert.Equal(t, Color{1, 1, 1}, sample.Weight)
+	}
+
+	// Light leaving a transparent material at a grazing angle should be totally internally reflected.
+	properties = ShadingProperties{DiffuseTexture: SolidTexture{}, RefractiveIndex: 1.5}
+	interaction.RefractiveIndex = 1.5
+	sample, ok = properties.SampleBsdf(interaction, outgoing)
+	if assert.True(t, ok) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-1, 0, 1}.ToUnit(), sample.Direction)
+		assert.Equal(t, Color{1, 1, 1}, sample.Weight)
+		assert.Equal(t, 1.5, sample.RefractiveIndex)
+	}
+}
+
+func TestShadingProperties_Pdf(t *testing.T) {
+	// The density of each sampled direction should match, with the perfectly reflected light excluded.
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	properties := ShadingProperties{DiffuseTexture: SolidTexture{Color{1, 1, 1}}, Opacity: 1, Reflectivity: 0.5}
+	for i := 0; i < 100; i++ {
+		if sample, ok := properties.SampleBsdf(interaction, outgoing); ok && sample.Pdf > 0 {
+			assert.InDelta(t, sample.Pdf, properties.Pdf(interaction, outgoing, sample.Direction), 1e-9)
+		}
+	}
+	assert.InDelta(t, 0.5/math.Pi, properties.Pdf(interaction, outgoing, interaction.Normal), 1e-9)
+	assert.Equal(t, 0.0, properties.Pdf(interaction, outgoing, geometry.Vector{1, 0, -1}.ToUnit()))
+}
+
+func TestShadingProperties_Highlight(t *testing.T) {
+	interaction := Interaction{Normal: geometry.Vector{0, 0, 1}, RefractiveIndex: 1,
+		Random: rand.New(rand.NewSource(0))}
+	outgoing := geometry.Vector{1, 0, 1}.ToUnit()
+	properties := ShadingProperties{Opacity: 1, SpecularIntensity: 0.5, SpecularExponent: 10}
+	assert.InDelta(t, 0.5, properties.Highlight(interaction, outgoing, geometry.Vector{-1, 0, 1}.ToUnit()), 1e-9)
+	assert.Less(t, properties.Highlight(interaction, outgoing, geometry.Vector{0, 0, 1}), 0.5)
+	assert.Equal(t, 0.0, properties.Highlight(interaction, outgoing, geometry.Vector{1, 0, 1}.ToUnit()))
+}
+
+func TestShadingProperties_Transmittance(t *testing.T) {
+	assert.Equal(t, Color{}, ShadingProperties{Opacity: 1}.Transmittance(Interaction{}))
+	assert.Equal(t, Color{0.75, 0.75, 0.75}, ShadingProperties{Opacity: 0.25}.Transmittance(Interaction{}))
+}
+
+func TestShadingProperties_IsRefractive(t *testing.T) {
+	assert.False(t, ShadingProperties{Opacity: 1, RefractiveIndex: 1.5}.IsRefractive())
+	assert.True(t, ShadingProperties{Opacity: 0.25, RefractiveIndex: 1.5}.IsRefractive())
+
+	// A transparent surface with the same refractive index as the space around it lets light straight through.
+	assert.False(t, ShadingProperties{Opacity: 0, RefractiveIndex: 1}.IsRefractive())
 }
@@ -2,14 +2,16 @@
 
 package shading
 
+import "math/rand"
+
 // Represents a texture that has one uniform and solid diffuse color.
 type SolidTexture struct {
 	Color Color // Single solid color of the texture
 }
 
 // Returns the same solid color at all texture coordinates.
-func (texture SolidTexture) AlbedoAt(u, v, ditherVariation float64) Color {
-	return texture.Color.Dither(ditherVariation)
+func (texture SolidTexture) AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color {
+	return texture.Color.Dither(ditherVariation, random)
 }
 
 func (texture SolidTexture) NeedsTextureCoordinates() bool {
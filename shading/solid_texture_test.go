@@ -4,15 +4,17 @@ package shading
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math/rand"
 	"testing"
 )
 
 func TestSolidTexture_AlbedoAt(t *testing.T) {
 	color := Color{0.3, 0.5, 0.7}
 	texture := SolidTexture{color}
+	random := rand.New(rand.NewSource(0))
 	ditherVariation := 0.1
 	assert.False(t, texture.NeedsTextureCoordinates())
 
-	assertColorEqual(t, color, texture.AlbedoAt(0, 0, ditherVariation), ditherVariation)
-	assertColorEqual(t, color, texture.AlbedoAt(-1, 5, ditherVariation), ditherVariation)
+	AssertColorEqual(t, color, texture.AlbedoAt(0, 0, ditherVariation, random), ditherVariation)
+	AssertColorEqual(t, color, texture.AlbedoAt(-1, 5, ditherVariation, random), ditherVariation)
 }
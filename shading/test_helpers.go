@@ -0,0 +1,15 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package shading
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Asserts equality of the two given colors, within the given allowable error.
+func AssertColorEqual(t *testing.T, expected, actual Color, delta float64) {
+	assert.InDelta(t, expected.R, actual.R, delta, "R expected: %v, actual: %v", expected.R, actual.R)
+	assert.InDelta(t, expected.G, actual.G, delta, "G expected: %v, actual: %v", expected.G, actual.G)
+	assert.InDelta(t, expected.B, actual.B, delta, "B expected: %v, actual: %v", expected.B, actual.B)
+}
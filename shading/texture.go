@@ -2,11 +2,30 @@
 
 package shading
 
+import (
+	"github.com/patfair/raytracer/geometry"
+	"math/rand"
+)
+
 // Interface for determining the amount of diffuse light reflected at a given point on a surface.
 type Texture interface {
-	// Returns the diffuse color that the texture should have at the given point in texture coordinates.
-	AlbedoAt(u, v, ditherVariation float64) Color
+	// Returns the diffuse color that the texture should have at the given point in texture coordinates, dithered using
+	// the given source of random numbers (which may be nil if the dither variation is zero).
+	AlbedoAt(u, v, ditherVariation float64, random *rand.Rand) Color
 
 	// Returns whether the specific texture implementation is independent of coordinates.
 	NeedsTextureCoordinates() bool
 }
+
+// Interface for textures that can be evaluated at points in space rather than at a surface's texture coordinates, so
+// that their patterns aren't affected by how each surface is parameterized.
+type SpatialTexture interface {
+	Texture
+
+	// Returns the diffuse color that the texture should have at the given point in world coordinates, dithered in the
+	// same way as by AlbedoAt.
+	AlbedoAtPoint(point geometry.Point, ditherVariation float64, random *rand.Rand) Color
+
+	// Returns whether the texture should be evaluated using AlbedoAtPoint rather than AlbedoAt.
+	IsSpatial() bool
+}
@@ -0,0 +1,291 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"math"
+	"sort"
+)
+
+const (
+	// Relative cost of testing a ray against a bounding box, compared to testing it against a surface.
+	bvhTraversalCost = 0.125
+
+	// Maximum number of surfaces to keep in a leaf even if splitting it further isn't estimated to be any cheaper.
+	bvhMaxSurfacesPerLeaf = 8
+
+	// Number of buckets along an axis into which surfaces are grouped when evaluating candidate splits.
+	bvhSahBins = 16
+)
+
+// Tree of nested bounding boxes built over a set of surfaces, which allows a ray to be tested against only the small
+// fraction of surfaces whose boxes it actually passes through.
+type BoundingVolumeHierarchy struct {
+	nodes    []bvhNode // Flattened tree in depth-first order, such that a node's first child immediately follows it
+	surfaces []Surface // Surfaces reordered so that each leaf refers to a contiguous range
+}
+
+type bvhNode struct {
+	box          geometry.BoundingBox // Box containing every surface under this node
+	offset       int                  // For a leaf, index of its first surface; otherwise, index of its second child
+	surfaceCount int                  // Number of surfaces in a leaf, or zero for an interior node
+	axis         int                  // For an interior node, the axis along which its children were split
+}
+
+// Builds a hierarchy over the given surfaces, using the surface area heuristic to decide how to partition them.
+func NewBoundingVolumeHierarchy(surfaces []Surface) *BoundingVolumeHierarchy {
+	bvh := &BoundingVolumeHierarchy{}
+	if len(surfaces) == 0 {
+		return bvh
+	}
+
+	primitives := make([]bvhPrimitive, len(surfaces))
+	for i, surface := range surfaces {
+		box := surface.BoundingBox()
+		primitives[i] = bvhPrimitive{surface: surface, box: box, centroid: box.Centroid()}
+	}
+	bvh.nodes = make([]bvhNode, 0, 2*len(surfaces))
+	bvh.surfaces = make([]Surface, 0, len(surfaces))
+	bvh.build(primitives)
+	return bvh
+}
+
+// Returns the box containing every surface in the hierarchy.
+func (bvh *BoundingVolumeHierarchy) BoundingBox() geometry.BoundingBox {
+	if len(bvh.nodes) == 0 {
+		return geometry.EmptyBoundingBox()
+	}
+	return bvh.nodes[0].box
+}
+
+// Returns the closest intersection between the given ray and any surface in the hierarchy, along with the surface that
+// was intersected. Returns nil values if the ray doesn't intersect anything. Composite surfaces are resolved to the
+// constituent surface that was hit.
+func (bvh *BoundingVolumeHierarchy) ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface) {
+	var closestIntersection *geometry.Intersection
+	var closestSurface Surface
+	bvh.traverse(ray, func() float64 {
+		if closestIntersection == nil {
+			return math.Inf(1)
+		}
+		return closestIntersection.Distance
+	}, func(surface Surface) bool {
+		var intersection *geometry.Intersection
+		if composite, ok := surface.(Composite); ok {
+			intersection, surface = composite.ClosestIntersection(ray)
+		} else {
+			intersection = surface.Intersection(ray)
+		}
+		if intersection != nil {
+			if closestIntersection == nil || intersection.Distance < closestIntersection.Distance {
+				closestIntersection = intersection
+				closestSurface = surface
+			}
+		}
+		return true
+	})
+	return closestIntersection, closestSurface
+}
+
+// Invokes the given function for every surface in the hierarchy that the ray intersects, in no particular order, until
+// the function returns false. Composite surfaces are visited once for each constituent surface that is intersected.
+// Returns false if visiting was stopped early.
+func (bvh *BoundingVolumeHierarchy) VisitIntersections(ray geometry.Ray,
+	visit func(surface Surface, intersection *geometry.Intersection) bool) bool {
+	return bvh.traverse(ray, func() float64 {
+		return math.Inf(1)
+	}, func(surface Surface) bool {
+		if composite, ok := surface.(Composite); ok {
+			return composite.VisitIntersections(ray, visit)
+		}
+		if intersection := surface.Intersection(ray); intersection != nil {
+			return visit(surface, intersection)
+		}
+		return true
+	})
+}
+
+// Walks the tree, invoking the given function for each surface in a leaf whose box is entered by the ray no further
+// away than the current maximum distance. Nearer children are visited first so that the maximum distance shrinks
+// quickly when searching for the closest intersection. Returns false if the traversal was stopped early by the visiting
+// function.
+func (bvh *BoundingVolumeHierarchy) traverse(ray geometry.Ray, maxDistance func() float64,
+	visit func(surface Surface) bool) bool {
+	if len(bvh.nodes) == 0 {
+		return true
+	}
+
+	direction := ray.Direction.ToUnit()
+	inverseDirection := geometry.Vector{1 / direction.X, 1 / direction.Y, 1 / direction.Z}
+	directionIsNegative := [3]bool{direction.X < 0, direction.Y < 0, direction.Z < 0}
+
+	var stackArray [64]int
+	stack := stackArray[:0]
+	nodeIndex := 0
+	for {
+		node := &bvh.nodes[nodeIndex]
+		near, _, ok := node.box.IntersectionWithInverse(ray.Origin, inverseDirection)
+		if ok && near <= maxDistance() {
+			if node.surfaceCount > 0 {
+				for _, surface := range bvh.surfaces[node.offset : node.offset+node.surfaceCount] {
+					if !visit(surface) {
+						return false
+					}
+				}
+			} else {
+				// Descend into the child on the near side of the split first and save the other one for later.
+				if directionIsNegative[node.axis] {
+					stack = append(stack, nodeIndex+1)
+					nodeIndex = node.offset
+				} else {
+					stack = append(stack, node.offset)
+					nodeIndex = nodeIndex + 1
+				}
+				continue
+			}
+		}
+
+		if len(stack) == 0 {
+			return true
+		}
+		nodeIndex = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+	}
+}
+
+// Holds the information about a single surface that is needed while building the tree.
+type bvhPrimitive struct {
+	surface  Surface
+	box      geometry.BoundingBox
+	centroid geometry.Point
+}
+
+// Recursively appends the nodes for the subtree containing the given primitives, returning the index of its root.
+func (bvh *BoundingVolumeHierarchy) build(primitives []bvhPrimitive) int {
+	nodeIndex := len(bvh.nodes)
+	box := geometry.EmptyBoundingBox()
+	for _, primitive := range primitives {
+		box = box.Union(primitive.box)
+	}
+	bvh.nodes = append(bvh.nodes, bvhNode{box: box})
+
+	axis, splitIndex, splitCost := partitionBySah(primitives)
+	leafCost := float64(len(primitives))
+	if splitIndex == 0 || (splitCost >= leafCost && len(primitives) <= bvhMaxSurfacesPerLeaf) {
+		bvh.nodes[nodeIndex].offset = len(bvh.surfaces)
+		bvh.nodes[nodeIndex].surfaceCount = len(primitives)
+		for _, primitive := range primitives {
+			bvh.surfaces = append(bvh.surfaces, primitive.surface)
+		}
+		return nodeIndex
+	}
+
+	bvh.build(primitives[:splitIndex])
+	secondChildIndex := bvh.build(primitives[splitIndex:])
+	bvh.nodes[nodeIndex].offset = secondChildIndex
+	bvh.nodes[nodeIndex].axis = axis
+	return nodeIndex
+}
+
+// Groups the primitives into bins by centroid along the axis in which the centroids are most spread out, evaluates the
+// estimated cost of splitting between each pair of adjacent bins, and reorders the primitives in place according to the
+// cheapest split. Returns the axis, the number of primitives on the near side of the split, and its estimated cost. If
+// the heuristic finds no partition cheaper than a leaf but there are too many primitives for one, they are split evenly
+// instead to keep the tree balanced. A returned index of zero indicates that no partition is possible.
+func partitionBySah(primitives []bvhPrimitive) (int, int, float64) {
+	if len(primitives) < 2 {
+		return 0, 0, math.Inf(1)
+	}
+	bounds := centroidBounds(primitives)
+	axis := bounds.LongestAxis()
+	minCoordinate := bounds.Min.Coordinate(axis)
+	extent := bounds.Max.Coordinate(axis) - minCoordinate
+	if extent <= 0 {
+		// Every centroid is in the same place, so there is nothing to distinguish the primitives by.
+		if len(primitives) <= bvhMaxSurfacesPerLeaf {
+			return axis, 0, math.Inf(1)
+		}
+		return axis, len(primitives) / 2, math.Inf(1)
+	}
+
+	var binCounts [bvhSahBins]int
+	var binBoxes [bvhSahBins]geometry.BoundingBox
+	for i := range binBoxes {
+		binBoxes[i] = geometry.EmptyBoundingBox()
+	}
+	binIndex := func(primitive bvhPrimitive) int {
+		bin := int(bvhSahBins * (primitive.centroid.Coordinate(axis) - minCoordinate) / extent)
+		if bin >= bvhSahBins {
+			bin = bvhSahBins - 1
+		}
+		return bin
+	}
+	parentBox := geometry.EmptyBoundingBox()
+	for _, primitive := range primitives {
+		bin := binIndex(primitive)
+		binCounts[bin]++
+		binBoxes[bin] = binBoxes[bin].Union(primitive.box)
+		parentBox = parentBox.Union(primitive.box)
+	}
+
+	// Sweep from the far end to precompute the cost contribution of every possible far-side partition.
+	var farCosts [bvhSahBins]float64
+	farBox := geometry.EmptyBoundingBox()
+	farCount := 0
+	for i := bvhSahBins - 1; i > 0; i-- {
+		farBox = farBox.Union(binBoxes[i])
+		farCount += binCounts[i]
+		farCosts[i] = farBox.SurfaceArea() * float64(farCount)
+	}
+
+	bestBin := 0
+	bestCost := math.Inf(1)
+	parentArea := parentBox.SurfaceArea()
+	nearBox := geometry.EmptyBoundingBox()
+	nearCount := 0
+	for i := 1; i < bvhSahBins; i++ {
+		nearBox = nearBox.Union(binBoxes[i-1])
+		nearCount += binCounts[i-1]
+		if nearCount == 0 || nearCount == len(primitives) {
+			continue
+		}
+		var cost float64
+		if parentArea > 0 {
+			cost = bvhTraversalCost + (nearBox.SurfaceArea()*float64(nearCount)+farCosts[i])/parentArea
+		} else {
+			// Degenerate case where every primitive is flat and coplanar; fall back to balancing the counts.
+			cost = bvhTraversalCost + math.Max(float64(nearCount), float64(len(primitives)-nearCount))
+		}
+		if cost < bestCost {
+			bestBin = i
+			bestCost = cost
+		}
+	}
+
+	if bestCost >= float64(len(primitives)) && len(primitives) > bvhMaxSurfacesPerLeaf {
+		sort.Slice(primitives, func(i, j int) bool {
+			return primitives[i].centroid.Coordinate(axis) < primitives[j].centroid.Coordinate(axis)
+		})
+		return axis, len(primitives) / 2, bestCost
+	}
+
+	// Partition the primitives in place so that those in the near bins come first.
+	splitIndex := 0
+	for i, primitive := range primitives {
+		if binIndex(primitive) < bestBin {
+			primitives[i], primitives[splitIndex] = primitives[splitIndex], primitives[i]
+			splitIndex++
+		}
+	}
+	return axis, splitIndex, bestCost
+}
+
+// Returns the smallest box containing the centroids of all the given primitives.
+func centroidBounds(primitives []bvhPrimitive) geometry.BoundingBox {
+	box := geometry.EmptyBoundingBox()
+	for _, primitive := range primitives {
+		box = box.AddPoint(primitive.centroid)
+	}
+	return box
+}
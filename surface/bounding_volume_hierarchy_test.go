@@ -0,0 +1,172 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestBoundingVolumeHierarchy_Empty(t *testing.T) {
+	bvh := NewBoundingVolumeHierarchy(nil)
+	intersection, surface := bvh.ClosestIntersection(geometry.Ray{geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}})
+	assert.Nil(t, intersection)
+	assert.Nil(t, surface)
+	assert.Equal(t, geometry.EmptyBoundingBox(), bvh.BoundingBox())
+}
+
+func TestBoundingVolumeHierarchy_ClosestIntersection(t *testing.T) {
+	surfaces := newRandomSpheres(500, 20)
+	plane, _ := NewPlane(geometry.Point{-30, -30, -25}, geometry.Vector{60, 0, 0}, geometry.Vector{0, 60, 0},
+		shading.ShadingProperties{Opacity: 1})
+	disc, _ := NewDisc(geometry.Point{0, 0, 25}, geometry.Vector{10, 0, 0}, geometry.Vector{0, 10, 0},
+		shading.ShadingProperties{Opacity: 1})
+	surfaces = append(surfaces, plane, disc)
+	bvh := NewBoundingVolumeHierarchy(surfaces)
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		ray := newRandomRay(random, 30)
+		expectedIntersection, expectedSurface := linearClosestIntersection(surfaces, ray)
+		intersection, surface := bvh.ClosestIntersection(ray)
+		if assert.Equal(t, expectedIntersection == nil, intersection == nil) && intersection != nil {
+			assert.Equal(t, expectedIntersection.Distance, intersection.Distance)
+			assert.Equal(t, expectedSurface, surface)
+		}
+	}
+}
+
+func TestBoundingVolumeHierarchy_VisitIntersections(t *testing.T) {
+	surfaces := newRandomSpheres(500, 20)
+	bvh := NewBoundingVolumeHierarchy(surfaces)
+
+	random := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		ray := newRandomRay(random, 30)
+		expectedCount := 0
+		for _, surface := range surfaces {
+			if surface.Intersection(ray) != nil {
+				expectedCount++
+			}
+		}
+
+		count := 0
+		bvh.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+			assert.Equal(t, surface.Intersection(ray), intersection)
+			count++
+			return true
+		})
+		assert.Equal(t, expectedCount, count)
+
+		// Check that visiting can be stopped early.
+		count = 0
+		bvh.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+			count++
+			return false
+		})
+		assert.Equal(t, expectedCount > 0, count == 1)
+	}
+}
+
+func TestBoundingVolumeHierarchy_Overlapping(t *testing.T) {
+	// Many identical surfaces offer no useful partition, but the tree must still be built without degenerating.
+	var surfaces []Surface
+	for i := 0; i < 1000; i++ {
+		surfaces = append(surfaces, newTestSphere(geometry.Point{0, 0, 0}, 1))
+	}
+	bvh := NewBoundingVolumeHierarchy(surfaces)
+
+	intersection, _ := bvh.ClosestIntersection(geometry.Ray{geometry.Point{-5, 0, 0}, geometry.Vector{1, 0, 0}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 4.0, intersection.Distance)
+	}
+}
+
+func BenchmarkBoundingVolumeHierarchy_ClosestIntersection(b *testing.B) {
+	surfaces := newRandomSpheres(10000, 100)
+	bvh := NewBoundingVolumeHierarchy(surfaces)
+	rays := newRandomRays(1000, 150)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bvh.ClosestIntersection(rays[n%len(rays)])
+	}
+}
+
+// Baseline for comparison with BenchmarkBoundingVolumeHierarchy_ClosestIntersection, testing every surface in turn as
+// the renderer did before the hierarchy was introduced.
+func BenchmarkLinearClosestIntersection(b *testing.B) {
+	surfaces := newRandomSpheres(10000, 100)
+	rays := newRandomRays(1000, 150)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		linearClosestIntersection(surfaces, rays[n%len(rays)])
+	}
+}
+
+func BenchmarkNewBoundingVolumeHierarchy(b *testing.B) {
+	surfaces := newRandomSpheres(10000, 100)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		NewBoundingVolumeHierarchy(surfaces)
+	}
+}
+
+// Returns the given number of small spheres scattered randomly within a cube of the given half-width.
+func newRandomSpheres(count int, halfWidth float64) []Surface {
+	random := rand.New(rand.NewSource(0))
+	surfaces := make([]Surface, count)
+	for i := range surfaces {
+		center := geometry.Point{
+			(2*random.Float64() - 1) * halfWidth,
+			(2*random.Float64() - 1) * halfWidth,
+			(2*random.Float64() - 1) * halfWidth,
+		}
+		surfaces[i] = newTestSphere(center, 0.2+random.Float64()*0.8)
+	}
+	return surfaces
+}
+
+// Returns a ray starting at a random point on a sphere of the given radius and pointing roughly toward the origin.
+func newRandomRay(random *rand.Rand, radius float64) geometry.Ray {
+	origin := geometry.Point{}.Translate(randomUnitVector(random).Multiply(radius))
+	target := geometry.Point{}.Translate(randomUnitVector(random).Multiply(radius / 3))
+	return geometry.Ray{Origin: origin, Direction: origin.VectorTo(target).ToUnit()}
+}
+
+func newRandomRays(count int, radius float64) []geometry.Ray {
+	random := rand.New(rand.NewSource(0))
+	rays := make([]geometry.Ray, count)
+	for i := range rays {
+		rays[i] = newRandomRay(random, radius)
+	}
+	return rays
+}
+
+func randomUnitVector(random *rand.Rand) geometry.Vector {
+	for {
+		vector := geometry.Vector{2*random.Float64() - 1, 2*random.Float64() - 1, 2*random.Float64() - 1}
+		if norm := vector.Norm(); norm > 0.01 && norm <= 1 {
+			return vector.ToUnit()
+		}
+	}
+}
+
+func linearClosestIntersection(surfaces []Surface, ray geometry.Ray) (*geometry.Intersection, Surface) {
+	var closestIntersection *geometry.Intersection
+	var closestSurface Surface
+	for _, surface := range surfaces {
+		if intersection := surface.Intersection(ray); intersection != nil {
+			if closestIntersection == nil || intersection.Distance < closestIntersection.Distance {
+				closestIntersection = intersection
+				closestSurface = surface
+			}
+		}
+	}
+	return closestIntersection, closestSurface
+}
@@ -8,13 +8,14 @@ import (
 	"github.com/patfair/raytracer/shading"
 )
 
-// Returns the set of six planes formed by extruding the plane defined by the given parameters by the given depth.
+// Returns the set of six planes formed by extruding the plane defined by the given parameters by the given depth. The
+// planes are closed, with their normals pointing out of the box.
 func NewBox(frontBottomLeftCorner geometry.Point, width, height geometry.Vector, depth float64,
-	shadingProperties shading.ShadingProperties) ([6]Plane, error) {
+	material shading.Material) ([6]Plane, error) {
 	if depth == 0 {
 		return [6]Plane{}, errors.New("depth must be non-zero")
 	}
-	front, err := NewPlane(frontBottomLeftCorner, width, height, shadingProperties)
+	front, err := NewPlane(frontBottomLeftCorner, width, height, material)
 	if err != nil {
 		return [6]Plane{}, err
 	}
@@ -22,11 +23,36 @@ func NewBox(frontBottomLeftCorner geometry.Point, width, height geometry.Vector,
 	depthVector := front.normal.Multiply(depth)
 	backTopRightCorner := frontBottomLeftCorner.Translate(width).Translate(height).Translate(depthVector)
 
-	bottom, _ := NewPlane(frontBottomLeftCorner, depthVector, width, shadingProperties)
-	left, _ := NewPlane(frontBottomLeftCorner, depthVector, height, shadingProperties)
-	back, _ := NewPlane(backTopRightCorner, width.Multiply(-1), height.Multiply(-1), shadingProperties)
-	top, _ := NewPlane(backTopRightCorner, depthVector.Multiply(-1), width.Multiply(-1), shadingProperties)
-	right, _ := NewPlane(backTopRightCorner, depthVector.Multiply(-1), height.Multiply(-1), shadingProperties)
+	bottom, err := NewPlane(frontBottomLeftCorner, depthVector, width, material)
+	if err != nil {
+		return [6]Plane{}, err
+	}
+	left, err := NewPlane(frontBottomLeftCorner, depthVector, height, material)
+	if err != nil {
+		return [6]Plane{}, err
+	}
+	back, err := NewPlane(backTopRightCorner, width.Multiply(-1), height.Multiply(-1), material)
+	if err != nil {
+		return [6]Plane{}, err
+	}
+	top, err := NewPlane(backTopRightCorner, depthVector.Multiply(-1), width.Multiply(-1), material)
+	if err != nil {
+		return [6]Plane{}, err
+	}
+	right, err := NewPlane(backTopRightCorner, depthVector.Multiply(-1), height.Multiply(-1), material)
+	if err != nil {
+		return [6]Plane{}, err
+	}
 
-	return [6]Plane{front, bottom, left, back, top, right}, nil
+	// Turn each face's normal to point out of the box and mark it as closed, so that rays can tell whether they are
+	// entering or leaving it.
+	center := frontBottomLeftCorner.Translate(width.Add(height).Add(depthVector).Multiply(0.5))
+	faces := [6]Plane{front, bottom, left, back, top, right}
+	for i, face := range faces {
+		if face.normal.Dot(face.bottomLeftCorner.VectorTo(center)) > 0 {
+			faces[i].normal = face.normal.Multiply(-1)
+		}
+		faces[i].closed = true
+	}
+	return faces, nil
 }
@@ -23,32 +23,54 @@ func TestNewBox(t *testing.T) {
 	assert.Equal(t, geometry.Point{1, 2, 3}, planes[0].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{2, 0, 0}, planes[0].width)
 	assert.Equal(t, geometry.Vector{0, 1, 0}, planes[0].height)
-	assert.Equal(t, shadingProperties, planes[0].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[0].material)
 
 	assert.Equal(t, geometry.Point{1, 2, 3}, planes[1].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{0, 0, -5}, planes[1].width)
 	assert.Equal(t, geometry.Vector{2, 0, 0}, planes[1].height)
-	assert.Equal(t, shadingProperties, planes[1].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[1].material)
 
 	assert.Equal(t, geometry.Point{1, 2, 3}, planes[2].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{0, 0, -5}, planes[2].width)
 	assert.Equal(t, geometry.Vector{0, 1, 0}, planes[2].height)
-	assert.Equal(t, shadingProperties, planes[2].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[2].material)
 
 	assert.Equal(t, geometry.Point{3, 3, -2}, planes[3].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{-2, 0, 0}, planes[3].width)
 	assert.Equal(t, geometry.Vector{0, -1, 0}, planes[3].height)
-	assert.Equal(t, shadingProperties, planes[3].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[3].material)
 
 	assert.Equal(t, geometry.Point{3, 3, -2}, planes[4].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{0, 0, 5}, planes[4].width)
 	assert.Equal(t, geometry.Vector{-2, 0, 0}, planes[4].height)
-	assert.Equal(t, shadingProperties, planes[4].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[4].material)
 
 	assert.Equal(t, geometry.Point{3, 3, -2}, planes[5].bottomLeftCorner)
 	assert.Equal(t, geometry.Vector{0, 0, 5}, planes[5].width)
 	assert.Equal(t, geometry.Vector{0, -1, 0}, planes[5].height)
-	assert.Equal(t, shadingProperties, planes[5].shadingProperties)
+	assert.Equal(t, shadingProperties, planes[5].material)
+
+	// The faces should be closed, with their normals all pointing out of the box even when hit from inside.
+	expectedNormals := []geometry.Vector{{0, 0, 1}, {0, -1, 0}, {-1, 0, 0}, {0, 0, -1}, {0, 1, 0}, {1, 0, 0}}
+	for i, plane := range planes {
+		assert.True(t, plane.IsClosed())
+		geometry.AssertVectorEqual(t, expectedNormals[i], plane.normal)
+	}
+	intersection := planes[0].Intersection(geometry.Ray{geometry.Point{2, 2.5, 0}, geometry.Vector{0, 0, 1}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertVectorEqual(t, geometry.Vector{0, 0, 1}, intersection.Normal)
+	}
+
+	// A box that isn't aligned with the axes should have all of its faces built despite floating-point error.
+	planes, err = NewBox(geometry.Point{2.5, 4.3, 0.1}, geometry.Vector{-0.8, 0.6, 0}, geometry.Vector{0, 0, 2}, 0.05,
+		shadingProperties)
+	assert.Nil(t, err)
+	center := geometry.Point{2.5, 4.3, 0.1}.Translate(geometry.Vector{-0.8, 0.6, 2}.Multiply(0.5)).
+		Translate(geometry.Vector{0.6, 0.8, 0}.Multiply(0.025))
+	for _, plane := range planes {
+		assert.Equal(t, shadingProperties, plane.material)
+		assert.Less(t, plane.normal.Dot(plane.bottomLeftCorner.VectorTo(center)), 0.0)
+	}
 }
 
 func TestNewBoxInvalid(t *testing.T) {
@@ -0,0 +1,22 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+)
+
+// Represents a surface made up of a number of simpler constituent surfaces (such as the triangles of a mesh), each of
+// which may have its own material and texture coordinates. Anything that needs to shade an intersection with
+// a composite should use these methods to find out which constituent surface was actually hit.
+type Composite interface {
+	Surface
+
+	// Returns the closest intersection between the given ray and any of the constituent surfaces, along with the
+	// constituent surface that was intersected. Nil values are returned if the ray doesn't intersect any of them.
+	ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface)
+
+	// Invokes the given function for every constituent surface that the given ray intersects, in no particular order,
+	// until the function returns false. Returns false if visiting was stopped early.
+	VisitIntersections(ray geometry.Ray, visit func(surface Surface, intersection *geometry.Intersection) bool) bool
+}
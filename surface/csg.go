@@ -0,0 +1,145 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a way of combining two solids using constructive solid geometry.
+type CsgOperation int
+
+const (
+	CsgUnion        CsgOperation = iota // The volume inside either solid
+	CsgIntersection                     // The volume inside both solids
+	CsgDifference                       // The volume inside the first solid but not the second
+)
+
+// Represents a solid formed by combining two other solids (either of which may themselves be combinations), such as a
+// sphere with a box carved out of it or a lens formed from two overlapping spheres. Each point on its surface is shaded
+// using the properties of whichever of the original solids it came from.
+type CsgSolid struct {
+	operation   CsgOperation
+	left        Solid
+	right       Solid
+	boundingBox geometry.BoundingBox
+}
+
+// Returns a new solid comprising the volume inside either of the given solids.
+func NewUnion(left, right Solid) CsgSolid {
+	return newCsgSolid(CsgUnion, left, right)
+}
+
+// Returns a new solid comprising the volume inside both of the given solids.
+func NewIntersect(left, right Solid) CsgSolid {
+	return newCsgSolid(CsgIntersection, left, right)
+}
+
+// Returns a new solid comprising the volume inside the first given solid but outside the second.
+func NewDifference(left, right Solid) CsgSolid {
+	return newCsgSolid(CsgDifference, left, right)
+}
+
+func newCsgSolid(operation CsgOperation, left, right Solid) CsgSolid {
+	leftBox := left.BoundingBox()
+	rightBox := right.BoundingBox()
+	var boundingBox geometry.BoundingBox
+	switch operation {
+	case CsgUnion:
+		boundingBox = leftBox.Union(rightBox)
+	case CsgIntersection:
+		boundingBox = geometry.BoundingBox{
+			Min: geometry.Point{math.Max(leftBox.Min.X, rightBox.Min.X), math.Max(leftBox.Min.Y, rightBox.Min.Y),
+				math.Max(leftBox.Min.Z, rightBox.Min.Z)},
+			Max: geometry.Point{math.Min(leftBox.Max.X, rightBox.Max.X), math.Min(leftBox.Max.Y, rightBox.Max.Y),
+				math.Min(leftBox.Max.Z, rightBox.Max.Z)},
+		}
+	case CsgDifference:
+		boundingBox = leftBox
+	}
+	return CsgSolid{operation: operation, left: left, right: right, boundingBox: boundingBox}
+}
+
+// Returns the operation used to combine the two solids.
+func (csg CsgSolid) Operation() CsgOperation {
+	return csg.operation
+}
+
+// Returns the two solids being combined.
+func (csg CsgSolid) Operands() (Solid, Solid) {
+	return csg.left, csg.right
+}
+
+func (csg CsgSolid) Intervals(ray geometry.Ray) []Interval {
+	leftIntervals := csg.left.Intervals(ray)
+	if len(leftIntervals) == 0 && csg.operation != CsgUnion {
+		// Neither an intersection nor a difference can contain anything outside the first solid.
+		return nil
+	}
+	return combineIntervals(leftIntervals, csg.right.Intervals(ray), csg.operation)
+}
+
+func (csg CsgSolid) Intersection(ray geometry.Ray) *geometry.Intersection {
+	intersection, _ := csg.ClosestIntersection(ray)
+	return intersection
+}
+
+func (csg CsgSolid) IsClosed() bool {
+	return true
+}
+
+// Returns the material of the first solid. Since the two solids may differ, callers that need to shade a
+// particular point should use ClosestIntersection to find the surface that was actually hit.
+func (csg CsgSolid) Material() shading.Material {
+	return csg.left.Material()
+}
+
+// Returns the texture coordinates of the given point with respect to the first solid. Callers that need to shade a
+// particular point should instead use ClosestIntersection to find the surface that was actually hit.
+func (csg CsgSolid) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	return csg.left.ToTextureCoordinates(point)
+}
+
+// Returns the texture tangents at the given point with respect to the first solid, with the same caveat as for
+// ToTextureCoordinates.
+func (csg CsgSolid) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	return csg.left.ToTextureTangents(point)
+}
+
+func (csg CsgSolid) BoundingBox() geometry.BoundingBox {
+	return csg.boundingBox
+}
+
+// Returns the closest point at which the given ray crosses the boundary of the combined solid, with a normal pointing
+// out of it, along with the surface of whichever original solid the boundary came from.
+func (csg CsgSolid) ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface) {
+	return closestCrossing(ray, csg.Intervals(ray))
+}
+
+// Visits each point in front of the ray's origin at which it crosses the boundary of the combined solid. The boundaries
+// of the original solids lying inside the combined one aren't visited, since they aren't actually there.
+func (csg CsgSolid) VisitIntersections(ray geometry.Ray,
+	visit func(surface Surface, intersection *geometry.Intersection) bool) bool {
+	for _, interval := range csg.Intervals(ray) {
+		for _, crossing := range []Crossing{interval.Entry, interval.Exit} {
+			if crossing.Distance > 0 && !visit(crossing.Surface, crossing.toIntersection(ray)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns whether a point inside or outside each of the two solids as given lies inside their combination.
+func (operation CsgOperation) contains(insideLeft, insideRight bool) bool {
+	switch operation {
+	case CsgUnion:
+		return insideLeft || insideRight
+	case CsgIntersection:
+		return insideLeft && insideRight
+	default:
+		return insideLeft && !insideRight
+	}
+}
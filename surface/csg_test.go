@@ -0,0 +1,128 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCsgSolid_Intersect(t *testing.T) {
+	left := newTestSphere(geometry.Point{0, 0, 0}, 2)
+	right := newTestSphere(geometry.Point{2, 0, 0}, 2)
+	lens := NewIntersect(left, right)
+	var _ Solid = lens
+	var _ Composite = lens
+
+	assert.Equal(t, CsgIntersection, lens.Operation())
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{0, -2, -2}, geometry.Point{2, 2, 2}), lens.BoundingBox())
+
+	ray := geometry.Ray{geometry.Point{-5, 0, 0}, geometry.Vector{1, 0, 0}}
+	intervals := lens.Intervals(ray)
+	if assert.Equal(t, 1, len(intervals)) {
+		assert.Equal(t, 5.0, intervals[0].Entry.Distance)
+		assert.Equal(t, geometry.Vector{-1, 0, 0}, intervals[0].Entry.Normal)
+		assert.Equal(t, right, intervals[0].Entry.Surface)
+		assert.Equal(t, 7.0, intervals[0].Exit.Distance)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intervals[0].Exit.Normal)
+		assert.Equal(t, left, intervals[0].Exit.Surface)
+	}
+	intersection, surface := lens.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, geometry.Point{0, 0, 0}, intersection.Point)
+		assert.Equal(t, 5.0, intersection.Distance)
+		assert.Equal(t, right, surface)
+	}
+	assert.Equal(t, intersection, lens.Intersection(ray))
+
+	// From inside the lens
+	intersection, surface = lens.ClosestIntersection(geometry.Ray{geometry.Point{1, 0, 0}, geometry.Vector{1, 0, 0}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, geometry.Point{2, 0, 0}, intersection.Point)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intersection.Normal)
+		assert.Equal(t, left, surface)
+	}
+
+	// Through only one of the spheres
+	intersection, surface = lens.ClosestIntersection(geometry.Ray{geometry.Point{-1, -5, 0}, geometry.Vector{0, 1, 0}})
+	assert.Nil(t, intersection)
+	assert.Nil(t, surface)
+	assert.Nil(t, lens.Intersection(geometry.Ray{geometry.Point{-5, 0, 0}, geometry.Vector{-1, 0, 0}}))
+}
+
+func TestCsgSolid_Union(t *testing.T) {
+	left := newTestSphere(geometry.Point{0, 0, 0}, 2)
+	right := newTestSphere(geometry.Point{2, 0, 0}, 2)
+	far := newTestSphere(geometry.Point{10, 0, 0}, 1)
+	union := NewUnion(NewUnion(left, right), far)
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{-2, -2, -2}, geometry.Point{11, 2, 2}),
+		union.BoundingBox())
+
+	ray := geometry.Ray{geometry.Point{-5, 0, 0}, geometry.Vector{1, 0, 0}}
+	intervals := union.Intervals(ray)
+	if assert.Equal(t, 2, len(intervals)) {
+		assert.Equal(t, 3.0, intervals[0].Entry.Distance)
+		assert.Equal(t, left, intervals[0].Entry.Surface)
+		assert.Equal(t, 9.0, intervals[0].Exit.Distance)
+		assert.Equal(t, right, intervals[0].Exit.Surface)
+		assert.Equal(t, 14.0, intervals[1].Entry.Distance)
+		assert.Equal(t, 16.0, intervals[1].Exit.Distance)
+		assert.Equal(t, far, intervals[1].Exit.Surface)
+	}
+
+	// The overlapping parts of the spheres shouldn't be visited.
+	var distances []float64
+	var surfaces []Surface
+	assert.True(t, union.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+		distances = append(distances, intersection.Distance)
+		surfaces = append(surfaces, surface)
+		return true
+	}))
+	assert.Equal(t, []float64{3, 9, 14, 16}, distances)
+	assert.Equal(t, []Surface{left, right, far, far}, surfaces)
+
+	// Visiting should stop once requested, and skip crossings behind the ray.
+	distances = nil
+	assert.False(t, union.VisitIntersections(geometry.Ray{geometry.Point{5, 0, 0}, geometry.Vector{1, 0, 0}},
+		func(surface Surface, intersection *geometry.Intersection) bool {
+			distances = append(distances, intersection.Distance)
+			return len(distances) < 2
+		}))
+	assert.Equal(t, []float64{4, 6}, distances)
+}
+
+func TestCsgSolid_Difference(t *testing.T) {
+	sphere := newTestSphere(geometry.Point{0, 0, 0}, 2)
+	boxShadingProperties := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 1, 0}},
+		Opacity: 1}
+	box, _ := NewSolidBox(geometry.Point{0, -3, -3}, geometry.Vector{3, 0, 0}, geometry.Vector{0, 6, 0}, 6,
+		boxShadingProperties)
+	hemisphere := NewDifference(sphere, box)
+	assert.Equal(t, CsgDifference, hemisphere.Operation())
+	left, right := hemisphere.Operands()
+	assert.Equal(t, sphere, left)
+	assert.Equal(t, box, right)
+	assert.Equal(t, sphere.BoundingBox(), hemisphere.BoundingBox())
+	assert.Equal(t, sphere.Material(), hemisphere.Material())
+
+	// The cut face should be shaded like the box, with its normal facing out of the hemisphere.
+	ray := geometry.Ray{geometry.Point{5, 0, 0}, geometry.Vector{-1, 0, 0}}
+	intersection, surface := hemisphere.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, geometry.Point{0, 0, 0}, intersection.Point)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intersection.Normal)
+		assert.Equal(t, boxShadingProperties, surface.Material())
+	}
+	intervals := hemisphere.Intervals(ray)
+	if assert.Equal(t, 1, len(intervals)) {
+		assert.Equal(t, 7.0, intervals[0].Exit.Distance)
+		assert.Equal(t, geometry.Vector{-1, 0, 0}, intervals[0].Exit.Normal)
+		assert.Equal(t, sphere, intervals[0].Exit.Surface)
+	}
+
+	// Rays through the carved-out part shouldn't hit anything.
+	assert.Nil(t, hemisphere.Intersection(geometry.Ray{geometry.Point{1, -5, 0}, geometry.Vector{0, 1, 0}}))
+	assert.Empty(t, hemisphere.Intervals(geometry.Ray{geometry.Point{-5, 5, 0}, geometry.Vector{1, 0, 0}}))
+}
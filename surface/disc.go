@@ -18,7 +18,7 @@ type Disc struct {
 
 // Returns a new plane, or an error if the parameters are invalid.
 func NewDisc(center geometry.Point, width geometry.Vector, height geometry.Vector,
-	shadingProperties shading.ShadingProperties) (Disc, error) {
+	material shading.Material) (Disc, error) {
 	if width.Dot(height) != 0 {
 		return Disc{}, errors.New("disc width and height must be perpendicular")
 	}
@@ -26,8 +26,14 @@ func NewDisc(center geometry.Point, width geometry.Vector, height geometry.Vecto
 		return Disc{}, errors.New("disc width and height must have the same magnitude")
 	}
 
-	plane, err := NewPlane(center, width, height, shadingProperties)
-	return Disc{plane: plane}, err
+	plane, err := NewPlane(center, width, height, material)
+	if err != nil {
+		return Disc{}, err
+	}
+	disc := Disc{plane: plane}
+	uSize, vSize := disc.TextureSize()
+	disc.plane.material = fitMaterial(material, shading.PolarMapping, uSize, vSize)
+	return disc, nil
 }
 
 func (disc Disc) Intersection(ray geometry.Ray) *geometry.Intersection {
@@ -60,8 +66,22 @@ func (disc Disc) Intersection(ray geometry.Ray) *geometry.Intersection {
 	return intersection
 }
 
-func (disc Disc) ShadingProperties() shading.ShadingProperties {
-	return disc.plane.ShadingProperties()
+func (disc Disc) Area() float64 {
+	return math.Pi * disc.radius() * disc.radius()
+}
+
+// Chooses a point on the disc uniformly by area, which requires the distance from the center to grow with the square
+// root of the random number.
+func (disc Disc) SamplePoint(u1, u2 float64) (geometry.Point, geometry.Vector) {
+	r := disc.radius() * math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	offset := disc.plane.width.ToUnit().Multiply(r * math.Cos(phi)).
+		Add(disc.plane.height.ToUnit().Multiply(r * math.Sin(phi)))
+	return disc.plane.bottomLeftCorner.Translate(offset), disc.plane.normal
+}
+
+func (disc Disc) Material() shading.Material {
+	return disc.plane.Material()
 }
 
 func (disc Disc) ToTextureCoordinates(point geometry.Point) (float64, float64) {
@@ -76,6 +96,33 @@ func (disc Disc) ToTextureCoordinates(point geometry.Point) (float64, float64) {
 	return r, phi
 }
 
+func (disc Disc) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	r, phi := disc.ToTextureCoordinates(point)
+	widthDirection, heightDirection := disc.plane.width.ToUnit(), disc.plane.height.ToUnit()
+	radial := widthDirection.Multiply(math.Cos(phi)).Add(heightDirection.Multiply(math.Sin(phi)))
+	angular := widthDirection.Multiply(-math.Sin(phi)).Add(heightDirection.Multiply(math.Cos(phi)))
+	return radial, angular.Multiply(r)
+}
+
+// Returns the diameter of the disc along both axes, to which image textures using the polar mapping are fitted since
+// they convert its polar coordinates to Cartesian ones centered on it.
+func (disc Disc) TextureSize() (float64, float64) {
+	return 2 * disc.radius(), 2 * disc.radius()
+}
+
+func (disc Disc) BoundingBox() geometry.BoundingBox {
+	// The extent of a circle along each axis shrinks as its normal becomes more closely aligned with that axis.
+	center := disc.plane.bottomLeftCorner
+	normal := disc.plane.normal
+	radius := disc.radius()
+	extent := geometry.Vector{
+		X: radius * math.Sqrt(math.Max(1-normal.X*normal.X, 0)),
+		Y: radius * math.Sqrt(math.Max(1-normal.Y*normal.Y, 0)),
+		Z: radius * math.Sqrt(math.Max(1-normal.Z*normal.Z, 0)),
+	}
+	return geometry.NewBoundingBox(center.Translate(extent.Multiply(-1)), center.Translate(extent))
+}
+
 // Returns true if the given disc on the plane in world coordinates is within the defined boundaries of the disc.
 func (disc Disc) isPointWithinLimits(point geometry.Point) bool {
 	u, v := disc.plane.ToTextureCoordinates(point)
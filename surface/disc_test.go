@@ -21,7 +21,7 @@ func TestNewDisc(t *testing.T) {
 		shadingProperties)
 	assert.Nil(t, err)
 
-	assert.Equal(t, shadingProperties, disc.ShadingProperties())
+	assert.Equal(t, shadingProperties, disc.Material())
 	assert.Equal(t, 1.5, disc.radius())
 }
 
@@ -88,6 +88,10 @@ func TestDisc_ToTextureCoordinates(t *testing.T) {
 	assert.Equal(t, 1.0, r)
 	assert.Equal(t, 0.0, phi)
 
+	width, height := disc.TextureSize()
+	assert.Equal(t, 4.0, width)
+	assert.Equal(t, 4.0, height)
+
 	r, phi = disc.ToTextureCoordinates(geometry.Point{2, 1, 5})
 	assert.Equal(t, math.Sqrt(2), r)
 	assert.Equal(t, math.Pi/4, phi)
@@ -116,3 +120,60 @@ func TestDisc_ToTextureCoordinates(t *testing.T) {
 	assert.Equal(t, math.Sqrt(2), r)
 	assert.Equal(t, -math.Pi/4, phi)
 }
+
+func TestDisc_FitsTextures(t *testing.T) {
+	texture, _ := shading.NewImageTexture([][]shading.Color{{{1, 0, 0}, {0, 1, 0}}, {{0, 0, 1}, {1, 1, 1}}})
+	texture.Filter = shading.NearestFilter
+	texture.Mapping = shading.PolarMapping
+	disc, _ := NewDisc(geometry.Point{0, 0, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: texture, Opacity: 1})
+
+	// The image should be centered on the disc and just cover it.
+	fitted := disc.Material().(shading.ShadingProperties).DiffuseTexture
+	shading.AssertColorEqual(t, shading.Color{0, 1, 0}, fitted.AlbedoAt(1.5, math.Pi/4, 0, nil), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0, 0, 1}, fitted.AlbedoAt(1.5, -3*math.Pi/4, 0, nil), 1e-9)
+
+	// Textures using the UV mapping don't match the disc's coordinates, and are left as they are.
+	texture.Mapping = shading.UvMapping
+	disc, _ = NewDisc(geometry.Point{0, 0, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: texture, Opacity: 1})
+	assert.Equal(t, texture, disc.Material().(shading.ShadingProperties).DiffuseTexture)
+}
+
+func TestDisc_ToTextureTangents(t *testing.T) {
+	disc, _ := NewDisc(geometry.Point{1, 0, 5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{Opacity: 1})
+
+	uTangent, vTangent := disc.ToTextureTangents(geometry.Point{1, 1.5, 5})
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 1, 0}, uTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{-1.5, 0, 0}, vTangent)
+	for _, point := range []geometry.Point{{1, 1.5, 5}, {2, 1, 5}, {0.5, -0.2, 5}} {
+		assertTextureTangents(t, disc, point)
+	}
+}
+
+func TestDisc_SamplePoint(t *testing.T) {
+	disc, _ := NewDisc(geometry.Point{1, 0, 5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{Opacity: 1})
+	assert.InDelta(t, 4*math.Pi, disc.Area(), 1e-9)
+	assertSamplePoints(t, disc, geometry.Point{1, 0, 5})
+
+	// Points should be spread evenly rather than bunched towards the center.
+	point, _ := disc.SamplePoint(0.25, 0)
+	geometry.AssertVectorEqual(t, geometry.Vector{2, 0, 5}, geometry.Point{}.VectorTo(point))
+}
+
+func TestDisc_BoundingBox(t *testing.T) {
+	disc, _ := NewDisc(geometry.Point{1, 0, 5}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{Opacity: 1})
+	box := disc.BoundingBox()
+	assert.Equal(t, geometry.Point{-1, -2, 5}, box.Min)
+	assert.Equal(t, geometry.Point{3, 2, 5}, box.Max)
+
+	// Tilted at 45 degrees about the Y-axis
+	disc, _ = NewDisc(geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 1}, geometry.Vector{0, math.Sqrt(2), 0},
+		shading.ShadingProperties{Opacity: 1})
+	box = disc.BoundingBox()
+	geometry.AssertVectorEqual(t, geometry.Vector{-1, -math.Sqrt(2), -1}, geometry.Point{}.VectorTo(box.Min))
+	geometry.AssertVectorEqual(t, geometry.Vector{1, math.Sqrt(2), 1}, geometry.Point{}.VectorTo(box.Max))
+}
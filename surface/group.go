@@ -0,0 +1,65 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+)
+
+// Represents a collection of arbitrary surfaces that are treated as a single object, such as the six planes of a box,
+// so that they can be instanced together.
+type Group struct {
+	surfaces  []Surface
+	hierarchy *BoundingVolumeHierarchy // Acceleration structure over the surfaces
+}
+
+// Returns a new group made up of the given surfaces, or an error if there aren't any.
+func NewGroup(surfaces []Surface) (Group, error) {
+	if len(surfaces) == 0 {
+		return Group{}, errors.New("group must have at least one surface")
+	}
+	return Group{surfaces: surfaces, hierarchy: NewBoundingVolumeHierarchy(surfaces)}, nil
+}
+
+// Returns the surfaces that make up the group.
+func (group Group) Surfaces() []Surface {
+	return group.surfaces
+}
+
+func (group Group) Intersection(ray geometry.Ray) *geometry.Intersection {
+	intersection, _ := group.hierarchy.ClosestIntersection(ray)
+	return intersection
+}
+
+// Returns the material of the first surface in the group. Since surfaces may differ, callers that need to
+// shade a particular point should use ClosestIntersection to find the surface that was actually hit.
+func (group Group) Material() shading.Material {
+	return group.surfaces[0].Material()
+}
+
+// Returns the texture coordinates of the given point with respect to the first surface in the group. Callers that need
+// to shade a particular point should instead use ClosestIntersection to find the surface that was actually hit.
+func (group Group) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	return group.surfaces[0].ToTextureCoordinates(point)
+}
+
+// Returns the texture tangents at the given point with respect to the first surface in the group, with the same caveat
+// as for ToTextureCoordinates.
+func (group Group) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	return group.surfaces[0].ToTextureTangents(point)
+}
+
+func (group Group) BoundingBox() geometry.BoundingBox {
+	return group.hierarchy.BoundingBox()
+}
+
+func (group Group) ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface) {
+	return group.hierarchy.ClosestIntersection(ray)
+}
+
+func (group Group) VisitIntersections(ray geometry.Ray,
+	visit func(surface Surface, intersection *geometry.Intersection) bool) bool {
+	return group.hierarchy.VisitIntersections(ray, visit)
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewGroupInvalid(t *testing.T) {
+	_, err := NewGroup(nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "at least one surface")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	red := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}}, Opacity: 1}
+	blue := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 0, 1}}, Opacity: 1}
+	planes, _ := NewBox(geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, 1, red)
+	sphere, _ := NewSphere(geometry.Point{3, 0.5, 0.5}, 0.5, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0}, blue)
+	surfaces := []Surface{sphere}
+	for _, plane := range planes {
+		surfaces = append(surfaces, plane)
+	}
+	group, err := NewGroup(surfaces)
+	assert.Nil(t, err)
+	var _ Composite = group
+
+	assert.Equal(t, surfaces, group.Surfaces())
+	assert.Equal(t, blue, group.Material())
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{0, 0, 0}, geometry.Point{3.5, 1, 1}), group.BoundingBox())
+
+	ray := geometry.Ray{geometry.Point{-1, 0.5, 0.5}, geometry.Vector{1, 0, 0}}
+	intersection := group.Intersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 1.0, intersection.Distance)
+	}
+	intersection, surface := group.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 1.0, intersection.Distance)
+		assert.Equal(t, planes[2], surface)
+	}
+
+	count := 0
+	group.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 3, count)
+
+	u, v := group.ToTextureCoordinates(geometry.Point{3, 0.5, 1})
+	expectedU, expectedV := sphere.ToTextureCoordinates(geometry.Point{3, 0.5, 1})
+	assert.Equal(t, expectedU, u)
+	assert.Equal(t, expectedV, v)
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+)
+
+// Represents a copy of another surface placed into the scene using an affine transformation, which allows a single
+// surface (such as a complex mesh) to appear many times without duplicating it, and allows shapes such as ellipsoids to
+// be expressed as scaled spheres. Rays are transformed into the surface's own coordinate space to be intersected, and
+// the results are transformed back out.
+type Instance struct {
+	surface        Surface
+	transformation *instanceTransformation // Shared with the instances wrapping the constituents of a composite
+}
+
+type instanceTransformation struct {
+	objectToWorld geometry.Matrix
+	worldToObject geometry.Matrix
+	normalToWorld geometry.Matrix // Inverse transpose of the object-to-world matrix, which keeps normals perpendicular
+}
+
+// Returns a new instance of the given surface transformed by the given matrix, or an error if the matrix isn't
+// invertible.
+func NewInstance(surface Surface, transform geometry.Matrix) (Instance, error) {
+	inverse, err := transform.Inverse()
+	if err != nil {
+		return Instance{}, err
+	}
+	return Instance{
+		surface: surface,
+		transformation: &instanceTransformation{
+			objectToWorld: transform,
+			worldToObject: inverse,
+			normalToWorld: inverse.Transpose(),
+		},
+	}, nil
+}
+
+// Returns the surface being instanced.
+func (instance Instance) Surface() Surface {
+	return instance.surface
+}
+
+// Returns the matrix transforming the instanced surface's coordinates into world coordinates.
+func (instance Instance) Transform() geometry.Matrix {
+	return instance.transformation.objectToWorld
+}
+
+func (instance Instance) Intersection(ray geometry.Ray) *geometry.Intersection {
+	return instance.toWorld(ray, instance.surface.Intersection(instance.toObject(ray)))
+}
+
+// Returns true if the instanced surface is closed, since an invertible transformation can't open it up.
+func (instance Instance) IsClosed() bool {
+	closedSurface, ok := instance.surface.(ClosedSurface)
+	return ok && closedSurface.IsClosed()
+}
+
+func (instance Instance) Material() shading.Material {
+	return instance.surface.Material()
+}
+
+func (instance Instance) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	return instance.surface.ToTextureCoordinates(instance.transformation.worldToObject.TransformPoint(point))
+}
+
+func (instance Instance) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	uTangent, vTangent :=
+		instance.surface.ToTextureTangents(instance.transformation.worldToObject.TransformPoint(point))
+	return instance.transformation.objectToWorld.TransformVector(uTangent),
+		instance.transformation.objectToWorld.TransformVector(vTangent)
+}
+
+func (instance Instance) BoundingBox() geometry.BoundingBox {
+	return instance.transformation.objectToWorld.TransformBoundingBox(instance.surface.BoundingBox())
+}
+
+// Returns the closest intersection with the instanced surface, along with the surface that was hit. If the instanced
+// surface is a composite, the constituent surface that was hit is returned wrapped in an instance having the same
+// transformation, so that it can be shaded in world coordinates.
+func (instance Instance) ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface) {
+	composite, ok := instance.surface.(Composite)
+	if !ok {
+		intersection := instance.Intersection(ray)
+		if intersection == nil {
+			return nil, nil
+		}
+		return intersection, instance
+	}
+
+	intersection, surface := composite.ClosestIntersection(instance.toObject(ray))
+	if intersection == nil {
+		return nil, nil
+	}
+	return instance.toWorld(ray, intersection), instance.wrap(surface)
+}
+
+func (instance Instance) VisitIntersections(ray geometry.Ray,
+	visit func(surface Surface, intersection *geometry.Intersection) bool) bool {
+	composite, ok := instance.surface.(Composite)
+	if !ok {
+		if intersection := instance.Intersection(ray); intersection != nil {
+			return visit(instance, intersection)
+		}
+		return true
+	}
+
+	return composite.VisitIntersections(instance.toObject(ray),
+		func(surface Surface, intersection *geometry.Intersection) bool {
+			return visit(instance.wrap(surface), instance.toWorld(ray, intersection))
+		})
+}
+
+// Returns the given world ray transformed into the instanced surface's coordinates. The direction is left
+// unnormalized, since surfaces normalize it themselves.
+func (instance Instance) toObject(ray geometry.Ray) geometry.Ray {
+	return geometry.Ray{
+		Origin:    instance.transformation.worldToObject.TransformPoint(ray.Origin),
+		Direction: instance.transformation.worldToObject.TransformVector(ray.Direction.ToUnit()),
+	}
+}
+
+// Returns the given intersection in the instanced surface's coordinates transformed back into world coordinates, with
+// its distance measured along the given world ray.
+func (instance Instance) toWorld(ray geometry.Ray, intersection *geometry.Intersection) *geometry.Intersection {
+	if intersection == nil {
+		return nil
+	}
+	point := instance.transformation.objectToWorld.TransformPoint(intersection.Point)
+	return &geometry.Intersection{
+		Point:    point,
+		Distance: ray.Origin.VectorTo(point).Dot(ray.Direction.ToUnit()),
+		Normal:   instance.transformation.normalToWorld.TransformVector(intersection.Normal).ToUnit(),
+	}
+}
+
+// Returns the given constituent of the instanced surface wrapped in an instance having the same transformation.
+func (instance Instance) wrap(surface Surface) Instance {
+	return Instance{surface: surface, transformation: instance.transformation}
+}
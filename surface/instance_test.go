@@ -0,0 +1,142 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewInstanceInvalid(t *testing.T) {
+	sphere, _ := NewSphere(geometry.Point{0, 0, 0}, 1, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shading.ShadingProperties{Opacity: 1})
+	_, err := NewInstance(sphere, geometry.NewScalingMatrix(1, 0, 1))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not invertible")
+	}
+}
+
+func TestInstance_Ellipsoid(t *testing.T) {
+	shadingProperties := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}},
+		Opacity: 1}
+	sphere, _ := NewSphere(geometry.Point{0, 0, 0}, 1, geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 0},
+		shadingProperties)
+	ellipsoid, err := NewInstance(sphere, geometry.NewScalingMatrix(2, 1, 1))
+	assert.Nil(t, err)
+	var _ Composite = ellipsoid
+
+	assert.Equal(t, sphere, ellipsoid.Surface())
+	assert.Equal(t, geometry.NewScalingMatrix(2, 1, 1), ellipsoid.Transform())
+	assert.Equal(t, shadingProperties, ellipsoid.Material())
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{-2, -1, -1}, geometry.Point{2, 1, 1}),
+		ellipsoid.BoundingBox())
+
+	intersection := ellipsoid.Intersection(geometry.Ray{geometry.Point{5, 0, 0}, geometry.Vector{-2, 0, 0}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{2, 0, 0}, intersection.Point)
+		assert.InDelta(t, 3, intersection.Distance, 1e-9)
+		geometry.AssertVectorEqual(t, geometry.Vector{1, 0, 0}, intersection.Normal)
+	}
+	intersection = ellipsoid.Intersection(geometry.Ray{geometry.Point{0, 0, 5}, geometry.Vector{0, 0, -1}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{0, 0, 1}, intersection.Point)
+		assert.InDelta(t, 4, intersection.Distance, 1e-9)
+	}
+
+	// The normal should be perpendicular to the stretched surface rather than just scaled along with it.
+	intersection = ellipsoid.Intersection(geometry.Ray{geometry.Point{math.Sqrt2, 5, 0}, geometry.Vector{0, -1, 0}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{math.Sqrt2, 1 / math.Sqrt2, 0}, intersection.Point)
+		geometry.AssertVectorEqual(t, geometry.Vector{1, 2, 0}.ToUnit(), intersection.Normal)
+	}
+
+	assert.Nil(t, ellipsoid.Intersection(geometry.Ray{geometry.Point{5, 1.5, 0}, geometry.Vector{-1, 0, 0}}))
+	intersection, surface := ellipsoid.ClosestIntersection(geometry.Ray{geometry.Point{5, 1.5, 0},
+		geometry.Vector{-1, 0, 0}})
+	assert.Nil(t, intersection)
+	assert.Nil(t, surface)
+
+	intersection, surface = ellipsoid.ClosestIntersection(geometry.Ray{geometry.Point{5, 0, 0},
+		geometry.Vector{-1, 0, 0}})
+	if assert.NotNil(t, intersection) {
+		assert.InDelta(t, 3, intersection.Distance, 1e-9)
+		assert.Equal(t, ellipsoid, surface)
+	}
+
+	u, v := ellipsoid.ToTextureCoordinates(geometry.Point{0, 1, 0})
+	expectedU, expectedV := sphere.ToTextureCoordinates(geometry.Point{0, 1, 0})
+	assert.Equal(t, expectedU, u)
+	assert.Equal(t, expectedV, v)
+	u, v = ellipsoid.ToTextureCoordinates(geometry.Point{-2, 0, 0})
+	expectedU, expectedV = sphere.ToTextureCoordinates(geometry.Point{-1, 0, 0})
+	assert.Equal(t, expectedU, u)
+	assert.Equal(t, expectedV, v)
+
+	// The tangents should be stretched along with the sphere.
+	uTangent, vTangent := ellipsoid.ToTextureTangents(geometry.Point{0, -1, 0})
+	geometry.AssertVectorEqual(t, geometry.Vector{2, 0, 0}, uTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0, -1}, vTangent)
+	assertTextureTangents(t, ellipsoid, geometry.Point{math.Sqrt2, 1 / math.Sqrt2, 0})
+}
+
+func TestInstance_Composite(t *testing.T) {
+	red := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}}, Opacity: 1}
+	blue := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 0, 1}}, Opacity: 1}
+
+	// Unit square in the XY-plane made of two differently colored triangles, moved to stand upright in the XZ-plane
+	// at y = 3.
+	triangle1, _ := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0}, red)
+	triangle2, _ := NewTriangle(geometry.Point{1, 1, 0}, geometry.Point{0, 1, 0}, geometry.Point{1, 0, 0}, blue)
+	mesh, _ := NewMesh([]Triangle{triangle1, triangle2})
+	transform := geometry.NewTranslationMatrix(geometry.Vector{0, 3, 0}).
+		Multiply(geometry.NewRotationMatrix(geometry.Vector{1, 0, 0}, 90))
+	instance, err := NewInstance(mesh, transform)
+	assert.Nil(t, err)
+
+	box := instance.BoundingBox()
+	geometry.AssertPointEqual(t, geometry.Point{0, 3, 0}, box.Min)
+	geometry.AssertPointEqual(t, geometry.Point{1, 3, 1}, box.Max)
+
+	ray := geometry.Ray{geometry.Point{0.8, 0, 0.7}, geometry.Vector{0, 1, 0}}
+	intersection, surface := instance.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{0.8, 3, 0.7}, intersection.Point)
+		assert.InDelta(t, 3, intersection.Distance, 1e-9)
+		geometry.AssertVectorEqual(t, geometry.Vector{0, -1, 0}, intersection.Normal)
+
+		// The triangle that was hit should be returned in world coordinates.
+		assert.Equal(t, blue, surface.Material())
+		u, v := surface.ToTextureCoordinates(intersection.Point)
+		expectedU, expectedV := triangle2.ToTextureCoordinates(geometry.Point{0.8, 0.7, 0})
+		assert.InDelta(t, expectedU, u, 1e-9)
+		assert.InDelta(t, expectedV, v, 1e-9)
+		geometry.AssertPointEqual(t, geometry.Point{0, 3, 0}, surface.BoundingBox().Min)
+	}
+
+	count := 0
+	instance.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+		assert.Equal(t, blue, surface.Material())
+		geometry.AssertPointEqual(t, geometry.Point{0.8, 3, 0.7}, intersection.Point)
+		count++
+		return true
+	})
+	assert.Equal(t, 1, count)
+
+	// Instances should work within a hierarchy, with many copies sharing the same mesh.
+	var surfaces []Surface
+	for i := 0; i < 10; i++ {
+		translation := geometry.NewTranslationMatrix(geometry.Vector{0, 0, float64(i)})
+		copy, _ := NewInstance(mesh, translation.Multiply(transform))
+		surfaces = append(surfaces, copy)
+	}
+	hierarchy := NewBoundingVolumeHierarchy(surfaces)
+	intersection, surface = hierarchy.ClosestIntersection(geometry.Ray{geometry.Point{0.2, 0, 5.3},
+		geometry.Vector{0, 1, 0}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{0.2, 3, 5.3}, intersection.Point)
+		assert.Equal(t, red, surface.Material())
+	}
+}
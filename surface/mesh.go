@@ -0,0 +1,122 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents an object made up of many triangles, such as a model loaded from a Wavefront OBJ file. Each triangle may
+// have its own shading properties.
+type Mesh struct {
+	triangles []Triangle
+	hierarchy *BoundingVolumeHierarchy // Acceleration structure over the triangles
+}
+
+// Returns a new mesh made up of the given triangles, or an error if there aren't any. The mesh is closed if it is
+// watertight, with every edge shared by exactly two triangles whose vertices appear counterclockwise from outside.
+func NewMesh(triangles []Triangle) (Mesh, error) {
+	if len(triangles) == 0 {
+		return Mesh{}, errors.New("mesh must have at least one triangle")
+	}
+
+	triangles = append([]Triangle(nil), triangles...)
+	if isClosedMesh(triangles) {
+		for i := range triangles {
+			triangles[i].closed = true
+		}
+	}
+	surfaces := make([]Surface, len(triangles))
+	for i, triangle := range triangles {
+		surfaces[i] = triangle
+	}
+	return Mesh{triangles: triangles, hierarchy: NewBoundingVolumeHierarchy(surfaces)}, nil
+}
+
+// Returns the triangles that make up the mesh.
+func (mesh Mesh) Triangles() []Triangle {
+	return mesh.triangles
+}
+
+func (mesh Mesh) Intersection(ray geometry.Ray) *geometry.Intersection {
+	intersection, _ := mesh.hierarchy.ClosestIntersection(ray)
+	return intersection
+}
+
+func (mesh Mesh) IsClosed() bool {
+	return mesh.triangles[0].closed
+}
+
+// Returns the material of the first triangle in the mesh. Since triangles may differ, callers that need to
+// shade a particular point should use ClosestIntersection to find the triangle that was actually hit.
+func (mesh Mesh) Material() shading.Material {
+	return mesh.triangles[0].Material()
+}
+
+// Returns the texture coordinates of the given point with respect to the triangle that it lies on. This requires a
+// search of every triangle; callers that need to shade a particular point should instead use ClosestIntersection to
+// find the triangle that was actually hit.
+func (mesh Mesh) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	return mesh.triangleContaining(point).ToTextureCoordinates(point)
+}
+
+// Returns the texture tangents at the given point with respect to the triangle that it lies on, with the same caveat as
+// for ToTextureCoordinates.
+func (mesh Mesh) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	return mesh.triangleContaining(point).ToTextureTangents(point)
+}
+
+func (mesh Mesh) BoundingBox() geometry.BoundingBox {
+	return mesh.hierarchy.BoundingBox()
+}
+
+func (mesh Mesh) ClosestIntersection(ray geometry.Ray) (*geometry.Intersection, Surface) {
+	return mesh.hierarchy.ClosestIntersection(ray)
+}
+
+func (mesh Mesh) VisitIntersections(ray geometry.Ray,
+	visit func(surface Surface, intersection *geometry.Intersection) bool) bool {
+	return mesh.hierarchy.VisitIntersections(ray, visit)
+}
+
+// Returns the triangle in the mesh that the given point lies on, or the closest one to it.
+func (mesh Mesh) triangleContaining(point geometry.Point) Triangle {
+	closestTriangle := mesh.triangles[0]
+	closestDistance := math.Inf(1)
+	for _, triangle := range mesh.triangles {
+		b1, b2 := triangle.barycentricCoordinates(point)
+		if b1 < 0 || b2 < 0 || b1+b2 > 1 {
+			continue
+		}
+		distance := math.Abs(triangle.vertices[0].VectorTo(point).Dot(triangle.normal))
+		if distance < closestDistance {
+			closestTriangle = triangle
+			closestDistance = distance
+		}
+	}
+	return closestTriangle
+}
+
+// Returns true if the given triangles form a watertight surface wound counterclockwise as seen from outside. Each edge
+// must be traversed exactly once in each direction by the triangles sharing it, and the signed volume enclosed must be
+// positive, since it is negative for a surface wound the other way.
+func isClosedMesh(triangles []Triangle) bool {
+	edgeCounts := make(map[[2]geometry.Point]int)
+	volume := 0.0
+	for _, triangle := range triangles {
+		for i := range triangle.vertices {
+			edgeCounts[[2]geometry.Point{triangle.vertices[i], triangle.vertices[(i+1)%3]}]++
+		}
+		origin := geometry.Point{}
+		volume += origin.VectorTo(triangle.vertices[0]).Dot(triangle.edge1.Cross(triangle.edge2)) / 6
+	}
+	for edge, count := range edgeCounts {
+		if count != 1 || edgeCounts[[2]geometry.Point{edge[1], edge[0]}] != 1 {
+			return false
+		}
+	}
+	return volume > 0
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewMeshInvalid(t *testing.T) {
+	_, err := NewMesh(nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "at least one triangle")
+	}
+}
+
+func TestMesh(t *testing.T) {
+	red := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}}, Opacity: 1}
+	blue := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 0, 1}}, Opacity: 1}
+
+	// Unit square in the XY-plane made of two differently colored triangles.
+	triangle1, _ := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0}, red)
+	triangle2, _ := NewTriangle(geometry.Point{1, 1, 0}, geometry.Point{0, 1, 0}, geometry.Point{1, 0, 0}, blue)
+	mesh, err := NewMesh([]Triangle{triangle1, triangle2})
+	assert.Nil(t, err)
+	var _ Composite = mesh
+
+	assert.Equal(t, []Triangle{triangle1, triangle2}, mesh.Triangles())
+	assert.Equal(t, red, mesh.Material())
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{0, 0, 0}, geometry.Point{1, 1, 0}), mesh.BoundingBox())
+
+	ray := geometry.Ray{geometry.Point{0.8, 0.7, 2}, geometry.Vector{0, 0, -1}}
+	intersection := mesh.Intersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 2.0, intersection.Distance)
+	}
+	intersection, surface := mesh.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 2.0, intersection.Distance)
+		assert.Equal(t, blue, surface.Material())
+	}
+
+	u, v := mesh.ToTextureCoordinates(geometry.Point{0.8, 0.7, 0})
+	expectedU, expectedV := triangle2.ToTextureCoordinates(geometry.Point{0.8, 0.7, 0})
+	assert.Equal(t, expectedU, u)
+	assert.Equal(t, expectedV, v)
+
+	count := 0
+	mesh.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+		assert.Equal(t, triangle2, surface)
+		count++
+		return true
+	})
+	assert.Equal(t, 1, count)
+
+	intersection, surface = mesh.ClosestIntersection(geometry.Ray{geometry.Point{2, 2, 2}, geometry.Vector{0, 0, -1}})
+	assert.Nil(t, intersection)
+	assert.Nil(t, surface)
+}
+
+func TestMesh_IsClosed(t *testing.T) {
+	shadingProperties := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity: 1}
+	a, b, c, d := geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0}, geometry.Point{0, 0, 1}
+	newTetrahedron := func(faces ...[3]geometry.Point) Mesh {
+		triangles := make([]Triangle, len(faces))
+		for i, face := range faces {
+			triangles[i], _ = NewTriangle(face[0], face[1], face[2], shadingProperties)
+		}
+		mesh, err := NewMesh(triangles)
+		assert.Nil(t, err)
+		return mesh
+	}
+
+	// A tetrahedron wound counterclockwise as seen from outside is closed, and gives outward normals from inside.
+	mesh := newTetrahedron([3]geometry.Point{a, c, b}, [3]geometry.Point{a, b, d}, [3]geometry.Point{a, d, c},
+		[3]geometry.Point{b, c, d})
+	assert.True(t, mesh.IsClosed())
+	intersection, surface := mesh.ClosestIntersection(geometry.Ray{geometry.Point{0.1, 0.1, 0.1},
+		geometry.Vector{0, 0, -1}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, geometry.Vector{0, 0, -1}, intersection.Normal)
+		assert.True(t, surface.(ClosedSurface).IsClosed())
+	}
+
+	// One wound the other way, or with a face missing, is not.
+	mesh = newTetrahedron([3]geometry.Point{a, b, c}, [3]geometry.Point{a, d, b}, [3]geometry.Point{a, c, d},
+		[3]geometry.Point{b, d, c})
+	assert.False(t, mesh.IsClosed())
+	mesh = newTetrahedron([3]geometry.Point{a, c, b}, [3]geometry.Point{a, b, d}, [3]geometry.Point{a, d, c})
+	assert.False(t, mesh.IsClosed())
+	intersection, _ = mesh.ClosestIntersection(geometry.Ray{geometry.Point{0.1, 0.1, 0.1}, geometry.Vector{0, 0, -1}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, geometry.Vector{0, 0, 1}, intersection.Normal)
+	}
+}
+
+func TestMesh_InBoundingVolumeHierarchy(t *testing.T) {
+	red := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}}, Opacity: 1}
+	blue := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{0, 0, 1}}, Opacity: 0.5,
+		RefractiveIndex: 1}
+	triangle1, _ := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0}, red)
+	triangle2, _ := NewTriangle(geometry.Point{0, 0, 1}, geometry.Point{1, 0, 1}, geometry.Point{0, 1, 1}, blue)
+	mesh, _ := NewMesh([]Triangle{triangle1, triangle2})
+	bvh := NewBoundingVolumeHierarchy([]Surface{mesh, newTestSphere(geometry.Point{10, 10, 10}, 1)})
+
+	// The hierarchy should resolve the mesh to whichever of its triangles was intersected.
+	ray := geometry.Ray{geometry.Point{0.2, 0.2, 3}, geometry.Vector{0, 0, -1}}
+	intersection, surface := bvh.ClosestIntersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 2.0, intersection.Distance)
+		assert.Equal(t, triangle2, surface)
+	}
+
+	var visited []Surface
+	bvh.VisitIntersections(ray, func(surface Surface, intersection *geometry.Intersection) bool {
+		visited = append(visited, surface)
+		return true
+	})
+	assert.ElementsMatch(t, []Surface{triangle1, triangle2}, visited)
+}
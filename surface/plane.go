@@ -6,34 +6,42 @@ import (
 	"errors"
 	"github.com/patfair/raytracer/geometry"
 	"github.com/patfair/raytracer/shading"
+	"math"
 )
 
+// Largest cosine of the angle between the width and height of a plane for them to be considered perpendicular, allowing
+// for floating-point error in vectors calculated from others.
+const perpendicularTolerance = 1e-9
+
 // Represents a two-sided, rectangular surface having a finite size and zero thickness.
 type Plane struct {
-	bottomLeftCorner  geometry.Point  // Point representing the bottom left corner of the plane
-	width             geometry.Vector // Direction and size of the plane extending "right" from the corner
-	height            geometry.Vector // Direction and size of the plane extending "up" from the corner
-	normal            geometry.Vector // Unit vector representing the direction normal to the surface of the plane
-	shadingProperties shading.ShadingProperties
+	bottomLeftCorner geometry.Point  // Point representing the bottom left corner of the plane
+	width            geometry.Vector // Direction and size of the plane extending "right" from the corner
+	height           geometry.Vector // Direction and size of the plane extending "up" from the corner
+	normal           geometry.Vector // Unit vector representing the direction normal to the surface of the plane
+	closed           bool            // Whether the plane is a face of a box, so its normal always points out of it
+	material         shading.Material
 }
 
 // Returns a new plane, or an error if the parameters are invalid.
 func NewPlane(bottomLeftCorner geometry.Point, width, height geometry.Vector,
-	shadingProperties shading.ShadingProperties) (Plane, error) {
-	if err := shadingProperties.Validate(); err != nil {
+	material shading.Material) (Plane, error) {
+	if err := material.Validate(); err != nil {
 		return Plane{}, err
 	}
-	if width.Dot(height) != 0 {
+	if math.Abs(width.Dot(height)) > perpendicularTolerance*width.Norm()*height.Norm() {
 		return Plane{}, errors.New("plane width and height must be perpendicular")
 	}
 
-	return Plane{
-		bottomLeftCorner:  bottomLeftCorner,
-		width:             width,
-		height:            height,
-		normal:            width.Cross(height).ToUnit(),
-		shadingProperties: shadingProperties,
-	}, nil
+	plane := Plane{
+		bottomLeftCorner: bottomLeftCorner,
+		width:            width,
+		height:           height,
+		normal:           width.Cross(height).ToUnit(),
+	}
+	uSize, vSize := plane.TextureSize()
+	plane.material = fitMaterial(material, shading.UvMapping, uSize, vSize)
+	return plane, nil
 }
 
 func (plane Plane) Intersection(ray geometry.Ray) *geometry.Intersection {
@@ -59,15 +67,27 @@ func (plane Plane) Intersection(ray geometry.Ray) *geometry.Intersection {
 	intersection.Distance = distance
 	intersection.Point = point
 	intersection.Normal = plane.normal
-	if denominator > 0 {
+	if denominator > 0 && !plane.closed {
 		intersection.Normal = intersection.Normal.Multiply(-1)
 	}
 
 	return intersection
 }
 
-func (plane Plane) ShadingProperties() shading.ShadingProperties {
-	return plane.shadingProperties
+func (plane Plane) IsClosed() bool {
+	return plane.closed
+}
+
+func (plane Plane) Area() float64 {
+	return plane.width.Cross(plane.height).Norm()
+}
+
+func (plane Plane) SamplePoint(u1, u2 float64) (geometry.Point, geometry.Vector) {
+	return plane.bottomLeftCorner.Translate(plane.width.Multiply(u1)).Translate(plane.height.Multiply(u2)), plane.normal
+}
+
+func (plane Plane) Material() shading.Material {
+	return plane.material
 }
 
 func (plane Plane) ToTextureCoordinates(point geometry.Point) (float64, float64) {
@@ -77,6 +97,24 @@ func (plane Plane) ToTextureCoordinates(point geometry.Point) (float64, float64)
 	return u, v
 }
 
+func (plane Plane) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	return plane.width.ToUnit(), plane.height.ToUnit()
+}
+
+// Returns the size of the plane along its texture axes, to which image textures using the UV mapping are fitted.
+func (plane Plane) TextureSize() (float64, float64) {
+	return plane.width.Norm(), plane.height.Norm()
+}
+
+func (plane Plane) BoundingBox() geometry.BoundingBox {
+	return geometry.NewBoundingBox(
+		plane.bottomLeftCorner,
+		plane.bottomLeftCorner.Translate(plane.width),
+		plane.bottomLeftCorner.Translate(plane.height),
+		plane.bottomLeftCorner.Translate(plane.width).Translate(plane.height),
+	)
+}
+
 // Returns true if the given point on the plane in world coordinates is within the defined boundaries of the plane.
 func (plane Plane) isPointWithinLimits(point geometry.Point) bool {
 	u, v := plane.ToTextureCoordinates(point)
@@ -84,3 +122,12 @@ func (plane Plane) isPointWithinLimits(point geometry.Point) bool {
 	height := plane.height.Norm()
 	return u >= 0 && u <= width && v >= 0 && v <= height
 }
+
+// Returns the given material with its image textures that use the given mapping fitted to a surface of the given size
+// along each of its texture axes, if it has any.
+func fitMaterial(material shading.Material, mapping shading.TextureMapping, uSize, vSize float64) shading.Material {
+	if texturedMaterial, ok := material.(shading.TexturedMaterial); ok {
+		return texturedMaterial.FitTextures(mapping, uSize, vSize)
+	}
+	return material
+}
@@ -20,7 +20,7 @@ func TestNewPlane(t *testing.T) {
 		shadingProperties)
 	assert.Nil(t, err)
 
-	assert.Equal(t, shadingProperties, plane.ShadingProperties())
+	assert.Equal(t, shadingProperties, plane.Material())
 	assert.Equal(t, geometry.Vector{0, 0, 1}, plane.normal)
 }
 
@@ -31,6 +31,11 @@ func TestNewPlaneInvalid(t *testing.T) {
 		assert.Contains(t, err.Error(), "must be perpendicular")
 	}
 
+	// Tiny deviations from perpendicular due to floating-point error should be tolerated.
+	_, err = NewPlane(geometry.Point{0, 0, 0}, geometry.Vector{0.1, 0.2, 0}, geometry.Vector{-0.2, 0.1 + 1e-15, 3},
+		shading.ShadingProperties{Opacity: 1})
+	assert.Nil(t, err)
+
 	_, err = NewPlane(geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 1},
 		shading.ShadingProperties{SpecularExponent: -1})
 	if assert.NotNil(t, err) {
@@ -99,6 +104,54 @@ func TestPlane_ToTextureCoordinates(t *testing.T) {
 	u, v = plane.ToTextureCoordinates(geometry.Point{4.5, -2, -0.1})
 	assert.Equal(t, 3.5, u)
 	assert.Equal(t, 3.1, v)
+
+	width, height := plane.TextureSize()
+	assert.Equal(t, 5.0, width)
+	assert.Equal(t, 4.0, height)
+}
+
+func TestPlane_FitsTextures(t *testing.T) {
+	texture, _ := shading.NewImageTexture([][]shading.Color{{{1, 0, 0}, {0, 1, 0}}, {{0, 0, 1}, {1, 1, 1}}})
+	texture.Filter = shading.NearestFilter
+	plane, _ := NewPlane(geometry.Point{0, 0, 0}, geometry.Vector{4, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: texture, Opacity: 1})
+
+	// The image should be stretched once across the plane.
+	fitted := plane.Material().(shading.ShadingProperties).DiffuseTexture
+	shading.AssertColorEqual(t, shading.Color{0, 0, 1}, fitted.AlbedoAt(1, 0.5, 0, nil), 1e-9)
+	shading.AssertColorEqual(t, shading.Color{0, 1, 0}, fitted.AlbedoAt(3, 1.5, 0, nil), 1e-9)
+
+	// Textures using other mappings don't match the plane's coordinates, and are left as they are.
+	texture.Mapping = shading.PolarMapping
+	plane, _ = NewPlane(geometry.Point{0, 0, 0}, geometry.Vector{4, 0, 0}, geometry.Vector{0, 2, 0},
+		shading.ShadingProperties{DiffuseTexture: texture, Opacity: 1})
+	assert.Equal(t, texture, plane.Material().(shading.ShadingProperties).DiffuseTexture)
+}
+
+func TestPlane_ToTextureTangents(t *testing.T) {
+	plane, _ := NewPlane(geometry.Point{1, -2, 3}, geometry.Vector{5, 0, 0}, geometry.Vector{0, 3, -4},
+		shading.ShadingProperties{Opacity: 1})
+
+	uTangent, vTangent := plane.ToTextureTangents(geometry.Point{2, -1, 1})
+	assert.Equal(t, geometry.Vector{1, 0, 0}, uTangent)
+	assert.Equal(t, geometry.Vector{0, 0.6, -0.8}, vTangent)
+	assertTextureTangents(t, plane, geometry.Point{2, -1, 1})
+}
+
+func TestPlane_SamplePoint(t *testing.T) {
+	plane, _ := NewPlane(geometry.Point{1, -2, 3}, geometry.Vector{5, 0, 0}, geometry.Vector{0, 3, -4},
+		shading.ShadingProperties{Opacity: 1})
+	assert.Equal(t, 25.0, plane.Area())
+	assertSamplePoints(t, plane, geometry.Point{3.5, -0.5, 1})
+}
+
+func TestPlane_BoundingBox(t *testing.T) {
+	plane, _ := NewPlane(geometry.Point{1, -2, 3}, geometry.Vector{5, 0, 0}, geometry.Vector{0, 3, -4},
+		shading.ShadingProperties{Opacity: 1})
+
+	box := plane.BoundingBox()
+	assert.Equal(t, geometry.Point{1, -2, -1}, box.Min)
+	assert.Equal(t, geometry.Point{6, 1, 3}, box.Max)
 }
 
 func BenchmarkPlane_IntersectionHit(b *testing.B) {
@@ -120,3 +173,37 @@ func BenchmarkPlane_IntersectionMiss(b *testing.B) {
 		plane.Intersection(ray)
 	}
 }
+
+// Asserts that moving a short distance from the given point along each of the surface's texture tangents there changes
+// only the corresponding texture coordinate, and by the same amount.
+func assertTextureTangents(t *testing.T, surface Surface, point geometry.Point) {
+	const step = 1e-6
+	u, v := surface.ToTextureCoordinates(point)
+	uTangent, vTangent := surface.ToTextureTangents(point)
+	uAfterU, vAfterU := surface.ToTextureCoordinates(point.Translate(uTangent.Multiply(step)))
+	assert.InDelta(t, 1, (uAfterU-u)/step, 1e-4, "point: %v", point)
+	assert.InDelta(t, 0, (vAfterU-v)/step, 1e-4, "point: %v", point)
+	uAfterV, vAfterV := surface.ToTextureCoordinates(point.Translate(vTangent.Multiply(step)))
+	assert.InDelta(t, 0, (uAfterV-u)/step, 1e-4, "point: %v", point)
+	assert.InDelta(t, 1, (vAfterV-v)/step, 1e-4, "point: %v", point)
+}
+
+// Asserts that the points chosen by sampling the given surface lie on it with the given normals, and that their average
+// is the given centroid as expected of points spread evenly over its area.
+func assertSamplePoints(t *testing.T, surface SampledSurface, centroid geometry.Point) {
+	// The grids of random numbers differ in size so that none of the points fall exactly on the diagonal of a triangle.
+	const uSteps, vSteps = 50, 49
+	var sum geometry.Vector
+	for i := 0; i < uSteps; i++ {
+		for j := 0; j < vSteps; j++ {
+			point, normal := surface.SamplePoint((float64(i)+0.5)/uSteps, (float64(j)+0.5)/vSteps)
+			assert.InDelta(t, 1, normal.Norm(), 1e-9)
+			intersection := surface.Intersection(geometry.Ray{point.Translate(normal), normal.Multiply(-1)})
+			if assert.NotNil(t, intersection, "point: %v", point) {
+				assert.InDelta(t, 1, intersection.Distance, 1e-9, "point: %v", point)
+			}
+			sum = sum.Add(centroid.VectorTo(point))
+		}
+	}
+	assert.InDelta(t, 0, sum.Multiply(1.0/(uSteps*vSteps)).Norm(), 0.02)
+}
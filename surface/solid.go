@@ -0,0 +1,104 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"sort"
+)
+
+// Represents a surface that completely encloses a volume, such that a ray passing through it can be described in terms
+// of where it enters and exits. Solids can be combined using constructive solid geometry.
+type Solid interface {
+	Surface
+
+	// Returns the intervals along the line of the given ray that lie inside the solid, in order of increasing distance.
+	// Intervals lying wholly or partly behind the origin of the ray are included, with negative distances.
+	Intervals(ray geometry.Ray) []Interval
+}
+
+// Represents a section of a ray lying inside a solid.
+type Interval struct {
+	Entry Crossing // Point at which the ray enters the solid
+	Exit  Crossing // Point at which the ray exits the solid
+}
+
+// Represents a point at which a ray crosses the boundary of a solid.
+type Crossing struct {
+	Distance float64         // Signed distance along the ray, in units of its normalized direction
+	Normal   geometry.Vector // Unit vector normal to the boundary at the crossing, pointing out of the solid
+	Surface  Surface         // Surface whose material and texture coordinates apply at the crossing
+}
+
+// Returns the closest crossing among the given intervals that lies in front of the origin of the given ray, converted
+// into an intersection, along with the surface providing its shading. Nil values are returned if there is none.
+func closestCrossing(ray geometry.Ray, intervals []Interval) (*geometry.Intersection, Surface) {
+	for _, interval := range intervals {
+		for _, crossing := range []Crossing{interval.Entry, interval.Exit} {
+			if crossing.Distance > 0 {
+				return crossing.toIntersection(ray), crossing.Surface
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Returns the intersection between the given ray and the boundary represented by the crossing.
+func (crossing Crossing) toIntersection(ray geometry.Ray) *geometry.Intersection {
+	return &geometry.Intersection{
+		Point:    ray.Origin.Translate(ray.Direction.ToUnit().Multiply(crossing.Distance)),
+		Distance: crossing.Distance,
+		Normal:   crossing.Normal,
+	}
+}
+
+// Returns the intervals lying inside the combination of two solids using the given operation, given the intervals of
+// each.
+func combineIntervals(left, right []Interval, operation CsgOperation) []Interval {
+	type event struct {
+		crossing   Crossing
+		isEntry    bool
+		isFromLeft bool
+	}
+	events := make([]event, 0, 2*(len(left)+len(right)))
+	for _, interval := range left {
+		events = append(events, event{interval.Entry, true, true}, event{interval.Exit, false, true})
+	}
+	for _, interval := range right {
+		events = append(events, event{interval.Entry, true, false}, event{interval.Exit, false, false})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].crossing.Distance < events[j].crossing.Distance
+	})
+
+	// Sweep along the ray, keeping track of which solids it is inside, and record each change in whether it is inside
+	// the combination.
+	var intervals []Interval
+	var current Interval
+	insideLeft, insideRight, inside := false, false, false
+	for _, event := range events {
+		if event.isFromLeft {
+			insideLeft = event.isEntry
+		} else {
+			insideRight = event.isEntry
+		}
+		nowInside := operation.contains(insideLeft, insideRight)
+		if nowInside == inside {
+			continue
+		}
+
+		crossing := event.crossing
+		if operation == CsgDifference && !event.isFromLeft {
+			// The boundary of the subtracted solid faces the other way in the result.
+			crossing.Normal = crossing.Normal.Multiply(-1)
+		}
+		if nowInside {
+			current.Entry = crossing
+		} else {
+			current.Exit = crossing
+			intervals = append(intervals, current)
+		}
+		inside = nowInside
+	}
+	return intervals
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a rectangular box that encloses a volume, which unlike the separate planes returned by NewBox can be
+// used in constructive solid geometry.
+type SolidBox struct {
+	corner      geometry.Point     // One corner of the box, from which each of the edge vectors extends
+	edges       [3]geometry.Vector // Mutually perpendicular vectors along the width, height and depth of the box
+	faces       [6]Plane           // Planes making up the faces of the box, used for texture mapping
+	boundingBox geometry.BoundingBox
+}
+
+// Returns a new solid box formed by extruding the plane defined by the given parameters by the given depth, or an
+// error if the parameters are invalid.
+func NewSolidBox(frontBottomLeftCorner geometry.Point, width, height geometry.Vector, depth float64,
+	material shading.Material) (SolidBox, error) {
+	faces, err := NewBox(frontBottomLeftCorner, width, height, depth, material)
+	if err != nil {
+		return SolidBox{}, err
+	}
+
+	depthVector := width.Cross(height).ToUnit().Multiply(depth)
+	boundingBox := geometry.EmptyBoundingBox()
+	for _, face := range faces {
+		boundingBox = boundingBox.Union(face.BoundingBox())
+	}
+	return SolidBox{
+		corner:      frontBottomLeftCorner,
+		edges:       [3]geometry.Vector{width, height, depthVector},
+		faces:       faces,
+		boundingBox: boundingBox,
+	}, nil
+}
+
+// Returns the interval between the points at which the line of the given ray enters and exits the box, if it does.
+func (box SolidBox) Intervals(ray geometry.Ray) []Interval {
+	direction := ray.Direction.ToUnit()
+	entry := Crossing{Distance: math.Inf(-1)}
+	exit := Crossing{Distance: math.Inf(1)}
+
+	// Clip the line against the pair of opposite faces perpendicular to each edge in turn.
+	offset := box.corner.VectorTo(ray.Origin)
+	for i, edge := range box.edges {
+		lengthSquared := edge.Dot(edge)
+		start := offset.Dot(edge)
+		rate := direction.Dot(edge)
+		if rate == 0 {
+			// The line is parallel to this pair of faces, so either lies between them throughout or misses the box.
+			if start < 0 || start > lengthSquared {
+				return nil
+			}
+			continue
+		}
+
+		// The faces at the near and far ends of the edge are indexed as in the array returned by NewBox.
+		nearFace, farFace := box.faces[[3]int{2, 1, 0}[i]], box.faces[[3]int{5, 4, 3}[i]]
+		outward := edge.ToUnit()
+		near := Crossing{Distance: -start / rate, Normal: outward.Multiply(-1), Surface: nearFace}
+		far := Crossing{Distance: (lengthSquared - start) / rate, Normal: outward, Surface: farFace}
+		if near.Distance > far.Distance {
+			near, far = far, near
+		}
+		if near.Distance > entry.Distance {
+			entry = near
+		}
+		if far.Distance < exit.Distance {
+			exit = far
+		}
+	}
+	if entry.Distance > exit.Distance {
+		return nil
+	}
+	return []Interval{{entry, exit}}
+}
+
+func (box SolidBox) Intersection(ray geometry.Ray) *geometry.Intersection {
+	intersection, _ := closestCrossing(ray, box.Intervals(ray))
+	return intersection
+}
+
+func (box SolidBox) IsClosed() bool {
+	return true
+}
+
+func (box SolidBox) Material() shading.Material {
+	return box.faces[0].Material()
+}
+
+// Returns the texture coordinates of the given point with respect to the face of the box that it lies closest to.
+func (box SolidBox) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	return box.closestFace(point).ToTextureCoordinates(point)
+}
+
+// Returns the texture tangents at the given point with respect to the face of the box that it lies closest to.
+func (box SolidBox) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	return box.closestFace(point).ToTextureTangents(point)
+}
+
+func (box SolidBox) BoundingBox() geometry.BoundingBox {
+	return box.boundingBox
+}
+
+// Returns the face of the box whose plane the given point lies closest to.
+func (box SolidBox) closestFace(point geometry.Point) Plane {
+	closestFace := box.faces[0]
+	closestDistance := math.Inf(1)
+	for _, face := range box.faces {
+		distance := math.Abs(face.bottomLeftCorner.VectorTo(point).Dot(face.normal))
+		if distance < closestDistance {
+			closestFace = face
+			closestDistance = distance
+		}
+	}
+	return closestFace
+}
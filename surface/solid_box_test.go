@@ -0,0 +1,88 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewSolidBoxInvalid(t *testing.T) {
+	_, err := NewSolidBox(geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}, geometry.Vector{0, 1, 0}, 0,
+		shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "depth must be non-zero")
+	}
+	_, err = NewSolidBox(geometry.Point{0, 0, 0}, geometry.Vector{1, 0, 0}, geometry.Vector{1, 1, 0}, 1,
+		shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must be perpendicular")
+	}
+}
+
+func TestSolidBox(t *testing.T) {
+	shadingProperties := shading.ShadingProperties{DiffuseTexture: shading.SolidTexture{shading.Color{1, 0, 0}},
+		Opacity: 1}
+	box, err := NewSolidBox(geometry.Point{1, 0, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 3, 0}, -4,
+		shadingProperties)
+	assert.Nil(t, err)
+	var _ Solid = box
+
+	assert.Equal(t, shadingProperties, box.Material())
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{1, 0, -4}, geometry.Point{3, 3, 0}), box.BoundingBox())
+
+	// Along an axis
+	ray := geometry.Ray{geometry.Point{0, 1, -1}, geometry.Vector{1, 0, 0}}
+	intervals := box.Intervals(ray)
+	if assert.Equal(t, 1, len(intervals)) {
+		assert.Equal(t, 1.0, intervals[0].Entry.Distance)
+		assert.Equal(t, geometry.Vector{-1, 0, 0}, intervals[0].Entry.Normal)
+		assert.Equal(t, 3.0, intervals[0].Exit.Distance)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intervals[0].Exit.Normal)
+	}
+	intersection := box.Intersection(ray)
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 1.0, intersection.Distance)
+		assert.Equal(t, geometry.Point{1, 1, -1}, intersection.Point)
+	}
+
+	// From inside, diagonally
+	ray = geometry.Ray{geometry.Point{2.5, 1, -1}, geometry.Vector{1, 0, -1}}
+	intervals = box.Intervals(ray)
+	if assert.Equal(t, 1, len(intervals)) {
+		assert.InDelta(t, -1.414, intervals[0].Entry.Distance, 0.001)
+		assert.Equal(t, geometry.Vector{0, 0, 1}, intervals[0].Entry.Normal)
+		assert.InDelta(t, 0.707, intervals[0].Exit.Distance, 0.001)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intervals[0].Exit.Normal)
+	}
+	intersection = box.Intersection(ray)
+	if assert.NotNil(t, intersection) {
+		geometry.AssertPointEqual(t, geometry.Point{3, 1, -1.5}, intersection.Point)
+		assert.Equal(t, geometry.Vector{1, 0, 0}, intersection.Normal)
+	}
+
+	// Missing
+	assert.Empty(t, box.Intervals(geometry.Ray{geometry.Point{0, 4, -1}, geometry.Vector{1, 0, 0}}))
+	assert.Empty(t, box.Intervals(geometry.Ray{geometry.Point{0, 1, -1}, geometry.Vector{-1, 2, 0}}))
+	assert.Nil(t, box.Intersection(geometry.Ray{geometry.Point{4, 1, -1}, geometry.Vector{1, 0, 0}}))
+
+	// Each face should be textured like the corresponding plane of a box made from planes.
+	planes, _ := NewBox(geometry.Point{1, 0, 0}, geometry.Vector{2, 0, 0}, geometry.Vector{0, 3, 0}, -4,
+		shadingProperties)
+	for _, point := range []geometry.Point{{2, 1, 0}, {2, 0, -1}, {1, 2, -3}, {2.5, 2, -4}, {2, 3, -2}, {3, 1, -1}} {
+		u, v := box.ToTextureCoordinates(point)
+		found := false
+		for _, plane := range planes {
+			if plane.isPointWithinLimits(point) {
+				expectedU, expectedV := plane.ToTextureCoordinates(point)
+				if u == expectedU && v == expectedV {
+					found = true
+				}
+			}
+		}
+		assert.True(t, found, "point: %v", point)
+		assertTextureTangents(t, box, point)
+	}
+}
@@ -11,18 +11,18 @@ import (
 
 // Represents a spherical surface.
 type Sphere struct {
-	center            geometry.Point  // Point at which the sphere is centered
-	radius            float64         // Radius of the sphere
-	uDirection        geometry.Vector // For texture mapping, vector representing the axis of rotation
-	wDirection        geometry.Vector // For texture mapping, vector pointing to a start point along the equator
-	vDirection        geometry.Vector // For texture mapping, vector normal to the other two
-	shadingProperties shading.ShadingProperties
+	center     geometry.Point  // Point at which the sphere is centered
+	radius     float64         // Radius of the sphere
+	uDirection geometry.Vector // For texture mapping, vector representing the axis of rotation
+	wDirection geometry.Vector // For texture mapping, vector pointing to a start point along the equator
+	vDirection geometry.Vector // For texture mapping, vector normal to the other two
+	material   shading.Material
 }
 
 // Returns a new sphere, or an error if the parameters are invalid.
 func NewSphere(center geometry.Point, radius float64, zenithReference, azimuthReference geometry.Vector,
-	shadingProperties shading.ShadingProperties) (Sphere, error) {
-	if err := shadingProperties.Validate(); err != nil {
+	material shading.Material) (Sphere, error) {
+	if err := material.Validate(); err != nil {
 		return Sphere{}, err
 	}
 	if radius <= 0 {
@@ -35,23 +35,18 @@ func NewSphere(center geometry.Point, radius float64, zenithReference, azimuthRe
 	uDirection := azimuthReference.ToUnit()
 	wDirection := zenithReference.ToUnit()
 	return Sphere{
-		center:            center,
-		radius:            radius,
-		uDirection:        uDirection,
-		wDirection:        wDirection,
-		vDirection:        wDirection.Cross(uDirection),
-		shadingProperties: shadingProperties,
+		center:     center,
+		radius:     radius,
+		uDirection: uDirection,
+		wDirection: wDirection,
+		vDirection: wDirection.Cross(uDirection),
+		material:   material,
 	}, nil
 }
 
 func (sphere Sphere) Intersection(ray geometry.Ray) *geometry.Intersection {
 	rayOriginToSphereCenter := ray.Origin.VectorTo(sphere.center)
 	midpointDistance := ray.Direction.ToUnit().Dot(rayOriginToSphereCenter)
-	if midpointDistance < 0 {
-		// The sphere is behind the ray; there is no intersection.
-		return nil
-	}
-
 	radiusSquared := sphere.radius * sphere.radius
 	rayDistanceSquared := rayOriginToSphereCenter.Dot(rayOriginToSphereCenter) - midpointDistance*midpointDistance
 	if rayDistanceSquared > radiusSquared {
@@ -61,6 +56,14 @@ func (sphere Sphere) Intersection(ray geometry.Ray) *geometry.Intersection {
 
 	halfChordDistance := math.Sqrt(radiusSquared - rayDistanceSquared)
 	closestIntersectionDistance := midpointDistance - halfChordDistance
+	if closestIntersectionDistance < 0 {
+		// The ray starts inside the sphere, so it leaves through the far side.
+		closestIntersectionDistance = midpointDistance + halfChordDistance
+		if closestIntersectionDistance < 0 {
+			// The sphere is behind the ray; there is no intersection.
+			return nil
+		}
+	}
 	closestIntersectionPoint := ray.Origin.Translate(ray.Direction.ToUnit().Multiply(closestIntersectionDistance))
 	normal := sphere.center.VectorTo(closestIntersectionPoint).ToUnit()
 
@@ -71,8 +74,46 @@ func (sphere Sphere) Intersection(ray geometry.Ray) *geometry.Intersection {
 	}
 }
 
-func (sphere Sphere) ShadingProperties() shading.ShadingProperties {
-	return sphere.shadingProperties
+// Returns the interval between the two points at which the line of the given ray crosses the sphere, if it does.
+func (sphere Sphere) Intervals(ray geometry.Ray) []Interval {
+	direction := ray.Direction.ToUnit()
+	rayOriginToSphereCenter := ray.Origin.VectorTo(sphere.center)
+	midpointDistance := direction.Dot(rayOriginToSphereCenter)
+	radiusSquared := sphere.radius * sphere.radius
+	rayDistanceSquared := rayOriginToSphereCenter.Dot(rayOriginToSphereCenter) - midpointDistance*midpointDistance
+	if rayDistanceSquared > radiusSquared {
+		// The line passes outside the sphere.
+		return nil
+	}
+
+	halfChordDistance := math.Sqrt(radiusSquared - rayDistanceSquared)
+	crossing := func(distance float64) Crossing {
+		point := ray.Origin.Translate(direction.Multiply(distance))
+		return Crossing{Distance: distance, Normal: sphere.center.VectorTo(point).ToUnit(), Surface: sphere}
+	}
+	return []Interval{{crossing(midpointDistance - halfChordDistance), crossing(midpointDistance + halfChordDistance)}}
+}
+
+func (sphere Sphere) IsClosed() bool {
+	return true
+}
+
+func (sphere Sphere) Area() float64 {
+	return 4 * math.Pi * sphere.radius * sphere.radius
+}
+
+// Chooses a point on the sphere uniformly by area, which is the case if its height along the axis is chosen uniformly.
+func (sphere Sphere) SamplePoint(u1, u2 float64) (geometry.Point, geometry.Vector) {
+	cosTheta := 1 - 2*u1
+	sinTheta := math.Sqrt(math.Max(1-cosTheta*cosTheta, 0))
+	phi := 2 * math.Pi * u2
+	normal := sphere.uDirection.Multiply(sinTheta * math.Cos(phi)).
+		Add(sphere.vDirection.Multiply(sinTheta * math.Sin(phi))).Add(sphere.wDirection.Multiply(cosTheta))
+	return sphere.center.Translate(normal.Multiply(sphere.radius)), normal
+}
+
+func (sphere Sphere) Material() shading.Material {
+	return sphere.material
 }
 
 func (sphere Sphere) ToTextureCoordinates(point geometry.Point) (float64, float64) {
@@ -88,3 +129,22 @@ func (sphere Sphere) ToTextureCoordinates(point geometry.Point) (float64, float6
 	phi := math.Acos(w / r)
 	return theta, phi
 }
+
+func (sphere Sphere) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	theta, phi := sphere.ToTextureCoordinates(point)
+	sinTheta, cosTheta := math.Sin(theta), math.Cos(theta)
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+
+	// Differentiate the point's position r(sin(phi)cos(theta)U + sin(phi)sin(theta)V + cos(phi)W) with respect to each
+	// angle.
+	thetaTangent := sphere.uDirection.Multiply(-sinTheta).Add(sphere.vDirection.Multiply(cosTheta)).
+		Multiply(sphere.radius * sinPhi)
+	phiTangent := sphere.uDirection.Multiply(cosPhi * cosTheta).Add(sphere.vDirection.Multiply(cosPhi * sinTheta)).
+		Add(sphere.wDirection.Multiply(-sinPhi)).Multiply(sphere.radius)
+	return thetaTangent, phiTangent
+}
+
+func (sphere Sphere) BoundingBox() geometry.BoundingBox {
+	extent := geometry.Vector{sphere.radius, sphere.radius, sphere.radius}
+	return geometry.NewBoundingBox(sphere.center.Translate(extent.Multiply(-1)), sphere.center.Translate(extent))
+}
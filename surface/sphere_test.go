@@ -21,7 +21,7 @@ func TestNewSphere(t *testing.T) {
 		shadingProperties)
 	assert.Nil(t, err)
 
-	assert.Equal(t, shadingProperties, sphere.ShadingProperties())
+	assert.Equal(t, shadingProperties, sphere.Material())
 }
 
 func TestNewSphereInvalid(t *testing.T) {
@@ -74,6 +74,15 @@ func TestSphere_Intersection(t *testing.T) {
 		assert.Equal(t, geometry.Vector{0, 0, 1}, intersection.Normal)
 	}
 
+	// From inside, leaving through the far side with the normal still pointing outwards
+	intersection = newTestSphere(geometry.Point{2, 0, 0}, 3).Intersection(geometry.Ray{geometry.Point{1, 0, 0},
+		geometry.Vector{-1, 0, 0}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 2.0, intersection.Distance)
+		assert.Equal(t, geometry.Point{-1, 0, 0}, intersection.Point)
+		assert.Equal(t, geometry.Vector{-1, 0, 0}, intersection.Normal)
+	}
+
 	// Intersecting behind ray
 	intersection = newTestSphere(geometry.Point{2, 0, 0}, 3).Intersection(geometry.Ray{geometry.Point{6, 0, 0},
 		geometry.Vector{1, 0, 0}})
@@ -85,6 +94,26 @@ func TestSphere_Intersection(t *testing.T) {
 	assert.Nil(t, intersection)
 }
 
+func TestSphere_Intervals(t *testing.T) {
+	sphere := newTestSphere(geometry.Point{2, 0, 0}, 3)
+	var _ Solid = sphere
+
+	// The whole chord should be returned, even when the ray starts inside the sphere.
+	for _, origin := range []geometry.Point{{-4.5, 0, 0}, {0, 0, 0}, {6, 0, 0}} {
+		intervals := sphere.Intervals(geometry.Ray{origin, geometry.Vector{2, 0, 0}})
+		if assert.Equal(t, 1, len(intervals)) {
+			assert.Equal(t, -1-origin.X, intervals[0].Entry.Distance)
+			assert.Equal(t, geometry.Vector{-1, 0, 0}, intervals[0].Entry.Normal)
+			assert.Equal(t, sphere, intervals[0].Entry.Surface)
+			assert.Equal(t, 5-origin.X, intervals[0].Exit.Distance)
+			assert.Equal(t, geometry.Vector{1, 0, 0}, intervals[0].Exit.Normal)
+			assert.Equal(t, sphere, intervals[0].Exit.Surface)
+		}
+	}
+
+	assert.Empty(t, sphere.Intervals(geometry.Ray{geometry.Point{0, 4, 0}, geometry.Vector{1, 0, 0}}))
+}
+
 func TestSphere_ToTextureCoordinates(t *testing.T) {
 	epsilon := 0.00001
 	sphere := newTestSphere(geometry.Point{0, 0, 0}, 1)
@@ -110,6 +139,42 @@ func TestSphere_ToTextureCoordinates(t *testing.T) {
 	assert.Equal(t, 3*math.Pi/4, phi)
 }
 
+func TestSphere_ToTextureTangents(t *testing.T) {
+	sphere, _ := NewSphere(geometry.Point{1, 2, 3}, 2, geometry.Vector{0, 1, 0}, geometry.Vector{1, 0, 0},
+		shading.ShadingProperties{Opacity: 1})
+
+	// On the equator, the tangents should point east and south with lengths given by the radius.
+	uTangent, vTangent := sphere.ToTextureTangents(geometry.Point{3, 2, 3})
+	geometry.AssertVectorEqual(t, geometry.Vector{0, 0, -2}, uTangent)
+	geometry.AssertVectorEqual(t, geometry.Vector{0, -2, 0}, vTangent)
+
+	points := []geometry.Point{{3, 2, 3}, {1, 2, 1}, {1 + math.Sqrt2, 2 + math.Sqrt2, 3}, {0, 3, 3 + math.Sqrt2}}
+	for _, point := range points {
+		assertTextureTangents(t, sphere, point)
+	}
+
+	// The azimuthal tangent should vanish at the poles.
+	uTangent, _ = sphere.ToTextureTangents(geometry.Point{1, 4, 3})
+	geometry.AssertVectorEqual(t, geometry.Vector{}, uTangent)
+}
+
+func TestSphere_SamplePoint(t *testing.T) {
+	sphere := newTestSphere(geometry.Point{1, -2, 3}, 1.5)
+	assert.InDelta(t, 9*math.Pi, sphere.Area(), 1e-9)
+	assertSamplePoints(t, sphere, geometry.Point{1, -2, 3})
+
+	// Half of the points should lie in each hemisphere.
+	point, normal := sphere.SamplePoint(0.5, 0)
+	assert.InDelta(t, 0, normal.Dot(sphere.wDirection), 1e-9)
+	assert.InDelta(t, 1.5, sphere.center.DistanceTo(point), 1e-9)
+}
+
+func TestSphere_BoundingBox(t *testing.T) {
+	box := newTestSphere(geometry.Point{1, -2, 3}, 1.5).BoundingBox()
+	assert.Equal(t, geometry.Point{-0.5, -3.5, 1.5}, box.Min)
+	assert.Equal(t, geometry.Point{2.5, -0.5, 4.5}, box.Max)
+}
+
 func BenchmarkSphere_IntersectionHit(b *testing.B) {
 	sphere := newTestSphere(geometry.Point{2, 0, 0}, 3)
 	ray := geometry.Ray{geometry.Point{-4.5, 0, 0}, geometry.Vector{1, 0, 0}}
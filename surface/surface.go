@@ -13,10 +13,41 @@ type Surface interface {
 	// is closest to the origin of the ray. A nil return value indicates that the ray and surface do not intersect.
 	Intersection(ray geometry.Ray) *geometry.Intersection
 
-	// Returns the properties necessary for determining how the surface should be shaded.
-	ShadingProperties() shading.ShadingProperties
+	// Returns the material that determines how the surface should be shaded.
+	Material() shading.Material
 
 	// Converts the given point in world coordinates on the surface to the equivalent (U, V) texture coordinates.
 	// Garbage output may be produced for an input point not actually on the surface.
 	ToTextureCoordinates(point geometry.Point) (float64, float64)
+
+	// Returns the rates of change of the given point in world coordinates on the surface with respect to each of its U
+	// and V texture coordinates, which are used to orient bump and normal maps. Either may be zero at singularities
+	// such as the poles of a sphere.
+	ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector)
+
+	// Returns the smallest axis-aligned box that fully contains the surface.
+	BoundingBox() geometry.BoundingBox
+}
+
+// Represents a surface whose points can be chosen at random, so that it can be sampled as a light source if its
+// material emits light.
+type SampledSurface interface {
+	Surface
+
+	// Returns the total area of the surface.
+	Area() float64
+
+	// Returns a point chosen uniformly at random over the surface's area given two random numbers in [0, 1), along with
+	// the unit geometric normal there (which may point to either side of a two-sided surface).
+	SamplePoint(u1, u2 float64) (geometry.Point, geometry.Vector)
+}
+
+// Represents a surface that may enclose a volume, such as a sphere or a watertight mesh. The intersections of a closed
+// surface give the normal pointing out of it even when the ray arrives from inside, so that rays entering and leaving it
+// can be told apart, whereas those of an open surface give the normal facing the ray.
+type ClosedSurface interface {
+	Surface
+
+	// Returns true if the surface encloses a volume.
+	IsClosed() bool
 }
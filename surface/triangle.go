@@ -0,0 +1,196 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"errors"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"math"
+)
+
+// Represents a two-sided, triangular surface having zero thickness.
+type Triangle struct {
+	vertices              [3]geometry.Point  // Corners of the triangle
+	edge1                 geometry.Vector    // Vector from the first vertex to the second
+	edge2                 geometry.Vector    // Vector from the first vertex to the third
+	normal                geometry.Vector    // Unit vector representing the direction normal to the plane of the triangle
+	vertexNormals         [3]geometry.Vector // For smooth shading, unit normals at each vertex to interpolate between
+	smooth                bool               // Whether the vertex normals should be used instead of the face normal
+	textureCoordinates    [3][2]float64      // Optional (U, V) texture coordinates at each vertex
+	hasTextureCoordinates bool               // Whether to interpolate the vertex texture coordinates
+	closed                bool               // Whether the triangle is part of a closed mesh whose outside it faces
+	material              shading.Material
+}
+
+// Returns a new flat-shaded triangle, or an error if the parameters are invalid.
+func NewTriangle(vertex0, vertex1, vertex2 geometry.Point, material shading.Material) (Triangle, error) {
+	if err := material.Validate(); err != nil {
+		return Triangle{}, err
+	}
+
+	edge1 := vertex0.VectorTo(vertex1)
+	edge2 := vertex0.VectorTo(vertex2)
+	normal := edge1.Cross(edge2)
+	if normal.Norm() == 0 {
+		return Triangle{}, errors.New("triangle vertices must not be collinear")
+	}
+
+	return Triangle{
+		vertices: [3]geometry.Point{vertex0, vertex1, vertex2},
+		edge1:    edge1,
+		edge2:    edge2,
+		normal:   normal.ToUnit(),
+		material: material,
+	}, nil
+}
+
+// Returns a new triangle whose shading normal is interpolated between the given normals at each vertex, to give the
+// appearance of a smoothly curved surface when used in a mesh. Returns an error if the parameters are invalid.
+func NewSmoothTriangle(vertex0, vertex1, vertex2 geometry.Point, normal0, normal1, normal2 geometry.Vector,
+	material shading.Material) (Triangle, error) {
+	triangle, err := NewTriangle(vertex0, vertex1, vertex2, material)
+	if err != nil {
+		return Triangle{}, err
+	}
+	if normal0.Norm() == 0 || normal1.Norm() == 0 || normal2.Norm() == 0 {
+		return Triangle{}, errors.New("triangle vertex normals must be non-zero")
+	}
+
+	triangle.vertexNormals = [3]geometry.Vector{normal0.ToUnit(), normal1.ToUnit(), normal2.ToUnit()}
+	triangle.smooth = true
+	return triangle, nil
+}
+
+// Uses the Möller–Trumbore algorithm to find the intersection without first computing the intersection with the plane
+// of the triangle.
+func (triangle Triangle) Intersection(ray geometry.Ray) *geometry.Intersection {
+	direction := ray.Direction.ToUnit()
+	p := direction.Cross(triangle.edge2)
+	determinant := triangle.edge1.Dot(p)
+	if determinant == 0 {
+		// The ray is parallel to the triangle; they do not intersect.
+		return nil
+	}
+	inverseDeterminant := 1 / determinant
+
+	t := triangle.vertices[0].VectorTo(ray.Origin)
+	b1 := t.Dot(p) * inverseDeterminant
+	if b1 < 0 || b1 > 1 {
+		return nil
+	}
+	q := t.Cross(triangle.edge1)
+	b2 := direction.Dot(q) * inverseDeterminant
+	if b2 < 0 || b1+b2 > 1 {
+		return nil
+	}
+
+	distance := triangle.edge2.Dot(q) * inverseDeterminant
+	if distance < 0 {
+		// The triangle is behind the ray.
+		return nil
+	}
+
+	intersection := new(geometry.Intersection)
+	intersection.Distance = distance
+	intersection.Point = ray.Origin.Translate(direction.Multiply(distance))
+	intersection.Normal = triangle.normalAt(b1, b2)
+	if triangle.normal.Dot(direction) > 0 && !triangle.closed {
+		intersection.Normal = intersection.Normal.Multiply(-1)
+	}
+
+	return intersection
+}
+
+func (triangle Triangle) IsClosed() bool {
+	return triangle.closed
+}
+
+func (triangle Triangle) Area() float64 {
+	return triangle.edge1.Cross(triangle.edge2).Norm() / 2
+}
+
+// Chooses a point on the triangle uniformly by area, by folding points that fall outside it back across the diagonal of
+// the parallelogram formed by its edges.
+func (triangle Triangle) SamplePoint(u1, u2 float64) (geometry.Point, geometry.Vector) {
+	if u1+u2 > 1 {
+		u1, u2 = 1-u1, 1-u2
+	}
+	return triangle.vertices[0].Translate(triangle.edge1.Multiply(u1)).Translate(triangle.edge2.Multiply(u2)),
+		triangle.normal
+}
+
+func (triangle Triangle) Material() shading.Material {
+	return triangle.material
+}
+
+// Returns the texture coordinates interpolated from those given for each vertex, if any, or otherwise the barycentric
+// coordinates of the point relative to the second and third vertices.
+func (triangle Triangle) ToTextureCoordinates(point geometry.Point) (float64, float64) {
+	b1, b2 := triangle.barycentricCoordinates(point)
+	if !triangle.hasTextureCoordinates {
+		return b1, b2
+	}
+
+	b0 := 1 - b1 - b2
+	u := b0*triangle.textureCoordinates[0][0] + b1*triangle.textureCoordinates[1][0] +
+		b2*triangle.textureCoordinates[2][0]
+	v := b0*triangle.textureCoordinates[0][1] + b1*triangle.textureCoordinates[1][1] +
+		b2*triangle.textureCoordinates[2][1]
+	return u, v
+}
+
+func (triangle Triangle) ToTextureTangents(point geometry.Point) (geometry.Vector, geometry.Vector) {
+	if !triangle.hasTextureCoordinates {
+		return triangle.edge1, triangle.edge2
+	}
+
+	// Invert the mapping from the changes in texture coordinates along each edge to the edges themselves.
+	du1 := triangle.textureCoordinates[1][0] - triangle.textureCoordinates[0][0]
+	dv1 := triangle.textureCoordinates[1][1] - triangle.textureCoordinates[0][1]
+	du2 := triangle.textureCoordinates[2][0] - triangle.textureCoordinates[0][0]
+	dv2 := triangle.textureCoordinates[2][1] - triangle.textureCoordinates[0][1]
+	determinant := du1*dv2 - du2*dv1
+	if determinant == 0 {
+		// The texture coordinates are degenerate, so there is no meaningful direction in which they change.
+		return geometry.Vector{}, geometry.Vector{}
+	}
+	uTangent := triangle.edge1.Multiply(dv2).Add(triangle.edge2.Multiply(-dv1)).Multiply(1 / determinant)
+	vTangent := triangle.edge1.Multiply(-du2).Add(triangle.edge2.Multiply(du1)).Multiply(1 / determinant)
+	return uTangent, vTangent
+}
+
+func (triangle Triangle) BoundingBox() geometry.BoundingBox {
+	return geometry.NewBoundingBox(triangle.vertices[0], triangle.vertices[1], triangle.vertices[2])
+}
+
+// Returns the weights of the second and third vertices (the weight of the first being one minus their sum) that,
+// when used to average the positions of the vertices, produce the given point on the plane of the triangle.
+func (triangle Triangle) barycentricCoordinates(point geometry.Point) (float64, float64) {
+	vector := triangle.vertices[0].VectorTo(point)
+	d00 := triangle.edge1.Dot(triangle.edge1)
+	d01 := triangle.edge1.Dot(triangle.edge2)
+	d11 := triangle.edge2.Dot(triangle.edge2)
+	d20 := vector.Dot(triangle.edge1)
+	d21 := vector.Dot(triangle.edge2)
+	denominator := d00*d11 - d01*d01
+	return (d11*d20 - d01*d21) / denominator, (d00*d21 - d01*d20) / denominator
+}
+
+// Returns the unit normal at the point having the given barycentric coordinates, oriented on the same side as the
+// face normal.
+func (triangle Triangle) normalAt(b1, b2 float64) geometry.Vector {
+	if !triangle.smooth {
+		return triangle.normal
+	}
+
+	normal := triangle.vertexNormals[0].Multiply(1 - b1 - b2).Add(triangle.vertexNormals[1].Multiply(b1)).
+		Add(triangle.vertexNormals[2].Multiply(b2)).ToUnit()
+	if normal.Norm() == 0 || math.IsNaN(normal.X) {
+		return triangle.normal
+	}
+	if normal.Dot(triangle.normal) < 0 {
+		normal = normal.Multiply(-1)
+	}
+	return normal
+}
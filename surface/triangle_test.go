@@ -0,0 +1,170 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestNewTriangle(t *testing.T) {
+	shadingProperties := shading.ShadingProperties{
+		DiffuseTexture:  shading.SolidTexture{shading.Color{0, 0.1, 0.2}},
+		Reflectivity:    0.5,
+		Opacity:         0.9,
+		RefractiveIndex: 1.1,
+	}
+	triangle, err := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0},
+		shadingProperties)
+	assert.Nil(t, err)
+
+	assert.Equal(t, shadingProperties, triangle.Material())
+	assert.Equal(t, geometry.Vector{0, 0, 1}, triangle.normal)
+}
+
+func TestNewTriangleInvalid(t *testing.T) {
+	_, err := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 1, 1}, geometry.Point{2, 2, 2},
+		shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "must not be collinear")
+	}
+
+	_, err = NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0},
+		shading.ShadingProperties{SpecularExponent: -1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "exponent must be non-negative")
+	}
+
+	_, err = NewSmoothTriangle(geometry.Point{0, 0, 0}, geometry.Point{1, 0, 0}, geometry.Point{0, 1, 0},
+		geometry.Vector{0, 0, 1}, geometry.Vector{0, 0, 0}, geometry.Vector{0, 0, 1}, shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "normals must be non-zero")
+	}
+}
+
+func TestTriangle_Intersection(t *testing.T) {
+	triangle, _ := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{2, 0, 0}, geometry.Point{0, 2, 0},
+		shading.ShadingProperties{Opacity: 1})
+
+	intersection := triangle.Intersection(geometry.Ray{geometry.Point{0.5, 0.5, 3}, geometry.Vector{0, 0, -2}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 3.0, intersection.Distance)
+		assert.Equal(t, geometry.Point{0.5, 0.5, 0}, intersection.Point)
+		assert.Equal(t, geometry.Vector{0, 0, 1}, intersection.Normal)
+	}
+
+	// Intersecting from behind
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{0.5, 0.5, -1.5}, geometry.Vector{0, 0, 1}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 1.5, intersection.Distance)
+		assert.Equal(t, geometry.Vector{0, 0, -1}, intersection.Normal)
+	}
+
+	// Intersecting behind ray
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{0.5, 0.5, 1}, geometry.Vector{0, 0, 1}})
+	assert.Nil(t, intersection)
+
+	// Intersecting the plane of the triangle outside of its edges
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{1.5, 1.5, 1}, geometry.Vector{0, 0, -1}})
+	assert.Nil(t, intersection)
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{-0.1, 1, 1}, geometry.Vector{0, 0, -1}})
+	assert.Nil(t, intersection)
+
+	// Parallel
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{-1, 0.5, 0}, geometry.Vector{1, 0, 0}})
+	assert.Nil(t, intersection)
+}
+
+func TestTriangle_IntersectionSmooth(t *testing.T) {
+	triangle, _ := NewSmoothTriangle(geometry.Point{0, 0, 0}, geometry.Point{2, 0, 0}, geometry.Point{0, 2, 0},
+		geometry.Vector{0, 0, 1}, geometry.Vector{1, 0, 1}, geometry.Vector{0, 1, 1}, shading.ShadingProperties{Opacity: 1})
+
+	intersection := triangle.Intersection(geometry.Ray{geometry.Point{0, 0, 1}, geometry.Vector{0, 0, -1}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertVectorEqual(t, geometry.Vector{0, 0, 1}, intersection.Normal)
+	}
+
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{2, 0, 1}, geometry.Vector{0, 0, -1}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertVectorEqual(t, geometry.Vector{1, 0, 1}.ToUnit(), intersection.Normal)
+	}
+
+	// The interpolated normal should be flipped when intersecting from behind.
+	intersection = triangle.Intersection(geometry.Ray{geometry.Point{1, 1, -1}, geometry.Vector{0, 0, 1}})
+	if assert.NotNil(t, intersection) {
+		geometry.AssertVectorEqual(t, geometry.Vector{-1, -1, -2}.ToUnit(), intersection.Normal)
+	}
+}
+
+func TestTriangle_ToTextureCoordinates(t *testing.T) {
+	triangle, _ := NewTriangle(geometry.Point{1, 1, 1}, geometry.Point{3, 1, 1}, geometry.Point{1, 1, 5},
+		shading.ShadingProperties{Opacity: 1})
+
+	u, v := triangle.ToTextureCoordinates(geometry.Point{1, 1, 1})
+	assert.Equal(t, 0.0, u)
+	assert.Equal(t, 0.0, v)
+
+	u, v = triangle.ToTextureCoordinates(geometry.Point{2, 1, 2})
+	assert.Equal(t, 0.5, u)
+	assert.Equal(t, 0.25, v)
+
+	triangle.textureCoordinates = [3][2]float64{{0.5, 0.5}, {1, 0.5}, {0.5, 0}}
+	triangle.hasTextureCoordinates = true
+	u, v = triangle.ToTextureCoordinates(geometry.Point{2, 1, 2})
+	assert.Equal(t, 0.75, u)
+	assert.Equal(t, 0.375, v)
+}
+
+func TestTriangle_ToTextureTangents(t *testing.T) {
+	triangle, _ := NewTriangle(geometry.Point{1, 1, 1}, geometry.Point{3, 1, 1}, geometry.Point{1, 1, 5},
+		shading.ShadingProperties{Opacity: 1})
+	uTangent, vTangent := triangle.ToTextureTangents(geometry.Point{2, 1, 2})
+	assert.Equal(t, geometry.Vector{2, 0, 0}, uTangent)
+	assert.Equal(t, geometry.Vector{0, 0, 4}, vTangent)
+	assertTextureTangents(t, triangle, geometry.Point{2, 1, 2})
+
+	triangle.textureCoordinates = [3][2]float64{{0.5, 0.5}, {1, 0.5}, {0.5, 0}}
+	triangle.hasTextureCoordinates = true
+	uTangent, vTangent = triangle.ToTextureTangents(geometry.Point{2, 1, 2})
+	assert.Equal(t, geometry.Vector{4, 0, 0}, uTangent)
+	assert.Equal(t, geometry.Vector{0, 0, -8}, vTangent)
+	assertTextureTangents(t, triangle, geometry.Point{2, 1, 2})
+
+	// Skewed texture coordinates should still be handled.
+	triangle.textureCoordinates = [3][2]float64{{0, 0}, {1, 1}, {-1, 2}}
+	assertTextureTangents(t, triangle, geometry.Point{2, 1, 2})
+
+	triangle.textureCoordinates = [3][2]float64{{0, 0}, {1, 1}, {2, 2}}
+	uTangent, vTangent = triangle.ToTextureTangents(geometry.Point{2, 1, 2})
+	assert.Equal(t, geometry.Vector{}, uTangent)
+	assert.Equal(t, geometry.Vector{}, vTangent)
+}
+
+func TestTriangle_SamplePoint(t *testing.T) {
+	triangle, _ := NewTriangle(geometry.Point{1, -1, 1}, geometry.Point{4, -1, 1}, geometry.Point{1, 2, 4},
+		shading.ShadingProperties{Opacity: 1})
+	assert.InDelta(t, 4.5*math.Sqrt(2), triangle.Area(), 1e-9)
+	assertSamplePoints(t, triangle, geometry.Point{2, 0, 2})
+}
+
+func TestTriangle_BoundingBox(t *testing.T) {
+	triangle, _ := NewTriangle(geometry.Point{1, -1, 1}, geometry.Point{3, 1, 1}, geometry.Point{1, 1, math.Pi},
+		shading.ShadingProperties{Opacity: 1})
+
+	box := triangle.BoundingBox()
+	assert.Equal(t, geometry.Point{1, -1, 1}, box.Min)
+	assert.Equal(t, geometry.Point{3, 1, math.Pi}, box.Max)
+}
+
+func BenchmarkTriangle_IntersectionHit(b *testing.B) {
+	triangle, _ := NewTriangle(geometry.Point{0, 0, 0}, geometry.Point{2, 0, 0}, geometry.Point{0, 2, 0},
+		shading.ShadingProperties{Opacity: 1})
+	ray := geometry.Ray{geometry.Point{0.5, 0.5, 3}, geometry.Vector{0, 0, -1}}
+
+	for n := 0; n < b.N; n++ {
+		triangle.Intersection(ray)
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Loads a mesh from the Wavefront OBJ file at the given path, along with the materials from any MTL files that it
+// references. Faces having more than three vertices are split into triangles, and faces that specify vertex normals
+// are smooth-shaded. Faces without a material, and material properties not given in the MTL file, take the given
+// default shading properties.
+//
+// Of the MTL material properties, Kd maps onto a solid diffuse color, Ns onto the specular exponent, d (or the
+// complement of Tr) onto the opacity and Ni onto the refractive index. The components of Ks are averaged to give the
+// specular intensity, which also becomes the reflectivity if the illumination model (illum) is 3 or higher. A material
+// with a non-black Ke instead becomes a diffuse emitter of that color, with a strength of 1.
+func LoadWavefrontObj(path string, defaultShadingProperties shading.ShadingProperties) (Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Mesh{}, err
+	}
+	defer file.Close()
+
+	loadMaterials := func(name string) (map[string]shading.Material, error) {
+		mtlPath := filepath.Join(filepath.Dir(path), name)
+		mtlFile, err := os.Open(mtlPath)
+		if err != nil {
+			return nil, err
+		}
+		defer mtlFile.Close()
+		return parseWavefrontMtl(mtlFile, mtlPath, defaultShadingProperties)
+	}
+	return parseWavefrontObj(file, path, defaultShadingProperties, loadMaterials)
+}
+
+// Parses the OBJ-format data from the given reader (whose name is used for error messages), using the given function to
+// load any referenced material libraries.
+func parseWavefrontObj(reader io.Reader, name string, defaultShadingProperties shading.ShadingProperties,
+	loadMaterials func(name string) (map[string]shading.Material, error)) (Mesh, error) {
+	if err := defaultShadingProperties.Validate(); err != nil {
+		return Mesh{}, err
+	}
+
+	var vertices []geometry.Point
+	var textureCoordinates [][2]float64
+	var normals []geometry.Vector
+	materials := make(map[string]shading.Material)
+	var material shading.Material = defaultShadingProperties
+	var triangles []Triangle
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lineError := func(err error) error {
+			return fmt.Errorf("%s:%d: %v", name, lineNumber, err)
+		}
+
+		switch fields[0] {
+		case "v":
+			values, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return Mesh{}, lineError(err)
+			}
+			vertices = append(vertices, geometry.Point{values[0], values[1], values[2]})
+		case "vt":
+			values, err := parseFloats(fields[1:], 1)
+			if err != nil {
+				return Mesh{}, lineError(err)
+			}
+			var coordinates [2]float64
+			copy(coordinates[:], values)
+			textureCoordinates = append(textureCoordinates, coordinates)
+		case "vn":
+			values, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return Mesh{}, lineError(err)
+			}
+			normals = append(normals, geometry.Vector{values[0], values[1], values[2]})
+		case "f":
+			if len(fields) < 4 {
+				return Mesh{}, lineError(errors.New("face must have at least three vertices"))
+			}
+			faceVertices := make([]objFaceVertex, len(fields)-1)
+			for i, field := range fields[1:] {
+				faceVertex, err := parseObjFaceVertex(field, len(vertices), len(textureCoordinates), len(normals))
+				if err != nil {
+					return Mesh{}, lineError(err)
+				}
+				faceVertices[i] = faceVertex
+			}
+
+			// Split the polygon into a fan of triangles sharing the first vertex.
+			for i := 1; i+1 < len(faceVertices); i++ {
+				corners := [3]objFaceVertex{faceVertices[0], faceVertices[i], faceVertices[i+1]}
+				triangle, err := newObjTriangle(corners, vertices, textureCoordinates, normals, material)
+				if err != nil {
+					// Skip degenerate triangles, which are common in real-world models and don't contribute anything.
+					continue
+				}
+				triangles = append(triangles, triangle)
+			}
+		case "mtllib":
+			for _, library := range fields[1:] {
+				libraryMaterials, err := loadMaterials(library)
+				if err != nil {
+					return Mesh{}, lineError(err)
+				}
+				for materialName, libraryMaterial := range libraryMaterials {
+					materials[materialName] = libraryMaterial
+				}
+			}
+		case "usemtl":
+			if len(fields) < 2 {
+				return Mesh{}, lineError(errors.New("material name must be specified"))
+			}
+			var ok bool
+			if material, ok = materials[fields[1]]; !ok {
+				return Mesh{}, lineError(fmt.Errorf("unknown material %q", fields[1]))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Mesh{}, err
+	}
+
+	mesh, err := NewMesh(triangles)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("%s: %v", name, err)
+	}
+	return mesh, nil
+}
+
+// Parses the MTL-format data from the given reader (whose name is used for error messages) and returns the materials
+// it defines, keyed by name.
+func parseWavefrontMtl(reader io.Reader, name string,
+	defaultShadingProperties shading.ShadingProperties) (map[string]shading.Material, error) {
+	materials := make(map[string]shading.Material)
+	var materialName string
+	var material shading.ShadingProperties
+	var materialLineNumber, illuminationModel int
+	var specularIntensity float64
+	var emissionColor shading.Color
+
+	// Applies any properties that depend on more than one statement and saves the material being built.
+	finishMaterial := func() error {
+		if materialName == "" {
+			return nil
+		}
+		if illuminationModel >= 3 {
+			material.Reflectivity = math.Min(specularIntensity, 1)
+		}
+		if err := material.Validate(); err != nil {
+			return fmt.Errorf("%s:%d: material %q: %v", name, materialLineNumber, materialName, err)
+		}
+		if emissionColor.MaxComponent() > 0 {
+			materials[materialName] = shading.DiffuseEmitter{Color: emissionColor, Strength: 1}
+		} else {
+			materials[materialName] = material
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+		var values []float64
+		switch fields[0] {
+		case "newmtl":
+			if err = finishMaterial(); err != nil {
+				return nil, err
+			}
+			if len(fields) < 2 {
+				err = errors.New("material name must be specified")
+				break
+			}
+			materialName = fields[1]
+			material = defaultShadingProperties
+			materialLineNumber = lineNumber
+			illuminationModel = 0
+			specularIntensity = material.SpecularIntensity
+			emissionColor = shading.Color{}
+		case "Kd":
+			if values, err = parseFloats(fields[1:], 3); err == nil {
+				material.DiffuseTexture = shading.SolidTexture{Color: shading.Color{values[0], values[1], values[2]}}
+			}
+		case "Ks":
+			if values, err = parseFloats(fields[1:], 3); err == nil {
+				specularIntensity = (values[0] + values[1] + values[2]) / 3
+				material.SpecularIntensity = specularIntensity
+			}
+		case "Ke":
+			if values, err = parseFloats(fields[1:], 3); err == nil {
+				emissionColor = shading.Color{values[0], values[1], values[2]}
+			}
+		case "Ns":
+			if values, err = parseFloats(fields[1:], 1); err == nil {
+				material.SpecularExponent = values[0]
+			}
+		case "d":
+			if values, err = parseFloats(fields[1:], 1); err == nil {
+				material.Opacity = values[0]
+			}
+		case "Tr":
+			if values, err = parseFloats(fields[1:], 1); err == nil {
+				material.Opacity = 1 - values[0]
+			}
+		case "Ni":
+			if values, err = parseFloats(fields[1:], 1); err == nil {
+				material.RefractiveIndex = values[0]
+			}
+		case "illum":
+			if values, err = parseFloats(fields[1:], 1); err == nil {
+				illuminationModel = int(values[0])
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finishMaterial(); err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
+// Holds the resolved zero-based indices for one corner of a face, with -1 representing an omitted index.
+type objFaceVertex struct {
+	vertex            int
+	textureCoordinate int
+	normal            int
+}
+
+// Parses a single face vertex in one of the forms "v", "v/vt", "v//vn" or "v/vt/vn", given the number of each kind of
+// element defined so far (against which negative, relative indices are resolved).
+func parseObjFaceVertex(field string, numVertices, numTextureCoordinates, numNormals int) (objFaceVertex, error) {
+	parts := strings.Split(field, "/")
+	if len(parts) > 3 {
+		return objFaceVertex{}, fmt.Errorf("invalid face vertex %q", field)
+	}
+
+	indices := [3]int{-1, -1, -1}
+	counts := [3]int{numVertices, numTextureCoordinates, numNormals}
+	for i, part := range parts {
+		if part == "" && i > 0 {
+			continue
+		}
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return objFaceVertex{}, fmt.Errorf("invalid face vertex %q", field)
+		}
+		if index < 0 {
+			index += counts[i]
+		} else {
+			index--
+		}
+		if index < 0 || index >= counts[i] {
+			return objFaceVertex{}, fmt.Errorf("face vertex %q refers to an undefined element", field)
+		}
+		indices[i] = index
+	}
+
+	return objFaceVertex{vertex: indices[0], textureCoordinate: indices[1], normal: indices[2]}, nil
+}
+
+// Creates a triangle from the given face corners, using vertex normals and texture coordinates only if every corner
+// specifies them.
+func newObjTriangle(corners [3]objFaceVertex, vertices []geometry.Point, textureCoordinates [][2]float64,
+	normals []geometry.Vector, material shading.Material) (Triangle, error) {
+	point0 := vertices[corners[0].vertex]
+	point1 := vertices[corners[1].vertex]
+	point2 := vertices[corners[2].vertex]
+
+	var triangle Triangle
+	var err error
+	if corners[0].normal >= 0 && corners[1].normal >= 0 && corners[2].normal >= 0 {
+		triangle, err = NewSmoothTriangle(point0, point1, point2, normals[corners[0].normal],
+			normals[corners[1].normal], normals[corners[2].normal], material)
+		if err != nil {
+			// Fall back to flat shading if the file contains degenerate normals.
+			triangle, err = NewTriangle(point0, point1, point2, material)
+		}
+	} else {
+		triangle, err = NewTriangle(point0, point1, point2, material)
+	}
+	if err != nil {
+		return Triangle{}, err
+	}
+
+	if corners[0].textureCoordinate >= 0 && corners[1].textureCoordinate >= 0 && corners[2].textureCoordinate >= 0 {
+		triangle.textureCoordinates = [3][2]float64{
+			textureCoordinates[corners[0].textureCoordinate],
+			textureCoordinates[corners[1].textureCoordinate],
+			textureCoordinates[corners[2].textureCoordinate],
+		}
+		triangle.hasTextureCoordinates = true
+	}
+	return triangle, nil
+}
+
+// Parses the given fields as floating-point numbers, requiring at least the given number of them.
+func parseFloats(fields []string, minCount int) ([]float64, error) {
+	if len(fields) < minCount {
+		return nil, fmt.Errorf("expected at least %d numeric values", minCount)
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Patrick Fairbank. All Rights Reserved.
+
+package surface
+
+import (
+	"github.com/patfair/raytracer/geometry"
+	"github.com/patfair/raytracer/shading"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestLoadWavefrontObj(t *testing.T) {
+	defaultShadingProperties := shading.ShadingProperties{
+		DiffuseTexture: shading.SolidTexture{shading.Color{1, 1, 1}},
+		Opacity:        1,
+	}
+	mesh, err := LoadWavefrontObj("testdata/pyramid.obj", defaultShadingProperties)
+	assert.Nil(t, err)
+
+	// The square base is split into two triangles, plus one for each side.
+	triangles := mesh.Triangles()
+	if assert.Equal(t, 6, len(triangles)) {
+		base := triangles[0]
+		baseShadingProperties := base.Material().(shading.ShadingProperties)
+		assert.Equal(t, shading.SolidTexture{shading.Color{0.1, 0.2, 0.3}}, baseShadingProperties.DiffuseTexture)
+		assert.Equal(t, 0.0, baseShadingProperties.Reflectivity)
+		assert.True(t, base.smooth)
+		assert.True(t, base.hasTextureCoordinates)
+		u, v := base.ToTextureCoordinates(geometry.Point{0.25, 0.25, 0})
+		assert.InDelta(t, 0.75, u, 1e-9)
+		assert.InDelta(t, 0.75, v, 1e-9)
+
+		side := triangles[5]
+		sideShadingProperties := side.Material().(shading.ShadingProperties)
+		assert.Equal(t, shading.SolidTexture{shading.Color{0.8, 0.6, 0.4}}, sideShadingProperties.DiffuseTexture)
+		assert.Equal(t, 50.0, sideShadingProperties.SpecularExponent)
+		assert.InDelta(t, 0.6, sideShadingProperties.SpecularIntensity, 1e-9)
+		assert.InDelta(t, 0.6, sideShadingProperties.Reflectivity, 1e-9)
+		assert.Equal(t, 1.0, sideShadingProperties.Opacity)
+		assert.False(t, side.smooth)
+		assert.False(t, side.hasTextureCoordinates)
+		assert.Equal(t, [3]geometry.Point{{-0.5, 0.5, 0}, {-0.5, -0.5, 0}, {0, 0, 1}}, side.vertices)
+	}
+
+	assert.Equal(t, geometry.NewBoundingBox(geometry.Point{-0.5, -0.5, 0}, geometry.Point{0.5, 0.5, 1}),
+		mesh.BoundingBox())
+	intersection := mesh.Intersection(geometry.Ray{geometry.Point{0, 0, -1}, geometry.Vector{0, 0, 1}})
+	if assert.NotNil(t, intersection) {
+		assert.Equal(t, 1.0, intersection.Distance)
+		geometry.AssertVectorEqual(t, geometry.Vector{0, 0, -1}, intersection.Normal)
+	}
+}
+
+func TestLoadWavefrontObjInvalid(t *testing.T) {
+	_, err := LoadWavefrontObj("testdata/nonexistent.obj", shading.ShadingProperties{Opacity: 1})
+	assert.NotNil(t, err)
+
+	noMaterials := func(name string) (map[string]shading.Material, error) {
+		return map[string]shading.Material{}, nil
+	}
+	parse := func(data string) error {
+		_, err := parseWavefrontObj(strings.NewReader(data), "test.obj", shading.ShadingProperties{Opacity: 1},
+			noMaterials)
+		return err
+	}
+
+	err = parse("v 0 0 0\nv 1 0 0\nv 0 1 0\n# comment\nf 1 2 4\n")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.obj:5: face vertex \"4\" refers to an undefined element")
+	}
+
+	err = parse("v 0 0 0\nv 1 zero 0\n")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.obj:2: invalid number \"zero\"")
+	}
+
+	err = parse("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2\n")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.obj:4: face must have at least three vertices")
+	}
+
+	err = parse("v 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl shiny\nf 1 2 3\n")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.obj:4: unknown material \"shiny\"")
+	}
+
+	// Only degenerate faces
+	err = parse("v 0 0 0\nv 1 0 0\nv 2 0 0\nf 1 2 3\n")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.obj: mesh must have at least one triangle")
+	}
+}
+
+func TestParseWavefrontMtl(t *testing.T) {
+	// A material with an emission color should glow instead of scattering light.
+	data := "newmtl lamp\nKd 0.5 0.5 0.5\nKe 1 0.8 0.6\n\nnewmtl dark\nKd 0.1 0.1 0.1\nKe 0 0 0\n"
+	materials, err := parseWavefrontMtl(strings.NewReader(data), "test.mtl", shading.ShadingProperties{Opacity: 1})
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(materials)) {
+		assert.Equal(t, shading.DiffuseEmitter{Color: shading.Color{1, 0.8, 0.6}, Strength: 1}, materials["lamp"])
+		assert.Equal(t, shading.SolidTexture{shading.Color{0.1, 0.1, 0.1}},
+			materials["dark"].(shading.ShadingProperties).DiffuseTexture)
+	}
+}
+
+func TestParseWavefrontMtlInvalid(t *testing.T) {
+	_, err := parseWavefrontMtl(strings.NewReader("newmtl glass\nKd 1 1 1\nd 0.5\nNi 0.5\nnewmtl other\n"),
+		"test.mtl", shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.mtl:1: material \"glass\": refractive index must be at least 1")
+	}
+
+	_, err = parseWavefrontMtl(strings.NewReader("newmtl red\nKd 1 0\n"), "test.mtl",
+		shading.ShadingProperties{Opacity: 1})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "test.mtl:2: expected at least 3 numeric values")
+	}
+}
+
+func TestParseObjFaceVertex(t *testing.T) {
+	faceVertex, err := parseObjFaceVertex("3", 5, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, objFaceVertex{2, -1, -1}, faceVertex)
+
+	faceVertex, err = parseObjFaceVertex("3/1", 5, 2, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, objFaceVertex{2, 0, -1}, faceVertex)
+
+	faceVertex, err = parseObjFaceVertex("3//4", 5, 0, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, objFaceVertex{2, -1, 3}, faceVertex)
+
+	faceVertex, err = parseObjFaceVertex("-1/-2/-3", 5, 2, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, objFaceVertex{4, 0, 1}, faceVertex)
+
+	_, err = parseObjFaceVertex("0", 5, 0, 0)
+	assert.NotNil(t, err)
+	_, err = parseObjFaceVertex("1/2/3/4", 5, 5, 5)
+	assert.NotNil(t, err)
+	_, err = parseObjFaceVertex("a/b", 5, 5, 5)
+	assert.NotNil(t, err)
+}